@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/DanielFillol/Jarvis/internal/llm"
 	"github.com/DanielFillol/Jarvis/internal/metabase"
 	"github.com/DanielFillol/Jarvis/internal/outline"
+	"github.com/DanielFillol/Jarvis/internal/schedule"
 	"github.com/DanielFillol/Jarvis/internal/slack"
 	"github.com/DanielFillol/Jarvis/internal/telemetry"
 )
@@ -21,7 +23,7 @@ import (
 func main() {
 	// Load configuration (from .env and environment)
 	cfg := config.Load()
-	log.Printf("[BOOT] env check: SLACK_SIGNING_SECRET=%t SLACK_BOT_TOKEN=%t SLACK_USER_TOKEN=%t OPENAI_API_KEY=%t OPENAI_MODEL=%q OPENAI_LESSER_MODEL=%q JIRA_BASE_URL=%t JIRA_EMAIL=%t JIRA_API_TOKEN=%t JIRA_CREATE_ENABLED=%t JIRA_PROJECT_KEYS=%v BOT_NAME=%q", cfg.SlackSigningSecret != "", cfg.SlackBotToken != "", cfg.SlackUserToken != "", cfg.OpenAIAPIKey != "", cfg.OpenAIModel, cfg.OpenAILesserModel, cfg.JiraBaseURL != "", cfg.JiraEmail != "", cfg.JiraAPIToken != "", cfg.JiraCreateEnabled, cfg.JiraProjectKeys, cfg.BotName)
+	log.Printf("[BOOT] env check: SLACK_SIGNING_SECRET=%t SLACK_BOT_TOKEN=%t SLACK_USER_TOKEN=%t SLACK_SOCKET_MODE=%t OPENAI_API_KEY=%t OPENAI_MODEL=%q OPENAI_LESSER_MODEL=%q JIRA_BASE_URL=%t JIRA_EMAIL=%t JIRA_API_TOKEN=%t JIRA_CREATE_ENABLED=%t JIRA_PROJECT_KEYS=%v BOT_NAME=%q", cfg.SlackSigningSecret != "", cfg.SlackBotToken != "", cfg.SlackUserToken != "", cfg.SlackSocketMode, cfg.OpenAIAPIKey != "", cfg.OpenAIModel, cfg.OpenAILesserModel, cfg.JiraBaseURL != "", cfg.JiraEmail != "", cfg.JiraAPIToken != "", cfg.JiraCreateEnabled, cfg.JiraProjectKeys, cfg.BotName)
 
 	// Initialize clients
 	slackClient := slack.NewClient(cfg)
@@ -40,25 +42,66 @@ func main() {
 	// Construct core service
 	service := app.NewService(cfg, slackClient, jiraClient, llmClient, metabaseClient, fs, outlineClient, googleDriveClient, hubspotClient, telemetryClient)
 
+	// Workspace user directory for mention resolution and name lookups.
+	if slackClient != nil && cfg.SlackUserDirectoryRefresh > 0 {
+		go slackClient.RunUserDirectory(context.Background(), cfg.SlackUserDirectoryRefresh)
+	}
+
+	// Recurring questions ("todo dia útil às 9h me mande ...").
+	if cfg.ScheduleMaxPerUser > 0 {
+		service.Schedules = schedule.NewStore(cfg)
+		go service.RunSchedules(context.Background())
+	}
+
 	// Generate company context asynchronously from Jira + Metabase docs + Outline.
 	go func() {
-		ctx := app.GenerateCompanyContext(cfg, outlineClient, llmClient)
-		if ctx != "" {
-			service.SetCompanyCtx(ctx)
+		if companyCtx := app.GenerateCompanyContext(context.Background(), cfg, outlineClient, llmClient); companyCtx != "" {
+			service.SetCompanyCtx(companyCtx)
 		}
 	}()
 
 	// Slack events endpoint
-	slackHandler := httpinternal.NewSlackHandler(slackClient, service)
+	slackHandler := httpinternal.NewSlackHandler(slackClient, service, slack.NewEventDedup(cfg))
+
+	// Slash commands endpoint (/jarvis ...)
+	commandHandler := httpinternal.NewCommandHandler(slackClient, service)
+
+	// Block Kit interactions endpoint (buttons and modals)
+	interactionHandler := httpinternal.NewInteractionHandler(slackClient, service)
 
 	// Direct HTTP chat endpoint
 	chatHandler := httpinternal.NewChatHandler(service, cfg.ChatAPIKey)
 
+	// Slack rate-limit counters
+	metricsHandler := httpinternal.NewMetricsHandler(slackClient, cfg.ChatAPIKey)
+
+	// Socket Mode: same dispatch paths as the /slack/* endpoints, over a
+	// WebSocket.
+	if cfg.SlackSocketMode {
+		if slackClient == nil || cfg.SlackAppToken == "" {
+			log.Printf("[BOOT] SLACK_SOCKET_MODE=true but SLACK_APP_TOKEN or the Slack client is missing — socket mode disabled")
+		} else {
+			log.Printf("[BOOT] Slack socket mode enabled")
+			go slack.NewSocketMode(slackClient, cfg.SlackAppToken).Run(context.Background(), httpinternal.SocketRouter(slackHandler, commandHandler, interactionHandler))
+		}
+	}
+
 	mux := http.NewServeMux()
 	mux.Handle("/slack/events", slackHandler)
+	mux.Handle("/slack/commands", commandHandler)
+	mux.Handle("/slack/interactions", interactionHandler)
 	mux.Handle("/api/chat", chatHandler)
+	mux.Handle("/metrics/slack", metricsHandler)
 	mux.Handle("/files/", fs)
 
+	// OAuth install flow for additional workspaces
+	if cfg.SlackOAuthEnabled() && slackClient != nil {
+		oauthHandler := httpinternal.NewOAuthHandler(slackClient, cfg)
+		mux.HandleFunc("/slack/install", oauthHandler.Install)
+		mux.HandleFunc("/slack/oauth/callback", oauthHandler.Callback)
+		log.Printf("[BOOT] Slack OAuth install enabled redirect_url=%q", cfg.SlackOAuthRedirectURL)
+	}
+
 	// Start HTTP server
 	if err := http.ListenAndServe(":"+cfg.Port, mux); err != nil {
 		log.Fatalf("[BOOT] ListenAndServe: %v", err)
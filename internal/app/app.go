@@ -1,9 +1,9 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -13,19 +13,20 @@ import (
 	"github.com/DanielFillol/Jarvis/internal/fileserver"
 	"github.com/DanielFillol/Jarvis/internal/googledrive"
 	"github.com/DanielFillol/Jarvis/internal/hubspot"
+	"github.com/DanielFillol/Jarvis/internal/identity"
 	"github.com/DanielFillol/Jarvis/internal/jira"
 	"github.com/DanielFillol/Jarvis/internal/llm"
 	"github.com/DanielFillol/Jarvis/internal/metabase"
 	"github.com/DanielFillol/Jarvis/internal/outline"
 	"github.com/DanielFillol/Jarvis/internal/parse"
+	"github.com/DanielFillol/Jarvis/internal/schedule"
+	"github.com/DanielFillol/Jarvis/internal/skill"
 	"github.com/DanielFillol/Jarvis/internal/slack"
 	"github.com/DanielFillol/Jarvis/internal/state"
 	"github.com/DanielFillol/Jarvis/internal/telemetry"
+	"github.com/DanielFillol/Jarvis/internal/transcribe"
 )
 
-// reSheetName matches "aba X" or "tab X" (Portuguese/English) to detect sheet name in user messages.
-var reSheetName = regexp.MustCompile(`(?i)\bab[ao]\s+([^\s,\.]+)`)
-
 // pendingReply holds the message chunks for a long answer awaiting confirmation
 // together with the originTs of the original question that triggered the answer.
 // Storing originalOriginTs allows all posted chunks to be tracked for deletion
@@ -33,6 +34,8 @@ var reSheetName = regexp.MustCompile(`(?i)\bab[ao]\s+([^\s,\.]+)`)
 type pendingReply struct {
 	chunks           []string
 	originalOriginTs string
+	// channel and threadTs are where the chunks go once confirmed.
+	channel, threadTs string
 }
 
 // Service encapsulates the core orchestration logic of Jarvis.  It
@@ -65,9 +68,24 @@ type Service struct {
 	GoogleDrive *googledrive.Client
 	HubSpot     *hubspot.Client
 	Telemetry   *telemetry.Client
+	// Schedules stores the recurring questions run by RunSchedules; nil
+	// disables scheduling.
+	Schedules *schedule.Store
+	// Identity links Slack users to their Jira account and HubSpot owner.
+	Identity *identity.Resolver
+	// Transcriber turns audio and video attachments into text; nil when
+	// transcription is not configured.
+	Transcriber *transcribe.Client
+
+	// Skills dispatches router actions to the integrations (see package skill).
+	Skills *skill.Registry
 
 	// companyCtx holds the generated domain glossary injected into every answer call.
 	companyCtx atomic.Value
+
+	// budget tracks today's token usage for the daily per-user and
+	// per-channel caps.
+	budget tokenBudget
 }
 
 // NewService constructs a new Jarvis service from its dependencies.
@@ -78,7 +96,12 @@ type Service struct {
 // hubspotClient may be nil when HubSpot integration is not configured.
 // telemetryClient may be nil when telemetry is not configured.
 func NewService(cfg config.Config, slackClient *slack.Client, jiraClient *jira.Client, llmClient *llm.Client, metabaseClient *metabase.Client, fs *fileserver.FileServer, outlineClient *outline.Client, googleDriveClient *googledrive.Client, hubspotClient *hubspot.Client, telemetryClient *telemetry.Client) *Service {
-	return &Service{
+	// A nil *hubspot.Client must stay a nil interface for the resolver.
+	var owners identity.OwnerFinder
+	if hubspotClient != nil {
+		owners = hubspotClient
+	}
+	s := &Service{
 		Slack:       slackClient,
 		Jira:        jiraClient,
 		LLM:         llmClient,
@@ -90,7 +113,11 @@ func NewService(cfg config.Config, slackClient *slack.Client, jiraClient *jira.C
 		GoogleDrive: googleDriveClient,
 		HubSpot:     hubspotClient,
 		Telemetry:   telemetryClient,
+		Identity:    identity.NewResolver(cfg, slackClient, jiraClient, owners),
+		Transcriber: transcribe.NewClient(cfg),
 	}
+	s.registerBuiltinSkills()
+	return s
 }
 
 // SetCompanyCtx stores the generated domain glossary for injection into answer calls.
@@ -107,7 +134,9 @@ func (s *Service) getCompanyCtx() string {
 // delegates to the appropriate flows: Jira creation, context retrieval, and
 // answer generation.  All routing decisions go through the LLM — there are no
 // hardcoded keyword overrides.  On error, a fallback answer is posted to Slack.
-func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, question, senderUserID string, files []slack.File) error {
+// Cancelling ctx (the user deleted the message) aborts in-flight LLM and SQL
+// work and returns ctx.Err() without posting anything.
+func (s *Service) HandleMessage(ctx context.Context, channel, threadTs, originTs, originalText, question, senderUserID string, files []slack.File) error {
 	start := time.Now()
 	log.Printf("[JARVIS] start question=%q originTs=%q senderUserID=%q", preview(question, 180), originTs, senderUserID)
 
@@ -124,7 +153,15 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 		LLMModel:     s.Cfg.OpenAIModel,
 		Success:      true,
 	}
+	// Every LLM call below records its token usage into meter.
+	meter := llm.NewUsageMeter()
+	ctx = llm.WithUsageMeter(ctx, meter)
 	defer func() {
+		if ctx.Err() != nil {
+			telEvent.Success = false
+			telEvent.ErrorStage = "cancelled"
+		}
+		s.recordUsage(&telEvent, meter, senderUserID, channel)
 		telEvent.DurationMs = int(time.Since(start).Milliseconds())
 		s.Telemetry.Record(telEvent)
 	}()
@@ -150,23 +187,14 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 			pr := raw.(pendingReply)
 			if isLongReplyCancellation(question) {
 				s.pendingReplies.Delete(pendingKey)
-				_ = s.Slack.PostMessage(channel, threadTs, "Ok, resposta cancelada.")
+				_ = s.Slack.PostMessage(ctx, channel, threadTs, "Ok, resposta cancelada.")
 				log.Printf("[JARVIS] long reply cancelled dur=%s", time.Since(start))
 				return nil
 			}
 			if isLongReplyConfirmation(question) {
 				s.pendingReplies.Delete(pendingKey)
-				for i, chunk := range pr.chunks {
-					chunkTs, postErr := s.Slack.PostMessageAndGetTS(channel, threadTs, chunk)
-					if postErr != nil {
-						log.Printf("[ERR] long reply chunk %d/%d: %v", i+1, len(pr.chunks), postErr)
-					} else if chunkTs != "" {
-						// Track each chunk against the original question so deleting it
-						// removes all reply chunks from the thread.
-						s.Slack.Tracker.Track(channel, pr.originalOriginTs, chunkTs)
-					}
-				}
-				log.Printf("[JARVIS] long reply confirmed, posted %d chunks dur=%s", len(pr.chunks), time.Since(start))
+				n := s.postLongReply(ctx, pr)
+				log.Printf("[JARVIS] long reply confirmed, posted %d chunks dur=%s", n, time.Since(start))
 				return nil
 			}
 			// Not a clear yes/no — discard pending and process as a new question.
@@ -174,13 +202,27 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 		}
 	}
 
+	// edited is the previous answer when re-answering an edited question; it
+	// is replaced in place instead of posting a new reply.
+	edited := editedReplyTs(ctx)
+
+	// 2b) Daily token budgets: refuse politely before spending any tokens.
+	if msg := s.budgetExceeded(ctx, senderUserID, channel); msg != "" {
+		telEvent.Success = false
+		telEvent.ErrorStage = "token_budget"
+		if edited != "" {
+			return s.Slack.UpdateMessage(ctx, channel, edited, markEdited(ctx, msg))
+		}
+		return s.Slack.PostMessage(ctx, channel, threadTs, msg)
+	}
+
 	// 3) Thread history (full fetch when an explicit permalink was provided).
 	var threadHist string
 	var err error
 	if hasThreadPermalink {
-		threadHist, err = s.Slack.GetThreadHistoryFull(contextChannel, contextThreadTs, 400, 40000)
+		threadHist, err = s.Slack.GetThreadHistoryFull(ctx, contextChannel, contextThreadTs, 400, 40000)
 	} else {
-		threadHist, err = s.Slack.GetThreadHistory(contextChannel, contextThreadTs, 60)
+		threadHist, err = s.Slack.GetThreadHistory(ctx, contextChannel, contextThreadTs, 60)
 	}
 	if err != nil {
 		log.Printf("[WARN] thread history failed: %v", err)
@@ -190,50 +232,51 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 	// 3b) Intro request: user asked the bot to introduce itself.
 	if isIntroRequest(question, s.Cfg.BotName) {
 		log.Printf("[JARVIS] introFlow handled dur=%s", time.Since(start))
-		return s.handleIntroRequest(channel, threadTs, originTs)
+		return s.handleIntroRequest(ctx, channel, threadTs, originTs)
 	}
 
 	// 4) Unified action dispatcher: one LLM call determines every skill needed.
 	// Resolve Slack mentions before passing to the router.
-	questionForLLM := s.Slack.ResolveUserMentions(s.Slack.ResolveChannelMentions(parse.StripSlackPermalinks(question)))
+	questionForLLM := s.Slack.ResolveUserMentions(ctx, s.Slack.ResolveChannelMentions(ctx, parse.StripSlackPermalinks(question)))
 	// Enhance the question to improve routing accuracy before DecideActions.
-	questionForLLM = s.LLM.EnhancePrompt(
+	questionForLLM = s.LLM.EnhancePrompt(ctx,
 		questionForLLM,
 		threadHist,
-		s.buildAvailableSources(),
-		s.Cfg.OpenAILesserModel,
+		s.buildAvailableSources(ctx),
+		s.Cfg.RoutingModel(s.Cfg.OpenAILesserModel),
 	)
 	log.Printf("[JARVIS] enhanced question=%q", preview(questionForLLM, 180))
-	hasPending := s.Cfg.JiraEnabled() && s.Store.Load(channel, threadTs) != nil
-	storedDBID, _ := s.loadThreadDBID(contextChannel, contextThreadTs)
-
-	hubspotCatalog := ""
-	if s.HubSpot != nil {
-		hubspotCatalog = s.HubSpot.CatalogCompact
+	// An edit is not an answer to a pending draft's questions.
+	hasPending := edited == "" && s.Cfg.JiraEnabled() && s.Store.Load(channel, threadTs) != nil
+	req := skill.Request{
+		Channel:         contextChannel,
+		ThreadTs:        contextThreadTs,
+		TeamID:          s.Slack.InstalledTeam(ctx),
+		Question:        question,
+		QuestionForLLM:  questionForLLM,
+		ThreadHistory:   threadHist,
+		SenderUserID:    senderUserID,
+		Sender:          s.resolveIdentity(ctx, senderUserID),
+		ThreadPermalink: hasThreadPermalink,
+	}
+
+	var actions []llm.ActionDescriptor
+	plan, actErr := s.LLM.PlanActions(ctx, s.Cfg.RoutingModel(s.Cfg.OpenAIModel), s.Skills.Router(s.Cfg, req))
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
-	actions, actErr := s.LLM.DecideActions(
-		questionForLLM, threadHist, s.Cfg.OpenAIModel,
-		s.Cfg.JiraEnabled(), s.Jira.CatalogCompact, senderUserID,
-		s.formattedMetabaseDatabases(), storedDBID,
-		s.Cfg.OutlineEnabled(),
-		s.Cfg.GoogleDriveEnabled(),
-		s.Cfg.HubSpotEnabled(),
-		hubspotCatalog,
-	)
 	if actErr != nil {
 		log.Printf("[WARN] decideActions failed: %v", actErr)
 		actions = fallbackActions(hasThreadPermalink)
+	} else {
+		// A copy: the filters below must not rewrite the plan's Actions,
+		// which Continue reports back to the router.
+		actions = append([]llm.ActionDescriptor(nil), plan.Actions...)
 	}
 	telEvent.Actions = actionKinds(actions)
 	// Explicit permalink → thread is already the authoritative context; drop Slack searches.
 	if hasThreadPermalink {
-		filtered := actions[:0]
-		for _, a := range actions {
-			if a.Kind != llm.ActionSlackSearch {
-				filtered = append(filtered, a)
-			}
-		}
-		actions = filtered
+		actions = dropKind(actions, kindSlackSearch)
 	}
 	log.Printf("[JARVIS] actions=%v hasPending=%t", actionKinds(actions), hasPending)
 
@@ -247,9 +290,20 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 	var anyHandled bool
 	var handlerReplyParts []string
 
-	if containsKind(handlerActions, llm.ActionJiraCreate) || hasPending {
-		res, createErr := s.maybeHandleJiraCreateFlows(channel, threadTs, originTs, originalText, question, threadHist,
-			containsKind(handlerActions, llm.ActionJiraCreate), quiet)
+	// Re-answering an edit never repeats Jira actions: the card was already
+	// created or changed when the message was first answered.
+	if edited != "" && len(handlerActions) > 0 {
+		log.Printf("[JARVIS] edit: skipping handler actions=%v", actionKinds(handlerActions))
+		handlerActions = nil
+		if len(contextActions) == 0 {
+			return s.noteEditedAction(ctx, channel, edited)
+		}
+		handlerReplyParts = append(handlerReplyParts, editedActionNote)
+	}
+
+	if containsKind(handlerActions, kindJiraCreate) || hasPending {
+		res, createErr := s.maybeHandleJiraCreateFlows(ctx, channel, threadTs, senderUserID, originTs, originalText, question, threadHist,
+			containsKind(handlerActions, kindJiraCreate), quiet)
 		if res.Handled {
 			anyHandled = true
 			createdKey = res.CreatedKey
@@ -267,8 +321,8 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 	// (e.g. bot is asking for missing fields). Once a card is actually created
 	// (createdKey != ""), edit may run to apply extra fields from the same request.
 	pendingCreateHandled := anyHandled && createdKey == ""
-	if containsKind(handlerActions, llm.ActionJiraEdit) && !pendingCreateHandled {
-		editRes, editErr := s.maybeHandleJiraEditFlows(channel, threadTs, senderUserID, question, threadHist, createdKey, true, quiet)
+	if containsKind(handlerActions, kindJiraEdit) && !pendingCreateHandled {
+		editRes, editErr := s.maybeHandleJiraEditFlows(ctx, channel, threadTs, senderUserID, question, threadHist, createdKey, true, quiet)
 		if editRes.Handled {
 			anyHandled = true
 			if editRes.Reply != "" {
@@ -289,647 +343,94 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 	// 4b) Smoke-test command: run the prompt library test cycle.
 	if isTestCommand(question, s.Cfg.BotName) {
 		log.Printf("[JARVIS] testFlow triggered dur=%s", time.Since(start))
-		return s.handleTestFlow(channel, threadTs)
+		return s.handleTestFlow(ctx, channel, threadTs)
 	}
 
 	// 5) Post a "searching…" placeholder so the user knows Jarvis is working.
-	busyTs, busyErr := s.Slack.PostMessageAndGetTS(channel, threadTs, "_buscando..._")
-	if busyErr != nil {
-		log.Printf("[WARN] could not post busy indicator: %v", busyErr)
+	//    When re-answering an edited question, the previous answer (already
+	//    tracked) becomes the placeholder and is updated in place.
+	busyTs := edited
+	if busyTs != "" {
+		if err := s.Slack.UpdateBlocks(ctx, channel, busyTs, "_atualizando..._", nil); err != nil {
+			log.Printf("[WARN] could not reuse previous answer ts=%q: %v", busyTs, err)
+			busyTs = ""
+		}
 	}
-
-	// replyFn updates the busy placeholder in-place; falls back to a new post.
-	replyFn := func(text string) error {
+	if busyTs == "" {
+		var busyErr error
+		busyTs, busyErr = s.Slack.PostMessageAndGetTS(ctx, channel, threadTs, "_buscando..._")
+		if busyErr != nil {
+			log.Printf("[WARN] could not post busy indicator: %v", busyErr)
+		}
 		if busyTs != "" {
-			if err := s.Slack.UpdateMessage(channel, busyTs, text); err != nil {
-				log.Printf("[WARN] UpdateMessage failed, falling back: %v", err)
-				return s.Slack.PostMessage(channel, threadTs, text)
-			}
-			return nil
+			// Track the placeholder right away so deleting the question while
+			// the answer is still being built removes it too.
+			s.Slack.Tracker.Track(channel, originTs, busyTs)
 		}
-		return s.Slack.PostMessage(channel, threadTs, text)
 	}
 
-	// Pass 2 — context actions: fetch external data, then call the answer LLM.
-	var slackCtxParts []string
-	var jiraCtxParts []string
-	var dbCtxParts []string
-	var dbQueryResults []*metabase.QueryResult
-	var dbQueryActions []llm.ActionDescriptor
-	var outlineCtx, outlineSources string
-	var googleDriveCtx, googleDriveSources string
-	var hubspotCtx, hubspotSources string
-	var executedSlackSearch, executedJiraSearch bool
-	var slackMatches, jiraIssuesFound int
-	var csvDownloadLine string // set when a CSV file is generated; appended to answer unconditionally
-
-	// Pre-step: directly fetch any Google Drive/Sheets URLs present in the message.
-	if s.GoogleDrive != nil {
-		if driveFileIDs := googledrive.ExtractFileIDsFromText(question); len(driveFileIDs) > 0 {
-			detectedSheetName := ""
-			if m := reSheetName.FindStringSubmatch(question); len(m) >= 2 {
-				detectedSheetName = m[1]
-			}
-			var directResults []*googledrive.SearchResult
-			for _, fileID := range driveFileIDs {
-				log.Printf("[JARVIS] googleDriveDirectFetch fileID=%q sheetName=%q", fileID, detectedSheetName)
-				r, fErr := s.GoogleDrive.FetchByFileID(fileID, detectedSheetName)
-				if fErr != nil {
-					log.Printf("[WARN] googleDriveDirectFetch failed: %v", fErr)
-					continue
-				}
-				directResults = append(directResults, r)
-			}
-			if len(directResults) > 0 {
-				googleDriveCtx = googledrive.FormatContext(directResults, 50000)
-				googleDriveSources = googledrive.FormatSources(directResults)
-				log.Printf("[JARVIS] googleDriveDirectFetch files=%d chars=%d", len(directResults), len(googleDriveCtx))
+	// While the answer is built, the placeholder shows what the skills are
+	// doing and then the answer as it streams in (throttled chat.update).
+	var ph *placeholder
+	if busyTs != "" && s.Cfg.SlackStreamAnswers {
+		ph = newPlaceholder(s.Cfg.SlackUpdateInterval, func(text string) error {
+			err := s.Slack.UpdateMessage(ctx, channel, busyTs, text)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("[WARN] placeholder update failed: %v", err)
 			}
-		}
+			return err
+		})
+		defer ph.Stop()
+		req.Progress = ph.Progress
 	}
 
-	for _, action := range contextActions {
-		switch action.Kind {
-
-		case llm.ActionSlackSearch:
-			if strings.TrimSpace(action.Query) == "" || s.Cfg.SlackUserToken == "" {
-				continue
-			}
-			executedSlackSearch = true
-			telEvent.SlackSearched = true
-			unresolvedUserIDs := extractFromUserIDs(action.Query)
-			resolvedQuery := s.Slack.ResolveUserIDsInQuery(action.Query)
-			log.Printf("[JARVIS] slackSearch query=%q", resolvedQuery)
-			matches, sErr := s.Slack.SearchMessagesAll(resolvedQuery)
-			if sErr != nil {
-				log.Printf("[WARN] slack search failed: %v", sErr)
-				break
-			}
-			if len(unresolvedUserIDs) > 0 {
-				filtered := matches[:0]
-				for _, m := range matches {
-					for _, uid := range unresolvedUserIDs {
-						if m.UserID == uid {
-							filtered = append(filtered, m)
-							break
-						}
-					}
-				}
-				log.Printf("[JARVIS] clientSideUserFilter from=%v reduced %d→%d", unresolvedUserIDs, len(matches), len(filtered))
-				matches = filtered
-			}
-			if len(matches) > 0 {
-				slackMatches += len(matches)
-				telEvent.SlackMatches += len(matches)
-				ctx := buildSlackContext(matches, 25)
-				log.Printf("[JARVIS] slackContext matches=%d chars=%d", len(matches), len(ctx))
-				slackCtxParts = append(slackCtxParts, ctx)
-			} else {
-				log.Printf("[JARVIS] slackSearch query=%q returned 0 results", resolvedQuery)
-			}
-
-		case llm.ActionJiraSearch:
-			executedJiraSearch = true
-			telEvent.JiraSearched = true
-			jql := strings.TrimSpace(action.JQL)
-			if jql == "" {
-				jql = defaultJQLForIntent(action.JiraIntent, question, s.Cfg.JiraProjectKeys)
-			}
-			jql = sanitizeJQL(jql)
-			log.Printf("[JARVIS] jiraJQL=%q", jql)
-			issues, jErr := s.Jira.FetchAll(jql, 200)
-			if jErr != nil {
-				log.Printf("[WARN] jira search failed: %v", jErr)
-				// Attempt JQL correction using real workflow statuses from catalog.
-				if corrected := correctJQLStatus(jql, s.Jira.WorkflowStatuses); corrected != jql {
-					log.Printf("[JARVIS] jiraJQL corrected=%q", corrected)
-					issues, jErr = s.Jira.FetchAll(corrected, 200)
-				}
-			}
-			if jErr != nil {
-				telEvent.JiraError = true
-				jiraCtxParts = append(jiraCtxParts,
-					"[JIRA_ERROR: A busca falhou. NÃO invente issues, títulos, assignees ou chaves. "+
-						"Informe o usuário que houve um erro ao consultar o Jira e peça para refinar a busca.]")
-				break
-			}
-			// When the initial query returns 0 results, attempt status name correction
-			// before giving up — Jira status names must match exactly and the LLM may
-			// have generated a slightly different casing than what the workflow uses.
-			if len(issues) == 0 {
-				if corrected := correctJQLStatus(jql, s.Jira.WorkflowStatuses); corrected != jql {
-					log.Printf("[JARVIS] jiraJQL corrected for empty result=%q", corrected)
-					if corrIssues, corrErr := s.Jira.FetchAll(corrected, 200); corrErr == nil && len(corrIssues) > 0 {
-						issues = corrIssues
-						log.Printf("[JARVIS] jiraJQL corrected returned issues=%d", len(issues))
-					}
-				}
-			}
-			if len(issues) == 0 {
-				jiraCtxParts = append(jiraCtxParts,
-					fmt.Sprintf("[JIRA_EMPTY: JQL '%s' retornou 0 issues. "+
-						"Informe o usuário que não foram encontradas issues com esses critérios. "+
-						"NÃO invente issues.]", jql))
-				break
-			}
-			jiraIssuesFound += len(issues)
-			telEvent.JiraIssues += len(issues)
-			ctx := buildJiraContext(issues, 40)
-			log.Printf("[JARVIS] jiraContext issues=%d chars=%d", len(issues), len(ctx))
-			jiraCtxParts = append(jiraCtxParts, ctx)
-
-		case llm.ActionOutlineSearch:
-			telEvent.OutlineSearched = true
-			outlineQuery := strings.TrimSpace(action.Query)
-			if outlineQuery == "" {
-				outlineQuery = s.LLM.GenerateOutlineQuery(questionForLLM, s.Cfg.OpenAILesserModel)
-				if outlineQuery != "" {
-					log.Printf("[JARVIS] outlineQuery generated=%q", outlineQuery)
-				}
-			}
-			if s.Outline != nil && outlineQuery != "" {
-				log.Printf("[JARVIS] outlineSearch query=%q", outlineQuery)
-				results, oErr := s.Outline.SearchDocuments(outlineQuery, 5)
-				if oErr != nil {
-					log.Printf("[WARN] outline search failed: %v", oErr)
-				} else {
-					outlineCtx = outline.FormatContext(results, 8000)
-					outlineSources = outline.FormatSources(results)
-					log.Printf("[JARVIS] outlineContext docs=%d chars=%d", len(results), len(outlineCtx))
-					if outlineCtx == "" {
-						outlineCtx = "[AVISO: A busca no Outline não retornou documentos. Informe ao usuário que não foram encontrados docs relevantes para a consulta realizada.]"
-					}
-				}
-			}
-
-		case llm.ActionGoogleDriveSearch:
-			driveQuery := strings.TrimSpace(action.Query)
-			if s.GoogleDrive != nil && driveQuery != "" {
-				log.Printf("[JARVIS] googleDriveSearch query=%q sheetName=%q", driveQuery, action.GoogleDriveSheetName)
-				results, dErr := s.GoogleDrive.SearchAndFetch(driveQuery, action.GoogleDriveSheetName)
-				if dErr != nil {
-					log.Printf("[WARN] google drive search failed: %v", dErr)
-				} else {
-					googleDriveCtx = googledrive.FormatContext(results, 30000)
-					googleDriveSources = googledrive.FormatSources(results)
-					log.Printf("[JARVIS] googleDriveContext files=%d chars=%d", len(results), len(googleDriveCtx))
-					if googleDriveCtx == "" {
-						googleDriveCtx = "[AVISO: A busca no Google Drive não retornou arquivos. Informe ao usuário que não foram encontrados documentos relevantes para a consulta realizada.]"
-					}
-				}
-			}
-
-		case llm.ActionHubSpotSearch:
-			if s.HubSpot == nil {
-				break
-			}
-			objectType := strings.TrimSpace(action.HubSpotObjectType)
-			query := strings.TrimSpace(action.HubSpotQuery)
-			if query == "" {
-				query = question
-			}
-			// ID-based lookup: try FetchByID first when a record ID is provided.
-			if action.HubSpotRecordID != "" {
-				typesToTry := []string{objectType}
-				if objectType == "" {
-					typesToTry = []string{"tickets", "deals", "contacts", "companies"}
-				}
-				log.Printf("[JARVIS] hubspotFetchByID id=%q types=%v", action.HubSpotRecordID, typesToTry)
-				for _, ot := range typesToTry {
-					r, fErr := s.HubSpot.FetchByID(ot, action.HubSpotRecordID)
-					if fErr != nil {
-						log.Printf("[WARN] hubspot FetchByID type=%s id=%s: %v", ot, action.HubSpotRecordID, fErr)
-						continue
-					}
-					if r != nil {
-						hubspotCtx = hubspot.FormatContext([]*hubspot.SearchResult{r}, 8000)
-						hubspotSources = hubspot.FormatSources([]*hubspot.SearchResult{r})
-						log.Printf("[JARVIS] hubspotFetchByID found type=%s id=%s chars=%d", ot, action.HubSpotRecordID, len(hubspotCtx))
-						break
-					}
-				}
-				if hubspotCtx != "" {
-					break // found by ID — skip text search
-				}
-				// Fall through to text search. Only use the bare record ID when no
-				// richer query is available; if the LLM omitted hubspot_query (leaving
-				// query == question), keep the full question so text search has more
-				// tokens to match against.
-				if query == "" {
-					query = action.HubSpotRecordID
-				}
-			}
-			log.Printf("[JARVIS] hubspotSearch object_type=%q query=%q after=%q before=%q", objectType, query, action.HubSpotAfter, action.HubSpotBefore)
-			results, hErr := s.HubSpot.Search(objectType, query, action.HubSpotAfter, action.HubSpotBefore)
-			if hErr != nil {
-				log.Printf("[WARN] hubspot search failed: %v", hErr)
-				hubspotCtx = "[HUBSPOT_ERROR: busca falhou. NÃO invente dados de CRM.]"
-			} else if len(results) == 0 {
-				// Ask LLM to generate alternative query variants and retry.
-				variants := s.LLM.GenerateHubSpotQueryVariants(query, questionForLLM, s.Cfg.OpenAILesserModel)
-				log.Printf("[JARVIS] hubspotSearch empty, LLM variants=%v", variants)
-				for _, v := range variants {
-					if strings.TrimSpace(v) == "" || v == query {
-						continue
-					}
-					log.Printf("[JARVIS] hubspotSearch retry variant=%q", v)
-					results, hErr = s.HubSpot.Search(objectType, v, action.HubSpotAfter, action.HubSpotBefore)
-					if hErr != nil {
-						log.Printf("[WARN] hubspot search failed variant=%q: %v", v, hErr)
-						break
-					}
-					if len(results) > 0 {
-						log.Printf("[JARVIS] hubspotContext records=%d chars=%d (variant=%q)", len(results), len(hubspot.FormatContext(results, 4000)), v)
-						break
-					}
-				}
-				if len(results) == 0 {
-					hubspotCtx = "[HUBSPOT_EMPTY: nenhum resultado encontrado no HubSpot.]"
-				} else {
-					hubspotCtx = hubspot.FormatContext(results, 4000)
-					hubspotSources = hubspot.FormatSources(results)
-				}
-			} else {
-				hubspotCtx = hubspot.FormatContext(results, 4000)
-				hubspotSources = hubspot.FormatSources(results)
-				log.Printf("[JARVIS] hubspotContext records=%d chars=%d", len(results), len(hubspotCtx))
-			}
-
-		case llm.ActionMetabaseQuery:
-			telEvent.MetabaseQueried = true
-			if s.Metabase == nil {
-				break
-			}
-			var baseSQL string
-			if v, ok := s.threadLastSQL.Load(contextChannel + ":" + contextThreadTs); ok {
-				if sql, ok2 := v.(string); ok2 {
-					baseSQL = sql
-				}
-			}
-			mRes := s.runMetabaseQuery(questionForLLM, threadHist, action.MetabaseDatabaseID, baseSQL, action.WantsAllRows)
-
-			// Cross-database fallback: when primary DB returned no data, failed entirely,
-			// OR returned a clarification about a missing table/schema — try remaining
-			// databases before asking the user.  A clarification about which DB to use
-			// is a signal that the schema doesn't match; another DB may answer correctly.
-			primaryNeedsRetry := mRes.DBCtx == "" ||
-				strings.HasPrefix(mRes.DBCtx, llm.ClarificationPrefix) ||
-				(mRes.QueryResult != nil && len(mRes.QueryResult.Data.Rows) == 0)
-			if primaryNeedsRetry {
-				for _, db := range s.Metabase.Databases {
-					if db.ID == action.MetabaseDatabaseID {
-						continue
-					}
-					log.Printf("[METABASE] primary db=%d needs retry (clarification/empty), trying fallback db=%d (%s)",
-						action.MetabaseDatabaseID, db.ID, db.Name)
-					fbRes := s.runMetabaseQuery(questionForLLM, threadHist, db.ID, "", action.WantsAllRows)
-					if fbRes.QueryResult != nil && len(fbRes.QueryResult.Data.Rows) > 0 {
-						mRes = fbRes
-						action.MetabaseDatabaseID = db.ID
-						log.Printf("[METABASE] fallback db=%d succeeded rows=%d", db.ID, len(fbRes.QueryResult.Data.Rows))
-						break
-					}
-				}
-			}
-
-			thisDBCtx, thisQR, thisSql := mRes.DBCtx, mRes.QueryResult, mRes.ExecutedSQL
-			if thisQR != nil {
-				telEvent.MetabaseRows += len(thisQR.Data.Rows)
-			}
-			if strings.HasPrefix(thisDBCtx, llm.ClarificationPrefix) {
-				clarificationQ := strings.TrimPrefix(thisDBCtx, llm.ClarificationPrefix)
-				if replyErr := replyFn(clarificationQ); replyErr != nil {
-					log.Printf("[ERR] clarification reply failed: %v", replyErr)
-					return replyErr
-				}
-				if busyTs != "" {
-					s.Slack.Tracker.Track(channel, originTs, busyTs)
-				}
-				log.Printf("[JARVIS] clarification requested dur=%s", time.Since(start))
-				return nil
-			}
-			if thisSql != "" {
-				s.threadLastSQL.Store(contextChannel+":"+contextThreadTs, thisSql)
-				s.storeThreadDBID(contextChannel, contextThreadTs, action.MetabaseDatabaseID)
-			}
-
-			// CSV export or large result handling.
-			const largeResultThreshold = 30
-			// Auto-trigger CSV when the result is large enough that an inline table
-			// would be unreadably long (> 100 rows), even if the user didn't explicitly
-			// request a CSV export.
-			const csvAutoThreshold = 100
-			wantsCSV := action.WantsCSVExport || (thisQR != nil && len(thisQR.Data.Rows) > csvAutoThreshold && action.WantsAllRows)
-			if thisQR != nil && wantsCSV && s.FileServer != nil && strings.TrimSpace(s.Cfg.PublicBaseURL) != "" {
-				nRows := len(thisQR.Data.Rows)
-				cols := make([]string, len(thisQR.Data.Cols))
-				for i, c := range thisQR.Data.Cols {
-					if c.DisplayName != "" {
-						cols[i] = c.DisplayName
-					} else {
-						cols[i] = c.Name
-					}
-				}
-				csvBytes := []byte(metabase.FormatQueryResultAsCSV(*thisQR))
-				if len(csvBytes) > 0 {
-					fileID := s.FileServer.Store("resultado.csv", csvBytes, time.Hour)
-					csvURL := s.Cfg.PublicBaseURL + "/files/" + fileID
-					// Store the download line separately so it is always appended to the
-					// final answer — never rely on the LLM to include it.
-					csvDownloadLine = fmt.Sprintf(":page_facing_up: *Download CSV:* <%s|resultado.csv> _(expira em 1 hora)_", csvURL)
-					telEvent.CSVGenerated = true
-					dbCtxParts = append(dbCtxParts, fmt.Sprintf(
-						"Query SQL retornou %d registros com os campos: %s.\n\n"+
-							"INSTRUÇÃO INTERNA: Escreva APENAS 1 frase curta de introdução descrevendo o resultado "+
-							"(ex: \"Encontrei %d registros...\"). Não exiba a tabela de dados.\n\n"+
-							"Amostra (3 de %d registros):\n%s",
-						nRows, strings.Join(cols, ", "), nRows, nRows,
-						metabase.FormatQueryResult(*thisQR, 3),
-					))
-					log.Printf("[JARVIS] CSV generated rows=%d id=%s", nRows, fileID)
-				}
-				dbQueryResults = append(dbQueryResults, nil) // placeholder; CSV handled in context
-				dbQueryActions = append(dbQueryActions, action)
-				break
-			} else if thisQR != nil && len(thisQR.Data.Rows) > largeResultThreshold && action.WantsAllRows {
-				nRows := len(thisQR.Data.Rows)
-				cols := make([]string, len(thisQR.Data.Cols))
-				for i, c := range thisQR.Data.Cols {
-					if c.DisplayName != "" {
-						cols[i] = c.DisplayName
-					} else {
-						cols[i] = c.Name
-					}
-				}
-				dbCtxParts = append(dbCtxParts, fmt.Sprintf(
-					"Query SQL retornou %d registros com os campos: %s.\n\n"+
-						"INSTRUÇÃO INTERNA: Escreva APENAS 1 frase curta de introdução descrevendo o resultado "+
-						"(ex: \"Encontrei %d registros...\"). Finalize com o marcador exato [TABLE] e nada mais.\n\n"+
-						"Amostra (3 de %d registros):\n%s",
-					nRows, strings.Join(cols, ", "), nRows, nRows,
-					metabase.FormatQueryResult(*thisQR, 3),
-				))
-				log.Printf("[JARVIS] large result bypass: rows=%d", nRows)
-				dbQueryResults = append(dbQueryResults, thisQR)
-				dbQueryActions = append(dbQueryActions, action)
-				break
-			}
-
-			if thisDBCtx == "" {
-				thisDBCtx = "[ERRO: A consulta ao banco de dados falhou ou não retornou dados. NÃO invente métricas, nomes ou valores. Informe ao usuário que não foi possível obter os dados neste momento e sugira tentar novamente.]"
-			}
-			dbCtxParts = append(dbCtxParts, thisDBCtx)
-			dbQueryResults = append(dbQueryResults, thisQR)
-			dbQueryActions = append(dbQueryActions, action)
-
-		case llm.ActionShowSQL:
-			var baseSQL string
-			if v, ok := s.threadLastSQL.Load(contextChannel + ":" + contextThreadTs); ok {
-				if sql, ok2 := v.(string); ok2 {
-					baseSQL = sql
-				}
-			}
-			dbID := action.MetabaseDatabaseID
-			executedSQL := s.runMetabaseQuery(questionForLLM, threadHist, dbID, baseSQL, false).ExecutedSQL
-			var showSQLReply string
-			if executedSQL != "" {
-				showSQLReply = fmt.Sprintf(
-					"Reconstruí e validei a query com base no contexto desta conversa:\n```sql\n%s\n```\n\n> _Nota: esta é uma reconstrução — pode diferir levemente da query original, mas foi executada com sucesso no banco de dados._",
-					executedSQL,
-				)
-			} else {
-				showSQLReply = "Não consegui reconstruir uma query válida para esta conversa após várias tentativas. Tente reformular a pergunta original para que eu possa buscar os dados novamente."
-			}
-			if replyErr := replyFn(showSQLReply); replyErr != nil {
-				log.Printf("[ERR] show SQL reply failed: %v", replyErr)
-				return replyErr
-			}
-			if busyTs != "" {
-				s.Slack.Tracker.Track(channel, originTs, busyTs)
+	// replyFn updates the busy placeholder in-place; falls back to a new post.
+	replyFn := func(text string) error {
+		if ph != nil {
+			ph.Stop()
+		}
+		if busyTs != "" {
+			if err := s.Slack.UpdateMessage(ctx, channel, busyTs, text); err != nil {
+				log.Printf("[WARN] UpdateMessage failed, falling back: %v", err)
+				return s.Slack.PostMessage(ctx, channel, threadTs, text)
 			}
-			log.Printf("[JARVIS] show SQL handled executedSQL_len=%d dur=%s", len(executedSQL), time.Since(start))
 			return nil
 		}
+		return s.Slack.PostMessage(ctx, channel, threadTs, text)
 	}
 
-	// Pass 3 — fallback: when all context sources returned empty/error, ask the router
-	// again with a note about what was tried, then execute any newly suggested sources.
-	if len(contextActions) > 0 && allContextsNegative(hubspotCtx, jiraCtxParts, slackCtxParts, dbCtxParts, outlineCtx, googleDriveCtx) {
-		var triedSources []string
-		for _, a := range contextActions {
-			triedSources = append(triedSources, a.Kind)
+	// replyBlocksFn is replyFn for Block Kit messages.
+	replyBlocksFn := func(text string, blocks []slack.Block) error {
+		if ph != nil {
+			ph.Stop()
 		}
-		fallbackNote := fmt.Sprintf(
-			"[SISTEMA: fontes consultadas retornaram vazio ou erro: %s. Considere fontes alternativas disponíveis.] ",
-			strings.Join(triedSources, ", "))
-		fallbackActions2, _ := s.LLM.DecideActions(
-			fallbackNote+questionForLLM, threadHist, s.Cfg.OpenAIModel,
-			s.Cfg.JiraEnabled(), s.Jira.CatalogCompact, senderUserID,
-			s.formattedMetabaseDatabases(), storedDBID,
-			s.Cfg.OutlineEnabled(), s.Cfg.GoogleDriveEnabled(), s.Cfg.HubSpotEnabled(),
-			hubspotCatalog,
-		)
-		triedKinds := make(map[string]bool)
-		for _, a := range contextActions {
-			triedKinds[a.Kind] = true
-		}
-		_, fallbackCtxActions := splitActions(fallbackActions2)
-		var newActions []llm.ActionDescriptor
-		for _, a := range fallbackCtxActions {
-			if !triedKinds[a.Kind] {
-				newActions = append(newActions, a)
-			}
-		}
-		log.Printf("[JARVIS] fallbackPass tried=%v new=%v", triedSources, actionKinds(newActions))
-		for _, action := range newActions {
-			switch action.Kind {
-			case llm.ActionSlackSearch:
-				if strings.TrimSpace(action.Query) == "" || s.Cfg.SlackUserToken == "" {
-					continue
-				}
-				executedSlackSearch = true
-				resolvedQuery := s.Slack.ResolveUserIDsInQuery(action.Query)
-				log.Printf("[JARVIS] fallback slackSearch query=%q", resolvedQuery)
-				matches, sErr := s.Slack.SearchMessagesAll(resolvedQuery)
-				if sErr != nil {
-					log.Printf("[WARN] fallback slack search failed: %v", sErr)
-					break
-				}
-				if len(matches) > 0 {
-					slackMatches += len(matches)
-					slackCtxParts = append(slackCtxParts, buildSlackContext(matches, 25))
-					log.Printf("[JARVIS] fallback slackContext matches=%d", len(matches))
-				}
-			case llm.ActionJiraSearch:
-				executedJiraSearch = true
-				jql := strings.TrimSpace(action.JQL)
-				if jql == "" {
-					jql = defaultJQLForIntent(action.JiraIntent, question, s.Cfg.JiraProjectKeys)
-				}
-				jql = sanitizeJQL(jql)
-				log.Printf("[JARVIS] fallback jiraJQL=%q", jql)
-				issues, jErr := s.Jira.FetchAll(jql, 200)
-				if jErr != nil {
-					log.Printf("[WARN] fallback jira search failed: %v", jErr)
-					break
-				}
-				if len(issues) > 0 {
-					jiraIssuesFound += len(issues)
-					jiraCtxParts = append(jiraCtxParts, buildJiraContext(issues, 40))
-					log.Printf("[JARVIS] fallback jiraContext issues=%d", len(issues))
-				}
-			case llm.ActionOutlineSearch:
-				outlineQuery := strings.TrimSpace(action.Query)
-				if s.Outline != nil && outlineQuery != "" {
-					log.Printf("[JARVIS] fallback outlineSearch query=%q", outlineQuery)
-					results, oErr := s.Outline.SearchDocuments(outlineQuery, 5)
-					if oErr != nil {
-						log.Printf("[WARN] fallback outline search failed: %v", oErr)
-					} else {
-						outlineCtx = outline.FormatContext(results, 8000)
-						outlineSources = outline.FormatSources(results)
-						log.Printf("[JARVIS] fallback outlineContext docs=%d", len(results))
-					}
-				}
-			case llm.ActionGoogleDriveSearch:
-				driveQuery := strings.TrimSpace(action.Query)
-				if s.GoogleDrive != nil && driveQuery != "" {
-					log.Printf("[JARVIS] fallback googleDriveSearch query=%q sheetName=%q", driveQuery, action.GoogleDriveSheetName)
-					results, dErr := s.GoogleDrive.SearchAndFetch(driveQuery, action.GoogleDriveSheetName)
-					if dErr != nil {
-						log.Printf("[WARN] fallback google drive search failed: %v", dErr)
-					} else {
-						googleDriveCtx = googledrive.FormatContext(results, 30000)
-						googleDriveSources = googledrive.FormatSources(results)
-						log.Printf("[JARVIS] fallback googleDriveContext files=%d", len(results))
-					}
-				}
-			case llm.ActionHubSpotSearch:
-				if s.HubSpot == nil {
-					break
-				}
-				objectType := strings.TrimSpace(action.HubSpotObjectType)
-				query := strings.TrimSpace(action.HubSpotQuery)
-				if query == "" {
-					query = question
-				}
-				// ID-based lookup first.
-				if action.HubSpotRecordID != "" {
-					typesToTry := []string{objectType}
-					if objectType == "" {
-						typesToTry = []string{"tickets", "deals", "contacts", "companies"}
-					}
-					log.Printf("[JARVIS] fallback hubspotFetchByID id=%q types=%v", action.HubSpotRecordID, typesToTry)
-					for _, ot := range typesToTry {
-						r, fErr := s.HubSpot.FetchByID(ot, action.HubSpotRecordID)
-						if fErr != nil {
-							log.Printf("[WARN] fallback hubspot FetchByID type=%s id=%s: %v", ot, action.HubSpotRecordID, fErr)
-							continue
-						}
-						if r != nil {
-							hubspotCtx = hubspot.FormatContext([]*hubspot.SearchResult{r}, 8000)
-							hubspotSources = hubspot.FormatSources([]*hubspot.SearchResult{r})
-							break
-						}
-					}
-					if hubspotCtx != "" {
-						break
-					}
-					if query == "" {
-						query = action.HubSpotRecordID
-					}
-				}
-				log.Printf("[JARVIS] fallback hubspotSearch object_type=%q query=%q", objectType, query)
-				results, hErr := s.HubSpot.Search(objectType, query, action.HubSpotAfter, action.HubSpotBefore)
-				if hErr != nil {
-					log.Printf("[WARN] fallback hubspot search failed: %v", hErr)
-				} else if len(results) == 0 {
-					variants := s.LLM.GenerateHubSpotQueryVariants(query, questionForLLM, s.Cfg.OpenAILesserModel)
-					log.Printf("[JARVIS] fallback hubspotSearch empty, LLM variants=%v", variants)
-					for _, v := range variants {
-						if strings.TrimSpace(v) == "" || v == query {
-							continue
-						}
-						results, hErr = s.HubSpot.Search(objectType, v, action.HubSpotAfter, action.HubSpotBefore)
-						if hErr != nil {
-							log.Printf("[WARN] fallback hubspot search failed variant=%q: %v", v, hErr)
-							break
-						}
-						if len(results) > 0 {
-							log.Printf("[JARVIS] fallback hubspotContext records=%d (variant=%q)", len(results), v)
-							break
-						}
-					}
-				}
-				if len(results) > 0 {
-					hubspotCtx = hubspot.FormatContext(results, 4000)
-					hubspotSources = hubspot.FormatSources(results)
-				}
+		if busyTs != "" {
+			if err := s.Slack.UpdateBlocks(ctx, channel, busyTs, text, blocks); err == nil {
+				return nil
 			}
 		}
+		_, err := s.Slack.PostBlocks(ctx, channel, threadTs, text, blocks)
+		return err
 	}
 
-	// Channel history fallback: runs once after all slack_search actions are done,
-	// for raw <#CHANID> mentions that cannot be resolved to names for the search API.
-	if executedSlackSearch {
-		if chanIDs := extractChannelIDsFromText(question); len(chanIDs) > 0 {
-			now := time.Now()
-			weekday := int(now.Weekday())
-			if weekday == 0 {
-				weekday = 7
-			}
-			weekStart := now.AddDate(0, 0, -(weekday - 1)).Truncate(24 * time.Hour)
-			var directMsgs []slack.SearchMessage
-			for _, cid := range chanIDs {
-				msgs, chErr := s.Slack.GetChannelHistoryForPeriod(cid, weekStart, now, 80)
-				if chErr != nil {
-					log.Printf("[JARVIS] channelHistory %s failed: %v", cid, chErr)
-					continue
-				}
-				log.Printf("[JARVIS] channelHistory %s messages=%d", cid, len(msgs))
-				directMsgs = append(directMsgs, msgs...)
-			}
-			if len(directMsgs) > 0 {
-				histCtx := buildSlackContext(directMsgs, 40)
-				slackCtxParts = append(slackCtxParts, histCtx)
-				slackMatches += len(directMsgs)
-				log.Printf("[JARVIS] channelHistory total=%d chars=%d", len(directMsgs), len(histCtx))
-			}
-		}
-		// If all searches returned nothing, inject a single warning.
-		if slackMatches == 0 {
-			slackCtxParts = append(slackCtxParts, "[AVISO: A busca no Slack não retornou mensagens. NÃO invente conteúdo de canais ou mensagens. Informe ao usuário que não foram encontrados dados para a busca realizada e sugira alternativas.]")
-		}
+	// Pass 2 — context actions: fetch external data through the skills, then
+	// call the answer LLM.  Handler actions are reported as already executed.
+	var seed []llm.ActionResult
+	for _, a := range handlerActions {
+		seed = append(seed, llm.ActionResult{Action: a, Summary: "executada pelo fluxo do Jira."})
 	}
-
-	// Merge multi-source contexts.
-	slackCtx := strings.Join(slackCtxParts, "\n\n")
-	jiraCtx := strings.Join(jiraCtxParts, "\n\n")
-	dbCtx := strings.Join(dbCtxParts, "\n\n")
-
-	// Prepend HubSpot pipeline/stage ID→label catalog so the LLM can decode
-	// numeric dealstage/pipeline IDs in the search results.
-	if s.HubSpot != nil && strings.TrimSpace(s.HubSpot.CatalogForLLM) != "" &&
-		hubspotCtx != "" && !strings.HasPrefix(hubspotCtx, "[HUBSPOT_ERROR") && !strings.HasPrefix(hubspotCtx, "[HUBSPOT_EMPTY") {
-		hubspotCtx = s.HubSpot.CatalogForLLM + "\n\n" + hubspotCtx
+	run := s.runSkills(ctx, req, plan, contextActions, seed, &telEvent)
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
-
-	// Build appendDataTable for large inline results.
-	var appendDataTable string
-	for i, qr := range dbQueryResults {
-		if qr == nil {
-			continue
-		}
-		if i < len(dbQueryActions) && dbQueryActions[i].WantsAllRows && len(qr.Data.Rows) > 30 {
-			// Cap inline table to 100 rows; larger results should have gone through CSV.
-			showRows := len(qr.Data.Rows)
-			if showRows > 100 {
-				showRows = 100
-			}
-			appendDataTable += metabase.FormatQueryResult(*qr, showRows)
+	if run.Reply != "" {
+		if replyErr := replyFn(markEdited(ctx, run.Reply)); replyErr != nil {
+			log.Printf("[ERR] skill reply failed: %v", replyErr)
+			return replyErr
 		}
+		log.Printf("[JARVIS] skill reply handled dur=%s", time.Since(start))
+		return nil
 	}
-	_ = dbQueryActions // suppress unused warning if no large results
 
 	// 10) File context from attachments (current message + thread history files).
 	// When the user references a file shared in an earlier reply, we collect all
@@ -940,7 +441,7 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 		if fetchTs == "" {
 			fetchTs = threadTs
 		}
-		if tf, err := s.Slack.GetThreadFiles(contextChannel, fetchTs); err != nil {
+		if tf, err := s.Slack.GetThreadFiles(ctx, contextChannel, fetchTs); err != nil {
 			log.Printf("[WARN] GetThreadFiles failed: %v", err)
 		} else {
 			seen := make(map[string]bool)
@@ -964,28 +465,38 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 			}
 		}
 	}
-	fileCtx := s.buildFileContext(allFiles)
+	if ph != nil && len(allFiles) > 0 {
+		ph.Progress("lendo anexos…")
+	}
+	fileCtx := s.buildFileContext(ctx, allFiles)
 	if fileCtx != "" {
 		log.Printf("[JARVIS] fileContext files=%d chars=%d", len(allFiles), len(fileCtx))
 	}
-	images := s.buildImageAttachments(allFiles)
+	images := s.buildImageAttachments(ctx, allFiles)
 	if len(images) > 0 {
 		log.Printf("[JARVIS] imageAttachments count=%d", len(images))
 	}
 
 	// 11) Generate the answer with the primary LLM (with retry and fallback).
-	answer, err := s.LLM.AnswerWithRetry(
+	var onPartial func(string)
+	if ph != nil {
+		onPartial = ph.Partial
+	}
+	answer, err := s.LLM.AnswerWithRetry(ctx,
 		s.getCompanyCtx(),
-		questionForLLM, threadHist, slackCtx, jiraCtx, dbCtx, fileCtx, outlineCtx, googleDriveCtx, hubspotCtx, images,
+		questionForLLM, threadHist, run.Sections, fileCtx, images, onPartial,
 		s.Cfg.OpenAIModel, s.Cfg.OpenAILesserModel, 2, 0,
 	)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	if err != nil || strings.TrimSpace(answer) == "" {
 		log.Printf("[ERR] llmAnswer failed: %v", err)
-		answer = buildInformativeFallback(executedSlackSearch, slackMatches, executedJiraSearch, jiraIssuesFound, "")
+		answer = run.Fallback()
 	}
 	answer = strings.TrimSpace(answer)
 	if answer == "" {
-		answer = buildInformativeFallback(executedSlackSearch, slackMatches, executedJiraSearch, jiraIssuesFound, "")
+		answer = run.Fallback()
 	}
 
 	// Prepend handler action confirmations (e.g. "Card criado ✅") when they were
@@ -995,35 +506,8 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 		answer = strings.Join(handlerReplyParts, "\n\n") + "\n\n" + answer
 	}
 
-	// Append CSV download link unconditionally when a file was generated.
-	// We never rely on the LLM to copy the link from the context.
-	if csvDownloadLine != "" {
-		answer += "\n\n" + csvDownloadLine
-	}
-
-	// Append Outline source links when documentation was used.
-	if outlineSources != "" {
-		answer += "\n\n" + outlineSources
-	}
-
-	// Append Google Drive source links when files were used.
-	if googleDriveSources != "" {
-		answer += "\n\n" + googleDriveSources
-	}
-
-	// Append HubSpot source links when CRM data was used.
-	if hubspotSources != "" {
-		answer += "\n\n" + hubspotSources
-	}
-
-	// Append full data table when bypassing LLM for large result formatting.
-	if appendDataTable != "" {
-		if strings.Contains(answer, "[TABLE]") {
-			answer = strings.Replace(answer, "[TABLE]", "\n```\n"+appendDataTable+"\n```", 1)
-		} else {
-			answer = answer + "\n\n```\n" + appendDataTable + "\n```"
-		}
-	}
+	// Append CSV download links, source links and large data tables.
+	answer = markEdited(ctx, run.Decorate(answer))
 
 	// If the answer is too long for an in-place update, ask for confirmation
 	// before posting multiple messages to the thread.
@@ -1034,22 +518,28 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 		if pendingKeyTs == "" {
 			pendingKeyTs = originTs
 		}
-		s.pendingReplies.Store(contextChannel+":"+pendingKeyTs, pendingReply{
+		pendingKey := contextChannel + ":" + pendingKeyTs
+		s.pendingReplies.Store(pendingKey, pendingReply{
 			chunks:           chunks,
 			originalOriginTs: originTs,
+			channel:          channel,
+			threadTs:         threadTs,
 		})
 		var confirmMsg string
 		if len(chunks) == 1 {
-			confirmMsg = "Essa resposta é longa. Posso postar na thread? _(responda *sim* ou *não*)_"
+			confirmMsg = "Essa resposta é longa. Posso postar na thread?"
 		} else {
-			confirmMsg = fmt.Sprintf("Essa resposta precisará de *%d mensagens* para ser enviada por completo. Posso postar tudo? _(responda *sim* ou *não*)_", len(chunks))
+			confirmMsg = fmt.Sprintf("Essa resposta precisará de *%d mensagens* para ser enviada por completo. Posso postar tudo?", len(chunks))
 		}
-		if err := replyFn(confirmMsg); err != nil {
-			log.Printf("[ERR] long reply confirmation prompt failed: %v", err)
-			return err
-		}
-		if busyTs != "" {
-			s.Slack.Tracker.Track(channel, originTs, busyTs)
+		// Buttons resolve the pending reply by key; typing *sim*/*não* in the
+		// thread still works where interactivity is not configured.
+		fallback := confirmMsg + " _(responda *sim* ou *não*)_"
+		if err := replyBlocksFn(fallback, longReplyBlocks(confirmMsg, pendingKey)); err != nil {
+			log.Printf("[WARN] long reply prompt blocks failed, falling back to text: %v", err)
+			if err := replyFn(fallback); err != nil {
+				log.Printf("[ERR] long reply confirmation prompt failed: %v", err)
+				return err
+			}
 		}
 		log.Printf("[JARVIS] long reply pending chunks=%d total_chars=%d dur=%s", len(chunks), len(answer), time.Since(start))
 		return nil
@@ -1064,9 +554,6 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 		telEvent.ErrorStage = "post_message"
 		return err
 	}
-	if busyTs != "" {
-		s.Slack.Tracker.Track(channel, originTs, busyTs)
-	}
 	log.Printf("[JARVIS] done dur=%s answer_len=%d", time.Since(start), len(answer))
 	return nil
 }
@@ -1076,7 +563,7 @@ func (s *Service) HandleMessage(channel, threadTs, originTs, originalText, quest
 func splitActions(actions []llm.ActionDescriptor) (handlers, context []llm.ActionDescriptor) {
 	for _, a := range actions {
 		switch a.Kind {
-		case llm.ActionJiraCreate, llm.ActionJiraEdit:
+		case kindJiraCreate, kindJiraEdit:
 			handlers = append(handlers, a)
 		default:
 			context = append(context, a)
@@ -1109,7 +596,7 @@ func fallbackActions(hasThreadPermalink bool) []llm.ActionDescriptor {
 	if hasThreadPermalink {
 		return nil // thread content is already the context
 	}
-	return []llm.ActionDescriptor{{Kind: llm.ActionJiraSearch, JiraIntent: "default"}}
+	return []llm.ActionDescriptor{{Kind: kindJiraSearch, JiraIntent: "default"}}
 }
 
 // channelType returns "dm" for direct-message channels (IDs starting with "D")
@@ -1121,28 +608,47 @@ func channelType(channel string) string {
 	return "channel"
 }
 
-// allContextsNegative returns true when every accumulated context is empty or
-// contains only an error/empty marker — indicating all sources came up blank.
-func allContextsNegative(hubspotCtx string, jiraCtxParts, slackCtxParts, dbCtxParts []string, outlineCtx, googleDriveCtx string) bool {
-	isNeg := func(s string) bool {
-		return s == "" ||
-			strings.HasPrefix(s, "[HUBSPOT_EMPTY") ||
-			strings.HasPrefix(s, "[HUBSPOT_ERROR") ||
-			strings.HasPrefix(s, "[JIRA_EMPTY") ||
-			strings.HasPrefix(s, "[JIRA_ERROR") ||
-			strings.HasPrefix(s, "[ERRO:") ||
-			strings.HasPrefix(s, "[AVISO:")
-	}
-	allNeg := func(parts []string) bool {
-		if len(parts) == 0 {
-			return true
-		}
-		for _, p := range parts {
-			if !isNeg(p) {
-				return false
-			}
+// followUpContextActions keeps the context actions requested by a follow-up
+// router step.  Handler actions and show_sql only make sense as a direct reply
+// to the user's message, so they are dropped after the first round.
+func followUpContextActions(actions []llm.ActionDescriptor) []llm.ActionDescriptor {
+	_, ctxActions := splitActions(actions)
+	return dropKind(ctxActions, kindShowSQL)
+}
+
+// dropKind returns the actions not of the given kind in a new slice;
+// actions itself is left untouched.
+func dropKind(actions []llm.ActionDescriptor, kind string) []llm.ActionDescriptor {
+	var out []llm.ActionDescriptor
+	for _, a := range actions {
+		if a.Kind != kind {
+			out = append(out, a)
 		}
-		return true
 	}
-	return isNeg(hubspotCtx) && allNeg(jiraCtxParts) && len(slackCtxParts) == 0 && allNeg(dbCtxParts) && isNeg(outlineCtx) && isNeg(googleDriveCtx)
+	return out
+}
+
+// summarizeActionContext describes an action's context for the router: the
+// error/empty marker as-is, or the size and first lines of the data found.
+func summarizeActionContext(ctx string) string {
+	ctx = strings.TrimSpace(ctx)
+	switch {
+	case ctx == "":
+		return "nenhum resultado (fonte vazia ou indisponível)."
+	case isNegativeContext(ctx):
+		return ctx
+	}
+	return fmt.Sprintf("%d caracteres de contexto obtidos. Início:\n%s", len(ctx), preview(ctx, 600))
+}
+
+// isNegativeContext reports whether a context block is only an error or
+// empty-result marker.
+func isNegativeContext(s string) bool {
+	return s == "" ||
+		strings.HasPrefix(s, "[HUBSPOT_EMPTY") ||
+		strings.HasPrefix(s, "[HUBSPOT_ERROR") ||
+		strings.HasPrefix(s, "[JIRA_EMPTY") ||
+		strings.HasPrefix(s, "[JIRA_ERROR") ||
+		strings.HasPrefix(s, "[ERRO:") ||
+		strings.HasPrefix(s, "[AVISO:")
 }
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DanielFillol/Jarvis/internal/llm"
+)
+
+func TestDropKindLeavesInputIntact(t *testing.T) {
+	actions := []llm.ActionDescriptor{
+		{Kind: kindSlackSearch, CallID: "1"},
+		{Kind: kindJiraSearch, CallID: "2"},
+		{Kind: kindSlackSearch, CallID: "3"},
+		{Kind: kindShowSQL, CallID: "4"},
+	}
+	orig := append([]llm.ActionDescriptor(nil), actions...)
+
+	got := dropKind(actions, kindSlackSearch)
+	if kinds := actionKinds(got); !reflect.DeepEqual(kinds, []string{kindJiraSearch, kindShowSQL}) {
+		t.Errorf("dropKind = %v", kinds)
+	}
+	if !reflect.DeepEqual(actions, orig) {
+		t.Errorf("input rewritten: %v, want %v", actionKinds(actions), actionKinds(orig))
+	}
+	got[0].Kind = "changed"
+	if actions[1].Kind != kindJiraSearch {
+		t.Error("result shares its backing array with the input")
+	}
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/DanielFillol/Jarvis/internal/skill"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+)
+
+// reCitation matches the citation markers the answer model writes after a
+// claim: [[S:3fa9c1]] or [[J:PROJ-1, S:3fa9c1]], with any leading spaces.
+var reCitation = regexp.MustCompile(`[ \t]*\[\[([^\[\]\n]{1,200})\]\]`)
+
+// slackSourceID returns the stable source ID of a Slack message: a short
+// hash of its permalink (or channel and timestamp when there is none), so
+// the same message gets the same ID across searches and rounds.
+func slackSourceID(m slack.SearchMessage) string {
+	key := m.Permalink
+	if key == "" {
+		key = m.Channel + "/" + m.Ts + "/" + m.Text
+	}
+	sum := sha1.Sum([]byte(key))
+	return "S:" + hex.EncodeToString(sum[:3])
+}
+
+// jiraSourceID returns the source ID of a Jira issue.
+func jiraSourceID(key string) string { return "J:" + key }
+
+// jiraIssueURL returns the browse link of an issue, or "" when Jira is not configured.
+func (s *Service) jiraIssueURL(key string) string {
+	base := strings.TrimRight(strings.TrimSpace(s.Cfg.JiraBaseURL), "/")
+	if base == "" {
+		return ""
+	}
+	return base + "/browse/" + key
+}
+
+// renderCitations replaces citation markers with footnote numbers, in order
+// of first use, and appends the numbered list of sources.  Markers naming
+// IDs that were never retrieved are dropped, so the model cannot cite a
+// message or card that was not in its context.  plain renders bare [n]
+// references and a list of plain URLs for /api/chat clients, which do not
+// understand Slack mrkdwn.
+func renderCitations(answer string, citations []skill.Citation, plain bool) string {
+	byID := make(map[string]skill.Citation, len(citations))
+	for _, c := range citations {
+		byID[c.ID] = c
+	}
+	number := map[string]int{}
+	var used []skill.Citation
+	dropped := 0
+	answer = reCitation.ReplaceAllStringFunc(answer, func(m string) string {
+		inner := reCitation.FindStringSubmatch(m)[1]
+		var refs []string
+		for _, id := range strings.FieldsFunc(inner, func(r rune) bool { return r == ',' || r == ';' || r == ' ' }) {
+			c, ok := byID[id]
+			if !ok {
+				dropped++
+				continue
+			}
+			n, seen := number[id]
+			if !seen {
+				used = append(used, c)
+				n = len(used)
+				number[id] = n
+			}
+			ref := fmt.Sprintf("[%d]", n)
+			if c.URL != "" && !plain {
+				ref = fmt.Sprintf("<%s|[%d]>", c.URL, n)
+			}
+			refs = append(refs, ref)
+		}
+		if len(refs) == 0 {
+			return ""
+		}
+		return " " + strings.Join(refs, "")
+	})
+	if dropped > 0 {
+		log.Printf("[JARVIS] citations dropped=%d (unknown source IDs)", dropped)
+	}
+	if len(used) == 0 {
+		return answer
+	}
+	var b strings.Builder
+	b.WriteString(answer)
+	if plain {
+		b.WriteString("\n\nFontes:")
+	} else {
+		b.WriteString("\n\n:link: _Fontes:_")
+	}
+	for i, c := range used {
+		label := c.Label
+		switch {
+		case c.URL == "":
+		case plain:
+			label = fmt.Sprintf("%s (%s)", c.Label, c.URL)
+		default:
+			label = fmt.Sprintf("<%s|%s>", c.URL, c.Label)
+		}
+		fmt.Fprintf(&b, "\n%d. %s", i+1, label)
+	}
+	return b.String()
+}
+
+// stripCitations removes citation markers, for partial answers shown before
+// the sources are known.
+func stripCitations(s string) string {
+	return reCitation.ReplaceAllString(s, "")
+}
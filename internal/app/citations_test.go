@@ -0,0 +1,33 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/DanielFillol/Jarvis/internal/skill"
+)
+
+func TestRenderCitations(t *testing.T) {
+	citations := []skill.Citation{
+		{ID: "J:PROJ-1", Label: "PROJ-1 Deploy", URL: "https://acme.atlassian.net/browse/PROJ-1"},
+		{ID: "S:3fa9c1", Label: "#eng"},
+	}
+	answer := "O deploy foi adiado [[J:PROJ-1]]. Confirmado no canal [[S:3fa9c1, S:ffffff]]."
+
+	tests := []struct {
+		name  string
+		plain bool
+		want  string
+	}{
+		{"slack", false, "O deploy foi adiado <https://acme.atlassian.net/browse/PROJ-1|[1]>. Confirmado no canal [2].\n\n" +
+			":link: _Fontes:_\n1. <https://acme.atlassian.net/browse/PROJ-1|PROJ-1 Deploy>\n2. #eng"},
+		{"direct", true, "O deploy foi adiado [1]. Confirmado no canal [2].\n\n" +
+			"Fontes:\n1. PROJ-1 Deploy (https://acme.atlassian.net/browse/PROJ-1)\n2. #eng"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderCitations(answer, citations, tt.plain); got != tt.want {
+				t.Errorf("renderCitations() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
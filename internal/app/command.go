@@ -0,0 +1,291 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/jira"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/metabase"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// Slash command subcommands and their Portuguese aliases.
+var commandAliases = map[string]string{
+	"ask":          "ask",
+	"perguntar":    "ask",
+	"pergunta":     "ask",
+	"jira":         "jira",
+	"sql":          "sql",
+	"export":       "export",
+	"exportar":     "export",
+	"help":         "help",
+	"ajuda":        "help",
+	"agendamentos": "schedules",
+	"agenda":       "schedules",
+	"schedules":    "schedules",
+}
+
+// splitCommand returns the canonical subcommand of a /jarvis invocation and
+// the rest of its text.  Unknown or empty subcommands map to "help".
+func splitCommand(text string) (sub, args string) {
+	text = strings.TrimSpace(text)
+	first, rest, _ := strings.Cut(text, " ")
+	sub, ok := commandAliases[strings.ToLower(first)]
+	if !ok {
+		return "help", text
+	}
+	return sub, strings.TrimSpace(rest)
+}
+
+// CommandAck returns the reply sent within Slack's 3-second ack window.
+// async reports whether HandleCommand must run afterwards to deliver the
+// real answer through the command's response_url.
+func (s *Service) CommandAck(cmd slack.SlashCommand) (ack slack.CommandResponse, async bool) {
+	sub, args := splitCommand(cmd.Text)
+	if sub == "schedules" {
+		return slack.CommandResponse{ResponseType: "ephemeral", Text: s.commandSchedules(cmd, args)}, false
+	}
+	if sub == "help" || args == "" {
+		return slack.CommandResponse{ResponseType: "ephemeral", Text: s.commandHelp(cmd.Command, sub, args)}, false
+	}
+	return slack.CommandResponse{ResponseType: "ephemeral", Text: "_processando..._"}, true
+}
+
+// HandleCommand runs a /jarvis subcommand and posts its result to the
+// command's response_url.  Token usage is metered and charged to the daily
+// budgets like a mention.
+func (s *Service) HandleCommand(ctx context.Context, cmd slack.SlashCommand) {
+	start := time.Now()
+	sub, args := splitCommand(cmd.Text)
+	log.Printf("[JARVIS] command sub=%s args=%q user=%q channel=%q", sub, preview(args, 180), cmd.UserID, cmd.ChannelID)
+
+	telEvent := telemetry.Event{
+		Channel:      cmd.ChannelID,
+		ChannelType:  channelType(cmd.ChannelID),
+		SenderUserID: cmd.UserID,
+		QuestionLen:  len(args),
+		LLMModel:     s.Cfg.OpenAIModel,
+		Actions:      []string{"command_" + sub},
+		Success:      true,
+	}
+	meter := llm.NewUsageMeter()
+	ctx = llm.WithUsageMeter(ctx, meter)
+	defer func() {
+		s.recordUsage(&telEvent, meter, cmd.UserID, cmd.ChannelID)
+		telEvent.DurationMs = int(time.Since(start).Milliseconds())
+		s.Telemetry.Record(telEvent)
+	}()
+
+	respond := func(responseType, text string) {
+		for _, chunk := range splitIntoChunks(strings.TrimSpace(text), 3900) {
+			if err := s.Slack.Respond(ctx, cmd.ResponseURL, slack.CommandResponse{ResponseType: responseType, Text: chunk}); err != nil {
+				log.Printf("[WARN] command respond failed: %v", err)
+				return
+			}
+		}
+	}
+
+	if msg := s.budgetExceeded(ctx, cmd.UserID, cmd.ChannelID); msg != "" {
+		telEvent.Success = false
+		telEvent.ErrorStage = "token_budget"
+		respond("ephemeral", msg)
+		return
+	}
+
+	var (
+		text   string
+		public bool
+	)
+	switch sub {
+	case "ask":
+		text, public = s.commandAsk(ctx, cmd, args), true
+	case "jira":
+		text, public = s.commandJira(ctx, cmd, args)
+	case "sql":
+		text, public = s.commandSQL(ctx, args, false), true
+	case "export":
+		text, public = s.commandSQL(ctx, args, true), true
+	}
+	if ctx.Err() != nil {
+		telEvent.Success = false
+		telEvent.ErrorStage = "cancelled"
+		return
+	}
+	responseType := "ephemeral"
+	if public {
+		responseType = "in_channel"
+	}
+	respond(responseType, text)
+	log.Printf("[JARVIS] command sub=%s done dur=%s", sub, time.Since(start))
+}
+
+// commandAsk answers a free-form question through the direct pipeline.  The
+// question is quoted above the answer since the command text itself is not
+// shown in the channel.
+func (s *Service) commandAsk(ctx context.Context, cmd slack.SlashCommand, question string) string {
+	threadID := fmt.Sprintf("command-%s-%d", cmd.ChannelID, time.Now().UnixNano())
+	answer, err := s.ProcessDirect(ctx, question, cmd.UserID, threadID, "", nil)
+	if err != nil {
+		log.Printf("[ERR] command ask: %v", err)
+		return "Não consegui gerar a resposta (erro interno)."
+	}
+	return fmt.Sprintf("<@%s> perguntou: _%s_\n\n%s", cmd.UserID, clip(question, 300), answer)
+}
+
+// commandJira handles "/jarvis jira create ...".  The explicit form
+// "PROJ | Tipo | Título | Descrição" creates the card as given; free text is
+// read by the same extractor used for mentions.  Replies are public only
+// when a card was created.
+func (s *Service) commandJira(ctx context.Context, cmd slack.SlashCommand, args string) (string, bool) {
+	action, rest, _ := strings.Cut(args, " ")
+	switch strings.ToLower(action) {
+	case "create", "criar":
+	default:
+		return s.commandHelp(cmd.Command, "jira", args), false
+	}
+	if !s.Cfg.JiraEnabled() {
+		return "A integração com o Jira não está configurada nesta instalação.", false
+	}
+	if !s.skillGranted(ctx, kindJiraSearch) {
+		return "A integração com o Jira não está liberada neste workspace.", false
+	}
+	if !s.Cfg.JiraCreateEnabled {
+		return "Criação de issues no Jira está desabilitada.", false
+	}
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return s.commandHelp(cmd.Command, "jira", args), false
+	}
+
+	var draft jira.IssueDraft
+	if parts := strings.Split(rest, "|"); len(parts) >= 3 {
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		draft = jira.IssueDraft{Project: strings.ToUpper(parts[0]), IssueType: parts[1], Summary: parts[2]}
+		if len(parts) > 3 {
+			draft.Description = strings.Join(parts[3:], " | ")
+		}
+	} else {
+		var err error
+		draft, err = s.LLM.ExtractIssueFromThread(ctx, "", "", rest, s.Cfg.OpenAIModel, nil, s.Cfg.JiraProjectNameMapFor(s.Slack.InstalledTeam(ctx)))
+		if err != nil {
+			return fmt.Sprintf("Não consegui interpretar o card: %v", err), false
+		}
+	}
+	if missing := missingFields(draft); len(missing) > 0 {
+		return fmt.Sprintf("Faltam campos para criar o card: *%s*.\nUse `%s jira create PROJ | Tipo | Título | Descrição`.",
+			strings.Join(missing, ", "), cmd.Command), false
+	}
+
+	if !s.jiraProjectAllowed(ctx, draft.Project) {
+		return fmt.Sprintf("Este workspace não pode criar cards no projeto *%s*.", draft.Project), false
+	}
+	draft.Description = strings.TrimSpace(draft.Description) + fmt.Sprintf("\n\n---\nCriado via %s por @%s no Slack.", cmd.Command, cmd.UserName)
+	created, err := s.Jira.CreateIssue(ctx, draft)
+	if err != nil {
+		return fmt.Sprintf("Não consegui criar o card no Jira: %v", err), false
+	}
+	log.Printf("[JARVIS] command jira created key=%s", created.Key)
+	return fmt.Sprintf("Card criado ✅ *%s* — %s\n%s", created.Key, draft.Summary, s.jiraIssueURL(created.Key)), true
+}
+
+// commandSQL handles "/jarvis sql <db> <pergunta>" and "/jarvis export <db>
+// <pergunta>": the question is answered by a generated query against the
+// named database.  export returns every row as a CSV download.
+func (s *Service) commandSQL(ctx context.Context, args string, export bool) string {
+	if s.Metabase == nil {
+		return "A integração com o Metabase não está configurada nesta instalação."
+	}
+	if !s.skillGranted(ctx, kindMetabaseQuery) {
+		return "A integração com o Metabase não está liberada neste workspace."
+	}
+	dbRef, question, _ := strings.Cut(args, " ")
+	question = strings.TrimSpace(question)
+	teamID := s.Slack.InstalledTeam(ctx)
+	db, ok := s.findMetabaseDatabase(dbRef)
+	if !ok || !s.Cfg.MetabaseDBAllowed(teamID, db.ID) {
+		return "Banco não encontrado: `" + dbRef + "`. Bancos disponíveis:\n" + strings.Join(s.formattedMetabaseDatabases(teamID), "\n")
+	}
+	if question == "" {
+		return "Informe a pergunta depois do banco, ex: `sql " + dbRef + " quantos pedidos foram criados ontem?`"
+	}
+
+	res := s.runMetabaseQuery(ctx, question, "", db.ID, "", export)
+	if strings.HasPrefix(res.DBCtx, llm.ClarificationPrefix) {
+		return strings.TrimSpace(strings.TrimPrefix(res.DBCtx, llm.ClarificationPrefix))
+	}
+	if res.QueryResult == nil {
+		return fmt.Sprintf("Não consegui gerar uma consulta que funcione em *%s* para essa pergunta. Tente reformular.", db.Name)
+	}
+
+	header := fmt.Sprintf("*%s* — _%s_\n```\n%s\n```", db.Name, clip(question, 300), res.ExecutedSQL)
+	if !export {
+		return header + "\n```\n" + metabase.FormatQueryResult(*res.QueryResult, 50) + "\n```"
+	}
+	if s.FileServer == nil || strings.TrimSpace(s.Cfg.PublicBaseURL) == "" {
+		return header + "\nExportação CSV indisponível: configure `PUBLIC_BASE_URL`."
+	}
+	fileID := s.FileServer.Store("resultado.csv", []byte(metabase.FormatQueryResultAsCSV(*res.QueryResult)), time.Hour)
+	csvURL := strings.TrimRight(s.Cfg.PublicBaseURL, "/") + "/files/" + fileID
+	return fmt.Sprintf("%s\n:page_facing_up: *Download CSV:* <%s|resultado.csv> — %d linhas _(expira em 1 hora)_",
+		header, csvURL, len(res.QueryResult.Data.Rows))
+}
+
+// findMetabaseDatabase resolves a database by ID or by name (exact, then
+// prefix, case-insensitive).
+func (s *Service) findMetabaseDatabase(ref string) (metabase.Database, bool) {
+	ref = strings.TrimSpace(ref)
+	if id, err := strconv.Atoi(ref); err == nil {
+		for _, db := range s.Metabase.Databases {
+			if db.ID == id {
+				return db, true
+			}
+		}
+		return metabase.Database{}, false
+	}
+	lower := strings.ToLower(ref)
+	for _, db := range s.Metabase.Databases {
+		if strings.ToLower(db.Name) == lower {
+			return db, true
+		}
+	}
+	for _, db := range s.Metabase.Databases {
+		if lower != "" && strings.HasPrefix(strings.ToLower(db.Name), lower) {
+			return db, true
+		}
+	}
+	return metabase.Database{}, false
+}
+
+// commandHelp describes the available subcommands.  sub and args are what
+// the user typed, used to point out an unknown subcommand.
+func (s *Service) commandHelp(command, sub, args string) string {
+	if command == "" {
+		command = "/jarvis"
+	}
+	var b strings.Builder
+	if sub == "help" && args != "" && !strings.EqualFold(args, "help") && !strings.EqualFold(args, "ajuda") {
+		fmt.Fprintf(&b, "Não conheço o comando `%s`.\n\n", clip(args, 60))
+	}
+	fmt.Fprintf(&b, "*Comandos do %s:*\n", s.Cfg.BotName)
+	fmt.Fprintf(&b, "• `%s ask <pergunta>` — responde usando todas as fontes configuradas\n", command)
+	if s.Cfg.JiraEnabled() && s.Cfg.JiraCreateEnabled {
+		fmt.Fprintf(&b, "• `%s jira create PROJ | Tipo | Título | Descrição` — cria um card (ou descreva o card em texto livre)\n", command)
+	}
+	if s.Metabase != nil {
+		fmt.Fprintf(&b, "• `%s sql <banco> <pergunta>` — consulta o banco e mostra a query e o resultado\n", command)
+		fmt.Fprintf(&b, "• `%s export <banco> <pergunta>` — exporta todas as linhas do resultado em CSV\n", command)
+	}
+	if s.Schedules != nil && s.Cfg.ScheduleMaxPerUser > 0 {
+		fmt.Fprintf(&b, "• `%s agendamentos [pausar|retomar|excluir <id>]` — lista e gerencia suas perguntas agendadas (crie com, ex: _todo dia útil às 9h me mande os bugs abertos_)\n", command)
+	}
+	fmt.Fprintf(&b, "• `%s help` — mostra esta ajuda", command)
+	return b.String()
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"log"
 	"os"
 	"path/filepath"
@@ -14,7 +15,7 @@ import (
 // GenerateCompanyContext synthesizes Jira, Metabase, and Outline documentation
 // into a compact domain glossary and writes it to cfg.CompanyContextPath.
 // Returns the generated string, or "" if there is no source material.
-func GenerateCompanyContext(cfg config.Config, outlineClient *outline.Client, llmClient *llm.Client) string {
+func GenerateCompanyContext(ctx context.Context, cfg config.Config, outlineClient *outline.Client, llmClient *llm.Client) string {
 	jiraDoc := readDocFile(cfg.JiraProjectsPath, 6000)
 
 	compactMetabasePath := strings.TrimSuffix(cfg.MetabaseSchemaPath, ".md") + "_compact.md"
@@ -22,7 +23,7 @@ func GenerateCompanyContext(cfg config.Config, outlineClient *outline.Client, ll
 
 	var outlineDocs string
 	if outlineClient != nil {
-		results, err := outlineClient.ListDocuments(15)
+		results, err := outlineClient.ListDocuments(ctx, 15)
 		if err != nil {
 			log.Printf("[BOOT] company_context: outline list failed: %v", err)
 		} else {
@@ -37,18 +38,18 @@ func GenerateCompanyContext(cfg config.Config, outlineClient *outline.Client, ll
 		return ""
 	}
 
-	ctx := llmClient.GenerateCompanyContext(jiraDoc, metabaseDoc, outlineDocs, hubspotDoc, cfg.OpenAILesserModel)
-	if strings.TrimSpace(ctx) == "" {
+	glossary := llmClient.GenerateCompanyContext(ctx, jiraDoc, metabaseDoc, outlineDocs, hubspotDoc, cfg.OpenAILesserModel)
+	if strings.TrimSpace(glossary) == "" {
 		return ""
 	}
 
 	if err := os.MkdirAll(filepath.Dir(cfg.CompanyContextPath), 0o755); err != nil {
 		log.Printf("[BOOT] company_context: mkdir failed: %v", err)
-	} else if err := os.WriteFile(cfg.CompanyContextPath, []byte(ctx), 0o644); err != nil {
+	} else if err := os.WriteFile(cfg.CompanyContextPath, []byte(glossary), 0o644); err != nil {
 		log.Printf("[BOOT] company_context: write failed: %v", err)
 	} else {
-		log.Printf("[BOOT] company context written to %s (%d bytes)", cfg.CompanyContextPath, len(ctx))
+		log.Printf("[BOOT] company context written to %s (%d bytes)", cfg.CompanyContextPath, len(glossary))
 	}
 
-	return ctx
+	return glossary
 }
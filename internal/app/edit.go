@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/DanielFillol/Jarvis/internal/slack"
+)
+
+type editedReplyKey struct{}
+
+// editedReplyTs returns the answer being replaced when ctx comes from
+// ReanswerEdited, or "".
+func editedReplyTs(ctx context.Context) string {
+	ts, _ := ctx.Value(editedReplyKey{}).(string)
+	return ts
+}
+
+// markEdited flags the answer to an edited question.  It is applied before a
+// long answer is split, so the marker ends the last chunk whether the answer
+// is posted in place or after a long-reply confirmation.
+func markEdited(ctx context.Context, text string) string {
+	if editedReplyTs(ctx) == "" {
+		return text
+	}
+	return text + "\n\n_(atualizado)_"
+}
+
+// editedActionNote tells the user that the Jira actions of an edited
+// question were not run again.
+const editedActionNote = "_Mensagem editada: criação e edição de cards no Jira não são refeitas. Para repetir a ação, envie uma nova mensagem._"
+
+// noteEditedAction answers an edit that only asked for Jira actions: the
+// previous answer (usually the card confirmation) is kept and the note is
+// added under it.
+func (s *Service) noteEditedAction(ctx context.Context, channel, replyTs string) error {
+	text := editedActionNote
+	if prev, err := s.Slack.GetMessage(ctx, channel, replyTs); err != nil {
+		log.Printf("[WARN] edit: read previous answer ts=%q failed: %v", replyTs, err)
+	} else if prevText := strings.TrimSpace(prev.Text); prevText != "" {
+		text = prevText
+		if !strings.Contains(prevText, editedActionNote) {
+			text += "\n\n" + editedActionNote
+		}
+	}
+	return s.Slack.UpdateMessage(ctx, channel, replyTs, text)
+}
+
+// ReanswerEdited answers an edited question again, replacing the previous
+// answer in place (marked "atualizado") instead of posting a new one.  Extra
+// messages of the old answer (long-reply chunks) are deleted.  Jira create
+// and edit actions are not run again.  Falls back to a regular answer when
+// nothing was tracked for originTs.
+func (s *Service) ReanswerEdited(ctx context.Context, channel, threadTs, originTs, originalText, question, senderUserID string, files []slack.File) error {
+	tracked := append([]string(nil), s.Slack.Tracker.GetAll(channel, originTs)...)
+	if len(tracked) == 0 {
+		return s.HandleMessage(ctx, channel, threadTs, originTs, originalText, question, senderUserID, files)
+	}
+	replyTs := tracked[0]
+	for _, ts := range tracked[1:] {
+		if err := s.Slack.DeleteMessage(ctx, channel, ts); err != nil {
+			log.Printf("[WARN] edit: delete old reply ts=%q failed: %v", ts, err)
+		}
+	}
+	s.Slack.Tracker.Delete(channel, originTs)
+	s.Slack.Tracker.Track(channel, originTs, replyTs)
+	// A long-reply confirmation for the old answer no longer applies.
+	s.pendingReplies.Delete(channel + ":" + threadTs)
+
+	log.Printf("[JARVIS] re-answering edited question origin=%q reply=%q dropped=%d", originTs, replyTs, len(tracked)-1)
+	return s.HandleMessage(context.WithValue(ctx, editedReplyKey{}, replyTs), channel, threadTs, originTs, originalText, question, senderUserID, files)
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DanielFillol/Jarvis/internal/slack"
+)
+
+func TestMarkEditedReachesLongReplies(t *testing.T) {
+	if got := markEdited(context.Background(), "resposta"); got != "resposta" {
+		t.Errorf("new question marked: %q", got)
+	}
+	ctx := context.WithValue(context.Background(), editedReplyKey{}, "1700000000.000100")
+	if got := markEdited(ctx, "resposta"); got != "resposta\n\n_(atualizado)_" {
+		t.Errorf("edited answer = %q", got)
+	}
+
+	// A long answer is marked before it is split, so the chunks posted after
+	// the confirmation end with the marker too.
+	long := markEdited(ctx, strings.Repeat("linha de resposta\n", 400))
+	chunks := splitIntoChunks(long, 3900)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want a long reply", len(chunks))
+	}
+	if !strings.HasSuffix(chunks[len(chunks)-1], "_(atualizado)_") {
+		t.Errorf("last chunk does not end with the marker: %q", chunks[len(chunks)-1])
+	}
+	for _, c := range chunks[:len(chunks)-1] {
+		if strings.Contains(c, "atualizado") {
+			t.Error("marker repeated in an earlier chunk")
+		}
+	}
+}
+
+func TestNoteEditedActionKeepsPreviousAnswer(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		text = "Card criado ✅ *PROJ-5* — Corrigir login"
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/conversations.replies":
+			json.NewEncoder(w).Encode(map[string]any{"ok": true, "messages": []map[string]string{{"ts": "2.0", "text": text}}})
+		case "/chat.update":
+			var body struct {
+				Ts   string `json:"ts"`
+				Text string `json:"text"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Ts != "2.0" {
+				t.Errorf("updated ts=%q, want the tracked answer", body.Ts)
+			}
+			text = body.Text
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			t.Errorf("unexpected call %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	s := &Service{Slack: &slack.Client{APIBaseURL: srv.URL, HTTPClient: srv.Client(), BotToken: "xoxb-test"}}
+
+	// Editing twice adds the note once.
+	for i := 0; i < 2; i++ {
+		if err := s.noteEditedAction(context.Background(), "C1", "2.0"); err != nil {
+			t.Fatalf("noteEditedAction: %v", err)
+		}
+	}
+	if want := "Card criado ✅ *PROJ-5* — Corrigir login\n\n" + editedActionNote; text != want {
+		t.Errorf("answer = %q, want %q", text, want)
+	}
+}
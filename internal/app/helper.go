@@ -8,9 +8,11 @@ import (
 	"io"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/DanielFillol/Jarvis/internal/jira"
+	"github.com/DanielFillol/Jarvis/internal/skill"
 	pdflib "github.com/ledongthuc/pdf"
 	"github.com/xuri/excelize/v2"
 )
@@ -223,6 +225,70 @@ func extractJQLTextQuery(question string) string {
 	return strings.Join(kept, " ")
 }
 
+var reJQLOrderBy = regexp.MustCompile(`(?i)\s*\bORDER\s+BY\b`)
+
+// scopeJQL restricts jql to projects: the query's conditions are wrapped as
+// `project in (...) AND (...)`, keeping its ORDER BY.  A query whose
+// parentheses do not balance (outside quoted strings) could close the wrapper
+// and is rejected, as is an empty project list.
+func scopeJQL(jql string, projects []string) (string, error) {
+	if len(projects) == 0 {
+		return "", fmt.Errorf("no Jira projects allowed")
+	}
+	quoted := make([]string, len(projects))
+	for i, p := range projects {
+		quoted[i] = strconv.Quote(strings.ToUpper(strings.TrimSpace(p)))
+	}
+	where, order := strings.TrimSpace(jql), ""
+	for _, loc := range reJQLOrderBy.FindAllStringIndex(where, -1) {
+		if _, open := jqlScan(where[:loc[0]]); !open {
+			where, order = strings.TrimSpace(where[:loc[0]]), strings.TrimSpace(where[loc[0]:])
+			break
+		}
+	}
+	if depth, open := jqlScan(where); depth != 0 || open {
+		return "", fmt.Errorf("unbalanced parentheses in JQL")
+	}
+	scoped := "project in (" + strings.Join(quoted, ", ") + ")"
+	if where != "" {
+		scoped += " AND (" + where + ")"
+	}
+	if order != "" {
+		scoped += " " + order
+	}
+	return scoped, nil
+}
+
+// jqlScan walks jql and returns the parenthesis depth outside quoted strings
+// (-1 as soon as a parenthesis closes one that was never opened) and whether
+// a quoted string is left open.
+func jqlScan(jql string) (depth int, open bool) {
+	var quote rune
+	escaped := false
+	for _, r := range jql {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == '\\' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth < 0 {
+				return -1, quote != 0
+			}
+		}
+	}
+	return depth, quote != 0
+}
+
 func sanitizeJQL(jql string) string {
 	j := strings.TrimSpace(jql)
 	if j == "" {
@@ -312,25 +378,32 @@ func fixJQLPrecedence(jql string) string {
 
 // buildJiraContext produces a formatted context summary from a slice
 // of Jira issues.  If the number of issues exceeds 'limit,' it will
-// group by status and summarize counts.
-func buildJiraContext(issues []jira.SearchJQLRespIssue, limit int) string {
+// group by status and summarize counts.  Every issue written is tagged with
+// its source ID ([[J:KEY]]) and returned as a citation linking to issueURL.
+func buildJiraContext(issues []jira.SearchJQLRespIssue, limit int, issueURL func(key string) string) (string, []skill.Citation) {
 	if limit <= 0 {
 		limit = 40
 	}
+	var cites []skill.Citation
+	cite := func(it jira.SearchJQLRespIssue) string {
+		id := jiraSourceID(it.Key)
+		cites = append(cites, skill.Citation{ID: id, Label: it.Key + " — " + it.Summary, URL: issueURL(it.Key)})
+		return "[[" + id + "]]"
+	}
 	if len(issues) <= limit {
-		return buildJiraContextSimple(issues)
+		return buildJiraContextSimple(issues, cite), cites
 	}
-	return buildJiraContextGrouped(issues)
+	return buildJiraContextGrouped(issues, cite), cites
 }
 
-func buildJiraContextSimple(issues []jira.SearchJQLRespIssue) string {
+func buildJiraContextSimple(issues []jira.SearchJQLRespIssue, cite func(jira.SearchJQLRespIssue) string) string {
 	var b strings.Builder
 	for i, it := range issues {
 		sprint := ""
 		if it.Sprint != "" {
 			sprint = " | sprint=" + it.Sprint
 		}
-		b.WriteString(fmt.Sprintf("%s [%s] (%s) %s — %s | assignee=%s | updated=%s | created=%s%s\n", it.Key, it.Status, it.Type, it.Priority, it.Summary, it.Assignee, it.Updated, it.Created, sprint))
+		b.WriteString(fmt.Sprintf("%s [%s] (%s) %s — %s | assignee=%s | updated=%s | created=%s%s\n", cite(it), it.Status, it.Type, it.Priority, it.Summary, it.Assignee, it.Updated, it.Created, sprint))
 		if i >= 39 {
 			remaining := len(issues) - 40
 			if remaining > 0 {
@@ -342,7 +415,7 @@ func buildJiraContextSimple(issues []jira.SearchJQLRespIssue) string {
 	return b.String()
 }
 
-func buildJiraContextGrouped(issues []jira.SearchJQLRespIssue) string {
+func buildJiraContextGrouped(issues []jira.SearchJQLRespIssue, cite func(jira.SearchJQLRespIssue) string) string {
 	byStatus := make(map[string][]jira.SearchJQLRespIssue)
 	for _, it := range issues {
 		byStatus[it.Status] = append(byStatus[it.Status], it)
@@ -372,7 +445,7 @@ func buildJiraContextGrouped(issues []jira.SearchJQLRespIssue) string {
 				b.WriteString(fmt.Sprintf("  ... e mais %d\n", remaining))
 				break
 			}
-			b.WriteString(fmt.Sprintf("  %s (%s/%s): %s\n", it.Key, it.Type, it.Priority, it.Summary))
+			b.WriteString(fmt.Sprintf("  %s (%s/%s): %s\n", cite(it), it.Type, it.Priority, it.Summary))
 		}
 		b.WriteString("\n")
 	}
@@ -562,6 +635,13 @@ func isImageMimetype(mimetype string) bool {
 	return false
 }
 
+// isMediaMimetype reports whether the MIME type is audio or video, which is
+// transcribed instead of parsed.
+func isMediaMimetype(mimetype string) bool {
+	mimetype = strings.ToLower(strings.TrimSpace(mimetype))
+	return strings.HasPrefix(mimetype, "audio/") || strings.HasPrefix(mimetype, "video/")
+}
+
 // buildInformativeFallback constructs a fallback answer when the LLM
 // fails or no useful context is found.  It informs the user what
 // context was attempted and suggests next steps.
@@ -0,0 +1,39 @@
+package app
+
+import "testing"
+
+func TestScopeJQL(t *testing.T) {
+	projects := []string{"backend", "FRONT"}
+	tests := []struct {
+		name string
+		jql  string
+		want string
+		err  bool
+	}{
+		{"conditions and order", `project = OTHER AND status = "Em andamento" ORDER BY updated DESC`,
+			`project in ("BACKEND", "FRONT") AND (project = OTHER AND status = "Em andamento") ORDER BY updated DESC`, false},
+		{"or stays inside the wrapper", `project = OTHER OR project = BACKEND`,
+			`project in ("BACKEND", "FRONT") AND (project = OTHER OR project = BACKEND)`, false},
+		{"order only", `ORDER BY created DESC`, `project in ("BACKEND", "FRONT") ORDER BY created DESC`, false},
+		{"order by inside a string", `text ~ "order by" ORDER BY updated`,
+			`project in ("BACKEND", "FRONT") AND (text ~ "order by") ORDER BY updated`, false},
+		{"parenthesis inside a string", `text ~ "a) OR (b"`, `project in ("BACKEND", "FRONT") AND (text ~ "a) OR (b")`, false},
+		{"closing the wrapper", `status = Done) OR (project = OTHER`, "", true},
+		{"unbalanced", `(status = Done`, "", true},
+		{"open string", `text ~ "abc`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scopeJQL(tt.jql, projects)
+			if (err != nil) != tt.err {
+				t.Fatalf("scopeJQL(%q) err = %v, want error %t", tt.jql, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("scopeJQL(%q)\n got %q\nwant %q", tt.jql, got, tt.want)
+			}
+		})
+	}
+	if _, err := scopeJQL("status = Done", nil); err == nil {
+		t.Error("scopeJQL with no projects: want error")
+	}
+}
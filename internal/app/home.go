@@ -0,0 +1,245 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/skill"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+)
+
+// App Home action and view IDs.
+const (
+	actionHomeAsk     = "home_ask"
+	actionHomeHelp    = "home_help"
+	actionHomeRefresh = "home_refresh"
+	viewHomeAsk       = "home_ask"
+	viewHomeHelp      = "home_help"
+	homeFieldQuestion = "question"
+)
+
+// homeMaxDrafts, homeMaxSchedules and homeMaxConversations cap the App Home
+// lists; Slack accepts at most 100 blocks per view.
+const (
+	homeMaxDrafts        = 5
+	homeMaxSchedules     = 10
+	homeMaxConversations = 5
+)
+
+// HandleHomeOpened publishes the App Home when userID opens the Home tab.
+// Opening the Messages tab is ignored.
+func (s *Service) HandleHomeOpened(ctx context.Context, userID, tab string) {
+	if tab != "home" {
+		return
+	}
+	if err := s.PublishHome(ctx, userID); err != nil {
+		log.Printf("[WARN] publish home user=%q failed: %v", userID, err)
+	}
+}
+
+// PublishHome renders userID's App Home: quick actions, enabled
+// integrations, the Jira drafts waiting on them, their schedules and their
+// latest questions.
+func (s *Service) PublishHome(ctx context.Context, userID string) error {
+	teamID := s.Slack.InstalledTeam(ctx)
+	opts := s.introOpts(teamID)
+	botName := s.Cfg.BotName
+	if botName == "" {
+		botName = "Jarvis"
+	}
+
+	blocks := []slack.Block{
+		slack.Header("Olá! Eu sou o " + botName),
+		slack.Section("Me mencione em qualquer canal, mande uma mensagem na aba *Mensagens* ou use os atalhos abaixo."),
+		slack.Actions("home_actions",
+			slack.Button(actionHomeAsk, "Fazer uma pergunta", "", "primary"),
+			slack.Button(actionHomeHelp, "Comandos", "", ""),
+			slack.Button(actionHomeRefresh, "Atualizar", "", ""),
+		),
+		slack.Divider(),
+		slack.Header("Integrações"),
+		slack.Section(s.homeIntegrations(opts, teamID)),
+	}
+
+	if opts.jiraCreateEnabled {
+		blocks = append(blocks, slack.Divider(), slack.Header("Rascunhos de cards pendentes"))
+		blocks = append(blocks, s.homeDrafts(ctx, userID)...)
+	}
+
+	if s.Schedules != nil && s.Cfg.ScheduleMaxPerUser > 0 {
+		blocks = append(blocks, slack.Divider(), slack.Header("Agendamentos"))
+		blocks = append(blocks, s.homeSchedules(ctx, userID)...)
+	}
+
+	blocks = append(blocks, slack.Divider(), slack.Header("Suas últimas perguntas"))
+	blocks = append(blocks, s.homeConversations(ctx, userID)...)
+
+	return s.Slack.PublishHome(ctx, userID, blocks)
+}
+
+// homeIntegrations lists every registered skill with its status in the
+// workspace teamID, plus Jira card creation, which is a handler rather than a
+// skill.
+func (s *Service) homeIntegrations(opts introOptions, teamID string) string {
+	status := func(name string, on bool, detail string) string {
+		if !on {
+			if detail != "" {
+				return ":white_circle: " + name + " — _" + detail + "_"
+			}
+			return ":white_circle: " + name + " — _desativado_"
+		}
+		if detail != "" {
+			return ":large_green_circle: " + name + " — " + detail
+		}
+		return ":large_green_circle: " + name
+	}
+	var lines []string
+	for _, sk := range s.Skills.All() {
+		on := sk.Enabled(s.Cfg)
+		detail := ""
+		if on && !skill.Granted(sk, s.Cfg, teamID) {
+			on, detail = false, "não liberado neste workspace"
+		}
+		switch sk.Kind() {
+		case kindJiraSearch:
+			if on && len(opts.jiraProjectKeys) > 0 {
+				detail = "projetos: " + projectList(opts.jiraProjectKeys, opts.jiraKeyToName)
+			}
+		case kindMetabaseQuery:
+			if on && opts.csvEnabled {
+				detail = "com exportação CSV"
+			}
+		}
+		lines = append(lines, status(sk.DisplayName(), on, detail))
+		if sk.Kind() == kindJiraSearch {
+			lines = append(lines, status("Criação e edição de cards no Jira", opts.jiraCreateEnabled, ""))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// homeDrafts renders the user's pending Jira drafts with the same buttons
+// posted in their threads.
+func (s *Service) homeDrafts(ctx context.Context, userID string) []slack.Block {
+	drafts := s.Store.ListByUser(userID)
+	if len(drafts) == 0 {
+		return []slack.Block{slack.Context("_Nenhum rascunho pendente._")}
+	}
+	var blocks []slack.Block
+	for i, p := range drafts {
+		if i == homeMaxDrafts {
+			blocks = append(blocks, slack.Context(fmt.Sprintf("_e mais %d rascunho(s)._", len(drafts)-homeMaxDrafts)))
+			break
+		}
+		key := p.Channel + ":" + p.ThreadTs
+		draft := draftBlocks(p.Draft, missingFields(p.Draft), key)
+		// Swap the "reply in this thread" hint for a link to the thread.
+		draft = draft[:len(draft)-1]
+		hint := fmt.Sprintf("Pedido em <#%s> %s", p.Channel, homeAge(p.CreatedAt))
+		if link, err := s.Slack.GetPermalink(ctx, p.Channel, p.ThreadTs); err == nil && link != "" {
+			hint += " · <" + link + "|abrir thread>"
+		}
+		blocks = append(blocks, draft...)
+		blocks = append(blocks, slack.Context(hint))
+	}
+	return blocks
+}
+
+// homeConversations renders the user's latest questions and answers from
+// the telemetry database.
+func (s *Service) homeConversations(ctx context.Context, userID string) []slack.Block {
+	if s.Telemetry == nil {
+		return []slack.Block{slack.Context("_Histórico indisponível: a telemetria (`TELEMETRY_DB_URL`) não está configurada._")}
+	}
+	convs, err := s.Telemetry.RecentConversations(ctx, userID, homeMaxConversations)
+	if err != nil {
+		log.Printf("[WARN] home recent conversations user=%q: %v", userID, err)
+		return []slack.Block{slack.Context("_Não consegui carregar o histórico agora._")}
+	}
+	if len(convs) == 0 {
+		return []slack.Block{slack.Context("_Você ainda não me fez nenhuma pergunta._")}
+	}
+	blocks := make([]slack.Block, 0, len(convs))
+	for _, cv := range convs {
+		blocks = append(blocks, slack.Section(fmt.Sprintf("*%s*\n%s", clip(cv.Question, 200), clip(cv.Answer, 500))))
+		blocks = append(blocks, slack.Context(fmt.Sprintf("<#%s> %s", cv.Channel, homeAge(cv.ReceivedAt))))
+	}
+	return blocks
+}
+
+// homeAge formats t relative to now ("há 5 min", "há 3 h", "em 02/01").
+func homeAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "agora há pouco"
+	case d < time.Hour:
+		return fmt.Sprintf("há %d min", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("há %d h", int(d.Hours()))
+	default:
+		return "em " + t.Local().Format("02/01")
+	}
+}
+
+// homeAskModal is the "ask a question" form opened from the App Home.
+func homeAskModal() slack.Block {
+	return slack.Modal(viewHomeAsk, "Fazer uma pergunta", "Perguntar", "", []slack.Block{
+		slack.TextInput(homeFieldQuestion, "Sua pergunta", "", false, true),
+		slack.Context("A resposta chega na aba *Mensagens*."),
+	})
+}
+
+// resolveHomeAction handles the App Home quick-action buttons.
+func (s *Service) resolveHomeAction(ctx context.Context, p slack.InteractionPayload, a slack.BlockAction) {
+	var err error
+	switch a.ActionID {
+	case actionHomeAsk:
+		err = s.Slack.OpenView(ctx, p.TriggerID, homeAskModal())
+	case actionHomeHelp:
+		err = s.Slack.OpenView(ctx, p.TriggerID, slack.Modal(viewHomeHelp, "Comandos", "", "", []slack.Block{
+			slack.Section(s.commandHelp("/jarvis", "help", "")),
+		}))
+	case actionHomeRefresh:
+		err = s.PublishHome(ctx, p.User.ID)
+	}
+	if err != nil {
+		log.Printf("[WARN] home action=%s failed: %v", a.ActionID, err)
+	}
+}
+
+// submitHomeAsk answers a question sent through the App Home form in the
+// user's DM with the bot, as if it had been asked there.
+func (s *Service) submitHomeAsk(p slack.InteractionPayload) *slack.ViewResponse {
+	question := p.View.Value(homeFieldQuestion)
+	if question == "" {
+		return &slack.ViewResponse{ResponseAction: "errors", Errors: map[string]string{homeFieldQuestion: "Escreva a pergunta."}}
+	}
+	userID := p.User.ID
+	log.Printf("[JARVIS] home ask user=%q question=%q", userID, preview(question, 180))
+	base := slack.WithTeam(context.Background(), p.Team.ID)
+	go func() {
+		ctx, cancel := context.WithTimeout(base, 15*time.Second)
+		defer cancel()
+		dm, err := s.Slack.OpenDM(ctx, userID)
+		if err != nil {
+			log.Printf("[ERR] home ask: open DM: %v", err)
+			return
+		}
+		ts, err := s.Slack.PostMessageAndGetTS(ctx, dm, "", "Pergunta enviada pela Home:\n>"+strings.ReplaceAll(question, "\n", "\n>"))
+		if err != nil {
+			log.Printf("[ERR] home ask: post question: %v", err)
+			return
+		}
+		msgCtx, done := s.Slack.Tracker.Begin(base, dm, ts)
+		defer done()
+		if err := s.HandleMessage(msgCtx, dm, ts, ts, question, question, userID, nil); err != nil && msgCtx.Err() == nil {
+			log.Printf("[ERR] home ask: %v", err)
+			_ = s.Slack.PostMessage(msgCtx, dm, ts, "Não consegui gerar a resposta (erro interno).")
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+func TestHomeIntegrationsListsEverySkill(t *testing.T) {
+	s := &Service{Cfg: config.Config{
+		JiraBaseURL:   "https://acme.atlassian.net",
+		HubSpotAPIKey: "pat-123",
+	}}
+	s.registerBuiltinSkills()
+
+	got := s.homeIntegrations(introOptions{jiraProjectKeys: []string{"BACK"}, jiraCreateEnabled: true}, "")
+	lines := strings.Split(got, "\n")
+	// One line per skill plus Jira card creation.
+	if want := len(s.Skills.All()) + 1; len(lines) != want {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), want, got)
+	}
+	for _, want := range []string{
+		":large_green_circle: Busca de issues no Jira — projetos: BACK",
+		":large_green_circle: Criação e edição de cards no Jira",
+		":large_green_circle: HubSpot CRM",
+		":white_circle: Arquivos do Google Drive — _desativado_",
+		":white_circle: Documentação interna (Outline) — _desativado_",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestHomeIntegrationsHidesUngrantedSkills(t *testing.T) {
+	s := &Service{Cfg: config.Config{
+		JiraBaseURL:     "https://acme.atlassian.net",
+		HubSpotAPIKey:   "pat-123",
+		SlackTeamSkills: map[string][]string{"T0456": {"jira_search"}},
+	}}
+	s.registerBuiltinSkills()
+
+	got := s.homeIntegrations(s.introOpts("T0456"), "T0456")
+	for _, want := range []string{
+		":large_green_circle: Busca de issues no Jira",
+		":white_circle: HubSpot CRM — _não liberado neste workspace_",
+		":large_green_circle: Busca de mensagens no Slack",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/jira"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+	"github.com/DanielFillol/Jarvis/internal/state"
+)
+
+// Block Kit action and view IDs.  Button values carry the pending-state key
+// ("channel:threadTs"), so a click resolves exactly the reply or draft it
+// was posted for — no matter what else was said in the thread since.
+const (
+	actionLongReplyPost   = "long_reply_post"
+	actionLongReplyCancel = "long_reply_cancel"
+	actionDraftConfirm    = "jira_draft_confirm"
+	actionDraftEdit       = "jira_draft_edit"
+	actionDraftCancel     = "jira_draft_cancel"
+	viewDraftEdit         = "jira_draft_edit"
+)
+
+// Modal input block IDs.
+const (
+	draftFieldProject  = "project"
+	draftFieldType     = "issue_type"
+	draftFieldSummary  = "summary"
+	draftFieldPriority = "priority"
+	draftFieldLabels   = "labels"
+)
+
+var jiraPriorities = []string{"Highest", "High", "Medium", "Low", "Lowest"}
+
+// longReplyBlocks renders the "post the long reply?" prompt with buttons.
+func longReplyBlocks(prompt, pendingKey string) []slack.Block {
+	return []slack.Block{
+		slack.Section(prompt),
+		slack.Actions("long_reply",
+			slack.Button(actionLongReplyPost, "Postar", pendingKey, "primary"),
+			slack.Button(actionLongReplyCancel, "Cancelar", pendingKey, ""),
+		),
+	}
+}
+
+// postLongReply posts the chunks of a confirmed long reply in its thread and
+// tracks them against the original question.  It returns how many were posted.
+func (s *Service) postLongReply(ctx context.Context, pr pendingReply) int {
+	posted := 0
+	for i, chunk := range pr.chunks {
+		chunkTs, postErr := s.Slack.PostMessageAndGetTS(ctx, pr.channel, pr.threadTs, chunk)
+		if postErr != nil {
+			log.Printf("[ERR] long reply chunk %d/%d: %v", i+1, len(pr.chunks), postErr)
+			continue
+		}
+		posted++
+		if chunkTs != "" {
+			// Track each chunk against the original question so deleting it
+			// removes all reply chunks from the thread.
+			s.Slack.Tracker.Track(pr.channel, pr.originalOriginTs, chunkTs)
+		}
+	}
+	return posted
+}
+
+// draftBlocks renders a pending Jira draft with Confirm/Edit/Cancel buttons.
+func draftBlocks(d jira.IssueDraft, missing []string, key string) []slack.Block {
+	var b strings.Builder
+	if len(missing) > 0 {
+		fmt.Fprintf(&b, "Para criar o card preciso de: *%s*.\n\n", strings.Join(missing, "*, *"))
+	} else {
+		b.WriteString("Rascunho pronto para criar:\n\n")
+	}
+	fmt.Fprintf(&b, "*Resumo:* %s\n*Projeto:* %s\n*Tipo:* %s\n*Prioridade:* %s\n*Labels:* %s",
+		orDash(d.Summary), orDash(d.Project), orDash(d.IssueType), orDash(d.Priority), orDash(strings.Join(d.Labels, ", ")))
+	return []slack.Block{
+		slack.Section(b.String()),
+		slack.Actions("jira_draft",
+			slack.Button(actionDraftConfirm, "Criar card", key, "primary"),
+			slack.Button(actionDraftEdit, "Editar campos", key, ""),
+			slack.Button(actionDraftCancel, "Cancelar", key, "danger"),
+		),
+		slack.Context("Você também pode responder neste thread com as informações que faltam."),
+	}
+}
+
+// promptDraft posts the draft with its buttons in the thread, falling back
+// to the plain question when Block Kit fails, and remembers the prompt's ts
+// so a modal edit can refresh it.
+func (s *Service) promptDraft(ctx context.Context, p *state.PendingIssue, missing []string) {
+	key := p.Channel + ":" + p.ThreadTs
+	ts, err := s.Slack.PostBlocks(ctx, p.Channel, p.ThreadTs, askForMissingFields(missing), draftBlocks(p.Draft, missing, key))
+	if err != nil {
+		log.Printf("[WARN] draft prompt blocks failed, falling back to text: %v", err)
+		_ = s.Slack.PostMessage(ctx, p.Channel, p.ThreadTs, askForMissingFields(missing))
+	}
+	p.PromptTs = ts
+	s.Store.Save(p)
+}
+
+// draftModal builds the modal used to edit a pending draft's fields.
+func (s *Service) draftModal(d jira.IssueDraft, key, teamID string) slack.Block {
+	return slack.Modal(viewDraftEdit, "Card do Jira", "Salvar", key, s.draftFields(d, teamID))
+}
+
+// draftFields returns the modal inputs for a draft's fields.  The project
+// choices are those of the workspace the modal is opened in.
+func (s *Service) draftFields(d jira.IssueDraft, teamID string) []slack.Block {
+	project := slack.TextInput(draftFieldProject, "Projeto (chave)", d.Project, false, false)
+	if keys := s.Cfg.JiraProjectsFor(teamID); len(keys) > 0 {
+		project = slack.SelectInput(draftFieldProject, "Projeto", keys, d.Project, false)
+	}
+	return []slack.Block{
+		project,
+		slack.TextInput(draftFieldType, "Tipo (ex: Bug, Task, Story)", d.IssueType, false, false),
+		slack.TextInput(draftFieldSummary, "Título", d.Summary, true, false),
+		slack.SelectInput(draftFieldPriority, "Prioridade", jiraPriorities, normalizePriority(d.Priority), true),
+		slack.TextInput(draftFieldLabels, "Labels (separadas por vírgula)", strings.Join(d.Labels, ", "), true, false),
+	}
+}
+
+// readDraftFields applies the submitted draftFields inputs to d.  errs maps
+// the block IDs of missing required fields to their error messages.
+func readDraftFields(v slack.View, d jira.IssueDraft) (jira.IssueDraft, map[string]string) {
+	d.Project = strings.ToUpper(v.Value(draftFieldProject))
+	d.IssueType = v.Value(draftFieldType)
+	if summary := v.Value(draftFieldSummary); summary != "" {
+		d.Summary = summary
+	}
+	d.Priority = v.Value(draftFieldPriority)
+	d.Labels = nil
+	for _, l := range strings.Split(v.Value(draftFieldLabels), ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			d.Labels = append(d.Labels, strings.ReplaceAll(l, " ", "-"))
+		}
+	}
+	errs := map[string]string{}
+	if d.Project == "" {
+		errs[draftFieldProject] = "Informe o projeto."
+	}
+	if d.IssueType == "" {
+		errs[draftFieldType] = "Informe o tipo."
+	}
+	return d, errs
+}
+
+// HandleBlockAction resolves a button click against the pending state named
+// by its value.  It runs after the interaction was acknowledged.
+func (s *Service) HandleBlockAction(ctx context.Context, p slack.InteractionPayload) {
+	for _, a := range p.Actions {
+		log.Printf("[JARVIS] block action=%s user=%q value=%q", a.ActionID, p.User.ID, a.Value)
+		switch a.ActionID {
+		case actionLongReplyPost, actionLongReplyCancel:
+			s.resolveLongReply(ctx, p, a)
+		case actionDraftConfirm, actionDraftEdit, actionDraftCancel:
+			s.resolveDraft(ctx, p, a)
+		case actionHomeAsk, actionHomeHelp, actionHomeRefresh:
+			s.resolveHomeAction(ctx, p, a)
+		case actionSchedulePause, actionScheduleResume, actionScheduleDelete:
+			s.resolveScheduleAction(ctx, p, a)
+		default:
+			log.Printf("[JARVIS] unknown block action=%q", a.ActionID)
+		}
+	}
+}
+
+func (s *Service) resolveLongReply(ctx context.Context, p slack.InteractionPayload, a slack.BlockAction) {
+	raw, ok := s.pendingReplies.LoadAndDelete(a.Value)
+	if !ok {
+		s.replaceInteractionMessage(ctx, p, "_Essa confirmação expirou ou já foi respondida._")
+		return
+	}
+	if a.ActionID == actionLongReplyCancel {
+		s.replaceInteractionMessage(ctx, p, fmt.Sprintf("Ok, resposta cancelada por <@%s>.", p.User.ID))
+		return
+	}
+	pr := raw.(pendingReply)
+	s.replaceInteractionMessage(ctx, p, "_Postando a resposta..._")
+	n := s.postLongReply(ctx, pr)
+	log.Printf("[JARVIS] long reply confirmed via button, posted %d/%d chunks", n, len(pr.chunks))
+	s.replaceInteractionMessage(ctx, p, fmt.Sprintf("Resposta postada abaixo (%d mensagens).", n))
+}
+
+func (s *Service) resolveDraft(ctx context.Context, p slack.InteractionPayload, a slack.BlockAction) {
+	channel, threadTs, _ := strings.Cut(a.Value, ":")
+	pending := s.Store.Load(channel, threadTs)
+	if pending == nil {
+		s.replaceInteractionMessage(ctx, p, "_Esse rascunho expirou ou já foi resolvido._")
+		return
+	}
+	switch a.ActionID {
+	case actionDraftCancel:
+		if s.Store.Take(channel, threadTs) == nil {
+			s.replaceInteractionMessage(ctx, p, "_Esse rascunho expirou ou já foi resolvido._")
+			return
+		}
+		s.replaceInteractionMessage(ctx, p, fmt.Sprintf("Rascunho descartado por <@%s>.", p.User.ID))
+	case actionDraftEdit:
+		if err := s.Slack.OpenView(ctx, p.TriggerID, s.draftModal(pending.Draft, a.Value, s.Slack.InstalledTeam(ctx))); err != nil {
+			log.Printf("[WARN] open draft modal failed: %v", err)
+		}
+	case actionDraftConfirm:
+		if len(missingFields(pending.Draft)) > 0 {
+			// Nothing to confirm yet: let the user fill the gaps.
+			if err := s.Slack.OpenView(ctx, p.TriggerID, s.draftModal(pending.Draft, a.Value, s.Slack.InstalledTeam(ctx))); err != nil {
+				log.Printf("[WARN] open draft modal failed: %v", err)
+			}
+			return
+		}
+		// A double click or a retried interaction confirms twice: only the
+		// caller that takes the draft creates the card.
+		pending = s.Store.Take(channel, threadTs)
+		if pending == nil {
+			log.Printf("[JARVIS] draft %s already resolved — ignoring confirm by %q", a.Value, p.User.ID)
+			return
+		}
+		s.replaceInteractionMessage(ctx, p, fmt.Sprintf("Rascunho confirmado por <@%s>.", p.User.ID))
+		draft := pending.Draft
+		s.appendSlackOrigin(ctx, &draft, channel, threadTs, pending.OriginTs, pending.OriginalText)
+		if _, err := s.createIssueAndReply(ctx, channel, threadTs, draft, false); err != nil {
+			log.Printf("[ERR] create issue from draft: %v", err)
+		}
+	}
+}
+
+// HandleViewSubmission applies a submitted modal.  It runs inside the ack
+// window: the returned response (validation errors) is sent back to Slack,
+// nil closes the modal.  Slow work happens afterwards.
+func (s *Service) HandleViewSubmission(ctx context.Context, p slack.InteractionPayload) *slack.ViewResponse {
+	switch p.View.CallbackID {
+	case viewDraftEdit:
+		return s.submitDraftEdit(p)
+	case viewHomeAsk:
+		return s.submitHomeAsk(p)
+	case viewShortcutCreate:
+		return s.submitShortcutCreate(p)
+	}
+	log.Printf("[JARVIS] unknown view callback=%q", p.View.CallbackID)
+	return nil
+}
+
+// submitDraftEdit saves the fields of the draft modal and refreshes the
+// draft's prompt in its thread.
+func (s *Service) submitDraftEdit(p slack.InteractionPayload) *slack.ViewResponse {
+	channel, threadTs, _ := strings.Cut(p.View.PrivateMetadata, ":")
+	expired := &slack.ViewResponse{ResponseAction: "errors", Errors: map[string]string{
+		draftFieldProject: "Esse rascunho expirou. Peça o card de novo no thread.",
+	}}
+	pending := s.Store.Load(channel, threadTs)
+	if pending == nil {
+		return expired
+	}
+
+	d, errs := readDraftFields(p.View, pending.Draft)
+	if len(errs) > 0 {
+		return &slack.ViewResponse{ResponseAction: "errors", Errors: errs}
+	}
+
+	// The draft may have been confirmed or discarded while the modal was
+	// open: update it in place instead of saving it back.
+	pending = s.Store.Update(channel, threadTs, func(pi *state.PendingIssue) {
+		pi.Draft = d
+		pi.CreatedAt = time.Now()
+	})
+	if pending == nil {
+		return expired
+	}
+	log.Printf("[JARVIS] draft edited via modal user=%q project=%s type=%s", p.User.ID, d.Project, d.IssueType)
+	go func() {
+		ctx, cancel := context.WithTimeout(slack.WithTeam(context.Background(), p.Team.ID), 15*time.Second)
+		defer cancel()
+		if pending.PromptTs != "" {
+			text := fmt.Sprintf("Rascunho atualizado por <@%s>.", p.User.ID)
+			if err := s.Slack.UpdateBlocks(ctx, channel, pending.PromptTs, text, draftBlocks(d, nil, p.View.PrivateMetadata)); err != nil {
+				log.Printf("[WARN] refresh draft prompt failed: %v", err)
+			}
+		}
+		// The modal may have been opened from the App Home.
+		if err := s.PublishHome(ctx, p.User.ID); err != nil {
+			log.Printf("[WARN] refresh home failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// replaceInteractionMessage swaps the clicked message for text, removing its
+// buttons so they cannot be used twice.  Clicks made on the App Home have no
+// message to replace: the Home is republished instead.
+func (s *Service) replaceInteractionMessage(ctx context.Context, p slack.InteractionPayload, text string) {
+	if p.View.Type == "home" {
+		if err := s.PublishHome(ctx, p.User.ID); err != nil {
+			log.Printf("[WARN] refresh home failed: %v", err)
+		}
+		return
+	}
+	err := s.Slack.Respond(ctx, p.ResponseURL, slack.CommandResponse{Text: text, ReplaceOriginal: true})
+	if err != nil {
+		log.Printf("[WARN] replace interaction message failed: %v", err)
+	}
+}
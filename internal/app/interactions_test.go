@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/jira"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+	"github.com/DanielFillol/Jarvis/internal/state"
+)
+
+func TestResolveDraftConcurrentConfirmsCreateOnce(t *testing.T) {
+	var creates atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/rest/api/3/issue" {
+			creates.Add(1)
+			// Hold the create so the second confirm arrives while the first
+			// is still in flight.
+			time.Sleep(50 * time.Millisecond)
+			_, _ = w.Write([]byte(`{"id":"1","key":"PROJ-1"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"messages":[],"permalink":"https://slack.test/p"}`))
+	}))
+	defer srv.Close()
+
+	s := &Service{
+		Slack: &slack.Client{APIBaseURL: srv.URL, HTTPClient: srv.Client(), BotToken: "xoxb-test",
+			Tracker: slack.NewMessageTracker(), Users: slack.NewUserDirectory()},
+		Jira:  &jira.Client{BaseURL: srv.URL, Email: "bot@test", Token: "token"},
+		Store: *state.NewStore(0),
+	}
+	s.Store.Save(&state.PendingIssue{
+		CreatedAt: time.Now(), Channel: "C1", ThreadTs: "1.0", OriginTs: "1.0",
+		Draft: jira.IssueDraft{Project: "PROJ", IssueType: "Task", Summary: "Card"},
+	})
+
+	p := slack.InteractionPayload{Type: "block_actions", ResponseURL: srv.URL + "/respond"}
+	p.User.ID = "U1"
+	a := slack.BlockAction{ActionID: actionDraftConfirm, Value: "C1:1.0"}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			s.resolveDraft(context.Background(), p, a)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if n := creates.Load(); n != 1 {
+		t.Fatalf("issues created = %d, want 1", n)
+	}
+	if s.Store.Load("C1", "1.0") != nil {
+		t.Error("draft still pending after confirm")
+	}
+}
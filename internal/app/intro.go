@@ -1,10 +1,13 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"strings"
+
+	"github.com/DanielFillol/Jarvis/internal/outline"
 )
 
 // introOptions carries the feature-gate flags used to tailor the intro message.
@@ -53,37 +56,26 @@ func isIntroRequest(question, botName string) bool {
 // The LLM writes the message using real context from the configured integrations
 // (docs/jira_projects.md, docs/metabase_schema_compact.md, etc.).
 // Falls back to a static message if the LLM call fails.
-func (s *Service) handleIntroRequest(channel, threadTs, originTs string) error {
-	// Invert JiraProjectNameMap ("project-name" → "PROJ") to ("PROJ" → "Project-Name").
-	keyToName := make(map[string]string)
-	for name, key := range s.Cfg.JiraProjectNameMap {
-		display := strings.Title(strings.ToLower(name)) //nolint:staticcheck
-		keyToName[strings.ToUpper(key)] = display
-	}
-
-	opts := introOptions{
-		jiraEnabled:        s.Cfg.JiraEnabled(),
-		jiraCreateEnabled:  s.Cfg.JiraCreateEnabled,
-		jiraProjectKeys:    s.Cfg.JiraProjectKeys,
-		jiraKeyToName:      keyToName,
-		metabaseEnabled:    s.Cfg.MetabaseEnabled(),
-		csvEnabled:         strings.TrimSpace(s.Cfg.PublicBaseURL) != "",
-		outlineEnabled:     s.Cfg.OutlineEnabled(),
-		slackSearchEnabled: strings.TrimSpace(s.Cfg.SlackUserToken) != "",
-	}
+func (s *Service) handleIntroRequest(ctx context.Context, channel, threadTs, originTs string) error {
+	teamID := s.Slack.InstalledTeam(ctx)
+	opts := s.introOpts(teamID)
 
 	// Build feature description for the LLM prompt.
 	featuresDesc := buildFeaturesDesc(opts)
 
 	// Collect context from generated docs — the LLM uses these to write
-	// realistic examples with real project and table names.
-	docsContext := buildDocsContext(s.Cfg.JiraProjectsPath, s.Cfg.MetabaseSchemaPath)
+	// realistic examples with real project and table names.  The docs cover
+	// every project and database, so installed workspaces do not get them.
+	var docsContext string
+	if teamID == "" {
+		docsContext = buildDocsContext(s.Cfg.JiraProjectsPath, s.Cfg.MetabaseSchemaPath)
+	}
 
 	// Generate with LLM; static message is the fallback.
 	fallback := buildIntroMessage(s.Cfg.BotName, opts)
-	answer := s.LLM.GenerateIntroMessage(s.Cfg.BotName, featuresDesc, docsContext, s.Cfg.OpenAIModel, fallback)
+	answer := s.LLM.GenerateIntroMessage(ctx, s.Cfg.BotName, featuresDesc, docsContext, s.Cfg.OpenAIModel, fallback)
 
-	msgTs, err := s.Slack.PostMessageAndGetTS(channel, threadTs, answer)
+	msgTs, err := s.Slack.PostMessageAndGetTS(ctx, channel, threadTs, answer)
 	if err != nil {
 		log.Printf("[JARVIS] intro: PostMessage failed: %v", err)
 		return err
@@ -94,6 +86,30 @@ func (s *Service) handleIntroRequest(channel, threadTs, originTs string) error {
 	return nil
 }
 
+// introOpts reads the feature gates of the workspace teamID ("" for the env
+// tokens' one) from the configuration.  Also used by the App Home to show
+// which integrations are enabled.
+func (s *Service) introOpts(teamID string) introOptions {
+	// Invert JiraProjectNameMap ("project-name" → "PROJ") to ("PROJ" → "Project-Name").
+	keyToName := make(map[string]string)
+	for name, key := range s.Cfg.JiraProjectNameMapFor(teamID) {
+		display := strings.Title(strings.ToLower(name)) //nolint:staticcheck
+		keyToName[strings.ToUpper(key)] = display
+	}
+
+	jiraEnabled := s.Cfg.JiraEnabled() && s.Cfg.SkillGranted(teamID, kindJiraSearch)
+	return introOptions{
+		jiraEnabled:        jiraEnabled,
+		jiraCreateEnabled:  jiraEnabled && s.Cfg.JiraCreateEnabled,
+		jiraProjectKeys:    s.Cfg.JiraProjectsFor(teamID),
+		jiraKeyToName:      keyToName,
+		metabaseEnabled:    s.Cfg.MetabaseEnabled() && s.Cfg.SkillGranted(teamID, kindMetabaseQuery),
+		csvEnabled:         strings.TrimSpace(s.Cfg.PublicBaseURL) != "",
+		outlineEnabled:     s.Cfg.OutlineEnabled() && s.Cfg.SkillGranted(teamID, outline.ActionSearch),
+		slackSearchEnabled: strings.TrimSpace(s.Cfg.SlackUserToken) != "",
+	}
+}
+
 // buildFeaturesDesc returns a human-readable bullet list of active features
 // for inclusion in the LLM prompt.
 func buildFeaturesDesc(opts introOptions) string {
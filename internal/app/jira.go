@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"regexp"
@@ -18,6 +19,30 @@ import (
 
 var reJiraKey = regexp.MustCompile(`^[A-Z][A-Z0-9]+-\d+$`)
 
+// jiraProjectAllowed reports whether the workspace ctx is scoped to may use
+// the Jira project of projectOrKey (a project key, or an issue key such as
+// "PROJ-123").  The env tokens' workspace may use any project; an installed
+// one needs jira_search granted and the project listed for it.
+func (s *Service) jiraProjectAllowed(ctx context.Context, projectOrKey string) bool {
+	teamID := s.Slack.InstalledTeam(ctx)
+	if teamID == "" {
+		return true
+	}
+	if !s.Cfg.SkillGranted(teamID, kindJiraSearch) {
+		return false
+	}
+	project := strings.ToUpper(strings.TrimSpace(projectOrKey))
+	if i := strings.LastIndex(project, "-"); i > 0 && reJiraKey.MatchString(project) {
+		project = project[:i]
+	}
+	for _, k := range s.Cfg.JiraProjectsFor(teamID) {
+		if strings.EqualFold(k, project) {
+			return true
+		}
+	}
+	return false
+}
+
 // jiraEditResult holds the outcome of maybeHandleJiraEditFlows.
 type jiraEditResult struct {
 	// Handled is true when the edit flow consumed the message.
@@ -35,27 +60,27 @@ type jiraEditResult struct {
 // already verified the intent via DecideActions.
 // quiet suppresses the direct Slack success post; the confirmation text is instead
 // returned in jiraEditResult.Reply so callers can prepend it to a combined answer.
-func (s *Service) maybeHandleJiraEditFlows(channel, threadTs, senderUserID, question, threadHist, overrideIssueKey string, intentConfirmed, quiet bool) (jiraEditResult, error) {
+func (s *Service) maybeHandleJiraEditFlows(ctx context.Context, channel, threadTs, senderUserID, question, threadHist, overrideIssueKey string, intentConfirmed, quiet bool) (jiraEditResult, error) {
 	// Strip Slack markup (permalinks, mentions) so the LLM sees clean text.
-	cleanQ := s.Slack.ResolveUserMentions(parse.StripSlackPermalinks(question))
+	cleanQ := s.Slack.ResolveUserMentions(ctx, parse.StripSlackPermalinks(question))
 
 	if !s.Cfg.JiraCreateEnabled {
-		if intentConfirmed || s.LLM.ConfirmJiraEditIntent(cleanQ, threadHist, s.Cfg.OpenAILesserModel, s.Cfg.OpenAIModel) {
-			_ = s.Slack.PostMessage(channel, threadTs, "Edição de issues no Jira está desabilitada.")
+		if intentConfirmed || s.LLM.ConfirmJiraEditIntent(ctx, cleanQ, threadHist, s.Cfg.OpenAILesserModel, s.Cfg.OpenAIModel) {
+			_ = s.Slack.PostMessage(ctx, channel, threadTs, "Edição de issues no Jira está desabilitada.")
 			return jiraEditResult{Handled: true}, nil
 		}
 		return jiraEditResult{}, nil
 	}
 
-	if !intentConfirmed && !s.LLM.ConfirmJiraEditIntent(cleanQ, threadHist, s.Cfg.OpenAILesserModel, s.Cfg.OpenAIModel) {
+	if !intentConfirmed && !s.LLM.ConfirmJiraEditIntent(ctx, cleanQ, threadHist, s.Cfg.OpenAILesserModel, s.Cfg.OpenAIModel) {
 		return jiraEditResult{}, nil
 	}
 
-	senderName, _ := s.Slack.GetUsernameByID(senderUserID)
+	senderName, _ := s.Slack.GetUsernameByID(ctx, senderUserID)
 
-	req, err := s.LLM.ExtractJiraEditRequest(cleanQ, threadHist, senderName, s.Cfg.OpenAIModel)
+	req, err := s.LLM.ExtractJiraEditRequest(ctx, cleanQ, threadHist, senderName, s.Cfg.OpenAIModel)
 	if err != nil {
-		_ = s.Slack.PostMessage(channel, threadTs, fmt.Sprintf("Não consegui interpretar o pedido de edição: %v", err))
+		_ = s.Slack.PostMessage(ctx, channel, threadTs, fmt.Sprintf("Não consegui interpretar o pedido de edição: %v", err))
 		return jiraEditResult{Handled: true}, nil
 	}
 
@@ -66,11 +91,18 @@ func (s *Service) maybeHandleJiraEditFlows(channel, threadTs, senderUserID, ques
 	}
 
 	if req.IssueKey == "" {
-		_ = s.Slack.PostMessage(channel, threadTs, "Não consegui identificar o número do card. Informe a chave (ex: PROJ-123).")
+		_ = s.Slack.PostMessage(ctx, channel, threadTs, "Não consegui identificar o número do card. Informe a chave (ex: PROJ-123).")
 		return jiraEditResult{Handled: true}, nil
 	}
 
 	allKeys := append([]string{req.IssueKey}, req.AdditionalIssueKeys...)
+	for _, key := range append([]string{req.ParentKey}, allKeys...) {
+		if reJiraKey.MatchString(strings.ToUpper(key)) && !s.jiraProjectAllowed(ctx, key) {
+			log.Printf("[JARVIS] jiraEdit key=%s outside the workspace's projects — refusing", key)
+			_ = s.Slack.PostMessage(ctx, channel, threadTs, fmt.Sprintf("O card *%s* é de um projeto que este workspace não pode alterar.", key))
+			return jiraEditResult{Handled: true}, nil
+		}
+	}
 	log.Printf("[JARVIS] jiraEdit keys=%v targetStatus=%q assignee=%q parent=%q priority=%q summary=%q labels=%v generateDesc=%v",
 		allKeys, req.TargetStatus, req.AssigneeName, req.ParentKey, req.Priority, req.Summary, req.Labels, req.GenerateDescription)
 
@@ -88,7 +120,7 @@ func (s *Service) maybeHandleJiraEditFlows(channel, threadTs, senderUserID, ques
 		wg.Add(1)
 		go func(i int, key string) {
 			defer wg.Done()
-			lines := s.applyJiraEditToIssue(key, req, senderName, cleanQ, threadHist)
+			lines := s.applyJiraEditToIssue(ctx, key, req, senderUserID, senderName, cleanQ, threadHist)
 			results[i] = result{idx: i, key: key, lines: lines}
 		}(i, key)
 	}
@@ -105,7 +137,7 @@ func (s *Service) maybeHandleJiraEditFlows(channel, threadTs, senderUserID, ques
 	}
 	replyText := strings.Join(parts, "\n\n")
 	if !quiet {
-		_ = s.Slack.PostMessage(channel, threadTs, replyText)
+		_ = s.Slack.PostMessage(ctx, channel, threadTs, replyText)
 		return jiraEditResult{Handled: true}, nil
 	}
 	return jiraEditResult{Handled: true, Reply: replyText}, nil
@@ -115,19 +147,19 @@ func (s *Service) maybeHandleJiraEditFlows(channel, threadTs, senderUserID, ques
 // returns human-readable result lines.  When req.GenerateDescription is true
 // and req.Description is empty, the description is generated via LLM using
 // the individual card context.
-func (s *Service) applyJiraEditToIssue(issueKey string, req jira.EditRequest, senderName, cleanQ, threadHist string) []string {
+func (s *Service) applyJiraEditToIssue(ctx context.Context, issueKey string, req jira.EditRequest, senderUserID, senderName, cleanQ, threadHist string) []string {
 	var results []string
 
 	// Resolve generated description per card (each card gets its own content).
 	description := req.Description
 	if req.GenerateDescription && description == "" {
-		issue, err := s.Jira.GetIssue(issueKey)
+		issue, err := s.Jira.GetIssue(ctx, issueKey)
 		if err != nil {
 			log.Printf("[JARVIS] GenerateDescription GetIssue %s: %v", issueKey, err)
 			results = append(results, fmt.Sprintf("⚠️ Não consegui buscar o card para gerar descrição: %v", err))
 			return results
 		}
-		generated, err := s.LLM.GenerateIssueDescription(
+		generated, err := s.LLM.GenerateIssueDescription(ctx,
 			issueKey,
 			issue.Fields.IssueType.Name,
 			issue.Fields.Summary,
@@ -147,7 +179,7 @@ func (s *Service) applyJiraEditToIssue(issueKey string, req jira.EditRequest, se
 
 	// Transition (multi-step: chains through intermediates if needed)
 	if req.TargetStatus != "" {
-		tr, err := s.transitionToStatus(issueKey, req.TargetStatus)
+		tr, err := s.transitionToStatus(ctx, issueKey, req.TargetStatus)
 		if err != nil {
 			log.Printf("[JARVIS] transitionToStatus %s → %q: %v", issueKey, req.TargetStatus, err)
 			results = append(results, fmt.Sprintf("⚠️ Não consegui transicionar: %v", err))
@@ -166,32 +198,29 @@ func (s *Service) applyJiraEditToIssue(issueKey string, req jira.EditRequest, se
 		if req.AssigneeName == "@me" {
 			searchName = senderName
 		}
-		users, err := s.Jira.SearchAssignableUsers(issueKey, searchName, 5)
+		user, err := s.resolveJiraAssignee(ctx, issueKey, req.AssigneeName, senderUserID, searchName)
 		if err != nil {
 			log.Printf("[JARVIS] SearchAssignableUsers %s query=%q: %v", issueKey, searchName, err)
 			results = append(results, fmt.Sprintf("⚠️ Não consegui buscar usuários para *%s*: %v", searchName, err))
+		} else if user == nil {
+			results = append(results, fmt.Sprintf("⚠️ Usuário *%s* não encontrado como assignável", searchName))
+		} else if err := s.Jira.AssignIssue(ctx, issueKey, user.AccountID); err != nil {
+			log.Printf("[JARVIS] AssignIssue %s → %s: %v", issueKey, user.AccountID, err)
+			results = append(results, fmt.Sprintf("⚠️ Não consegui atribuir a *%s*: %v", user.DisplayName, err))
 		} else {
-			user := pickBestUser(users, searchName)
-			if user == nil {
-				results = append(results, fmt.Sprintf("⚠️ Usuário *%s* não encontrado como assignável", searchName))
-			} else if err := s.Jira.AssignIssue(issueKey, user.AccountID); err != nil {
-				log.Printf("[JARVIS] AssignIssue %s → %s: %v", issueKey, user.AccountID, err)
-				results = append(results, fmt.Sprintf("⚠️ Não consegui atribuir a *%s*: %v", user.DisplayName, err))
-			} else {
-				results = append(results, fmt.Sprintf("✅ Atribuído a *%s*", user.DisplayName))
-			}
+			results = append(results, fmt.Sprintf("✅ Atribuído a *%s*", user.DisplayName))
 		}
 	}
 
 	// Set parent — resolve name/text to a valid key first when necessary.
 	if req.ParentKey != "" {
-		parentKey, resolveErr := s.resolveParentKey(issueKey, req.ParentKey)
+		parentKey, resolveErr := s.resolveParentKey(ctx, issueKey, req.ParentKey)
 		if resolveErr != nil {
 			log.Printf("[JARVIS] resolveParentKey %s ref=%q: %v", issueKey, req.ParentKey, resolveErr)
 			results = append(results, fmt.Sprintf("⚠️ Não encontrei o card pai %q: %v", req.ParentKey, resolveErr))
 		} else {
 			fields := map[string]any{"parent": map[string]any{"key": parentKey}}
-			if err := s.Jira.UpdateIssue(issueKey, fields); err != nil {
+			if err := s.Jira.UpdateIssue(ctx, issueKey, fields); err != nil {
 				log.Printf("[JARVIS] SetParent %s → %s: %v", issueKey, parentKey, err)
 				results = append(results, fmt.Sprintf("⚠️ Não consegui definir o pai como *%s*: %v", parentKey, err))
 			} else {
@@ -216,7 +245,7 @@ func (s *Service) applyJiraEditToIssue(issueKey string, req jira.EditRequest, se
 	}
 	if len(updateFields) > 0 {
 		log.Printf("[JARVIS] UpdateIssue %s fields=%v", issueKey, fieldKeys(updateFields))
-		if err := s.Jira.UpdateIssue(issueKey, updateFields); err != nil {
+		if err := s.Jira.UpdateIssue(ctx, issueKey, updateFields); err != nil {
 			log.Printf("[JARVIS] UpdateIssue %s FAILED: %v", issueKey, err)
 			results = append(results, fmt.Sprintf("⚠️ Não consegui atualizar campos: %v", err))
 		} else {
@@ -239,11 +268,11 @@ func (s *Service) applyJiraEditToIssue(issueKey string, req jira.EditRequest, se
 
 	// Move to sprint
 	if req.TargetSprint != "" {
-		sprint, err := s.resolveTargetSprint(issueKey, req.TargetSprint)
+		sprint, err := s.resolveTargetSprint(ctx, issueKey, req.TargetSprint)
 		if err != nil {
 			log.Printf("[JARVIS] resolveTargetSprint %s target=%q: %v", issueKey, req.TargetSprint, err)
 			results = append(results, fmt.Sprintf("⚠️ Não consegui resolver a sprint: %v", err))
-		} else if err := s.Jira.MoveIssueToSprint(sprint.ID, issueKey); err != nil {
+		} else if err := s.Jira.MoveIssueToSprint(ctx, sprint.ID, issueKey); err != nil {
 			log.Printf("[JARVIS] MoveIssueToSprint %s → sprint %d: %v", issueKey, sprint.ID, err)
 			results = append(results, fmt.Sprintf("⚠️ Não consegui mover para a sprint *%s*: %v", sprint.Name, err))
 		} else {
@@ -265,7 +294,7 @@ type transitionResult struct {
 // The maximum number of steps is derived from the project's workflow size
 // (number of statuses in jira_projects.md catalog), falling back to 10.
 // Returns the final status name and the names of all transitions executed.
-func (s *Service) transitionToStatus(issueKey, desiredStatus string) (transitionResult, error) {
+func (s *Service) transitionToStatus(ctx context.Context, issueKey, desiredStatus string) (transitionResult, error) {
 	// Extract project key from issue key (e.g. "PROJ-522" → "PROJ").
 	maxSteps := 10
 	if idx := strings.Index(issueKey, "-"); idx > 0 {
@@ -274,13 +303,13 @@ func (s *Service) transitionToStatus(issueKey, desiredStatus string) (transition
 			maxSteps = len(statuses)
 			// Normalize the desired status to the project's actual status name
 			// so the equality check works regardless of language/casing.
-			desiredStatus = s.LLM.MapStatusName(statuses, desiredStatus, s.Cfg.OpenAILesserModel)
+			desiredStatus = s.LLM.MapStatusName(ctx, statuses, desiredStatus, s.Cfg.OpenAILesserModel)
 		}
 	}
 	log.Printf("[JARVIS] transitionToStatus %s → %q maxSteps=%d", issueKey, desiredStatus, maxSteps)
 	var steps []string
 	for i := 0; i < maxSteps; i++ {
-		issue, err := s.Jira.GetIssue(issueKey)
+		issue, err := s.Jira.GetIssue(ctx, issueKey)
 		if err != nil {
 			return transitionResult{Steps: steps}, fmt.Errorf("GetIssue: %w", err)
 		}
@@ -288,11 +317,11 @@ func (s *Service) transitionToStatus(issueKey, desiredStatus string) (transition
 		if strings.EqualFold(currentStatus, desiredStatus) {
 			return transitionResult{FinalStatus: currentStatus, Steps: steps}, nil
 		}
-		transitions, err := s.Jira.GetTransitions(issueKey)
+		transitions, err := s.Jira.GetTransitions(ctx, issueKey)
 		if err != nil {
 			return transitionResult{Steps: steps}, fmt.Errorf("GetTransitions: %w", err)
 		}
-		transID := s.LLM.PickBestTransition(transitions, desiredStatus, s.Cfg.OpenAIModel)
+		transID := s.LLM.PickBestTransition(ctx, transitions, desiredStatus, s.Cfg.OpenAIModel)
 		if transID == "" {
 			var tNames []string
 			for _, t := range transitions {
@@ -308,7 +337,7 @@ func (s *Service) transitionToStatus(issueKey, desiredStatus string) (transition
 				break
 			}
 		}
-		if err := s.Jira.TransitionIssue(issueKey, transID); err != nil {
+		if err := s.Jira.TransitionIssue(ctx, issueKey, transID); err != nil {
 			return transitionResult{Steps: steps}, fmt.Errorf("TransitionIssue(%s): %w", transName, err)
 		}
 		steps = append(steps, transName)
@@ -319,7 +348,7 @@ func (s *Service) transitionToStatus(issueKey, desiredStatus string) (transition
 
 // resolveTargetSprint finds the right sprint for the issue's project board
 // based on the LLM's extracted target ("current", "next", or a sprint name/number).
-func (s *Service) resolveTargetSprint(issueKey, target string) (*jira.Sprint, error) {
+func (s *Service) resolveTargetSprint(ctx context.Context, issueKey, target string) (*jira.Sprint, error) {
 	// Derive project key from issue key (e.g. "PROJ-522" → "PROJ").
 	idx := strings.Index(issueKey, "-")
 	if idx <= 0 {
@@ -327,7 +356,7 @@ func (s *Service) resolveTargetSprint(issueKey, target string) (*jira.Sprint, er
 	}
 	projectKey := issueKey[:idx]
 
-	boards, err := s.Jira.GetBoards(projectKey)
+	boards, err := s.Jira.GetBoards(ctx, projectKey)
 	if err != nil {
 		return nil, fmt.Errorf("GetBoards(%s): %w", projectKey, err)
 	}
@@ -338,7 +367,7 @@ func (s *Service) resolveTargetSprint(issueKey, target string) (*jira.Sprint, er
 
 	switch strings.ToLower(strings.TrimSpace(target)) {
 	case "current", "atual", "corrente", "ativa":
-		sprints, err := s.Jira.GetSprints(boardID, "active")
+		sprints, err := s.Jira.GetSprints(ctx, boardID, "active")
 		if err != nil {
 			return nil, fmt.Errorf("GetSprints(active): %w", err)
 		}
@@ -348,7 +377,7 @@ func (s *Service) resolveTargetSprint(issueKey, target string) (*jira.Sprint, er
 		return &sprints[0], nil
 
 	case "next", "next sprint", "próxima", "proxima", "seguinte":
-		sprints, err := s.Jira.GetSprints(boardID, "future")
+		sprints, err := s.Jira.GetSprints(ctx, boardID, "future")
 		if err != nil {
 			return nil, fmt.Errorf("GetSprints(future): %w", err)
 		}
@@ -361,7 +390,7 @@ func (s *Service) resolveTargetSprint(issueKey, target string) (*jira.Sprint, er
 		// Search by name/number across active + future sprints.
 		var candidates []jira.Sprint
 		for _, st := range []string{"active", "future"} {
-			ss, err := s.Jira.GetSprints(boardID, st)
+			ss, err := s.Jira.GetSprints(ctx, boardID, st)
 			if err == nil {
 				candidates = append(candidates, ss...)
 			}
@@ -370,7 +399,7 @@ func (s *Service) resolveTargetSprint(issueKey, target string) (*jira.Sprint, er
 			return nil, fmt.Errorf("nenhuma sprint ativa ou futura encontrada para o projeto %s", projectKey)
 		}
 		// Use LLM to pick the best match among candidate names.
-		sprintID := s.LLM.PickBestSprintByName(candidates, target, s.Cfg.OpenAILesserModel)
+		sprintID := s.LLM.PickBestSprintByName(ctx, candidates, target, s.Cfg.OpenAILesserModel)
 		if sprintID == 0 {
 			var names []string
 			for _, sp := range candidates {
@@ -391,7 +420,7 @@ func (s *Service) resolveTargetSprint(issueKey, target string) (*jira.Sprint, er
 // If parentRef already looks like a Jira key (e.g. "PROJ-164"), it is returned as-is.
 // Otherwise it searches Jira by text within the child issue's project and returns
 // the key of the first match.
-func (s *Service) resolveParentKey(issueKey, parentRef string) (string, error) {
+func (s *Service) resolveParentKey(ctx context.Context, issueKey, parentRef string) (string, error) {
 	parentRef = strings.TrimSpace(parentRef)
 	if reJiraKey.MatchString(parentRef) {
 		return parentRef, nil
@@ -406,7 +435,7 @@ func (s *Service) resolveParentKey(issueKey, parentRef string) (string, error) {
 		jql = fmt.Sprintf(`project = %s AND text ~ %q ORDER BY updated DESC`, projKey, parentRef)
 	}
 	log.Printf("[JARVIS] resolveParentKey %s ref=%q jql=%q", issueKey, parentRef, jql)
-	issues, err := s.Jira.FetchAll(jql, 5)
+	issues, err := s.Jira.FetchAll(ctx, jql, 5)
 	if err != nil {
 		return "", fmt.Errorf("busca por %q falhou: %w", parentRef, err)
 	}
@@ -417,6 +446,40 @@ func (s *Service) resolveParentKey(issueKey, parentRef string) (string, error) {
 	return issues[0].Key, nil
 }
 
+// resolveJiraAssignee finds the Jira account of an edit request's assignee.
+// "@me" and names of Slack users go through the identity mapping (email
+// match or IDENTITY_JIRA_USERS); anything else, or a user without a mapped
+// account, falls back to the assignable-user search by name.
+func (s *Service) resolveJiraAssignee(ctx context.Context, issueKey, assignee, senderUserID, searchName string) (*jira.JiraUser, error) {
+	slackUserID := ""
+	if assignee == "@me" {
+		slackUserID = senderUserID
+	} else if s.Slack != nil && s.Slack.DefaultTeam(ctx) {
+		// The user directory only covers the env tokens' workspace.
+		ref := strings.TrimPrefix(strings.TrimSpace(assignee), "@")
+		if u, ok := s.Slack.Users.ByHandle(ref); ok {
+			slackUserID = u.ID
+		} else if users := s.Slack.FindUsersByName(ref); len(users) == 1 {
+			slackUserID = users[0].ID
+		}
+	}
+	if slackUserID != "" {
+		if id := s.resolveIdentity(ctx, slackUserID); id.JiraAccountID != "" {
+			name := id.JiraName
+			if name == "" {
+				name = searchName
+			}
+			log.Printf("[JARVIS] jiraEdit %s assignee %q → slack=%s jira=%s", issueKey, assignee, slackUserID, id.JiraAccountID)
+			return &jira.JiraUser{AccountID: id.JiraAccountID, DisplayName: name, Active: true}, nil
+		}
+	}
+	users, err := s.Jira.SearchAssignableUsers(ctx, issueKey, strings.TrimPrefix(searchName, "@"), 5)
+	if err != nil {
+		return nil, err
+	}
+	return pickBestUser(users, strings.TrimPrefix(searchName, "@")), nil
+}
+
 // pickBestUser selects the most relevant user from assignable search results.
 // Prefers active users whose display name contains the query, then any active user.
 func pickBestUser(users []jira.JiraUser, query string) *jira.JiraUser {
@@ -482,10 +545,10 @@ type jiraCreateResult struct {
 // already verified the intent via DecideActions.
 // quiet suppresses the direct Slack success post; the confirmation text is instead
 // returned in jiraCreateResult.Reply so callers can prepend it to a combined answer.
-func (s *Service) maybeHandleJiraCreateFlows(channel, threadTs, originTs, originalText, question, threadHist string, intentConfirmed, quiet bool) (jiraCreateResult, error) {
+func (s *Service) maybeHandleJiraCreateFlows(ctx context.Context, channel, threadTs, senderUserID, originTs, originalText, question, threadHist string, intentConfirmed, quiet bool) (jiraCreateResult, error) {
 	if !s.Cfg.JiraCreateEnabled {
-		if intentConfirmed || s.LLM.ConfirmJiraCreateIntent(question, threadHist, s.Cfg.OpenAILesserModel, s.Cfg.OpenAIModel) {
-			_ = s.Slack.PostMessage(channel, threadTs, "Criação de issues no Jira está desabilitada.")
+		if intentConfirmed || s.LLM.ConfirmJiraCreateIntent(ctx, question, threadHist, s.Cfg.OpenAILesserModel, s.Cfg.OpenAIModel) {
+			_ = s.Slack.PostMessage(ctx, channel, threadTs, "Criação de issues no Jira está desabilitada.")
 			return jiraCreateResult{Handled: true}, nil
 		}
 		return jiraCreateResult{}, nil
@@ -496,24 +559,27 @@ func (s *Service) maybeHandleJiraCreateFlows(channel, threadTs, originTs, origin
 	//    and try to fill in what was missing.
 	if pending := s.Store.Load(channel, threadTs); pending != nil {
 		log.Printf("[JARVIS] pending Jira draft found for thread=%s, re-extracting", threadTs)
-		draft, extractErr := s.LLM.ExtractIssueFromThread(threadHist, pending.OriginalText, s.Cfg.OpenAIModel, nil, s.Cfg.JiraProjectNameMap)
+		draft, extractErr := s.LLM.ExtractIssueFromThread(ctx, threadHist, s.threadTranscripts(ctx, channel, threadTs), pending.OriginalText, s.Cfg.OpenAIModel, nil, s.Cfg.JiraProjectNameMapFor(s.Slack.InstalledTeam(ctx)))
 		if extractErr != nil {
-			_ = s.Slack.PostMessage(channel, threadTs, fmt.Sprintf("Não consegui interpretar o card: %v", extractErr))
+			_ = s.Slack.PostMessage(ctx, channel, threadTs, fmt.Sprintf("Não consegui interpretar o card: %v", extractErr))
 			s.Store.Delete(channel, threadTs)
 			return jiraCreateResult{Handled: true}, nil
 		}
 		if missing := missingFields(draft); len(missing) > 0 {
 			// Still missing — update store and ask again.
-			s.Store.Save(&state.PendingIssue{
+			s.promptDraft(ctx, &state.PendingIssue{
 				CreatedAt: time.Now(), Channel: channel, ThreadTs: threadTs,
-				OriginTs: pending.OriginTs, OriginalText: pending.OriginalText, Draft: draft,
-			})
-			_ = s.Slack.PostMessage(channel, threadTs, askForMissingFields(missing))
+				OriginTs: pending.OriginTs, OriginalText: pending.OriginalText, UserID: pending.UserID, Draft: draft,
+			}, missing)
+			return jiraCreateResult{Handled: true}, nil
+		}
+		// The draft's buttons may have confirmed it meanwhile.
+		if s.Store.Take(channel, threadTs) == nil {
+			log.Printf("[JARVIS] pending Jira draft thread=%s already resolved", threadTs)
 			return jiraCreateResult{Handled: true}, nil
 		}
-		s.Store.Delete(channel, threadTs)
-		s.appendSlackOrigin(&draft, channel, threadTs, pending.OriginTs, pending.OriginalText)
-		key, createErr := s.createIssueAndReply(channel, threadTs, draft, quiet)
+		s.appendSlackOrigin(ctx, &draft, channel, threadTs, pending.OriginTs, pending.OriginalText)
+		key, createErr := s.createIssueAndReply(ctx, channel, threadTs, draft, quiet)
 		var replyText string
 		if quiet && key != "" {
 			base := strings.TrimRight(s.Cfg.JiraBaseURL, "/")
@@ -523,32 +589,32 @@ func (s *Service) maybeHandleJiraCreateFlows(channel, threadTs, originTs, origin
 	}
 
 	// 2. Detect new Jira create intent.
-	if !intentConfirmed && !s.LLM.ConfirmJiraCreateIntent(question, threadHist, s.Cfg.OpenAILesserModel, s.Cfg.OpenAIModel) {
+	if !intentConfirmed && !s.LLM.ConfirmJiraCreateIntent(ctx, question, threadHist, s.Cfg.OpenAILesserModel, s.Cfg.OpenAIModel) {
 		return jiraCreateResult{}, nil
 	}
 
 	// 3. Extract draft using the primary model for better accuracy.
-	draft, extractErr := s.LLM.ExtractIssueFromThread(threadHist, question, s.Cfg.OpenAIModel, nil, s.Cfg.JiraProjectNameMap)
+	draft, extractErr := s.LLM.ExtractIssueFromThread(ctx, threadHist, s.threadTranscripts(ctx, channel, threadTs), question, s.Cfg.OpenAIModel, nil, s.Cfg.JiraProjectNameMapFor(s.Slack.InstalledTeam(ctx)))
 	if extractErr != nil {
-		_ = s.Slack.PostMessage(channel, threadTs, fmt.Sprintf("Não consegui entender o card a partir da thread: %v", extractErr))
+		_ = s.Slack.PostMessage(ctx, channel, threadTs, fmt.Sprintf("Não consegui entender o card a partir da thread: %v", extractErr))
 		return jiraCreateResult{Handled: true}, nil
 	}
 
 	// 4. If essential fields are missing, ask the user and save state.
 	if missing := missingFields(draft); len(missing) > 0 {
-		s.Store.Save(&state.PendingIssue{
+		s.promptDraft(ctx, &state.PendingIssue{
 			CreatedAt: time.Now(),
 			Channel:   channel, ThreadTs: threadTs,
 			OriginTs: originTs, OriginalText: originalText,
-			Draft: draft,
-		})
-		_ = s.Slack.PostMessage(channel, threadTs, askForMissingFields(missing))
+			UserID: senderUserID,
+			Draft:  draft,
+		}, missing)
 		return jiraCreateResult{Handled: true}, nil
 	}
 
 	// 5. All fields present — create the card.
-	s.appendSlackOrigin(&draft, channel, threadTs, originTs, originalText)
-	key, createErr := s.createIssueAndReply(channel, threadTs, draft, quiet)
+	s.appendSlackOrigin(ctx, &draft, channel, threadTs, originTs, originalText)
+	key, createErr := s.createIssueAndReply(ctx, channel, threadTs, draft, quiet)
 	var replyText string
 	if quiet && key != "" {
 		base := strings.TrimRight(s.Cfg.JiraBaseURL, "/")
@@ -559,11 +625,11 @@ func (s *Service) maybeHandleJiraCreateFlows(channel, threadTs, originTs, origin
 
 // fetchExampleIssues is a helper that fetches real Jira cards from the same project/type
 // to serve as inspiration for the LLM. Returns an empty slice on error or missing fields.
-func (s *Service) fetchExampleIssues(project, issueType string) []string {
+func (s *Service) fetchExampleIssues(ctx context.Context, project, issueType string) []string {
 	if strings.TrimSpace(project) == "" || strings.TrimSpace(issueType) == "" {
 		return nil
 	}
-	examples, err := s.Jira.FetchExampleIssues(project, issueType, 3)
+	examples, err := s.Jira.FetchExampleIssues(ctx, project, issueType, 3)
 	if err != nil {
 		log.Printf("[JARVIS] fetchExampleIssues project=%s type=%s err=%v", project, issueType, err)
 		return nil
@@ -574,13 +640,13 @@ func (s *Service) fetchExampleIssues(project, issueType string) []string {
 
 // appendSlackOrigin appends a "Thread de origem" section to the end of
 // the issue description, including Slack permalinks when available.
-func (s *Service) appendSlackOrigin(d *jira.IssueDraft, channel, threadTs, originTs, originalText string) {
+func (s *Service) appendSlackOrigin(ctx context.Context, d *jira.IssueDraft, channel, threadTs, originTs, originalText string) {
 	var originLink, threadLink string
-	if l, err := s.Slack.GetPermalink(channel, originTs); err == nil {
+	if l, err := s.Slack.GetPermalink(ctx, channel, originTs); err == nil {
 		originLink = l
 	}
 	if threadTs != "" && threadTs != originTs {
-		if l, err := s.Slack.GetPermalink(channel, threadTs); err == nil {
+		if l, err := s.Slack.GetPermalink(ctx, channel, threadTs); err == nil {
 			threadLink = l
 		}
 	}
@@ -607,35 +673,40 @@ func (s *Service) appendSlackOrigin(d *jira.IssueDraft, channel, threadTs, origi
 // images or videos found in the thread to the newly created issue.
 // Returns (issueKey, error).  When quiet=true the caller is responsible for
 // building and posting the confirmation text (typically prepended to a combined answer).
-func (s *Service) createIssueAndReply(channel, threadTs string, d jira.IssueDraft, quiet bool) (string, error) {
+func (s *Service) createIssueAndReply(ctx context.Context, channel, threadTs string, d jira.IssueDraft, quiet bool) (string, error) {
 	d.Project = strings.TrimSpace(d.Project)
 	d.IssueType = strings.TrimSpace(d.IssueType)
 	d.Summary = strings.TrimSpace(d.Summary)
 	d.Description = strings.TrimSpace(d.Description)
 	if d.Project == "" || d.IssueType == "" {
-		_ = s.Slack.PostMessage(channel, threadTs, missingFieldsMsg(d, d.Project == "", d.IssueType == "", s.Cfg.BotName))
+		_ = s.Slack.PostMessage(ctx, channel, threadTs, missingFieldsMsg(d, d.Project == "", d.IssueType == "", s.Cfg.BotName))
+		return "", nil
+	}
+	if !s.jiraProjectAllowed(ctx, d.Project) {
+		log.Printf("[JARVIS] create in project=%s outside the workspace's projects — refusing", d.Project)
+		_ = s.Slack.PostMessage(ctx, channel, threadTs, fmt.Sprintf("Este workspace não pode criar cards no projeto *%s*.", d.Project))
 		return "", nil
 	}
-	created, err := s.Jira.CreateIssue(d)
+	created, err := s.Jira.CreateIssue(ctx, d)
 	if err != nil {
-		_ = s.Slack.PostMessage(channel, threadTs, fmt.Sprintf("Não consegui criar o card no Jira: %v", err))
+		_ = s.Slack.PostMessage(ctx, channel, threadTs, fmt.Sprintf("Não consegui criar o card no Jira: %v", err))
 		return "", nil
 	}
 	base := strings.TrimRight(s.Cfg.JiraBaseURL, "/")
 	link := base + "/browse/" + created.Key
 	replyText := fmt.Sprintf("Card criado ✅ *%s*\n%s", created.Key, link)
 	if !quiet {
-		_ = s.Slack.PostMessage(channel, threadTs, replyText)
+		_ = s.Slack.PostMessage(ctx, channel, threadTs, replyText)
 	}
-	s.attachThreadMediaToIssue(created.Key, channel, threadTs)
+	s.attachThreadMediaToIssue(ctx, created.Key, channel, threadTs)
 	return created.Key, nil
 }
 
 // attachThreadMediaToIssue fetches all files from the Slack thread and uploads
 // them as attachments to the given Jira issue.  Errors are logged but not
 // propagated — attachment failures do not affect the card creation reply.
-func (s *Service) attachThreadMediaToIssue(issueKey, channel, threadTs string) {
-	files, err := s.Slack.GetThreadFiles(channel, threadTs)
+func (s *Service) attachThreadMediaToIssue(ctx context.Context, issueKey, channel, threadTs string) {
+	files, err := s.Slack.GetThreadFiles(ctx, channel, threadTs)
 	if err != nil {
 		log.Printf("[JARVIS] GetThreadFiles for %s: %v", issueKey, err)
 		return
@@ -647,12 +718,12 @@ func (s *Service) attachThreadMediaToIssue(issueKey, channel, threadTs string) {
 			log.Printf("[JARVIS] skipping %s (size %d > 10MB) for jira attach", f.Name, f.Size)
 			continue
 		}
-		data, dlErr := s.Slack.DownloadFile(f.URLPrivateDownload)
+		data, dlErr := s.Slack.DownloadFile(ctx, f.URLPrivateDownload)
 		if dlErr != nil {
 			log.Printf("[JARVIS] download %s for jira attach: %v", f.Name, dlErr)
 			continue
 		}
-		if attErr := s.Jira.AttachFileToIssue(issueKey, f.Name, data); attErr != nil {
+		if attErr := s.Jira.AttachFileToIssue(ctx, issueKey, f.Name, data); attErr != nil {
 			log.Printf("[JARVIS] attach %s to %s: %v", f.Name, issueKey, attErr)
 			continue
 		}
@@ -666,12 +737,14 @@ func (s *Service) attachThreadMediaToIssue(issueKey, channel, threadTs string) {
 
 // buildFileContext downloads files attached to the message and formats their
 // contents for inclusion in the LLM prompt.
-// Supported: text/*, JSON, YAML, XML, JS, TS (raw bytes) and XLSX (parsed as table).
-// Files larger than 20 MB are skipped. Total output is capped at 8 M chars to
-// stay safely under the OpenAI API limit of ~10 M chars per message.
-func (s *Service) buildFileContext(files []slack.File) string {
+// Supported: text/*, JSON, YAML, XML, JS, TS (raw bytes), XLSX (parsed as table)
+// and audio/video (timestamped transcript, when transcription is configured).
+// Files larger than 20 MB are skipped (25 MB for recordings). Total output is capped at 400 k chars;
+// the answer prompt then trims it to the model's context window together
+// with the other context sources.
+func (s *Service) buildFileContext(ctx context.Context, files []slack.File) string {
 	const maxFileBytes = 20 * 1024 * 1024 // 20 MB per file
-	const maxTotalChars = 100_000         // ~100 k chars — safe for 128k-token models
+	const maxTotalChars = 400_000         // hard cap; the answer prompt is trimmed to the model window later
 	if len(files) == 0 {
 		return ""
 	}
@@ -694,7 +767,7 @@ func (s *Service) buildFileContext(files []slack.File) string {
 				continue
 			}
 			log.Printf("[JARVIS] fetching Google Sheets file %q via Drive fileID=%s", f.Name, fileIDs[0])
-			result, driveErr := s.GoogleDrive.FetchByFileID(fileIDs[0], "")
+			result, driveErr := s.GoogleDrive.FetchByFileID(ctx, fileIDs[0], "")
 			if driveErr != nil {
 				log.Printf("[JARVIS] failed to fetch Google Sheets %q: %v", f.Name, driveErr)
 				continue
@@ -726,6 +799,21 @@ func (s *Service) buildFileContext(files []slack.File) string {
 			continue
 		}
 
+		if isMediaMimetype(f.Mimetype) {
+			if s.Transcriber == nil {
+				log.Printf("[JARVIS] skipping media file %q: transcription not configured", f.Name)
+				continue
+			}
+			t, err := s.transcribeSlackFile(ctx, f)
+			if err != nil {
+				log.Printf("[JARVIS] failed to transcribe %q: %v", f.Name, err)
+			}
+			if !writeTranscriptSection(&b, maxTotalChars, f.Name, f.Mimetype, t, err) {
+				break
+			}
+			continue
+		}
+
 		if !isText && !isXLSX && !isDocx && !isPDF {
 			log.Printf("[JARVIS] skipping unsupported file %q mimetype=%q", f.Name, f.Mimetype)
 			continue
@@ -738,7 +826,7 @@ func (s *Service) buildFileContext(files []slack.File) string {
 			log.Printf("[JARVIS] skipping file %q: no download URL", f.Name)
 			continue
 		}
-		data, err := s.Slack.DownloadFile(f.URLPrivateDownload)
+		data, err := s.Slack.DownloadFile(ctx, f.URLPrivateDownload)
 		if err != nil {
 			log.Printf("[JARVIS] failed to download file %q: %v", f.Name, err)
 			continue
@@ -795,7 +883,7 @@ func (s *Service) buildFileContext(files []slack.File) string {
 // buildImageAttachments downloads image files and returns them as vision
 // attachments for the LLM. Images larger than 5 MB are skipped (OpenAI
 // base64 limit).
-func (s *Service) buildImageAttachments(files []slack.File) []llm.ImageAttachment {
+func (s *Service) buildImageAttachments(ctx context.Context, files []slack.File) []llm.ImageAttachment {
 	const maxImageBytes = 5 * 1024 * 1024 // 5 MB (OpenAI base64 limit)
 	var out []llm.ImageAttachment
 	for _, f := range files {
@@ -810,7 +898,7 @@ func (s *Service) buildImageAttachments(files []slack.File) []llm.ImageAttachmen
 			log.Printf("[JARVIS] skipping image %q: no download URL", f.Name)
 			continue
 		}
-		data, err := s.Slack.DownloadFile(f.URLPrivateDownload)
+		data, err := s.Slack.DownloadFile(ctx, f.URLPrivateDownload)
 		if err != nil {
 			log.Printf("[JARVIS] failed to download image %q: %v", f.Name, err)
 			continue
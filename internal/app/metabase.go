@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -11,15 +12,19 @@ import (
 	"github.com/DanielFillol/Jarvis/internal/metabase"
 )
 
-// formattedMetabaseDatabases returns the available Metabase databases formatted
-// as ["1: Production DB (postgres)", ...] for injection into the router prompt.
-// Returns nil when Metabase is not configured.
-func (s *Service) formattedMetabaseDatabases() []string {
+// formattedMetabaseDatabases returns the Metabase databases the workspace
+// teamID may query, formatted as ["1: Production DB (postgres)", ...] for
+// injection into the router prompt ("" lists them all).  Returns nil when
+// Metabase is not configured.
+func (s *Service) formattedMetabaseDatabases(teamID string) []string {
 	if s.Metabase == nil || len(s.Metabase.Databases) == 0 {
 		return nil
 	}
 	out := make([]string, 0, len(s.Metabase.Cards))
 	for _, db := range s.Metabase.Databases {
+		if !s.Cfg.MetabaseDBAllowed(teamID, db.ID) {
+			continue
+		}
 		engine := db.Engine
 		if engine == "" {
 			engine = "unknown"
@@ -146,7 +151,7 @@ type metabaseQueryResult struct {
 //
 // When the LLM requests clarification before generating SQL, DBCtx is prefixed
 // with llm.ClarificationPrefix and QueryResult/ExecutedSQL are nil/"".
-func (s *Service) runMetabaseQuery(question, threadHist string, dbID int, baseSQL string, wantsAllRows bool) metabaseQueryResult {
+func (s *Service) runMetabaseQuery(ctx context.Context, question, threadHist string, dbID int, baseSQL string, wantsAllRows bool) metabaseQueryResult {
 	if s.Metabase == nil {
 		return metabaseQueryResult{}
 	}
@@ -170,7 +175,7 @@ func (s *Service) runMetabaseQuery(question, threadHist string, dbID int, baseSQ
 	var zeroResult *metabase.QueryResult // non-nil when phase 1 produced an all-zero result
 	var zeroSQL string                   // the SQL that produced the zero result
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		sql, err := s.LLM.GenerateSQL(question, threadHist, schema, lastSQL, lastErr, dbEngine, hintsCtx, wantsAllRows, s.Cfg.OpenAIModel)
+		sql, err := s.LLM.GenerateSQL(ctx, question, threadHist, schema, lastSQL, lastErr, dbEngine, hintsCtx, wantsAllRows, s.Cfg.RoutingModel(s.Cfg.OpenAIModel))
 		if err != nil {
 			log.Printf("[METABASE] GenerateSQL attempt %d failed: %v", attempt, err)
 			continue
@@ -180,24 +185,26 @@ func (s *Service) runMetabaseQuery(question, threadHist string, dbID int, baseSQ
 			return metabaseQueryResult{DBCtx: sql}
 		}
 		log.Printf("[METABASE] attempt %d sql: %s", attempt, clip(sql, 400))
-		qr, err := s.Metabase.ExecuteNativeQuery(dbID, sql)
+		qr, err := s.Metabase.ExecuteNativeQuery(ctx, dbID, sql)
 		if err != nil {
 			log.Printf("[METABASE] ExecuteNativeQuery attempt %d failed: %v", attempt, err)
+			s.LLM.ForgetSQL(sql)
 			lastSQL = sql
 			lastErr = err.Error()
 			continue
 		}
 		if qr.Error != "" {
 			log.Printf("[METABASE] query error attempt %d: %s", attempt, clip(qr.Error, 200))
+			s.LLM.ForgetSQL(sql)
 			lastSQL = sql
 			lastErr = qr.Error
 			continue
 		}
 		log.Printf("[METABASE] query succeeded attempt %d rows=%d", attempt, len(qr.Data.Rows))
 		if !isAllZeroResult(qr) {
-			ctx := fmt.Sprintf("Query executada (db=%d):\n```sql\n%s\n```\n\nResultado:\n%s",
+			dbCtx := fmt.Sprintf("Query executada (db=%d):\n```sql\n%s\n```\n\nResultado:\n%s",
 				dbID, sql, metabase.FormatQueryResult(*qr, 100))
-			return metabaseQueryResult{DBCtx: ctx, QueryResult: qr, ExecutedSQL: sql}
+			return metabaseQueryResult{DBCtx: dbCtx, QueryResult: qr, ExecutedSQL: sql}
 		}
 		// All-zero — save and break; Phase 2 will verify.
 		log.Printf("[METABASE] zero result detected at attempt %d — entering zero-result retry phase", attempt)
@@ -215,7 +222,7 @@ func (s *Service) runMetabaseQuery(question, threadHist string, dbID int, baseSQ
 
 		for zeroAttempt := 1; zeroAttempt <= maxZeroRetries; zeroAttempt++ {
 			log.Printf("[METABASE] zero-result retry %d/%d for db=%d", zeroAttempt, maxZeroRetries, dbID)
-			sql, err := s.LLM.GenerateSQL(question, threadHist, schema, lastSQL, zeroHint, dbEngine, hintsCtx, wantsAllRows, s.Cfg.OpenAIModel)
+			sql, err := s.LLM.GenerateSQL(ctx, question, threadHist, schema, lastSQL, zeroHint, dbEngine, hintsCtx, wantsAllRows, s.Cfg.RoutingModel(s.Cfg.OpenAIModel))
 			if err != nil {
 				log.Printf("[METABASE] zero-retry GenerateSQL attempt %d failed: %v", zeroAttempt, err)
 				continue
@@ -225,23 +232,25 @@ func (s *Service) runMetabaseQuery(question, threadHist string, dbID int, baseSQ
 				return metabaseQueryResult{DBCtx: sql}
 			}
 			log.Printf("[METABASE] zero-retry %d sql: %s", zeroAttempt, clip(sql, 400))
-			qr, err := s.Metabase.ExecuteNativeQuery(dbID, sql)
+			qr, err := s.Metabase.ExecuteNativeQuery(ctx, dbID, sql)
 			if err != nil {
 				log.Printf("[METABASE] zero-retry ExecuteNativeQuery attempt %d failed: %v", zeroAttempt, err)
+				s.LLM.ForgetSQL(sql)
 				lastSQL = sql
 				continue
 			}
 			if qr.Error != "" {
 				log.Printf("[METABASE] zero-retry query error attempt %d: %s", zeroAttempt, clip(qr.Error, 200))
+				s.LLM.ForgetSQL(sql)
 				lastSQL = sql
 				continue
 			}
 			log.Printf("[METABASE] zero-retry %d succeeded rows=%d", zeroAttempt, len(qr.Data.Rows))
 			if !isAllZeroResult(qr) {
 				log.Printf("[METABASE] zero-result overridden by non-zero result on retry %d", zeroAttempt)
-				ctx := fmt.Sprintf("Query executada (db=%d):\n```sql\n%s\n```\n\nResultado:\n%s",
+				dbCtx := fmt.Sprintf("Query executada (db=%d):\n```sql\n%s\n```\n\nResultado:\n%s",
 					dbID, sql, metabase.FormatQueryResult(*qr, 100))
-				return metabaseQueryResult{DBCtx: ctx, QueryResult: qr, ExecutedSQL: sql}
+				return metabaseQueryResult{DBCtx: dbCtx, QueryResult: qr, ExecutedSQL: sql}
 			}
 			log.Printf("[METABASE] zero-retry %d also returned all-zeros", zeroAttempt)
 			lastSQL = sql
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+func TestAnswerChatRefusesOverBudget(t *testing.T) {
+	// No LLM client: any call past the budget check would panic.
+	s := &Service{Cfg: config.Config{LLMDailyTokensPerUser: 100, LLMDailyTokensPerChannel: 1000}}
+	s.budget.mu.Lock()
+	s.budget.today()
+	s.budget.users["U1"] = 100
+	s.budget.channels[directChannel] = 0
+	s.budget.users["U2"] = 0
+	s.budget.mu.Unlock()
+
+	var budgetErr *BudgetError
+	_, err := s.AnswerChat(context.Background(), "quantos pedidos hoje?", "U1", "direct-1", "", nil)
+	if !errors.As(err, &budgetErr) || !strings.Contains(budgetErr.Message, "limite diário") {
+		t.Fatalf("AnswerChat: err = %v, want the budget message", err)
+	}
+
+	// The channel cap covers every /api/chat caller together.
+	s.budget.mu.Lock()
+	s.budget.channels[directChannel] = 1000
+	s.budget.mu.Unlock()
+	_, err = s.AnswerChat(context.Background(), "e ontem?", "U2", "direct-2", "", nil)
+	if !errors.As(err, &budgetErr) || !strings.Contains(budgetErr.Message, "Este canal atingiu") {
+		t.Errorf("AnswerChat: err = %v, want the channel budget message", err)
+	}
+}
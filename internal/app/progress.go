@@ -0,0 +1,134 @@
+package app
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/text"
+)
+
+// maxPlaceholderChars keeps streamed partial answers under the chat.update
+// text limit; the final answer is posted in full (or split) afterwards.
+const maxPlaceholderChars = 3500
+
+// placeholder renders progress into the "_buscando..._" message while an
+// answer is being built: status lines from the skills first, then the answer
+// itself as it streams in.  Slack updates run on a single goroutine and are
+// throttled to one per interval; only the latest text is sent, intermediate
+// states are dropped.
+type placeholder struct {
+	update   func(text string) error
+	interval time.Duration
+
+	mu      sync.Mutex
+	lines   []string
+	partial string
+	dirty   bool
+	stopped bool
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newPlaceholder starts the update loop.  update is called with the full
+// text of the message each time it changes.
+func newPlaceholder(interval time.Duration, update func(text string) error) *placeholder {
+	p := &placeholder{
+		update:   update,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.loop()
+	return p
+}
+
+// Progress appends a status line ("consultando Jira…").  Safe for concurrent use.
+func (p *placeholder) Progress(line string) {
+	p.mu.Lock()
+	if p.stopped || p.partial != "" {
+		p.mu.Unlock()
+		return
+	}
+	p.lines = append(p.lines, line)
+	p.dirty = true
+	p.mu.Unlock()
+	p.poke()
+}
+
+// Partial replaces the message with the answer generated so far (raw
+// Markdown, converted when sent).
+func (p *placeholder) Partial(answer string) {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.partial = answer
+	p.dirty = true
+	p.mu.Unlock()
+	p.poke()
+}
+
+// Stop ends the loop and waits for an in-flight update, so the final reply
+// is never overwritten by a late partial one.
+func (p *placeholder) Stop() {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.stopped = true
+	p.mu.Unlock()
+	close(p.stop)
+	<-p.done
+}
+
+func (p *placeholder) poke() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *placeholder) loop() {
+	defer close(p.done)
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-p.wake:
+		}
+		p.mu.Lock()
+		msg, dirty := p.render(), p.dirty
+		p.dirty = false
+		p.mu.Unlock()
+		if dirty {
+			_ = p.update(msg)
+		}
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(p.interval):
+		}
+	}
+}
+
+// render builds the message text.  Callers hold p.mu.
+func (p *placeholder) render() string {
+	if p.partial != "" {
+		answer := clip(text.MarkdownToMarkdown(strings.TrimSpace(stripCitations(p.partial))), maxPlaceholderChars)
+		return answer + "\n\n_escrevendo..._"
+	}
+	var sb strings.Builder
+	sb.WriteString("_buscando..._")
+	for _, l := range p.lines {
+		sb.WriteString("\n• _")
+		sb.WriteString(l)
+		sb.WriteString("_")
+	}
+	return sb.String()
+}
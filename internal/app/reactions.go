@@ -0,0 +1,177 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/outline"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// Reaction workflows, mapped to emojis by SLACK_REACTION_WORKFLOWS.
+const (
+	ReactionJira     = "jira"     // create a Jira card from the message's thread
+	ReactionTLDR     = "tldr"     // summarise the thread in it
+	ReactionBookmark = "bookmark" // save the message to the user's notes (their DM with the bot)
+	ReactionOutline  = "outline"  // publish the thread as an Outline document
+)
+
+// HandleReaction runs the workflow mapped to a reaction_added event.  action
+// is the already resolved mapping (see config.ReactionAction).  Results are
+// posted in the message's thread, except bookmarks, which go to the user.
+func (s *Service) HandleReaction(ctx context.Context, action string, ev slack.ReactionEvent) {
+	start := time.Now()
+	channel := ev.Item.Channel
+	log.Printf("[JARVIS] reaction action=%s emoji=%q user=%q channel=%q ts=%q", action, ev.Reaction, ev.User, channel, ev.Item.Ts)
+
+	msg, err := s.Slack.GetMessage(ctx, channel, ev.Item.Ts)
+	if err != nil {
+		// Usually a channel the bot was not added to.
+		log.Printf("[WARN] reaction: read message channel=%q ts=%q: %v", channel, ev.Item.Ts, err)
+		return
+	}
+	threadTs := msg.ThreadTs
+	if threadTs == "" {
+		threadTs = msg.Ts
+	}
+
+	telEvent := telemetry.Event{
+		Channel:      channel,
+		ChannelType:  channelType(channel),
+		ThreadTs:     threadTs,
+		OriginTs:     msg.Ts,
+		SenderUserID: ev.User,
+		LLMModel:     s.Cfg.OpenAIModel,
+		Actions:      []string{"reaction_" + action},
+		Success:      true,
+	}
+	meter := llm.NewUsageMeter()
+	ctx = llm.WithUsageMeter(ctx, meter)
+	defer func() {
+		s.recordUsage(&telEvent, meter, ev.User, channel)
+		telEvent.DurationMs = int(time.Since(start).Milliseconds())
+		s.Telemetry.Record(telEvent)
+	}()
+
+	if action != ReactionBookmark {
+		if budgetMsg := s.budgetExceeded(ctx, ev.User, channel); budgetMsg != "" {
+			telEvent.Success = false
+			telEvent.ErrorStage = "token_budget"
+			_ = s.Slack.PostMessage(ctx, channel, threadTs, budgetMsg)
+			return
+		}
+	}
+
+	switch action {
+	case ReactionJira:
+		err = s.reactionJira(ctx, ev, msg, channel, threadTs)
+	case ReactionTLDR:
+		err = s.reactionTLDR(ctx, ev, channel, threadTs)
+	case ReactionBookmark:
+		err = s.reactionBookmark(ctx, ev, msg, channel)
+	case ReactionOutline:
+		err = s.reactionOutline(ctx, ev, channel, threadTs)
+	default:
+		log.Printf("[WARN] reaction: unknown action %q for emoji %q", action, ev.Reaction)
+		return
+	}
+	if err != nil {
+		telEvent.Success = false
+		telEvent.ErrorStage = "reaction_" + action
+		log.Printf("[ERR] reaction action=%s: %v", action, err)
+		return
+	}
+	log.Printf("[JARVIS] reaction action=%s done dur=%s", action, time.Since(start))
+}
+
+// reactionJira runs the regular create flow with the intent already
+// confirmed: missing fields are asked for in the thread, with buttons.
+func (s *Service) reactionJira(ctx context.Context, ev slack.ReactionEvent, msg slack.Message, channel, threadTs string) error {
+	if !s.Cfg.JiraEnabled() {
+		return s.Slack.PostMessage(ctx, channel, threadTs, "A integração com o Jira não está configurada nesta instalação.")
+	}
+	if !s.skillGranted(ctx, kindJiraSearch) {
+		return s.Slack.PostMessage(ctx, channel, threadTs, "A integração com o Jira não está liberada neste workspace.")
+	}
+	threadHist, err := s.Slack.GetThreadHistory(ctx, channel, threadTs, 60)
+	if err != nil {
+		log.Printf("[WARN] reaction jira: thread history: %v", err)
+	}
+	question := "Crie um card a partir desta mensagem:\n" + msg.Text
+	_, err = s.maybeHandleJiraCreateFlows(ctx, channel, threadTs, ev.User, msg.Ts, "", question, threadHist, true, false)
+	return err
+}
+
+// reactionTLDR posts a summary of the thread in it.
+func (s *Service) reactionTLDR(ctx context.Context, ev slack.ReactionEvent, channel, threadTs string) error {
+	threadHist, err := s.Slack.GetThreadHistoryFull(ctx, channel, threadTs, 400, 40000)
+	if err != nil {
+		return fmt.Errorf("thread history: %w", err)
+	}
+	summary, err := s.LLM.SummarizeThread(ctx, threadHist, s.Cfg.OpenAIModel)
+	if err != nil {
+		_ = s.Slack.PostMessage(ctx, channel, threadTs, "Não consegui resumir esta thread (erro interno).")
+		return err
+	}
+	return s.Slack.PostMessage(ctx, channel, threadTs, fmt.Sprintf("*TL;DR* _(pedido por <@%s>)_\n%s", ev.User, summary))
+}
+
+// reactionBookmark sends the message to the user's DM with the bot, which
+// doubles as their notes.
+func (s *Service) reactionBookmark(ctx context.Context, ev slack.ReactionEvent, msg slack.Message, channel string) error {
+	dm, err := s.Slack.OpenDM(ctx, ev.User)
+	if err != nil {
+		return fmt.Errorf("open DM: %w", err)
+	}
+	link, err := s.Slack.GetPermalink(ctx, channel, msg.Ts)
+	if err != nil {
+		log.Printf("[WARN] reaction bookmark: permalink: %v", err)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, ":bookmark: *Nota salva* de <#%s>", channel)
+	if msg.User != "" {
+		fmt.Fprintf(&b, " — mensagem de <@%s>", msg.User)
+	}
+	if link != "" {
+		fmt.Fprintf(&b, " · <%s|abrir>", link)
+	}
+	if text := strings.TrimSpace(msg.Text); text != "" {
+		b.WriteString("\n>" + strings.ReplaceAll(clip(text, 2500), "\n", "\n>"))
+	}
+	return s.Slack.PostMessage(ctx, dm, "", b.String())
+}
+
+// reactionOutline publishes the thread as a wiki document and links it in
+// the thread.
+func (s *Service) reactionOutline(ctx context.Context, ev slack.ReactionEvent, channel, threadTs string) error {
+	if s.Outline == nil || s.Cfg.OutlineCollectionID == "" {
+		return s.Slack.PostMessage(ctx, channel, threadTs, "Publicação no Outline não configurada (`OUTLINE_BASE_URL`, `OUTLINE_API_KEY` e `OUTLINE_COLLECTION_ID`).")
+	}
+	// The wiki is internal: a workspace must be granted Outline to publish to it.
+	if !s.skillGranted(ctx, outline.ActionSearch) {
+		return s.Slack.PostMessage(ctx, channel, threadTs, "A integração com o Outline não está liberada neste workspace.")
+	}
+	threadHist, err := s.Slack.GetThreadHistoryFull(ctx, channel, threadTs, 400, 40000)
+	if err != nil {
+		return fmt.Errorf("thread history: %w", err)
+	}
+	title, body, err := s.LLM.ThreadToDocument(ctx, threadHist, s.Cfg.OpenAIModel)
+	if err != nil {
+		_ = s.Slack.PostMessage(ctx, channel, threadTs, "Não consegui escrever o documento (erro interno).")
+		return err
+	}
+	if link, err := s.Slack.GetPermalink(ctx, channel, threadTs); err == nil && link != "" {
+		body += "\n\n---\nThread de origem: " + link
+	}
+	doc, err := s.Outline.CreateDocument(ctx, title, body, s.Cfg.OutlineCollectionID)
+	if err != nil {
+		_ = s.Slack.PostMessage(ctx, channel, threadTs, fmt.Sprintf("Não consegui publicar no Outline: %v", err))
+		return err
+	}
+	return s.Slack.PostMessage(ctx, channel, threadTs, fmt.Sprintf(":books: Thread publicada no Outline por <@%s>: <%s|%s>", ev.User, doc.URL, doc.Title))
+}
@@ -0,0 +1,290 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/schedule"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// scheduleTick is how often due schedules are checked; cron expressions have
+// minute resolution.
+const scheduleTick = 30 * time.Second
+
+// App Home schedule buttons.  The value is the schedule ID.
+const (
+	actionSchedulePause  = "schedule_pause"
+	actionScheduleResume = "schedule_resume"
+	actionScheduleDelete = "schedule_delete"
+)
+
+// scheduledRunKey marks the context of a scheduled run, so its question
+// cannot create further schedules.
+type scheduledRunKey struct{}
+
+func isScheduledRun(ctx context.Context) bool {
+	_, ok := ctx.Value(scheduledRunKey{}).(int64)
+	return ok
+}
+
+// RunSchedules runs due schedules until ctx is cancelled.  It is a no-op when
+// scheduling is disabled.
+func (s *Service) RunSchedules(ctx context.Context) {
+	if s.Schedules == nil || s.Cfg.ScheduleMaxPerUser <= 0 || s.Slack == nil {
+		return
+	}
+	s.Schedules.Run(ctx, scheduleTick, s.runSchedule)
+}
+
+// runSchedule answers a schedule's question through the direct pipeline and
+// posts the result to its channel (or its owner's DM).  Usage is charged to
+// the owner's daily budget like a question they asked.
+func (s *Service) runSchedule(sc schedule.Schedule) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	ctx = context.WithValue(ctx, scheduledRunKey{}, sc.ID)
+	if !s.Slack.KnowsTeam(sc.TeamID) {
+		log.Printf("[SCHEDULE] id=%d team=%q is no longer installed — skipping", sc.ID, sc.TeamID)
+		return
+	}
+	ctx = slack.WithTeam(ctx, sc.TeamID)
+
+	channel := sc.Channel
+	if strings.HasPrefix(channel, "U") || strings.HasPrefix(channel, "W") {
+		dm, err := s.Slack.OpenDM(ctx, channel)
+		if err != nil {
+			log.Printf("[ERR] schedule id=%d open DM: %v", sc.ID, err)
+			return
+		}
+		channel = dm
+	}
+
+	telEvent := telemetry.Event{
+		Channel:      channel,
+		ChannelType:  channelType(channel),
+		SenderUserID: sc.UserID,
+		Question:     sc.Question,
+		QuestionLen:  len(sc.Question),
+		LLMModel:     s.Cfg.OpenAIModel,
+		Actions:      []string{"schedule_run"},
+		Success:      true,
+	}
+	meter := llm.NewUsageMeter()
+	ctx = llm.WithUsageMeter(ctx, meter)
+	defer func() {
+		s.recordUsage(&telEvent, meter, sc.UserID, channel)
+		telEvent.DurationMs = int(time.Since(start).Milliseconds())
+		s.Telemetry.Record(telEvent)
+	}()
+
+	header := fmt.Sprintf(":alarm_clock: *Agendamento #%d* — _%s_", sc.ID, clip(sc.Question, 300))
+	post := func(text string) {
+		for _, chunk := range splitIntoChunks(strings.TrimSpace(text), 3900) {
+			if err := s.Slack.PostMessage(ctx, channel, "", chunk); err != nil {
+				log.Printf("[ERR] schedule id=%d post: %v", sc.ID, err)
+				return
+			}
+		}
+	}
+
+	if msg := s.budgetExceeded(ctx, sc.UserID, channel); msg != "" {
+		telEvent.Success = false
+		telEvent.ErrorStage = "token_budget"
+		post(header + "\n\n" + msg)
+		return
+	}
+	answer, err := s.ProcessDirect(ctx, sc.Question, sc.UserID, fmt.Sprintf("schedule-%d", sc.ID), "", nil)
+	if err != nil {
+		log.Printf("[ERR] schedule id=%d: %v", sc.ID, err)
+		telEvent.Success = false
+		telEvent.ErrorStage = "schedule"
+		post(header + "\n\nNão consegui gerar a resposta desta vez (erro interno).")
+		return
+	}
+	telEvent.Answer = answer
+	telEvent.AnswerLen = len(answer)
+	post(header + "\n\n" + answer)
+	log.Printf("[JARVIS] schedule id=%d done dur=%s", sc.ID, time.Since(start))
+}
+
+// describeSchedule renders where and when a schedule runs.
+func describeSchedule(sc schedule.Schedule) string {
+	where := "na sua DM"
+	if !strings.HasPrefix(sc.Channel, "U") && !strings.HasPrefix(sc.Channel, "W") {
+		where = "em <#" + sc.Channel + ">"
+	}
+	tz := sc.Timezone
+	if tz == "" {
+		tz = "horário do servidor"
+	}
+	when := "pausado"
+	if !sc.Paused {
+		next := sc.NextRun
+		if loc, err := schedule.LoadLocation(sc.Timezone); err == nil {
+			next = next.In(loc)
+		}
+		when = "próxima execução " + next.Format("02/01 15:04")
+	}
+	return fmt.Sprintf("`%s` (%s) %s · %s", sc.Cron, tz, where, when)
+}
+
+// commandSchedules handles "/jarvis agendamentos [listar|pausar|retomar|
+// excluir <id>]".  It runs inside the ack window: every operation is a
+// single query.
+func (s *Service) commandSchedules(cmd slack.SlashCommand, args string) string {
+	if s.Schedules == nil || s.Cfg.ScheduleMaxPerUser <= 0 {
+		return "Agendamentos estão desabilitados nesta instalação."
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	command := cmd.Command
+	if command == "" {
+		command = "/jarvis"
+	}
+	action, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	action = strings.ToLower(action)
+	if action == "" || action == "listar" || action == "list" {
+		list, err := s.Schedules.ListByUser(ctx, cmd.UserID)
+		if err != nil {
+			log.Printf("[ERR] list schedules user=%q: %v", cmd.UserID, err)
+			return "Não consegui carregar seus agendamentos agora."
+		}
+		if len(list) == 0 {
+			return "Você não tem agendamentos. Para criar, me peça em linguagem natural, ex: _todo dia útil às 9h me mande os bugs abertos do BACKEND no #eng_."
+		}
+		var b strings.Builder
+		b.WriteString("*Seus agendamentos:*\n")
+		for _, sc := range list {
+			fmt.Fprintf(&b, "• *#%d* — _%s_\n    %s\n", sc.ID, clip(sc.Question, 200), describeSchedule(sc))
+		}
+		fmt.Fprintf(&b, "Use `%s agendamentos pausar|retomar|excluir <id>` para gerenciá-los.", command)
+		return b.String()
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(rest), "#"), 10, 64)
+	if err != nil {
+		return fmt.Sprintf("Informe o número do agendamento, ex: `%s agendamentos %s 3`.", command, action)
+	}
+	var msg, verb string
+	switch action {
+	case "pausar", "pause":
+		msg, verb = s.setSchedulePaused(ctx, id, cmd.UserID, true), "pausado"
+	case "retomar", "resume":
+		msg, verb = s.setSchedulePaused(ctx, id, cmd.UserID, false), "retomado"
+	case "excluir", "delete", "remover":
+		msg, verb = s.deleteSchedule(ctx, id, cmd.UserID), "excluído"
+	default:
+		return fmt.Sprintf("Não conheço a ação `%s`. Use `%s agendamentos [listar|pausar|retomar|excluir <id>]`.", clip(action, 30), command)
+	}
+	if msg != "" {
+		return msg
+	}
+	return fmt.Sprintf("Agendamento *#%d* %s.", id, verb)
+}
+
+// setSchedulePaused pauses or resumes one of userID's schedules.  It returns
+// a message for the user when that failed, or "" on success.
+func (s *Service) setSchedulePaused(ctx context.Context, id int64, userID string, paused bool) string {
+	var next time.Time
+	if !paused {
+		sc, found, err := s.Schedules.Get(ctx, id, userID)
+		if err != nil {
+			log.Printf("[ERR] get schedule id=%d: %v", id, err)
+			return "Não consegui atualizar o agendamento agora."
+		}
+		if !found {
+			return fmt.Sprintf("Não encontrei o agendamento #%d entre os seus.", id)
+		}
+		if next, err = schedule.NextRun(sc.Cron, sc.Timezone, time.Now()); err != nil {
+			return fmt.Sprintf("Não consegui retomar o agendamento #%d: %v", id, err)
+		}
+	}
+	ok, err := s.Schedules.SetPaused(ctx, id, userID, paused, next)
+	if err != nil {
+		log.Printf("[ERR] pause schedule id=%d: %v", id, err)
+		return "Não consegui atualizar o agendamento agora."
+	}
+	if !ok {
+		return fmt.Sprintf("Não encontrei o agendamento #%d entre os seus.", id)
+	}
+	log.Printf("[JARVIS] schedule id=%d paused=%t user=%q", id, paused, userID)
+	return ""
+}
+
+// deleteSchedule removes one of userID's schedules.  It returns a message
+// for the user when that failed, or "" on success.
+func (s *Service) deleteSchedule(ctx context.Context, id int64, userID string) string {
+	ok, err := s.Schedules.Delete(ctx, id, userID)
+	if err != nil {
+		log.Printf("[ERR] delete schedule id=%d: %v", id, err)
+		return "Não consegui excluir o agendamento agora."
+	}
+	if !ok {
+		return fmt.Sprintf("Não encontrei o agendamento #%d entre os seus.", id)
+	}
+	log.Printf("[JARVIS] schedule id=%d deleted user=%q", id, userID)
+	return ""
+}
+
+// homeSchedules renders the user's schedules with pause/resume and delete
+// buttons for the App Home.
+func (s *Service) homeSchedules(ctx context.Context, userID string) []slack.Block {
+	list, err := s.Schedules.ListByUser(ctx, userID)
+	if err != nil {
+		log.Printf("[WARN] home schedules user=%q: %v", userID, err)
+		return []slack.Block{slack.Context("_Não consegui carregar seus agendamentos agora._")}
+	}
+	if len(list) == 0 {
+		return []slack.Block{slack.Context("_Nenhum agendamento. Peça algo como: todo dia útil às 9h me mande os bugs abertos do BACKEND._")}
+	}
+	var blocks []slack.Block
+	for i, sc := range list {
+		if i == homeMaxSchedules {
+			blocks = append(blocks, slack.Context(fmt.Sprintf("_e mais %d agendamento(s); veja todos com `/jarvis agendamentos`._", len(list)-homeMaxSchedules)))
+			break
+		}
+		id := strconv.FormatInt(sc.ID, 10)
+		toggle := slack.Button(actionSchedulePause, "Pausar", id, "")
+		if sc.Paused {
+			toggle = slack.Button(actionScheduleResume, "Retomar", id, "primary")
+		}
+		blocks = append(blocks,
+			slack.Section(fmt.Sprintf("*#%d* — %s\n%s", sc.ID, clip(sc.Question, 200), describeSchedule(sc))),
+			slack.Actions("schedule_"+id, toggle, slack.Button(actionScheduleDelete, "Excluir", id, "danger")),
+		)
+	}
+	return blocks
+}
+
+// resolveScheduleAction handles the App Home schedule buttons and
+// republishes the Home with the result.
+func (s *Service) resolveScheduleAction(ctx context.Context, p slack.InteractionPayload, a slack.BlockAction) {
+	id, err := strconv.ParseInt(a.Value, 10, 64)
+	if err != nil || s.Schedules == nil {
+		return
+	}
+	var msg string
+	switch a.ActionID {
+	case actionSchedulePause:
+		msg = s.setSchedulePaused(ctx, id, p.User.ID, true)
+	case actionScheduleResume:
+		msg = s.setSchedulePaused(ctx, id, p.User.ID, false)
+	case actionScheduleDelete:
+		msg = s.deleteSchedule(ctx, id, p.User.ID)
+	}
+	if msg != "" {
+		log.Printf("[WARN] schedule action=%s id=%d: %s", a.ActionID, id, msg)
+	}
+	if err := s.PublishHome(ctx, p.User.ID); err != nil {
+		log.Printf("[WARN] publish home user=%q failed: %v", p.User.ID, err)
+	}
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/jira"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// Message shortcut and view IDs.  The shortcut's callback ID must match the
+// one configured under Interactivity & Shortcuts.
+const (
+	shortcutCreateJiraCard = "create_jira_card"
+	viewShortcutCreate     = "jira_shortcut_create"
+	draftFieldDescription  = "description"
+)
+
+// HandleShortcut runs a message shortcut.  "Criar card no Jira a partir
+// desta mensagem" opens a modal right away (trigger IDs expire after 3
+// seconds), reads the message's thread into a draft and fills the modal in
+// once the draft is ready.
+func (s *Service) HandleShortcut(ctx context.Context, p slack.InteractionPayload) {
+	log.Printf("[JARVIS] shortcut callback=%q user=%q channel=%q ts=%q", p.CallbackID, p.User.ID, p.Channel.ID, p.Message.Ts)
+	if p.CallbackID != shortcutCreateJiraCard {
+		log.Printf("[JARVIS] unknown shortcut callback=%q", p.CallbackID)
+		return
+	}
+	ephemeral := func(text string) {
+		if err := s.Slack.Respond(ctx, p.ResponseURL, slack.CommandResponse{ResponseType: "ephemeral", Text: text}); err != nil {
+			log.Printf("[WARN] shortcut respond failed: %v", err)
+		}
+	}
+	if !s.Cfg.JiraEnabled() {
+		ephemeral("A integração com o Jira não está configurada nesta instalação.")
+		return
+	}
+	if !s.skillGranted(ctx, kindJiraSearch) {
+		ephemeral("A integração com o Jira não está liberada neste workspace.")
+		return
+	}
+	if !s.Cfg.JiraCreateEnabled {
+		ephemeral("Criação de issues no Jira está desabilitada.")
+		return
+	}
+
+	channel, msgTs := p.Channel.ID, p.Message.Ts
+	threadTs := p.Message.ThreadTs
+	if threadTs == "" {
+		threadTs = msgTs
+	}
+	meta := channel + ":" + threadTs + ":" + msgTs
+	viewID, err := s.Slack.OpenViewAndGetID(ctx, p.TriggerID, slack.Modal(viewShortcutCreate, "Card do Jira", "", meta, []slack.Block{
+		slack.Section(":hourglass_flowing_sand: _Lendo a thread e preparando o rascunho..._"),
+	}))
+	if err != nil {
+		log.Printf("[WARN] open shortcut modal failed: %v", err)
+		return
+	}
+	showError := func(text string) {
+		if err := s.Slack.UpdateView(ctx, viewID, slack.Modal(viewShortcutCreate, "Card do Jira", "", meta, []slack.Block{slack.Section(text)})); err != nil {
+			log.Printf("[WARN] update shortcut modal failed: %v", err)
+		}
+	}
+
+	telEvent := telemetry.Event{
+		Channel:      channel,
+		ChannelType:  channelType(channel),
+		ThreadTs:     threadTs,
+		OriginTs:     msgTs,
+		SenderUserID: p.User.ID,
+		LLMModel:     s.Cfg.OpenAIModel,
+		Actions:      []string{"shortcut_jira_create"},
+		Success:      true,
+	}
+	start := time.Now()
+	meter := llm.NewUsageMeter()
+	ctx = llm.WithUsageMeter(ctx, meter)
+	defer func() {
+		s.recordUsage(&telEvent, meter, p.User.ID, channel)
+		telEvent.DurationMs = int(time.Since(start).Milliseconds())
+		s.Telemetry.Record(telEvent)
+	}()
+	if msg := s.budgetExceeded(ctx, p.User.ID, channel); msg != "" {
+		telEvent.Success = false
+		telEvent.ErrorStage = "token_budget"
+		showError(msg)
+		return
+	}
+
+	// Private channels the bot was not added to cannot be read: the
+	// message text alone is still enough for a draft.
+	threadHist, err := s.Slack.GetThreadHistory(ctx, channel, threadTs, 60)
+	if err != nil {
+		log.Printf("[WARN] shortcut thread history channel=%q: %v", channel, err)
+	}
+	instruction := "Crie um card a partir desta mensagem:\n" + p.Message.Text
+	draft, err := s.LLM.ExtractIssueFromThread(ctx, threadHist, s.threadTranscripts(ctx, channel, threadTs), instruction, s.Cfg.OpenAIModel, nil, s.Cfg.JiraProjectNameMapFor(s.Slack.InstalledTeam(ctx)))
+	if err != nil {
+		telEvent.Success = false
+		telEvent.ErrorStage = "extract_issue"
+		showError(fmt.Sprintf("Não consegui montar o rascunho a partir da thread: %v", err))
+		return
+	}
+	if err := s.Slack.UpdateView(ctx, viewID, s.shortcutModal(draft, meta, s.Slack.InstalledTeam(ctx))); err != nil {
+		log.Printf("[WARN] update shortcut modal failed: %v", err)
+	}
+}
+
+// shortcutModal is the draft edit form plus the description, which the
+// user only gets to review in this flow.
+func (s *Service) shortcutModal(d jira.IssueDraft, meta, teamID string) slack.Block {
+	fields := append(s.draftFields(d, teamID),
+		slack.TextInput(draftFieldDescription, "Descrição", clip(d.Description, 2900), true, true),
+		slack.Context("O link da mensagem de origem e os arquivos da thread são anexados ao card."),
+	)
+	return slack.Modal(viewShortcutCreate, "Card do Jira", "Criar card", meta, fields)
+}
+
+// submitShortcutCreate validates the shortcut modal and creates the card in
+// the background, replying in the message's thread.
+func (s *Service) submitShortcutCreate(p slack.InteractionPayload) *slack.ViewResponse {
+	parts := strings.SplitN(p.View.PrivateMetadata, ":", 3)
+	if len(parts) != 3 {
+		log.Printf("[ERR] shortcut view: bad metadata %q", p.View.PrivateMetadata)
+		return nil
+	}
+	channel, threadTs, msgTs := parts[0], parts[1], parts[2]
+
+	d, errs := readDraftFields(p.View, jira.IssueDraft{})
+	if d.Summary == "" {
+		errs[draftFieldSummary] = "Informe o título."
+	}
+	if len(errs) > 0 {
+		return &slack.ViewResponse{ResponseAction: "errors", Errors: errs}
+	}
+	d.Description = p.View.Value(draftFieldDescription)
+	log.Printf("[JARVIS] shortcut create user=%q project=%s type=%s", p.User.ID, d.Project, d.IssueType)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(slack.WithTeam(context.Background(), p.Team.ID), 2*time.Minute)
+		defer cancel()
+		s.appendSlackOrigin(ctx, &d, channel, threadTs, msgTs, "")
+		d.Description += fmt.Sprintf("\nCriado via atalho por @%s no Slack.", p.User.Username)
+		if _, err := s.createIssueAndReply(ctx, channel, threadTs, d, false); err != nil {
+			log.Printf("[ERR] shortcut create issue: %v", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,287 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/skill"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+const (
+	// digestMaxMessages caps the history read for one digest.
+	digestMaxMessages = 2000
+	// digestChunkChars is the transcript size summarised by each map call.
+	digestChunkChars = 12000
+	// digestParallel is how many map calls run at once.
+	digestParallel = 3
+)
+
+var reDigestRef = regexp.MustCompile(`\[m(\d+)\]`)
+
+// kindChannelDigest is the action kind of the channel digest skill.
+const kindChannelDigest = "channel_digest"
+
+// digestSkill summarises a channel over a period (channel_digest): the full
+// history, thread replies included, is summarised in chunks and the notes are
+// reduced into decisions, open questions and action items that link back to
+// the messages.  The digest is the answer itself, so it is returned as the
+// block's Reply.
+type digestSkill struct{ s *Service }
+
+func (digestSkill) Kind() string               { return kindChannelDigest }
+func (digestSkill) Label() string              { return "RESUMO DE CANAL DO SLACK" }
+func (digestSkill) DisplayName() string        { return "Resumos de canais do Slack" }
+func (digestSkill) Enabled(config.Config) bool { return true }
+func (digestSkill) Local() bool                { return true }
+
+// Deadline gives a digest 3 minutes: it reads a whole period of history and
+// summarises it in several LLM calls.
+func (digestSkill) Deadline(config.Config, string) time.Duration { return 3 * time.Minute }
+
+func (digestSkill) Tools() []llm.ToolDef {
+	return []llm.ToolDef{{Kind: kindChannelDigest, Description: "Resumir tudo o que foi conversado em um canal do Slack num período (decisões, perguntas em aberto, ações).", Params: []llm.ToolParam{
+		{Name: "channel", Type: "string", Check: llm.NonEmpty, Description: "Canal a resumir: #nome-do-canal ou o ID (C...)."},
+		{Name: "after", Type: "string", Check: llm.ISODate, Description: "Data inicial inclusiva YYYY-MM-DD."},
+		{Name: "before", Type: "string", Optional: true, Check: llm.ISODate, Description: "Data final exclusiva YYYY-MM-DD, ou null para até agora."},
+	}}}
+}
+
+func (digestSkill) RouterPrompt(skill.Request) llm.RouterSnippet {
+	return llm.RouterSnippet{
+		Source:   "- Resumo de canal: tudo o que rolou em um canal do Slack num período (lê o histórico completo, com threads).",
+		Examples: []string{`{"kind": "channel_digest", "channel": "#prod-geral", "after": "2026-03-02", "before": null}`},
+		Rules: `- "resuma o #canal", "o que rolou no #canal", "resumo/digest do #canal desde segunda", "o que decidiram no #canal em março" → channel_digest (NÃO use slack_search).
+- channel_digest é a resposta inteira: não combine com outras ações.
+- after é obrigatório. Sem período explícito, use os últimos 7 dias.
+  - "desde segunda" → after: SEGUNDA-DESTA-SEMANA, before: null
+  - "em março" → after: ANO-03-01, before: ANO-04-01
+  - "ontem" → after: DATA-ONTEM, before: DATA-HOJE`,
+	}
+}
+
+// Execute reads the channel's history for the period, summarises it chunk
+// by chunk and reduces the notes into the final digest.
+func (k digestSkill) Execute(ctx context.Context, req skill.Request, action llm.ActionDescriptor) (skill.ContextBlock, skill.Sources, error) {
+	block := skill.ContextBlock{Kind: kindChannelDigest}
+	if req.Test {
+		block.Text = "[AVISO: resumo de canal desabilitado em modo de teste.]"
+		return block, "", nil
+	}
+	if k.s.Slack == nil {
+		return block, "", skill.ErrSkipped
+	}
+	ref, _ := action.Args["channel"].(string)
+	after, _ := action.Args["after"].(string)
+	before, _ := action.Args["before"].(string)
+
+	channelID, channelName := k.s.resolveChannelRef(ctx, req.Question, ref)
+	if channelID == "" {
+		block.Reply = fmt.Sprintf("Não encontrei o canal *%s*. Mencione o canal (#nome) e confira se eu fui adicionado a ele.", strings.TrimSpace(ref))
+		return block, "", nil
+	}
+	oldest, _ := time.ParseInLocation("2006-01-02", after, time.Local)
+	latest := time.Now()
+	if before != "" {
+		if t, err := time.ParseInLocation("2006-01-02", before, time.Local); err == nil && t.Before(latest) {
+			latest = t
+		}
+	}
+	if !oldest.Before(latest) {
+		block.Reply = "O período pedido é inválido: a data inicial precisa ser anterior à final."
+		return block, "", nil
+	}
+	period := digestPeriod(oldest, latest)
+
+	log.Printf("%s channelDigest channel=%s (%s) after=%s before=%s", req.Tag(), channelID, channelName, after, before)
+	req.Report("lendo o histórico de #%s…", channelName)
+	msgs, truncated, err := k.s.Slack.GetChannelMessages(ctx, channelID, req.SenderUserID, oldest, latest, digestMaxMessages)
+	if err != nil {
+		block.Text = fmt.Sprintf("[ERRO: não consegui ler o histórico de #%s: %v]", channelName, err)
+		return block, "", err
+	}
+	block.Count = len(msgs)
+	if len(msgs) == 0 {
+		block.Reply = fmt.Sprintf("Não encontrei mensagens em #%s no período (%s).", channelName, period)
+		return block, "", nil
+	}
+
+	lines, links := k.digestTranscript(ctx, channelID, msgs)
+	chunks := chunkLines(lines, digestChunkChars)
+	notes := chunks
+	if len(chunks) > 1 {
+		req.Report("resumindo %d mensagens de #%s em %d partes…", len(msgs), channelName, len(chunks))
+		notes, err = k.mapDigest(ctx, req, channelName, period, chunks)
+		if err != nil {
+			block.Text = fmt.Sprintf("[ERRO: não consegui resumir #%s: %v]", channelName, err)
+			return block, "", err
+		}
+	}
+	req.Report("escrevendo o resumo de #%s…", channelName)
+	digest, err := k.s.LLM.ReduceDigest(ctx, channelName, period, req.QuestionForLLM, notes, k.s.Cfg.OpenAIModel)
+	if err != nil {
+		block.Text = fmt.Sprintf("[ERRO: não consegui resumir #%s: %v]", channelName, err)
+		return block, "", err
+	}
+
+	channelRef := "<#" + channelID + ">"
+	if req.Direct {
+		channelRef = "#" + channelName
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "*Resumo de %s* — %s _(%d mensagens)_\n\n", channelRef, period, len(msgs))
+	b.WriteString(linkDigestRefs(digest, links, req.Direct))
+	if truncated {
+		fmt.Fprintf(&b, "\n\n_Limitei a leitura às %d mensagens mais recentes do período; peça um intervalo menor para cobrir o restante._", digestMaxMessages)
+	}
+	block.Reply = b.String()
+	log.Printf("%s channelDigest channel=%s messages=%d chunks=%d truncated=%t", req.Tag(), channelID, len(msgs), len(chunks), truncated)
+	return block, "", nil
+}
+
+func (digestSkill) Telemetry(ev *telemetry.Event, block skill.ContextBlock, _ error) {
+	ev.SlackSearched = true
+	ev.SlackMatches += block.Count
+}
+
+// digestTranscript renders the messages as tagged lines ("[m3] 02/03 14:05
+// @ana: ..."), with each thread's replies indented under its root, and
+// returns the permalink of every tag.
+func (k digestSkill) digestTranscript(ctx context.Context, channelID string, msgs []slack.HistoryMessage) ([]string, map[string]string) {
+	replies := map[string][]slack.HistoryMessage{}
+	var roots []slack.HistoryMessage
+	for _, m := range msgs {
+		if m.ThreadTs != "" {
+			replies[m.ThreadTs] = append(replies[m.ThreadTs], m)
+		} else {
+			roots = append(roots, m)
+		}
+	}
+	workspace, err := k.s.Slack.WorkspaceURL(ctx, channelID, msgs[0].Ts)
+	if err != nil {
+		log.Printf("[WARN] channelDigest permalinks unavailable: %v", err)
+	}
+	names := map[string]string{}
+	author := func(userID string) string {
+		if n, ok := names[userID]; ok {
+			return n
+		}
+		n := userID
+		if u, err := k.s.Slack.GetUsernameByID(ctx, userID); err == nil {
+			n = u
+		}
+		names[userID] = n
+		return n
+	}
+
+	var lines []string
+	links := map[string]string{}
+	write := func(m slack.HistoryMessage, indent string) {
+		tag := "m" + strconv.Itoa(len(lines)+1)
+		if workspace != "" {
+			links[tag] = slack.MessageURL(workspace, channelID, m.Ts, m.ThreadTs)
+		}
+		lines = append(lines, fmt.Sprintf("%s[%s] %s @%s: %s", indent, tag, slackTsTime(m.Ts).Format("02/01 15:04"), author(m.User), m.Text))
+	}
+	for _, r := range roots {
+		write(r, "")
+		for _, rep := range replies[r.Ts] {
+			write(rep, "  ↳ ")
+		}
+	}
+	return lines, links
+}
+
+// mapDigest summarises every chunk, digestParallel at a time.  Failed
+// chunks are dropped; the digest fails only when none succeeded.
+func (k digestSkill) mapDigest(ctx context.Context, req skill.Request, channelName, period string, chunks []string) ([]string, error) {
+	notes := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, digestParallel)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			notes[i], errs[i] = k.s.LLM.DigestChunk(ctx, channelName, fmt.Sprintf("%s, parte %d/%d", period, i+1, len(chunks)), chunk, k.s.Cfg.OpenAIModel)
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	var out []string
+	for i, n := range notes {
+		if errs[i] != nil {
+			log.Printf("%s channelDigest chunk %d/%d failed: %v", req.Warn(), i+1, len(chunks), errs[i])
+			continue
+		}
+		out = append(out, n)
+	}
+	if len(out) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+// chunkLines groups lines into chunks of at most max bytes (a single longer
+// line makes its own chunk).
+func chunkLines(lines []string, max int) []string {
+	var chunks []string
+	var b strings.Builder
+	for _, l := range lines {
+		if b.Len() > 0 && b.Len()+len(l)+1 > max {
+			chunks = append(chunks, b.String())
+			b.Reset()
+		}
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	if b.Len() > 0 {
+		chunks = append(chunks, b.String())
+	}
+	return chunks
+}
+
+// linkDigestRefs replaces the [mN] tags of the digest with links to the
+// messages.  Tags without a permalink are removed.
+func linkDigestRefs(digest string, links map[string]string, plain bool) string {
+	out := reDigestRef.ReplaceAllStringFunc(digest, func(m string) string {
+		u, ok := links[strings.Trim(m, "[]")]
+		switch {
+		case !ok:
+			return ""
+		case plain:
+			return "(" + u + ")"
+		}
+		return "<" + u + "|↗>"
+	})
+	return strings.ReplaceAll(out, " \n", "\n")
+}
+
+// digestPeriod formats the period for prompts and the digest header.
+// latest is exclusive.
+func digestPeriod(oldest, latest time.Time) string {
+	last := latest.Add(-time.Second)
+	if last.Format("2006-01-02") == oldest.Format("2006-01-02") {
+		return oldest.Format("02/01/2006")
+	}
+	return oldest.Format("02/01/2006") + " a " + last.Format("02/01/2006")
+}
+
+// slackTsTime converts a Slack message ts to local time.
+func slackTsTime(ts string) time.Time {
+	sec, _, _ := strings.Cut(ts, ".")
+	n, _ := strconv.ParseInt(sec, 10, 64)
+	return time.Unix(n, 0)
+}
@@ -0,0 +1,227 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/jira"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/skill"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// Action kinds of the Jira skill.  jira_create and jira_edit are handled by
+// the Jira flows before the skills run (see splitActions).
+const (
+	kindJiraSearch = "jira_search"
+	kindJiraCreate = "jira_create"
+	kindJiraEdit   = "jira_edit"
+)
+
+// jiraSkill searches Jira issues (jira_search).  It also declares
+// jira_create and jira_edit so the router can request them; those are handler
+// actions run by the Jira flows in HandleMessage, not by Execute.
+type jiraSkill struct{ s *Service }
+
+func (jiraSkill) Kind() string                   { return kindJiraSearch }
+func (jiraSkill) Label() string                  { return "CONTEXTO DO JIRA" }
+func (jiraSkill) DisplayName() string            { return "Busca de issues no Jira" }
+func (jiraSkill) Enabled(cfg config.Config) bool { return cfg.JiraEnabled() }
+func (jiraSkill) Weight() float64                { return 2 }
+
+func (jiraSkill) Tools() []llm.ToolDef {
+	return []llm.ToolDef{
+		{Kind: kindJiraCreate, Description: "Criar um card no Jira a partir da mensagem/thread. Use apenas com verbo de criação explícito."},
+		{Kind: kindJiraEdit, Description: "Editar um card existente no Jira: status, responsável, campos, sprint."},
+		{Kind: kindJiraSearch, Description: "Buscar issues no Jira (roadmap, bugs, status, progresso).", Params: []llm.ToolParam{
+			{Name: "jql", Type: "string", Optional: true, Description: "JQL completo quando conhecido; null para derivar de jira_intent."},
+			{Name: "jira_intent", Type: "string", Enum: []string{"listar_bugs_abertos", "busca_texto", "default"}, Description: "Intenção da busca usada quando jql é null."},
+		}},
+	}
+}
+
+// AnswerHints points the model at JQL and the configured base URL for
+// links, forbids invented issues when the search failed or came back empty,
+// and declines Jira requests when the integration is off.
+func (k jiraSkill) AnswerHints(sections []llm.ContextSection, enabled bool) []string {
+	if !enabled {
+		return []string{"JIRA NÃO CONFIGURADO: A integração com Jira não está habilitada nesta instalação. Se o usuário pedir algo relacionado a Jira (criar card, buscar issue, roadmap etc.), informe gentilmente que essa integração não está disponível e sugira que o administrador configure as variáveis JIRA_BASE_URL, JIRA_EMAIL e JIRA_API_TOKEN."}
+	}
+	jiraCtx := llm.SectionText(sections, kindJiraSearch)
+	var hints []string
+	// Answers built only from SQL results must not mention JQL (see the
+	// Metabase skill's hints).
+	if jiraCtx != "" || llm.SectionText(sections, kindMetabaseQuery) == "" {
+		hints = append(hints, "Se faltar contexto sobre issues, sugira uma JQL ou links do Jira para encontrar o que falta.")
+	}
+	if baseURL := strings.TrimRight(strings.TrimSpace(k.s.Cfg.JiraBaseURL), "/"); baseURL != "" {
+		hints = append(hints, strings.Join([]string{
+			"IMPORTANTE - Links do Jira:",
+			"- Quando precisar gerar um link completo de uma issue Jira, use SEMPRE este base URL: " + baseURL,
+			"- Formato: " + baseURL + "/browse/KEY (ex: " + baseURL + "/browse/PROJ-123)",
+			"- NUNCA use outros domínios além do base URL fornecido acima.",
+		}, "\n"))
+	}
+	if strings.Contains(jiraCtx, "[JIRA_ERROR:") || strings.Contains(jiraCtx, "[JIRA_EMPTY:") {
+		hints = append(hints, strings.Join([]string{
+			"DADOS JIRA AUSENTES: A busca no Jira falhou ou não retornou resultados.",
+			"- NÃO invente issues, títulos, assignees, chaves (PROJ-NNN) ou links.",
+			"- Use apenas o que está no CONTEXTO DO JIRA acima.",
+			"- Se não houver dados, informe o usuário claramente e sugira refinar a busca.",
+		}, "\n"))
+	}
+	return hints
+}
+
+func (k jiraSkill) RouterPrompt(req skill.Request) llm.RouterSnippet {
+	sn := llm.RouterSnippet{
+		Source: "- Jira: tickets, status, roadmap, bugs, histórias, épicos, progresso de tarefas.",
+		Examples: []string{
+			`{"kind": "jira_create"}`,
+			`{"kind": "jira_edit"}`,
+			`{"kind": "jira_search", "jql": "project = X AND sprint in openSprints()", "jira_intent": "default"}`,
+		},
+		Rules: `- Roadmap, escopo, "o que foi feito", "está no ar", bugs abertos → jira_search.
+- Resumo/retrospectiva de processo (sprint, fechamento, entrega) → jira_search E slack_search.
+- Se jira_search para pergunta substantiva (não apenas listagem de tickets), considere slack_search também.
+- Criar card no Jira → sem slack_search, sem jira_search.
+- jira_create e jira_edit NUNCA devem aparecer juntos, a menos que o edit use o card recém-criado (override). Se a intent for criar um card novo, use apenas jira_create.
+
+Regras para jira_create e jira_edit:
+- "jira_create": verbo de criação EXPLÍCITO (criar/cria/abre/abrir/gera/gerar) + tipo de issue Jira (tarefa, bug, história, épico, spike), pedido AGORA
+- "jira_create" NÃO se aplica quando o usuário pede criação de conteúdo textual (checklists, documentos, planos, textos, relatórios, listas) para ser exibido na conversa — nesses casos nenhuma ação é necessária.
+- "jira_create" NÃO se aplica quando o usuário diz explicitamente que quer o resultado na thread/chat ("em texto aqui", "quero aqui na thread", "responde aqui", "me manda aqui", "só me diz", "me mostra aqui").
+- "jira_edit": mudar status, atribuir, alterar campos, mover para sprint, "adicione para", "atribuir", "assign"
+- Hipóteses ("estou pensando em criar") → sem jira_create
+- Negações ("não quero criar") → sem jira_create
+- Criação + atribuição na mesma mensagem → jira_create ANTES de jira_edit
+- Criar um card SEM pedido explícito de dados externos → sem jira_search, sem slack_search
+- jira_create/jira_edit PODEM coexistir com metabase_query, slack_search ou jira_search quando o usuário pede EXPLICITAMENTE dados externos para incluir no card ou na resposta (ex: "escreva nesse card o total de X", "adicione as threads onde X foi mencionada", "busque o total de registros")
+
+Valores de jira_intent (campo de jira_search):
+- "listar_bugs_abertos": perguntas sobre bugs em aberto, falhas, erros.
+- "busca_texto": pesquisa de contexto sobre um tema específico no Jira.
+- "default": listagem geral ou roadmap.
+
+Regras para jql (campo de jira_search):
+- Se souber exatamente o JQL, preencha. Caso contrário, deixe "" e use jira_intent.
+- Use apenas campos padrão do Jira Cloud: project, issuetype, status, statusCategory, text, assignee, priority, labels, sprint, fixVersion, updated, created.
+- Para busca por texto: text ~ "termo"
+- Para bugs abertos: issuetype = Bug AND statusCategory != Done
+- Para busca por sprint: sprint = "Sprint N" ou sprint in openSprints() para sprints ativas.
+- SEMPRE agrupe condições OR com parênteses quando combinadas com AND: project = X AND (text ~ "a" OR text ~ "b") — NUNCA escreva: project = X AND text ~ "a" OR text ~ "b"
+- Para perguntas sobre cards "abertos", "criados" ou "registrados" em um período: use o campo created no JQL, NUNCA updated. Exemplos: "abertos em fevereiro/2026" → created >= "2026-02-01" AND created <= "2026-02-29"; "criados em março" → created >= "2026-03-01" AND created <= "2026-03-31".
+- Se o usuário fornecer diretamente uma string JQL na mensagem (ex: "use esse JQL: ...", "rode o JQL:", "execute esse JQL:"), extraia-a exatamente como escrita e use como valor do campo jql. Isso deve sempre gerar uma ação jira_search.`,
+	}
+	if k.s.Jira != nil {
+		if catalog := jiraCatalogFor(k.s.Jira.CatalogCompact, k.s.Cfg, req.TeamID); strings.TrimSpace(catalog) != "" {
+			sn.Context = fmt.Sprintf("Projetos Jira disponíveis (formato CHAVE=Nome [tipos de issue]):\n%s", catalog)
+		}
+	}
+	if req.Sender.JiraAccountID != "" {
+		sn.Context += fmt.Sprintf("\nConta Jira de quem está perguntando: accountId %q. Para \"meus cards\", \"minhas tarefas\", \"atribuídos a mim\" use assignee = %q no jql — NUNCA currentUser(), que é a conta do bot.", req.Sender.JiraAccountID, req.Sender.JiraAccountID)
+	}
+	return sn
+}
+
+// Execute runs one JQL search.  An empty JQL is derived from the intent, and
+// status names are corrected against the real workflows when the query fails
+// or comes back empty.
+func (k jiraSkill) Execute(ctx context.Context, req skill.Request, action llm.ActionDescriptor) (skill.ContextBlock, skill.Sources, error) {
+	block := skill.ContextBlock{Kind: kindJiraSearch}
+	if action.Kind != kindJiraSearch || k.s.Jira == nil {
+		return block, "", skill.ErrSkipped
+	}
+	jql := strings.TrimSpace(action.JQL)
+	if jql == "" {
+		jql = defaultJQLForIntent(action.JiraIntent, req.Question, k.s.Cfg.JiraProjectsFor(req.TeamID))
+	}
+	jql = sanitizeJQL(jql)
+	log.Printf("%s jiraJQL=%q", req.Tag(), jql)
+	// An installed workspace only sees its own projects: every query,
+	// corrected ones included, is scoped before it runs.
+	fetch := func(q string) ([]jira.SearchJQLRespIssue, error) {
+		if req.TeamID != "" {
+			scoped, err := scopeJQL(q, k.s.Cfg.JiraProjectsFor(req.TeamID))
+			if err != nil {
+				return nil, fmt.Errorf("jql outside the workspace's projects: %w", err)
+			}
+			log.Printf("%s jiraJQL scoped team=%q jql=%q", req.Tag(), req.TeamID, scoped)
+			q = scoped
+		}
+		return k.s.Jira.FetchAll(ctx, q, 200)
+	}
+	req.Report("consultando Jira…")
+	issues, err := fetch(jql)
+	if err != nil {
+		log.Printf("%s jira search failed: %v", req.Warn(), err)
+		// Attempt JQL correction using real workflow statuses from catalog.
+		if corrected := correctJQLStatus(jql, k.s.Jira.WorkflowStatuses); corrected != jql {
+			log.Printf("%s jiraJQL corrected=%q", req.Tag(), corrected)
+			issues, err = fetch(corrected)
+		}
+	}
+	if err != nil {
+		block.Text = "[JIRA_ERROR: A busca falhou. NÃO invente issues, títulos, assignees ou chaves. " +
+			"Informe o usuário que houve um erro ao consultar o Jira e peça para refinar a busca.]"
+		return block, "", err
+	}
+	// When the initial query returns 0 results, attempt status name correction
+	// before giving up — Jira status names must match exactly and the LLM may
+	// have generated a slightly different casing than what the workflow uses.
+	if len(issues) == 0 && ctx.Err() == nil {
+		if corrected := correctJQLStatus(jql, k.s.Jira.WorkflowStatuses); corrected != jql {
+			log.Printf("%s jiraJQL corrected for empty result=%q", req.Tag(), corrected)
+			if corrIssues, corrErr := fetch(corrected); corrErr == nil && len(corrIssues) > 0 {
+				issues = corrIssues
+				log.Printf("%s jiraJQL corrected returned issues=%d", req.Tag(), len(issues))
+			}
+		}
+	}
+	if len(issues) == 0 {
+		block.Text = fmt.Sprintf("[JIRA_EMPTY: JQL '%s' retornou 0 issues. "+
+			"Informe o usuário que não foram encontradas issues com esses critérios. "+
+			"NÃO invente issues.]", jql)
+		return block, "", nil
+	}
+	block.Count = len(issues)
+	block.Text, block.Citations = buildJiraContext(issues, 40, k.s.jiraIssueURL)
+	log.Printf("%s jiraContext issues=%d chars=%d", req.Tag(), len(issues), len(block.Text))
+	return block, "", nil
+}
+
+func (jiraSkill) Telemetry(ev *telemetry.Event, block skill.ContextBlock, err error) {
+	ev.JiraSearched = true
+	ev.JiraIssues += block.Count
+	if err != nil {
+		ev.JiraError = true
+	}
+}
+
+// jiraCatalogFor restricts the project catalog ("KEY=Name [types] | ...", or
+// the raw "KEY, KEY" list before GenerateCatalog finishes) to the projects of
+// an installed workspace (see Config.JiraProjectsFor).
+func jiraCatalogFor(catalog string, cfg config.Config, teamID string) string {
+	if teamID == "" {
+		return catalog
+	}
+	allowed := make(map[string]bool)
+	for _, k := range cfg.JiraProjectsFor(teamID) {
+		allowed[strings.ToUpper(k)] = true
+	}
+	sep := " | "
+	if !strings.Contains(catalog, sep) {
+		sep = ", "
+	}
+	var kept []string
+	for _, entry := range strings.Split(catalog, sep) {
+		key, _, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		if allowed[strings.ToUpper(strings.TrimSpace(key))] {
+			kept = append(kept, strings.TrimSpace(entry))
+		}
+	}
+	return strings.Join(kept, sep)
+}
@@ -0,0 +1,312 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/metabase"
+	"github.com/DanielFillol/Jarvis/internal/skill"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// Action kinds of the Metabase skill.
+const (
+	kindMetabaseQuery = "metabase_query"
+	kindShowSQL       = "show_sql"
+)
+
+// metabaseSkill answers data questions with generated SQL (metabase_query)
+// and reconstructs the SQL of the thread's last query (show_sql).
+type metabaseSkill struct{ s *Service }
+
+func (metabaseSkill) Kind() string        { return kindMetabaseQuery }
+func (metabaseSkill) Label() string       { return "DADOS DO BANCO DE DADOS (resultado de query SQL)" }
+func (metabaseSkill) DisplayName() string { return "Consultas ao banco de dados (Metabase)" }
+
+// Enabled requires at least one database: without one there is nothing to
+// route metabase_query to.
+func (k metabaseSkill) Enabled(cfg config.Config) bool {
+	return cfg.MetabaseEnabled() && len(k.s.formattedMetabaseDatabases("")) > 0
+}
+
+// Deadline lets SQL run for as long as the Metabase query client waits.
+func (metabaseSkill) Weight() float64 { return 3 }
+
+// AnswerHints keeps answers built from SQL results on the data (no JQL, no
+// Jira suggestions), forbids invented rows when no query result is
+// available, and declines data requests when Metabase is off.
+func (metabaseSkill) AnswerHints(sections []llm.ContextSection, enabled bool) []string {
+	if !enabled {
+		return []string{"METABASE NÃO CONFIGURADO: A integração com Metabase (banco de dados) não está habilitada nesta instalação. Se o usuário pedir consultas de dados, métricas ou relatórios que requeiram SQL, informe gentilmente que essa integração não está disponível e sugira que o administrador configure as variáveis METABASE_BASE_URL e METABASE_API_KEY."}
+	}
+	dbCtx := llm.SectionText(sections, kindMetabaseQuery)
+	if strings.TrimSpace(dbCtx) == "" {
+		return []string{strings.Join([]string{
+			"DADOS DE BANCO AUSENTES: Nenhum resultado de consulta SQL está disponível para esta resposta.",
+			"- Se a pergunta requer dados do banco de dados, informe o usuário que não foi possível buscar os dados.",
+			"- NÃO invente, estime ou fabrique registros, nomes, status, valores ou qualquer dado operacional.",
+			"- Responda apenas com o que está no contexto da thread ou nos outros contextos fornecidos.",
+		}, "\n")}
+	}
+	if llm.SectionText(sections, kindJiraSearch) != "" {
+		return nil
+	}
+	return []string{strings.Join([]string{
+		"CONTEXTO: Esta pergunta é respondida com dados de banco de dados SQL.",
+		"- NÃO mencione JQL em nenhuma hipótese — JQL é exclusivo para perguntas sobre o Jira.",
+		"- NÃO oriente o usuário a usar o Jira ou a fazer buscas no Jira.",
+		"- Se os dados forem insuficientes, pergunte ao usuário como refinar a consulta.",
+	}, "\n")}
+}
+
+func (metabaseSkill) Deadline(cfg config.Config, kind string) time.Duration {
+	if kind == kindMetabaseQuery || kind == kindShowSQL {
+		return cfg.MetabaseQueryTimeout
+	}
+	return 0
+}
+
+func (metabaseSkill) Tools() []llm.ToolDef {
+	return []llm.ToolDef{
+		{Kind: kindMetabaseQuery, Description: "Consultar o banco de dados operacional via Metabase (métricas, coletas, faturamento, transações).", Params: []llm.ToolParam{
+			{Name: "database_id", Type: "integer", Check: llm.NonNegative, Description: "ID do banco Metabase."},
+			{Name: "wants_all_rows", Type: "boolean", Description: "true quando o usuário quer todos os registros."},
+			{Name: "wants_csv_export", Type: "boolean", Description: "true quando o usuário pede exportação (csv, planilha, download)."},
+		}},
+		{Kind: kindShowSQL, Description: "Mostrar o SQL usado na consulta anterior desta thread.", Params: []llm.ToolParam{
+			{Name: "database_id", Type: "integer", Check: llm.NonNegative, Description: "ID do banco da consulta anterior, ou 0."},
+		}},
+	}
+}
+
+func (k metabaseSkill) RouterPrompt(req skill.Request) llm.RouterSnippet {
+	sn := llm.RouterSnippet{
+		Context: fmt.Sprintf("Bancos de dados Metabase disponíveis:\n- %s", strings.Join(k.s.formattedMetabaseDatabases(req.TeamID), "\n- ")),
+		Source:  "- Metabase (banco de dados): dados estruturados do banco operacional — coletas, faturamento, billing, preços, transações, pedidos, contratos, rotas, materiais, geradores, transportadores, métricas e histórico de qualquer entidade. Se a pergunta envolve métricas, preços, quantidades, status operacional, datas de coleta/entrega/fatura, use metabase_query. NÃO use slack_search para dados que vivem no banco. NÃO use hubspot_search para dados operacionais (fatura, preço, billing_cycle, coleta, transação).",
+		Examples: []string{
+			`{"kind": "metabase_query", "database_id": 1, "wants_all_rows": false, "wants_csv_export": false}`,
+			`{"kind": "show_sql", "database_id": 1}`,
+		},
+		Rules: `- Se o histórico contém SQL ou resultados de banco E o usuário faz follow-up → metabase_query. Use database_id da linha "Query executada (db=N):" do histórico.
+- show_sql SOMENTE quando usuário pede EXPLICITAMENTE o SQL/query/código usado ("SQL", "query", "consulta que você rodou", "me mostra o código"). Pedidos de dados → metabase_query, não show_sql. Inclua database_id do "Query executada (db=N):" do histórico (ou 0).
+- Follow-ups com pronomes ("dessas", "desses") referindo entidades já consultadas → metabase_query com mesmo database_id do turno anterior.
+- wants_all_rows=true: usuário quer TODOS OS REGISTROS de uma entidade de dados — mesmo que filtrado por data ou outro critério. Sinais: "todas as coletas", "todos os pedidos", "todos os registros", "sem limite", "lista completa", "traz tudo", "quero todos", "detalhamento de todas", "me traz todas". NÃO use true quando "todos" se refere a tópicos/aspectos de análise (ex: "analise todos os aspectos", "todas as categorias") — nesses casos o usuário quer uma análise agregada, não um dump de registros individuais.
+- wants_csv_export=true: exportação explícita ("exportar", "csv", "planilha", "download", "baixar", "excel") OU pedido de todos os dados. Quando true, também wants_all_rows=true.`,
+	}
+	if storedDBID, ok := k.threadDBID(req); ok {
+		sn.Context += fmt.Sprintf(
+			"\n\nEste thread já executou uma consulta no banco de dados Metabase ID=%d. "+
+				"Se a pergunta atual for um follow-up, refinamento ou pedido de execução da consulta anterior, "+
+				"inclua {\"kind\":\"metabase_query\",\"database_id\":%d}.",
+			storedDBID, storedDBID,
+		)
+	}
+	return sn
+}
+
+// threadKey returns the threadLastSQL/threadLastDBID key for req, or "" when
+// the request has no thread to remember state for.
+func (metabaseSkill) threadKey(req skill.Request) string {
+	if req.Test || (req.Channel == "" && req.ThreadTs == "") {
+		return ""
+	}
+	return req.Channel + ":" + req.ThreadTs
+}
+
+func (k metabaseSkill) threadDBID(req skill.Request) (int, bool) {
+	if k.threadKey(req) == "" {
+		return 0, false
+	}
+	return k.s.loadThreadDBID(req.Channel, req.ThreadTs)
+}
+
+func (k metabaseSkill) threadSQL(req skill.Request) string {
+	key := k.threadKey(req)
+	if key == "" {
+		return ""
+	}
+	if v, ok := k.s.threadLastSQL.Load(key); ok {
+		if sql, ok2 := v.(string); ok2 {
+			return sql
+		}
+	}
+	return ""
+}
+
+func (k metabaseSkill) Execute(ctx context.Context, req skill.Request, action llm.ActionDescriptor) (skill.ContextBlock, skill.Sources, error) {
+	if k.s.Metabase == nil {
+		return skill.ContextBlock{Kind: action.Kind}, "", skill.ErrSkipped
+	}
+	// Both kinds run SQL: the database named by the action, and the one the
+	// thread's stored query ran on, must be open to the workspace.
+	// show_sql may leave database_id at 0: it then uses the stored one.
+	storedDBID, hasStored := k.threadDBID(req)
+	if action.Kind == kindShowSQL && action.MetabaseDatabaseID == 0 && hasStored {
+		action.MetabaseDatabaseID = storedDBID
+	}
+	dbIDs := []int{action.MetabaseDatabaseID}
+	if hasStored {
+		dbIDs = append(dbIDs, storedDBID)
+	}
+	for _, dbID := range dbIDs {
+		if !k.s.Cfg.MetabaseDBAllowed(req.TeamID, dbID) {
+			log.Printf("%s metabase db=%d not allowed for team=%q", req.Tag(), dbID, req.TeamID)
+			block := skill.ContextBlock{Kind: action.Kind}
+			block.Reply = fmt.Sprintf("Este workspace não tem acesso ao banco %d. Bancos disponíveis:\n- %s",
+				dbID, strings.Join(k.s.formattedMetabaseDatabases(req.TeamID), "\n- "))
+			return block, "", nil
+		}
+	}
+	if action.Kind == kindShowSQL {
+		return k.showSQL(ctx, req, action)
+	}
+	block := skill.ContextBlock{Kind: kindMetabaseQuery}
+	req.Report("rodando SQL no banco %d…", action.MetabaseDatabaseID)
+	mRes := k.s.runMetabaseQuery(ctx, req.QuestionForLLM, req.ThreadHistory, action.MetabaseDatabaseID, k.threadSQL(req), action.WantsAllRows)
+
+	// Cross-database fallback: when primary DB returned no data, failed entirely,
+	// OR returned a clarification about a missing table/schema — try remaining
+	// databases before asking the user.  A clarification about which DB to use
+	// is a signal that the schema doesn't match; another DB may answer correctly.
+	primaryNeedsRetry := mRes.DBCtx == "" ||
+		strings.HasPrefix(mRes.DBCtx, llm.ClarificationPrefix) ||
+		(mRes.QueryResult != nil && len(mRes.QueryResult.Data.Rows) == 0)
+	if primaryNeedsRetry {
+		for _, db := range k.s.Metabase.Databases {
+			if db.ID == action.MetabaseDatabaseID || !k.s.Cfg.MetabaseDBAllowed(req.TeamID, db.ID) {
+				continue
+			}
+			if ctx.Err() != nil {
+				log.Printf("[METABASE] deadline reached, skipping remaining fallback databases")
+				break
+			}
+			log.Printf("[METABASE] primary db=%d needs retry (clarification/empty), trying fallback db=%d (%s)",
+				action.MetabaseDatabaseID, db.ID, db.Name)
+			req.Report("rodando SQL no banco %d…", db.ID)
+			fbRes := k.s.runMetabaseQuery(ctx, req.QuestionForLLM, req.ThreadHistory, db.ID, "", action.WantsAllRows)
+			if fbRes.QueryResult != nil && len(fbRes.QueryResult.Data.Rows) > 0 {
+				mRes = fbRes
+				action.MetabaseDatabaseID = db.ID
+				log.Printf("[METABASE] fallback db=%d succeeded rows=%d", db.ID, len(fbRes.QueryResult.Data.Rows))
+				break
+			}
+		}
+	}
+
+	thisDBCtx, thisQR, thisSQL := mRes.DBCtx, mRes.QueryResult, mRes.ExecutedSQL
+	if thisQR != nil {
+		block.Count = len(thisQR.Data.Rows)
+	}
+	if strings.HasPrefix(thisDBCtx, llm.ClarificationPrefix) {
+		block.Reply = strings.TrimPrefix(thisDBCtx, llm.ClarificationPrefix)
+		log.Printf("%s clarification requested", req.Tag())
+		return block, "", nil
+	}
+	if key := k.threadKey(req); thisSQL != "" && key != "" {
+		k.s.threadLastSQL.Store(key, thisSQL)
+		k.s.storeThreadDBID(req.Channel, req.ThreadTs, action.MetabaseDatabaseID)
+	}
+
+	// CSV export or large result handling.
+	const largeResultThreshold = 30
+	// Auto-trigger CSV when the result is large enough that an inline table
+	// would be unreadably long (> 100 rows), even if the user didn't explicitly
+	// request a CSV export.
+	const csvAutoThreshold = 100
+	wantsCSV := action.WantsCSVExport || (thisQR != nil && len(thisQR.Data.Rows) > csvAutoThreshold && action.WantsAllRows)
+	if thisQR != nil && wantsCSV && k.s.FileServer != nil && strings.TrimSpace(k.s.Cfg.PublicBaseURL) != "" {
+		nRows := len(thisQR.Data.Rows)
+		csvBytes := []byte(metabase.FormatQueryResultAsCSV(*thisQR))
+		if len(csvBytes) > 0 {
+			fileID := k.s.FileServer.Store("resultado.csv", csvBytes, time.Hour)
+			csvURL := k.s.Cfg.PublicBaseURL + "/files/" + fileID
+			// The download line is appended to the final answer verbatim —
+			// never rely on the LLM to include it.
+			if req.Direct {
+				block.Footer = fmt.Sprintf("Download CSV: %s (expira em 1 hora)", csvURL)
+			} else {
+				block.Footer = fmt.Sprintf(":page_facing_up: *Download CSV:* <%s|resultado.csv> _(expira em 1 hora)_", csvURL)
+			}
+			block.Text = fmt.Sprintf(
+				"Query SQL retornou %d registros com os campos: %s.\n\n"+
+					"INSTRUÇÃO INTERNA: Escreva APENAS 1 frase curta de introdução descrevendo o resultado "+
+					"(ex: \"Encontrei %d registros...\"). Não exiba a tabela de dados.\n\n"+
+					"Amostra (3 de %d registros):\n%s",
+				nRows, strings.Join(queryResultColumns(thisQR), ", "), nRows, nRows,
+				metabase.FormatQueryResult(*thisQR, 3),
+			)
+			log.Printf("%s CSV generated rows=%d id=%s", req.Tag(), nRows, fileID)
+		}
+		return block, "", nil
+	}
+	if thisQR != nil && len(thisQR.Data.Rows) > largeResultThreshold && action.WantsAllRows {
+		nRows := len(thisQR.Data.Rows)
+		block.Text = fmt.Sprintf(
+			"Query SQL retornou %d registros com os campos: %s.\n\n"+
+				"INSTRUÇÃO INTERNA: Escreva APENAS 1 frase curta de introdução descrevendo o resultado "+
+				"(ex: \"Encontrei %d registros...\"). Finalize com o marcador exato [TABLE] e nada mais.\n\n"+
+				"Amostra (3 de %d registros):\n%s",
+			nRows, strings.Join(queryResultColumns(thisQR), ", "), nRows, nRows,
+			metabase.FormatQueryResult(*thisQR, 3),
+		)
+		// Cap inline table to 100 rows; larger results should have gone through CSV.
+		block.Table = metabase.FormatQueryResult(*thisQR, min(nRows, 100))
+		log.Printf("%s large result bypass: rows=%d", req.Tag(), nRows)
+		return block, "", nil
+	}
+
+	if thisDBCtx == "" {
+		thisDBCtx = "[ERRO: A consulta ao banco de dados falhou ou não retornou dados. NÃO invente métricas, nomes ou valores. Informe ao usuário que não foi possível obter os dados neste momento e sugira tentar novamente.]"
+	}
+	block.Text = thisDBCtx
+	return block, "", nil
+}
+
+// showSQL rebuilds and validates the thread's last query and replies with it.
+// It needs a Slack thread, so it is skipped on the direct path.
+func (k metabaseSkill) showSQL(ctx context.Context, req skill.Request, action llm.ActionDescriptor) (skill.ContextBlock, skill.Sources, error) {
+	block := skill.ContextBlock{Kind: kindShowSQL}
+	if req.Direct {
+		return block, "", skill.ErrSkipped
+	}
+	executedSQL := k.s.runMetabaseQuery(ctx, req.QuestionForLLM, req.ThreadHistory, action.MetabaseDatabaseID, k.threadSQL(req), false).ExecutedSQL
+	if executedSQL != "" {
+		block.Reply = fmt.Sprintf(
+			"Reconstruí e validei a query com base no contexto desta conversa:\n```sql\n%s\n```\n\n> _Nota: esta é uma reconstrução — pode diferir levemente da query original, mas foi executada com sucesso no banco de dados._",
+			executedSQL,
+		)
+	} else {
+		block.Reply = "Não consegui reconstruir uma query válida para esta conversa após várias tentativas. Tente reformular a pergunta original para que eu possa buscar os dados novamente."
+	}
+	log.Printf("%s show SQL handled executedSQL_len=%d", req.Tag(), len(executedSQL))
+	return block, "", nil
+}
+
+func (metabaseSkill) Telemetry(ev *telemetry.Event, block skill.ContextBlock, _ error) {
+	if block.Kind != kindMetabaseQuery {
+		return
+	}
+	ev.MetabaseQueried = true
+	ev.MetabaseRows += block.Count
+	if block.Footer != "" {
+		ev.CSVGenerated = true
+	}
+}
+
+// queryResultColumns returns the display names of the result's columns.
+func queryResultColumns(qr *metabase.QueryResult) []string {
+	cols := make([]string, len(qr.Data.Cols))
+	for i, c := range qr.Data.Cols {
+		if c.DisplayName != "" {
+			cols[i] = c.DisplayName
+		} else {
+			cols[i] = c.Name
+		}
+	}
+	return cols
+}
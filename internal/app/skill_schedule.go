@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/schedule"
+	"github.com/DanielFillol/Jarvis/internal/skill"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// kindScheduleCreate is the action kind of the schedule skill.
+const kindScheduleCreate = "schedule_create"
+
+// scheduleSkill creates recurring questions (schedule_create): "todo dia útil
+// às 9h me mande os bugs abertos do BACKEND no #eng".  The confirmation is
+// the answer itself, so it is returned as the block's Reply.
+type scheduleSkill struct{ s *Service }
+
+func (scheduleSkill) Kind() string                   { return kindScheduleCreate }
+func (scheduleSkill) Label() string                  { return "AGENDAMENTO" }
+func (scheduleSkill) DisplayName() string            { return "Perguntas agendadas" }
+func (scheduleSkill) Enabled(cfg config.Config) bool { return cfg.ScheduleMaxPerUser > 0 }
+func (scheduleSkill) Local() bool                    { return true }
+
+func (scheduleSkill) Tools() []llm.ToolDef {
+	return []llm.ToolDef{{Kind: kindScheduleCreate, Description: "Agendar uma pergunta recorrente: o bot responde a pergunta automaticamente nos horários pedidos.", Params: []llm.ToolParam{
+		{Name: "cron", Type: "string", Check: llm.NonEmpty, Description: "Expressão cron de 5 campos (minuto hora dia-do-mês mês dia-da-semana), ex: \"0 9 * * 1-5\"."},
+		{Name: "question", Type: "string", Check: llm.NonEmpty, Description: "A pergunta a responder em cada execução, autossuficiente e sem a parte do agendamento."},
+		{Name: "channel", Type: "string", Optional: true, Description: "Onde postar: #nome-do-canal, \"dm\" para mensagem direta, ou null para a conversa atual."},
+		{Name: "timezone", Type: "string", Optional: true, Description: "Fuso horário IANA (ex: America/Sao_Paulo) quando o usuário citar um, ou null."},
+	}}}
+}
+
+func (scheduleSkill) RouterPrompt(skill.Request) llm.RouterSnippet {
+	return llm.RouterSnippet{
+		Source:   "- Agendamentos: perguntas recorrentes respondidas automaticamente (relatórios diários, semanais...).",
+		Examples: []string{`{"kind": "schedule_create", "cron": "0 9 * * 1-5", "question": "quais são os bugs abertos do projeto BACKEND?", "channel": "#eng", "timezone": null}`},
+		Rules: `- "todo dia/toda segunda/todo dia útil/toda semana às Xh me mande/poste ..." → schedule_create (pedido de recorrência, NÃO responda a pergunta agora).
+- schedule_create é a resposta inteira: não combine com outras ações.
+- cron tem 5 campos: minuto hora dia-do-mês mês dia-da-semana (0=domingo).
+  - "todo dia útil às 9h" → "0 9 * * 1-5"
+  - "toda segunda às 8h30" → "30 8 * * 1"
+  - "todo dia às 18h" → "0 18 * * *"
+  - "todo dia 1º do mês às 10h" → "0 10 1 * *"
+  - "de hora em hora no horário comercial" → "0 9-18 * * 1-5"
+- question: reescreva o pedido como pergunta autossuficiente, sem horário nem destino (ex: "quais são os bugs abertos do projeto BACKEND?").
+- channel: "no #canal" → "#canal"; "me mande", "no meu privado", "por DM" → "dm"; sem destino → null.`,
+	}
+}
+
+// Execute validates the schedule and stores it.  Schedules are not created
+// from inside a scheduled run or the smoke tests.
+func (k scheduleSkill) Execute(ctx context.Context, req skill.Request, action llm.ActionDescriptor) (skill.ContextBlock, skill.Sources, error) {
+	block := skill.ContextBlock{Kind: kindScheduleCreate}
+	if req.Test {
+		block.Text = "[AVISO: agendamentos desabilitados em modo de teste.]"
+		return block, "", nil
+	}
+	if k.s.Schedules == nil || k.s.Slack == nil || isScheduledRun(ctx) {
+		return block, "", skill.ErrSkipped
+	}
+	if req.SenderUserID == "" {
+		block.Reply = "Não consigo agendar perguntas por aqui: peça no Slack."
+		return block, "", nil
+	}
+	cronExpr, _ := action.Args["cron"].(string)
+	question, _ := action.Args["question"].(string)
+	target, _ := action.Args["channel"].(string)
+	tz, _ := action.Args["timezone"].(string)
+	if strings.TrimSpace(tz) == "" {
+		tz = k.s.Cfg.ScheduleTimezone
+	}
+
+	spec, err := schedule.Parse(cronExpr)
+	if err != nil {
+		block.Reply = fmt.Sprintf("Não entendi o horário do agendamento (`%s`): %v", cronExpr, err)
+		return block, "", nil
+	}
+	next, err := schedule.NextRun(spec.String(), tz, time.Now())
+	if err != nil {
+		block.Reply = fmt.Sprintf("Não consegui agendar: %v", err)
+		return block, "", nil
+	}
+
+	channel := ""
+	switch t := strings.ToLower(strings.TrimSpace(target)); {
+	case t == "dm" || t == "":
+		if t == "" && !req.Direct && req.Channel != "" && channelType(req.Channel) != "dm" {
+			channel = req.Channel
+		} else {
+			channel = req.SenderUserID
+		}
+	default:
+		channel, _ = k.s.resolveChannelRef(ctx, req.Question, target)
+		if channel == "" {
+			block.Reply = fmt.Sprintf("Não encontrei o canal *%s* para postar o agendamento. Mencione o canal (#nome) e confira se eu fui adicionado a ele.", target)
+			return block, "", nil
+		}
+	}
+
+	existing, err := k.s.Schedules.ListByUser(ctx, req.SenderUserID)
+	if err != nil {
+		block.Text = fmt.Sprintf("[ERRO: não consegui ler os agendamentos: %v]", err)
+		return block, "", err
+	}
+	if len(existing) >= k.s.Cfg.ScheduleMaxPerUser {
+		block.Reply = fmt.Sprintf("Você já tem %d agendamentos, o máximo permitido. Exclua algum com `/jarvis agendamentos excluir <id>` antes de criar outro.", len(existing))
+		return block, "", nil
+	}
+
+	sc, err := k.s.Schedules.Create(ctx, schedule.Schedule{
+		TeamID:   req.TeamID,
+		UserID:   req.SenderUserID,
+		Channel:  channel,
+		Question: strings.TrimSpace(question),
+		Cron:     spec.String(),
+		Timezone: tz,
+		NextRun:  next,
+	})
+	if err != nil {
+		block.Text = fmt.Sprintf("[ERRO: não consegui salvar o agendamento: %v]", err)
+		return block, "", err
+	}
+	log.Printf("%s schedule created id=%d user=%q channel=%q cron=%q tz=%q", req.Tag(), sc.ID, sc.UserID, sc.Channel, sc.Cron, sc.Timezone)
+	block.Count = 1
+	block.Reply = fmt.Sprintf("Agendado ✅ *#%d* — _%s_\n%s\nUse `/jarvis agendamentos` para listar, pausar ou excluir.",
+		sc.ID, clip(sc.Question, 300), describeSchedule(sc))
+	if !k.s.Schedules.Persistent() {
+		block.Reply += "\n_Atenção: sem banco configurado, os agendamentos são perdidos quando o bot reinicia._"
+	}
+	return block, "", nil
+}
+
+func (scheduleSkill) Telemetry(*telemetry.Event, skill.ContextBlock, error) {}
@@ -0,0 +1,200 @@
+package app
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/skill"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// kindSlackSearch is the action kind of the Slack search skill.
+const kindSlackSearch = "slack_search"
+
+// slackSkill searches Slack messages (slack_search).  It is always enabled;
+// searches are skipped when SLACK_USER_TOKEN is not configured.
+type slackSkill struct{ s *Service }
+
+func (slackSkill) Kind() string               { return kindSlackSearch }
+func (slackSkill) Label() string              { return "CONTEXTO DO SLACK (busca)" }
+func (slackSkill) DisplayName() string        { return "Busca de mensagens no Slack" }
+func (slackSkill) Enabled(config.Config) bool { return true }
+func (slackSkill) Local() bool                { return true }
+func (slackSkill) Weight() float64            { return 2 }
+
+// AnswerHints adds nothing: citation instructions cover Slack messages.
+func (slackSkill) AnswerHints([]llm.ContextSection, bool) []string { return nil }
+
+func (slackSkill) Tools() []llm.ToolDef {
+	return []llm.ToolDef{{Kind: kindSlackSearch, Description: "Buscar mensagens no Slack (discussões, decisões, links de threads).", Params: []llm.ToolParam{
+		{Name: "query", Type: "string", Normalize: normalizeSlackQuery, Check: llm.NonEmpty, Description: "Query da API de busca do Slack, com modificadores in:, from:, after:, before: quando necessário."},
+	}}}
+}
+
+func (slackSkill) RouterPrompt(skill.Request) llm.RouterSnippet {
+	return llm.RouterSnippet{
+		Source:   "- Slack: discussões, decisões, links de threads, contexto operacional.",
+		Examples: []string{`{"kind": "slack_search", "query": "deploy produção after:2026-03-01"}`},
+		Rules: `- "onde falamos", "qual foi a decisão", "me manda o link", "thread do slack" → slack_search.
+
+Regras para query em slack_search (IMPORTANTE):
+- query NUNCA vazio quando kind="slack_search" — sempre gere uma query útil.
+- Se mencionar canais (#nome), inclua in:#nome-do-canal.
+- Use 2–4 palavras-chave, sem has:thread, has:link, has:reaction.
+- "o que X falou/disse" → from:@username. Se só souber o nome da pessoa, use só o primeiro nome (ex: "o que a Fernanda disse" → from:@Fernanda): ele é resolvido para o usuário do Slack.
+- Usuário Slack (<@USERID>) em busca → inclua o identificador EXATO: ex. "<@U09FJSKP407>".
+
+Regras para datas na query slack_search:
+- NÃO use "essa semana" como termo — a API não interpreta.
+- Converta expressões de tempo:
+  - "essa semana" → after:SEGUNDA-DESTA-SEMANA (use a data calculada acima)
+  - "ontem" → after:DATA-ONTEM before:DATA-HOJE
+  - "mês passado" → after:ANO-MÊS-01 before:ANO-MÊS-01 do mês atual
+- Exemplo: "o que foi dito essa semana" → query: "termo-relevante after:2026-02-17"`,
+	}
+}
+
+// Execute runs one Slack search.  from:USERID filters the search API cannot
+// resolve are applied client-side.
+func (k slackSkill) Execute(ctx context.Context, req skill.Request, action llm.ActionDescriptor) (skill.ContextBlock, skill.Sources, error) {
+	block := skill.ContextBlock{Kind: kindSlackSearch}
+	if req.Test {
+		// Skip Slack search in test mode — avoid polluting real channels.
+		block.Text = "[AVISO: busca Slack desabilitada em modo de teste.]"
+		return block, "", nil
+	}
+	// Explicit permalink → the thread is already the authoritative context.
+	if req.ThreadPermalink || strings.TrimSpace(action.Query) == "" ||
+		k.s.Cfg.SlackUserToken == "" || k.s.Slack == nil {
+		return block, "", skill.ErrSkipped
+	}
+	unresolvedUserIDs := extractFromUserIDs(action.Query)
+	resolvedQuery := k.s.Slack.ResolveUserIDsInQuery(ctx, action.Query)
+	log.Printf("%s slackSearch query=%q", req.Tag(), resolvedQuery)
+	req.Report("buscando mensagens no Slack…")
+	matches, err := k.s.Slack.SearchMessagesAll(ctx, resolvedQuery)
+	if err != nil {
+		return block, "", err
+	}
+	if len(unresolvedUserIDs) > 0 {
+		filtered := matches[:0]
+		for _, m := range matches {
+			for _, uid := range unresolvedUserIDs {
+				if m.UserID == uid {
+					filtered = append(filtered, m)
+					break
+				}
+			}
+		}
+		log.Printf("%s clientSideUserFilter from=%v reduced %d→%d", req.Tag(), unresolvedUserIDs, len(matches), len(filtered))
+		matches = filtered
+	}
+	if len(matches) == 0 {
+		log.Printf("%s slackSearch query=%q returned 0 results", req.Tag(), resolvedQuery)
+		return block, "", nil
+	}
+	block.Count = len(matches)
+	block.Text, block.Citations = buildSlackContext(matches, 25)
+	log.Printf("%s slackContext matches=%d chars=%d", req.Tag(), len(matches), len(block.Text))
+	return block, "", nil
+}
+
+func (slackSkill) Telemetry(ev *telemetry.Event, block skill.ContextBlock, _ error) {
+	ev.SlackSearched = true
+	ev.SlackMatches += block.Count
+}
+
+// Finish runs once after every slack_search is done.  Raw <#CHANID> mentions
+// cannot be resolved to names for the search API, so this week's history of
+// those channels is read directly; when nothing was found at all a single
+// warning replaces the empty results.
+func (k slackSkill) Finish(ctx context.Context, req skill.Request, blocks []skill.ContextBlock) []skill.ContextBlock {
+	if len(blocks) == 0 || req.Test {
+		return blocks
+	}
+	matches := 0
+	for _, b := range blocks {
+		matches += b.Count
+	}
+	if k.s.Slack != nil {
+		if chanIDs := extractChannelIDsFromText(req.Question); len(chanIDs) > 0 {
+			now := time.Now()
+			weekday := int(now.Weekday())
+			if weekday == 0 {
+				weekday = 7
+			}
+			weekStart := now.AddDate(0, 0, -(weekday - 1)).Truncate(24 * time.Hour)
+			var directMsgs []slack.SearchMessage
+			for _, cid := range chanIDs {
+				msgs, chErr := k.s.Slack.GetChannelHistoryForPeriod(ctx, cid, weekStart, now, 80)
+				if chErr != nil {
+					log.Printf("%s channelHistory %s failed: %v", req.Tag(), cid, chErr)
+					continue
+				}
+				log.Printf("%s channelHistory %s messages=%d", req.Tag(), cid, len(msgs))
+				directMsgs = append(directMsgs, msgs...)
+			}
+			if len(directMsgs) > 0 {
+				histCtx, cites := buildSlackContext(directMsgs, 40)
+				blocks = append(blocks, skill.ContextBlock{Kind: kindSlackSearch, Text: histCtx, Count: len(directMsgs), Citations: cites})
+				matches += len(directMsgs)
+				log.Printf("%s channelHistory total=%d chars=%d", req.Tag(), len(directMsgs), len(histCtx))
+			}
+		}
+	}
+	if matches == 0 {
+		blocks = append(blocks, skill.ContextBlock{
+			Kind: kindSlackSearch,
+			Text: "[AVISO: A busca no Slack não retornou mensagens. NÃO invente conteúdo de canais ou mensagens. Informe ao usuário que não foram encontrados dados para a busca realizada e sugira alternativas.]",
+		})
+	}
+	return blocks
+}
+
+// normalizeSlackQuery fixes common formatting mistakes for Slack search.
+// It converts LLM-generated "menção USERID" patterns to <@USERID> and
+// normalizes from:/to: user ID filters.
+func normalizeSlackQuery(q string) string {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return q
+	}
+
+	// Convert "menção/mencao/mencionado USERID" (LLM hallucination) to <@USERID>
+	reMentionWord := regexp.MustCompile(`(?i)\b(?:menção|mencao|mencionado|mencionada|mentioned)\s+(([UW])[A-Z0-9]+)\b`)
+	q = reMentionWord.ReplaceAllString(q, "<@$1>")
+
+	// Strip leftover mention words when a <@USERID> is already present.
+	// e.g. "<@U09FJSKP407> menção" → "<@U09FJSKP407>"
+	if strings.Contains(q, "<@") {
+		reMentionLeftover := regexp.MustCompile(`(?i)\s*\b(?:menção|mencao|mencionado|mencionada|mentioned)\b\s*`)
+		q = strings.TrimSpace(reMentionLeftover.ReplaceAllString(q, " "))
+	}
+
+	q = strings.ReplaceAll(q, "to:@", "to:")
+
+	reFrom := regexp.MustCompile(`\bfrom:\s*(?:<@)?@?(U[A-Z0-9]+)(?:\|[^>]+)?>?`)
+	q = reFrom.ReplaceAllString(q, "from:$1")
+
+	reTo := regexp.MustCompile(`\bto:\s*(?:<@)?@?(U[A-Z0-9]+)(?:\|[^>]+)?>?`)
+	q = reTo.ReplaceAllString(q, "to:$1")
+
+	// Strip unresolved channel ID filters: in:#C09H8S8A0VD
+	// Raw Slack channel IDs are not supported in the in: search filter;
+	// keeping them returns 0 results. Better to search without a channel filter.
+	reRawChannelFilter := regexp.MustCompile(`\bin:#[CG][A-Z0-9]{8,}\b`)
+	q = strings.TrimSpace(reRawChannelFilter.ReplaceAllString(q, ""))
+	// Also, strip leftover <#CHANID> tokens the LLM might have included verbatim.
+	reRawChannelMention := regexp.MustCompile(`<#[CG][A-Z0-9]{8,}(?:\|[^>]*)?>`)
+	q = strings.TrimSpace(reRawChannelMention.ReplaceAllString(q, ""))
+	// Collapse multiple spaces left by removals.
+	q = strings.Join(strings.Fields(q), " ")
+
+	return strings.TrimSpace(q)
+}
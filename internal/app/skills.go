@@ -0,0 +1,331 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/googledrive"
+	"github.com/DanielFillol/Jarvis/internal/hubspot"
+	"github.com/DanielFillol/Jarvis/internal/identity"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/outline"
+	"github.com/DanielFillol/Jarvis/internal/skill"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// registerBuiltinSkills registers the bundled integrations.  Registration
+// order is the order of their sections in the routing and answer prompts.
+func (s *Service) registerBuiltinSkills() {
+	s.Skills = skill.NewRegistry()
+	for _, sk := range []skill.Skill{
+		slackSkill{s},
+		digestSkill{s},
+		jiraSkill{s},
+		metabaseSkill{s},
+		outline.NewSkill(s.Outline, s.LLM, s.Cfg.OpenAILesserModel),
+		googledrive.NewSkill(s.GoogleDrive),
+		hubspot.NewSkill(s.HubSpot, s.LLM, s.Cfg.OpenAILesserModel),
+		scheduleSkill{s},
+	} {
+		if err := s.Skills.Register(sk); err != nil {
+			panic(err) // builtin kinds are unique; a collision is a programming error
+		}
+	}
+}
+
+// skillGranted reports whether the workspace ctx is scoped to may use the
+// skill of kind.  Entry points that reach an integration without the router
+// (commands, reactions, shortcuts) check it themselves.
+func (s *Service) skillGranted(ctx context.Context, kind string) bool {
+	return s.Cfg.SkillGranted(s.Slack.InstalledTeam(ctx), kind)
+}
+
+// resolveIdentity returns the Jira account and HubSpot owner of a Slack user.
+// The mapping matches users by email, which only identifies someone in the
+// env tokens' workspace: users of installed workspaces get an empty identity.
+func (s *Service) resolveIdentity(ctx context.Context, slackUserID string) identity.Identity {
+	if s.Slack != nil && !s.Slack.DefaultTeam(ctx) {
+		return identity.Identity{}
+	}
+	return s.Identity.Resolve(ctx, slackUserID)
+}
+
+// skillRun is the outcome of dispatching a message's context actions.
+type skillRun struct {
+	// Sections is the merged context of every skill, in registry order.
+	Sections []llm.ContextSection
+	// Reply is set when a skill answered the user directly (clarification,
+	// show_sql); the run stopped there and Reply is the final answer.
+	Reply string
+
+	direct    bool // render citations for /api/chat (plain text)
+	sources   []string
+	footers   []string
+	table     string
+	tried     map[string]bool
+	counts    map[string]int
+	citations []skill.Citation
+}
+
+// runSkills executes the context actions through the skill registry.
+//
+// Actions run in rounds: after each round the router sees a summary of what
+// every source returned and may request more tools (an alternative source
+// when one came back empty, a refined query, ...) until the plan's step
+// limit.  seed holds results of actions already executed elsewhere (the Jira
+// handler flows) so they are reported in the first round.  ev may be nil.
+func (s *Service) runSkills(ctx context.Context, req skill.Request, plan *llm.ActionPlan, actions []llm.ActionDescriptor, seed []llm.ActionResult, ev *telemetry.Event) skillRun {
+	run := skillRun{tried: map[string]bool{}, counts: map[string]int{}, direct: req.Direct}
+	cited := map[string]bool{}
+	blocks := map[string][]skill.ContextBlock{}
+	sources := map[string][]string{}
+	record := func(sk skill.Skill, block skill.ContextBlock, src skill.Sources) {
+		run.tried[sk.Kind()] = true
+		blocks[sk.Kind()] = append(blocks[sk.Kind()], block)
+		if strings.TrimSpace(string(src)) != "" {
+			sources[sk.Kind()] = append(sources[sk.Kind()], string(src))
+		}
+	}
+
+	enabled := s.Skills.Enabled(s.Cfg, req.TeamID)
+	for _, sk := range enabled {
+		if p, ok := sk.(skill.Prefetcher); ok {
+			if block, src, found := p.Prefetch(ctx, req); found {
+				record(sk, block, src)
+			}
+		}
+	}
+
+	roundResults := seed
+	queue := actions
+	for round := 1; len(queue) > 0; round++ {
+		outcomes := s.executeRound(ctx, req, queue)
+		for i, o := range outcomes {
+			summary := "nenhum resultado (fonte vazia ou indisponível)."
+			if o.sk != nil && !errors.Is(o.err, skill.ErrSkipped) {
+				if o.err != nil {
+					log.Printf("%s skill=%s failed: %v", req.Warn(), o.sk.Kind(), o.err)
+				}
+				if ev != nil {
+					o.sk.Telemetry(ev, o.block, o.err)
+				}
+				if o.block.Reply != "" {
+					run.Reply = o.block.Reply
+					return run
+				}
+				record(o.sk, o.block, o.src)
+				summary = summarizeActionContext(o.block.Text)
+			}
+			roundResults = append(roundResults, llm.ActionResult{Action: queue[i], Summary: summary})
+		}
+
+		next, stepErr := plan.Continue(ctx, roundResults)
+		if stepErr != nil {
+			log.Printf("%s agent step failed: %v", req.Warn(), stepErr)
+		}
+		roundResults = nil
+		queue = followUpContextActions(next)
+		if len(queue) > 0 {
+			log.Printf("%s agentStep round=%d new=%v", req.Tag(), round+1, actionKinds(queue))
+			if ev != nil {
+				ev.Actions = append(ev.Actions, actionKinds(queue)...)
+			}
+		}
+	}
+
+	for _, sk := range enabled {
+		kind := sk.Kind()
+		if f, ok := sk.(skill.Finisher); ok && run.tried[kind] {
+			blocks[kind] = f.Finish(ctx, req, blocks[kind])
+		}
+		var parts []string
+		for _, b := range blocks[kind] {
+			run.counts[kind] += b.Count
+			if strings.TrimSpace(b.Text) != "" {
+				parts = append(parts, b.Text)
+			}
+			if b.Footer != "" {
+				run.footers = append(run.footers, b.Footer)
+			}
+			run.table += b.Table
+			for _, c := range b.Citations {
+				if !cited[c.ID] {
+					cited[c.ID] = true
+					run.citations = append(run.citations, c)
+				}
+			}
+		}
+		if len(parts) > 0 {
+			sec := llm.ContextSection{Kind: kind, Label: sk.Label(), Text: strings.Join(parts, "\n\n")}
+			if a, ok := sk.(skill.Answerer); ok {
+				sec.Weight = a.Weight()
+			}
+			run.Sections = append(run.Sections, sec)
+		}
+		run.sources = append(run.sources, sources[kind]...)
+	}
+	run.Sections = s.answerHints(run.Sections, enabled)
+	return run
+}
+
+// answerHints attaches every skill's answer instructions to its section.
+// Disabled skills and skills that produced no context get a section with
+// hints only.
+func (s *Service) answerHints(sections []llm.ContextSection, enabled []skill.Skill) []llm.ContextSection {
+	on := make(map[string]bool, len(enabled))
+	for _, sk := range enabled {
+		on[sk.Kind()] = true
+	}
+	out := append([]llm.ContextSection(nil), sections...)
+	for _, sk := range s.Skills.All() {
+		a, ok := sk.(skill.Answerer)
+		if !ok {
+			continue
+		}
+		hints := a.AnswerHints(sections, on[sk.Kind()])
+		if len(hints) == 0 {
+			continue
+		}
+		found := false
+		for i := range out {
+			if out[i].Kind == sk.Kind() {
+				out[i].Hints = hints
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, llm.ContextSection{Kind: sk.Kind(), Label: sk.Label(), Hints: hints})
+		}
+	}
+	return out
+}
+
+// skillGrace is how long a round keeps waiting past a source's deadline for
+// the partial result the skill gathered before noticing the deadline.
+const skillGrace = 2 * time.Second
+
+// skillOutcome is the result of one context action.  sk is nil when no
+// enabled skill handles the action.
+type skillOutcome struct {
+	sk    skill.Skill
+	block skill.ContextBlock
+	src   skill.Sources
+	err   error
+}
+
+// executeRound runs one round of context actions and returns their outcomes
+// in action order.  Actions of different skills run concurrently; actions of
+// the same skill run one after another so they can build on each other's
+// per-thread state (e.g. Metabase follow-ups) and do not hammer a single API.
+func (s *Service) executeRound(ctx context.Context, req skill.Request, actions []llm.ActionDescriptor) []skillOutcome {
+	out := make([]skillOutcome, len(actions))
+	lanes := map[string][]int{}
+	var order []string
+	for i, a := range actions {
+		sk := s.Skills.ForKind(a.Kind, s.Cfg, req.TeamID)
+		if sk == nil {
+			log.Printf("%s skill not available kind=%s", req.Tag(), a.Kind)
+			continue
+		}
+		out[i].sk = sk
+		if _, ok := lanes[sk.Kind()]; !ok {
+			order = append(order, sk.Kind())
+		}
+		lanes[sk.Kind()] = append(lanes[sk.Kind()], i)
+	}
+
+	var wg sync.WaitGroup
+	for _, kind := range order {
+		wg.Add(1)
+		go func(idx []int) {
+			defer wg.Done()
+			for _, i := range idx {
+				out[i] = s.executeWithDeadline(ctx, req, out[i].sk, actions[i])
+			}
+		}(lanes[kind])
+	}
+	wg.Wait()
+	return out
+}
+
+// skillDeadline returns the deadline of an action of kind run by sk.
+func skillDeadline(cfg config.Config, sk skill.Skill, kind string) time.Duration {
+	var def time.Duration
+	if d, ok := sk.(skill.Deadliner); ok {
+		def = d.Deadline(cfg, kind)
+	}
+	return cfg.SkillDeadline(kind, def)
+}
+
+// executeWithDeadline runs a single action under the deadline configured for
+// its kind.  Skills see the deadline on ctx and may return what they gathered
+// so far with context.DeadlineExceeded; such partial results are kept and
+// flagged.  A skill still blocked after the grace period is abandoned and
+// replaced by an [AVISO] marker so the answer is generated without it.
+func (s *Service) executeWithDeadline(ctx context.Context, req skill.Request, sk skill.Skill, action llm.ActionDescriptor) skillOutcome {
+	timeout := skillDeadline(s.Cfg, sk, action.Kind)
+	actx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan skillOutcome, 1) // buffered: an abandoned skill must not block
+	go func() {
+		block, src, err := sk.Execute(actx, req, action)
+		done <- skillOutcome{sk: sk, block: block, src: src, err: err}
+	}()
+
+	hard := time.NewTimer(timeout + skillGrace)
+	defer hard.Stop()
+	select {
+	case o := <-done:
+		if errors.Is(o.err, context.DeadlineExceeded) && strings.TrimSpace(o.block.Text) != "" {
+			log.Printf("%s skill=%s partial result after %s", req.Warn(), action.Kind, timeout)
+			o.block.Text = fmt.Sprintf("[AVISO: a fonte %s excedeu o tempo limite de %s; os dados abaixo são parciais. Deixe isso claro ao usuário.]", action.Kind, timeout) + "\n\n" + o.block.Text
+		}
+		return o
+	case <-hard.C:
+		log.Printf("%s skill=%s timeout after %s", req.Warn(), action.Kind, timeout)
+		return skillOutcome{
+			sk: sk,
+			block: skill.ContextBlock{
+				Kind: action.Kind,
+				Text: fmt.Sprintf("[AVISO: a fonte %s não respondeu dentro do tempo limite de %s. A resposta foi gerada sem esses dados — informe ao usuário que essa fonte não pôde ser consultada e sugira tentar novamente.]", action.Kind, timeout),
+			},
+			err: context.DeadlineExceeded,
+		}
+	}
+}
+
+// Fallback builds the informative answer used when the answer LLM fails.
+func (r skillRun) Fallback() string {
+	return buildInformativeFallback(
+		r.tried[kindSlackSearch], r.counts[kindSlackSearch],
+		r.tried[kindJiraSearch], r.counts[kindJiraSearch], "")
+}
+
+// Decorate appends what must never depend on the LLM copying it from the
+// context: numbered citations, download links, source links and the full
+// data table.
+func (r skillRun) Decorate(answer string) string {
+	answer = renderCitations(answer, r.citations, r.direct)
+	for _, f := range r.footers {
+		answer += "\n\n" + f
+	}
+	for _, src := range r.sources {
+		answer += "\n\n" + src
+	}
+	if r.table != "" {
+		if strings.Contains(answer, "[TABLE]") {
+			answer = strings.Replace(answer, "[TABLE]", "\n```\n"+r.table+"\n```", 1)
+		} else {
+			answer = answer + "\n\n```\n" + r.table + "\n```"
+		}
+	}
+	return answer
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/hubspot"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/outline"
+	"github.com/DanielFillol/Jarvis/internal/skill"
+)
+
+func TestSkillDeadline(t *testing.T) {
+	cfg := config.Config{SkillTimeout: 45 * time.Second, MetabaseQueryTimeout: 5 * time.Minute}
+	tests := []struct {
+		name string
+		sk   skill.Skill
+		kind string
+		over map[string]time.Duration
+		want time.Duration
+	}{
+		{"default", slackSkill{}, kindSlackSearch, nil, 45 * time.Second},
+		{"digest declares its own", digestSkill{}, kindChannelDigest, nil, 3 * time.Minute},
+		{"metabase query", metabaseSkill{}, kindMetabaseQuery, nil, 5 * time.Minute},
+		{"show_sql", metabaseSkill{}, kindShowSQL, nil, 5 * time.Minute},
+		{"SKILL_TIMEOUTS beats the skill", digestSkill{}, kindChannelDigest, map[string]time.Duration{kindChannelDigest: 10 * time.Minute}, 10 * time.Minute},
+		{"SKILL_TIMEOUTS beats SKILL_TIMEOUT", slackSkill{}, kindSlackSearch, map[string]time.Duration{kindSlackSearch: 20 * time.Second}, 20 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfg
+			c.SkillTimeouts = tt.over
+			if got := skillDeadline(c, tt.sk, tt.kind); got != tt.want {
+				t.Errorf("skillDeadline(%s) = %s, want %s", tt.kind, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkillsGrantedPerTeam(t *testing.T) {
+	s := &Service{Cfg: config.Config{
+		JiraBaseURL:     "https://acme.atlassian.net",
+		HubSpotAPIKey:   "pat-123",
+		OutlineBaseURL:  "https://wiki.acme.com",
+		OutlineAPIKey:   "ol-123",
+		SlackTeamSkills: map[string][]string{"T0456": {hubspot.ActionSearch}},
+	}}
+	s.registerBuiltinSkills()
+
+	in := s.Skills.Router(s.Cfg, skill.Request{TeamID: "T0456"})
+	var tools []string
+	for _, tool := range in.Tools {
+		tools = append(tools, tool.Kind)
+	}
+	// Slack-only skills need no grant; Jira and Outline were not granted.
+	want := []string{kindSlackSearch, kindChannelDigest, hubspot.ActionSearch}
+	if !reflect.DeepEqual(tools, want) {
+		t.Errorf("router tools for T0456 = %v, want %v", tools, want)
+	}
+
+	for _, tt := range []struct {
+		kind, team string
+		want       bool
+	}{
+		{hubspot.ActionSearch, "T0456", true},
+		{outline.ActionSearch, "T0456", false},
+		{kindJiraCreate, "T0456", false},
+		{kindSlackSearch, "T0456", true},
+		{outline.ActionSearch, "T0789", false},
+		{hubspot.ActionSearch, "T0789", false},
+		{outline.ActionSearch, "", true},
+		{kindJiraSearch, "", true},
+	} {
+		if got := s.Skills.ForKind(tt.kind, s.Cfg, tt.team) != nil; got != tt.want {
+			t.Errorf("ForKind(%s, team=%q) available = %t, want %t", tt.kind, tt.team, got, tt.want)
+		}
+	}
+}
+
+func TestAnswerHintsFromSkills(t *testing.T) {
+	s := &Service{Cfg: config.Config{
+		JiraBaseURL:  "https://acme.atlassian.net/",
+		JiraEmail:    "bot@acme.com",
+		JiraAPIToken: "tok",
+	}}
+	s.registerBuiltinSkills()
+	sections := []llm.ContextSection{{Kind: kindJiraSearch, Label: "CONTEXTO DO JIRA", Text: "[JIRA_EMPTY: nenhuma issue]"}}
+
+	out := s.answerHints(sections, s.Skills.Enabled(s.Cfg, ""))
+	hints := map[string]string{}
+	for _, sec := range out {
+		hints[sec.Kind] = strings.Join(sec.Hints, "\n")
+	}
+	for kind, want := range map[string]string{
+		kindJiraSearch:       "DADOS JIRA AUSENTES",
+		kindMetabaseQuery:    "METABASE NÃO CONFIGURADO",
+		outline.ActionSearch: "OUTLINE NÃO CONFIGURADO",
+		hubspot.ActionSearch: "HUBSPOT NÃO CONFIGURADO",
+	} {
+		if !strings.Contains(hints[kind], want) {
+			t.Errorf("hints for %s = %q, want %q", kind, hints[kind], want)
+		}
+	}
+	if !strings.Contains(hints[kindJiraSearch], "https://acme.atlassian.net/browse/PROJ-123") {
+		t.Errorf("Jira hints miss the base URL: %q", hints[kindJiraSearch])
+	}
+	if _, ok := hints[kindSlackSearch]; ok {
+		t.Error("Slack search added a section without context or hints")
+	}
+	if out[0].Text != sections[0].Text {
+		t.Errorf("hints changed the section text: %q", out[0].Text)
+	}
+}
@@ -1,15 +1,19 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"regexp"
 	"strings"
 
+	"github.com/DanielFillol/Jarvis/internal/skill"
 	"github.com/DanielFillol/Jarvis/internal/slack"
 )
 
 var reFromUserIDQuery = regexp.MustCompile(`\bfrom:([UW][A-Z0-9]+)\b`)
 var reChannelIDInText = regexp.MustCompile(`<#([CG][A-Z0-9]{8,})(?:\|[^>]*)?>`)
+var reChannelID = regexp.MustCompile(`^[CG][A-Z0-9]{8,}$`)
 
 // isLongReplyCancellation returns true when the user's message is a clear
 // negative token in response to the "can I post the long reply?" prompt.
@@ -51,23 +55,27 @@ func extractFromUserIDs(q string) []string {
 }
 
 // buildSlackContext builds a textual summary of Slack search results.
-// It limits the number of matches included to 'limit'.
-func buildSlackContext(matches []slack.SearchMessage, limit int) string {
+// It limits the number of matches included to 'limit'.  Each message is
+// tagged with its source ID ([[S:…]]) and returned as a citation.
+func buildSlackContext(matches []slack.SearchMessage, limit int) (string, []skill.Citation) {
 	if limit <= 0 {
 		limit = 8
 	}
 	var b strings.Builder
+	var cites []skill.Citation
 	for i, m := range matches {
 		if i >= limit {
 			break
 		}
+		id := slackSourceID(m)
+		cites = append(cites, skill.Citation{ID: id, Label: fmt.Sprintf("#%s · %s", m.Channel, m.Username), URL: m.Permalink})
 		if m.Permalink != "" {
-			b.WriteString(fmt.Sprintf("[#%s] %s: %s\nlink: %s\n\n", m.Channel, m.Username, m.Text, m.Permalink))
+			b.WriteString(fmt.Sprintf("[[%s]] [#%s] %s: %s\nlink: %s\n\n", id, m.Channel, m.Username, m.Text, m.Permalink))
 		} else {
-			b.WriteString(fmt.Sprintf("[#%s] %s: %s\n\n", m.Channel, m.Username, m.Text))
+			b.WriteString(fmt.Sprintf("[[%s]] [#%s] %s: %s\n\n", id, m.Channel, m.Username, m.Text))
 		}
 	}
-	return b.String()
+	return b.String(), cites
 }
 
 // extractChannelIDsFromText returns the unique channel IDs embedded in
@@ -88,3 +96,43 @@ func extractChannelIDsFromText(text string) []string {
 	}
 	return out
 }
+
+// resolveChannelRef returns the ID and name of the channel the model named:
+// a raw ID, a <#ID> mention, or a name matched against the channels
+// mentioned in question and the channels the bot is in.  Both are empty when
+// nothing matches.
+func (s *Service) resolveChannelRef(ctx context.Context, question, ref string) (id, name string) {
+	ref = strings.TrimSpace(ref)
+	if ids := extractChannelIDsFromText(ref); len(ids) > 0 {
+		ref = ids[0]
+	}
+	if reChannelID.MatchString(ref) {
+		name = s.Slack.GetChannelName(ctx, ref)
+		if name == "" {
+			name = ref
+		}
+		return ref, name
+	}
+	want := strings.ToLower(strings.TrimPrefix(ref, "#"))
+	if want == "" {
+		return "", ""
+	}
+	// Mentions in the question were resolved to #name for the router, so
+	// the model names a channel the bot may not list (private, not a member).
+	for _, cid := range extractChannelIDsFromText(question) {
+		if n := s.Slack.GetChannelName(ctx, cid); strings.EqualFold(n, want) {
+			return cid, n
+		}
+	}
+	channels, err := s.Slack.ListChannels(ctx)
+	if err != nil {
+		log.Printf("[WARN] resolve channel %q: list channels: %v", ref, err)
+		return "", ""
+	}
+	for _, ch := range channels {
+		if strings.EqualFold(ch.Name, want) {
+			return ch.ID, ch.Name
+		}
+	}
+	return "", ""
+}
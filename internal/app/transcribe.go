@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/slack"
+	"github.com/DanielFillol/Jarvis/internal/transcribe"
+)
+
+// maxThreadTranscripts caps the recordings transcribed for a Jira draft.
+const maxThreadTranscripts = 3
+
+// transcribeSlackFile returns the transcript of an audio/video attachment,
+// downloading it only when it is not cached yet.
+func (s *Service) transcribeSlackFile(ctx context.Context, f slack.File) (transcribe.Transcript, error) {
+	if t, ok := s.Transcriber.Cached(f.ID); ok {
+		return t, nil
+	}
+	if f.Size > transcribe.MaxBytes {
+		return transcribe.Transcript{}, fmt.Errorf("%w (%d bytes)", transcribe.ErrTooLarge, f.Size)
+	}
+	if err := s.Transcriber.CheckDuration(time.Duration(f.DurationMs) * time.Millisecond); err != nil {
+		return transcribe.Transcript{}, err
+	}
+	if f.URLPrivateDownload == "" {
+		return transcribe.Transcript{}, fmt.Errorf("no download URL")
+	}
+	data, err := s.Slack.DownloadFile(ctx, f.URLPrivateDownload)
+	if err != nil {
+		return transcribe.Transcript{}, fmt.Errorf("download: %w", err)
+	}
+	return s.Transcriber.Transcribe(ctx, f.ID, f.Name, f.Mimetype, data)
+}
+
+// transcriptHeader is the file-context header of a transcribed recording.
+func transcriptHeader(name, mimetype string, t transcribe.Transcript) string {
+	if t.Duration > 0 {
+		return fmt.Sprintf("--- arquivo: %s (tipo: %s, transcrição, duração: %s) ---\n", name, mimetype, transcribe.Timestamp(t.Duration))
+	}
+	return fmt.Sprintf("--- arquivo: %s (tipo: %s, transcrição) ---\n", name, mimetype)
+}
+
+// writeTranscriptSection appends the transcript of a recording to b or, when
+// err is set, a marker saying why it was not transcribed, so the answer
+// still acknowledges the attachment instead of ignoring it.
+func writeTranscriptSection(b *strings.Builder, maxTotal int, name, mimetype string, t transcribe.Transcript, err error) bool {
+	if err != nil {
+		return writeFileSection(b, maxTotal, name, transcriptHeader(name, mimetype, transcribe.Transcript{}), transcriptionFailure(err))
+	}
+	return writeFileSection(b, maxTotal, name, transcriptHeader(name, mimetype, t), t.Format())
+}
+
+// transcriptionFailure is the marker left in place of a transcript.
+func transcriptionFailure(err error) string {
+	switch {
+	case errors.Is(err, transcribe.ErrTooLarge):
+		return "[AVISO: arquivo grande demais para transcrever (máximo de 25 MB)]"
+	case errors.Is(err, transcribe.ErrTooLong):
+		return "[AVISO: gravação longa demais para transcrever]"
+	}
+	return "[AVISO: não foi possível transcrever este arquivo]"
+}
+
+// writeFileSection appends header and content to b within maxTotal chars,
+// truncating content when needed.  It returns false once the cap is reached.
+func writeFileSection(b *strings.Builder, maxTotal int, name, header, content string) bool {
+	available := maxTotal - b.Len() - len(header) - 2
+	if available <= 0 {
+		log.Printf("[JARVIS] fileContext cap reached, skipping file %q", name)
+		return false
+	}
+	b.WriteString(header)
+	if len(content) > available {
+		log.Printf("[JARVIS] truncating file %q: %d → %d chars", name, len(content), available)
+		b.WriteString(content[:available])
+		b.WriteString("\n[AVISO: conteúdo truncado por exceder o limite de contexto]\n")
+	} else {
+		b.WriteString(content)
+	}
+	b.WriteString("\n\n")
+	return true
+}
+
+// threadTranscripts transcribes the audio/video attachments of a thread for
+// ExtractIssueFromThread, so a recorded bug report can become a card.  It
+// returns "" when transcription is disabled or the thread has no recordings.
+func (s *Service) threadTranscripts(ctx context.Context, channel, threadTs string) string {
+	if s.Transcriber == nil || channel == "" || threadTs == "" {
+		return ""
+	}
+	files, err := s.Slack.GetThreadFiles(ctx, channel, threadTs)
+	if err != nil {
+		log.Printf("[WARN] thread transcripts channel=%q thread=%q: %v", channel, threadTs, err)
+		return ""
+	}
+	var b strings.Builder
+	n := 0
+	for _, f := range files {
+		if !isMediaMimetype(f.Mimetype) {
+			continue
+		}
+		if n == maxThreadTranscripts {
+			break
+		}
+		t, err := s.transcribeSlackFile(ctx, f)
+		text := t.Format()
+		if err != nil {
+			log.Printf("[WARN] transcribe %q: %v", f.Name, err)
+			t, text = transcribe.Transcript{}, transcriptionFailure(err)
+		}
+		if text != "" {
+			b.WriteString(transcriptHeader(f.Name, f.Mimetype, t))
+			b.WriteString(text)
+			b.WriteString("\n\n")
+			n++
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+	"github.com/DanielFillol/Jarvis/internal/transcribe"
+)
+
+func TestDirectFileContextTranscribesAndMarksFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("multipart: %v", err)
+		}
+		_, hdr, _ := r.FormFile("file")
+		if strings.HasPrefix(hdr.Filename, "broken") {
+			http.Error(w, "decode failed", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"segments":[{"start":5,"end":9,"text":" O checkout trava no pagamento."}]}`)
+	}))
+	defer srv.Close()
+	s := &Service{Transcriber: transcribe.NewClient(config.Config{TranscriptionBaseURL: srv.URL, TranscriptionModel: "whisper-1"})}
+
+	got := s.buildDirectFileContext(context.Background(), []DirectFile{
+		{Name: "bug.webm", Mimetype: "video/webm", Data: []byte("video")},
+		{Name: "broken.mp3", Mimetype: "audio/mpeg", Data: []byte("audio")},
+		{Name: "huge.mp4", Mimetype: "video/mp4", Data: make([]byte, transcribe.MaxBytes+1)},
+		{Name: "notes.txt", Mimetype: "text/plain", Data: []byte("passos para reproduzir")},
+	})
+
+	for _, want := range []string{
+		"--- arquivo: bug.webm (tipo: video/webm, transcrição, duração: 00:09) ---\n[00:05] O checkout trava no pagamento.",
+		"--- arquivo: broken.mp3 (tipo: audio/mpeg, transcrição) ---\n[AVISO: não foi possível transcrever este arquivo]",
+		"--- arquivo: huge.mp4 (tipo: video/mp4, transcrição) ---\n[AVISO: arquivo grande demais para transcrever",
+		"passos para reproduzir",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("file context missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestTranscribeSlackFileLimits(t *testing.T) {
+	c := transcribe.NewClient(config.Config{TranscriptionBaseURL: "http://127.0.0.1:1", TranscriptionModel: "whisper-1", TranscriptionMaxDuration: 10 * time.Minute})
+	s := &Service{Transcriber: c}
+	ctx := context.Background()
+
+	// Both limits are checked before the download, so no Slack client is needed.
+	if _, err := s.transcribeSlackFile(ctx, slack.File{ID: "F1", Size: transcribe.MaxBytes + 1}); !errors.Is(err, transcribe.ErrTooLarge) {
+		t.Errorf("oversized file: err = %v, want ErrTooLarge", err)
+	}
+	if _, err := s.transcribeSlackFile(ctx, slack.File{ID: "F2", Size: 1024, DurationMs: 11 * 60 * 1000}); !errors.Is(err, transcribe.ErrTooLong) {
+		t.Errorf("long recording: err = %v, want ErrTooLong", err)
+	}
+	if got := transcriptionFailure(fmt.Errorf("wrapped: %w", transcribe.ErrTooLong)); got != "[AVISO: gravação longa demais para transcrever]" {
+		t.Errorf("marker = %q", got)
+	}
+}
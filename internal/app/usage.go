@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// tokenBudget holds today's token consumption per Slack user and channel for
+// the daily caps (LLM_DAILY_TOKENS_PER_USER / LLM_DAILY_TOKENS_PER_CHANNEL).
+// Counters reset at midnight UTC.  The first lookup of a user or channel each
+// day is seeded from telemetry, so a restart does not hand out a fresh budget.
+type tokenBudget struct {
+	mu       sync.Mutex
+	day      string
+	users    map[string]int
+	channels map[string]int
+}
+
+// today resets the counters when the UTC day changes.  Callers hold b.mu.
+func (b *tokenBudget) today() {
+	if d := time.Now().UTC().Format("2006-01-02"); d != b.day || b.users == nil {
+		b.day = d
+		b.users = map[string]int{}
+		b.channels = map[string]int{}
+	}
+}
+
+// budgetExceeded returns the message to post when the sender or the channel
+// has used up its daily token budget, or "" when the question may proceed.
+func (s *Service) budgetExceeded(ctx context.Context, senderUserID, channel string) string {
+	userCap, channelCap := s.Cfg.LLMDailyTokensPerUser, s.Cfg.LLMDailyTokensPerChannel
+	if userCap <= 0 && channelCap <= 0 {
+		return ""
+	}
+	b := &s.budget
+	b.mu.Lock()
+	b.today()
+	_, knownUser := b.users[senderUserID]
+	_, knownChannel := b.channels[channel]
+	b.mu.Unlock()
+
+	if !knownUser || !knownChannel {
+		seedCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		userTokens, channelTokens, err := s.Telemetry.TokensToday(seedCtx, senderUserID, channel)
+		cancel()
+		if err != nil {
+			log.Printf("[WARN] token budget seed failed: %v", err)
+		}
+		b.mu.Lock()
+		b.today()
+		if _, ok := b.users[senderUserID]; !ok {
+			b.users[senderUserID] = userTokens
+		}
+		if _, ok := b.channels[channel]; !ok {
+			b.channels[channel] = channelTokens
+		}
+		b.mu.Unlock()
+	}
+
+	b.mu.Lock()
+	used, channelUsed := b.users[senderUserID], b.channels[channel]
+	b.mu.Unlock()
+	switch {
+	case userCap > 0 && used >= userCap:
+		log.Printf("[JARVIS] daily token budget exceeded user=%s used=%d cap=%d", senderUserID, used, userCap)
+		return fmt.Sprintf("Você atingiu o limite diário de uso do assistente (%d tokens). O limite é renovado à meia-noite (UTC) — se precisar de mais hoje, fale com o administrador.", userCap)
+	case channelCap > 0 && channelUsed >= channelCap:
+		log.Printf("[JARVIS] daily token budget exceeded channel=%s used=%d cap=%d", channel, channelUsed, channelCap)
+		return fmt.Sprintf("Este canal atingiu o limite diário de uso do assistente (%d tokens). O limite é renovado à meia-noite (UTC) — se precisar de mais hoje, fale com o administrador.", channelCap)
+	}
+	return ""
+}
+
+// recordUsage copies the meter into the telemetry event and charges the
+// tokens to the sender's and the channel's daily budgets.
+func (s *Service) recordUsage(ev *telemetry.Event, meter *llm.UsageMeter, senderUserID, channel string) {
+	calls := meter.Calls()
+	if len(calls) == 0 {
+		return
+	}
+	ev.PromptTokens, ev.CompletionTokens, ev.CostUSD = meter.Totals()
+	for _, c := range calls {
+		ev.LLMCalls = append(ev.LLMCalls, telemetry.LLMCall{
+			Site:             c.Site,
+			Model:            c.Model,
+			PromptTokens:     c.PromptTokens,
+			CompletionTokens: c.CompletionTokens,
+			CostUSD:          c.CostUSD,
+			Estimated:        c.Estimated,
+		})
+	}
+	log.Printf("[JARVIS] llm usage calls=%d prompt=%d completion=%d cost=$%.4f", len(calls), ev.PromptTokens, ev.CompletionTokens, ev.CostUSD)
+
+	b := &s.budget
+	b.mu.Lock()
+	b.today()
+	b.users[senderUserID] += ev.PromptTokens + ev.CompletionTokens
+	b.channels[channel] += ev.PromptTokens + ev.CompletionTokens
+	b.mu.Unlock()
+}
@@ -31,6 +31,152 @@ type Config struct {
 	// Defaults to "Jarvis".
 	BotName string
 
+	// ── Optional: Slack ──────────────────────────────────────────────────────
+	// SlackStreamAnswers streams the answer into the "_buscando..._"
+	// placeholder as it is generated, preceded by progress lines while the
+	// context sources run.  Defaults to true.  Set via SLACK_STREAM_ANSWERS.
+	SlackStreamAnswers bool
+	// SlackUpdateInterval is the minimum interval between chat.update calls
+	// on the placeholder, keeping streaming under Slack's rate limits.
+	// Defaults to 1.5s.  Set via SLACK_UPDATE_INTERVAL.
+	SlackUpdateInterval time.Duration
+	// SlackSocketMode receives events over a Socket Mode WebSocket instead of
+	// requiring a public /slack/events URL (the endpoint keeps working).
+	// Needs SlackAppToken.  Defaults to false.  Set via SLACK_SOCKET_MODE.
+	SlackSocketMode bool
+	// SlackAppToken is the app-level token (xapp-, connections:write scope)
+	// used to open Socket Mode connections.  Set via SLACK_APP_TOKEN.
+	SlackAppToken string
+	// SlackDedupTTL is how long a delivered event_id and message channel/ts
+	// are remembered to drop Slack retries.  0 disables deduplication.
+	// Defaults to 1h.  Set via SLACK_DEDUP_TTL.
+	SlackDedupTTL time.Duration
+	// SlackReactionWorkflows maps emojis to the action run when someone
+	// reacts with them (jira, tldr, bookmark, outline).  A "CHANNEL/emoji"
+	// key overrides the mapping in that channel; an empty action disables
+	// it there.  Empty by default.  Set via
+	// SLACK_REACTION_WORKFLOWS=jira=jira,tldr=tldr,C0123/tldr=.
+	SlackReactionWorkflows map[string]string
+	// SlackUserDirectoryRefresh is how often the cached user directory
+	// (users.list) used for mention resolution and name lookups is reloaded;
+	// user_change events keep it current in between.  0 disables the
+	// directory load; single users.info lookups are still cached.  Defaults
+	// to 6h.  Set via SLACK_USER_DIRECTORY_REFRESH.
+	SlackUserDirectoryRefresh time.Duration
+	// SlackRetryMax is how many times a Web API call answered with 429 is
+	// retried after its Retry-After delay; reads (GET) are also retried on
+	// network errors and 5xx with jittered backoff.  0 disables retries.
+	// Defaults to 3.  Set via SLACK_RETRY_MAX.
+	SlackRetryMax int
+	// SlackRetryMaxWait is the longest Retry-After the client waits for
+	// before giving up and returning the 429 to the caller.  Defaults to
+	// 30s.  Set via SLACK_RETRY_MAX_WAIT.
+	SlackRetryMaxWait time.Duration
+	// SlackRateLimits overrides the per-minute budget of Web API methods
+	// (the client paces each method by its Slack tier).  Set via
+	// SLACK_RATE_LIMITS=search.messages=10,chat.postMessage=30.
+	SlackRateLimits map[string]int
+	// SlackClientID + SlackClientSecret + SlackAllowedTeams enable the OAuth
+	// v2 install flow (/slack/install, /slack/oauth/callback), so the app
+	// can be installed in more than one workspace.  Installed workspaces are
+	// stored in TELEMETRY_DB_URL and their events use their own tokens; the
+	// env tokens keep serving their own workspace.  Set via SLACK_CLIENT_ID
+	// and SLACK_CLIENT_SECRET.
+	SlackClientID     string
+	SlackClientSecret string
+	// SlackAllowedTeams lists the team IDs that may install the app; installs
+	// from any other workspace are refused and revoked.  Set via
+	// SLACK_ALLOWED_TEAMS=T0123,T0456.
+	SlackAllowedTeams []string
+	// SlackOAuthRedirectURL is the redirect URL registered in the app's
+	// OAuth settings.  Defaults to PUBLIC_BASE_URL + "/slack/oauth/callback".
+	// Set via SLACK_OAUTH_REDIRECT_URL.
+	SlackOAuthRedirectURL string
+	// SlackOAuthBotScopes and SlackOAuthUserScopes are the scopes requested
+	// on install (comma-separated).  They default to the scopes listed in
+	// the README.  Set via SLACK_OAUTH_BOT_SCOPES and SLACK_OAUTH_USER_SCOPES.
+	SlackOAuthBotScopes  string
+	SlackOAuthUserScopes string
+	// SlackTeamSkills grants integrations to workspaces installed through
+	// OAuth (team ID → skill kinds such as jira_search, metabase_query,
+	// hubspot_search, outline_search, googledrive_search); an installed
+	// workspace not listed gets none.  Skills that only use the workspace's own
+	// Slack need no grant.  Set via SLACK_TEAM_SKILLS=T0123=jira_search|outline_search.
+	SlackTeamSkills map[string][]string
+	// SlackTeamJiraProjects grants Jira projects to workspaces installed
+	// through OAuth (team ID → project keys); an installed workspace not
+	// listed gets none.  The env tokens' workspace uses JiraProjectKeys.  Set
+	// via SLACK_TEAM_JIRA_PROJECTS=T0123=BACKEND|FRONT,T0456=SUPPORT.
+	SlackTeamJiraProjects map[string][]string
+	// SlackTeamMetabaseDBs grants Metabase databases to workspaces installed
+	// through OAuth (team ID → database IDs); an installed workspace not
+	// listed gets none.  Set via SLACK_TEAM_METABASE_DBS=T0456=3|4.
+	SlackTeamMetabaseDBs map[string][]int
+
+	// ── Optional: LLM providers ──────────────────────────────────────────────
+	// OpenAIBaseURL is the root of the OpenAI-compatible API used by the
+	// default "openai" provider.  Defaults to "https://api.openai.com/v1".
+	OpenAIBaseURL string
+	// AnthropicAPIKey + AnthropicBaseURL enable the "anthropic" provider
+	// (Messages API).  AnthropicBaseURL defaults to "https://api.anthropic.com/v1".
+	AnthropicAPIKey  string
+	AnthropicBaseURL string
+	// AzureOpenAIEndpoint + AzureOpenAIAPIKey enable the "azure" provider.
+	// The model name is used as the deployment name.  AzureOpenAIAPIVersion
+	// defaults to "2024-10-21".
+	AzureOpenAIEndpoint   string
+	AzureOpenAIAPIKey     string
+	AzureOpenAIAPIVersion string
+	// LocalLLMBaseURL enables the "local" provider, an OpenAI-compatible
+	// self-hosted server (vLLM, Ollama, LM Studio), e.g. http://localhost:11434/v1.
+	// LocalLLMAPIKey is optional.
+	LocalLLMBaseURL string
+	LocalLLMAPIKey  string
+	// LLMDefaultProvider is the provider used for models without an explicit
+	// mapping.  Defaults to "openai".  Set via LLM_DEFAULT_PROVIDER.
+	LLMDefaultProvider string
+	// LLMModelProviders maps model names to provider names.  A trailing "*"
+	// matches by prefix.  Set via LLM_MODEL_PROVIDERS=claude-*=anthropic,llama3.1:8b=local.
+	LLMModelProviders map[string]string
+	// LLMRouterModel overrides the model used for routing calls (DecideActions,
+	// EnhancePrompt, GenerateSQL) so they can run on a different provider than
+	// the answer model.  When empty each call keeps its usual model.
+	LLMRouterModel string
+	// LLMNativeTools exposes the router actions as provider tool calls with
+	// strict JSON schemas.  Disable for servers without tool support; the
+	// router then falls back to a bare JSON array.  Defaults to true.
+	LLMNativeTools bool
+	// LLMAgentMaxSteps bounds the router rounds per message: after the first
+	// actions run, the model sees their results and may request more tools
+	// until this limit.  Defaults to 3; 1 disables follow-up rounds.
+	LLMAgentMaxSteps int
+	// LLMContextWindows overrides the context window (in tokens) assumed for
+	// a model when sizing the answer prompt.  A trailing "*" matches by
+	// prefix.  Known OpenAI and Claude models need no entry; self-hosted
+	// models usually do.  Set via LLM_CONTEXT_WINDOWS=llama3.1:8b=8192,qwen*=32768.
+	LLMContextWindows map[string]int
+	// LLMCacheSize is the number of routing, enhancement, intent and SQL
+	// generation responses kept in memory.  0 disables the cache.  Defaults
+	// to 1000.  Set via LLM_CACHE_SIZE.
+	LLMCacheSize int
+	// LLMCacheTTLs overrides the per-call-site cache TTLs (enhance=24h,
+	// router=6h, intent=24h, sql=12h).  Set via LLM_CACHE_TTLS=router=1h,sql=30m.
+	LLMCacheTTLs map[string]time.Duration
+	// LLMCachePersist also stores cached responses in the TELEMETRY_DB_URL
+	// database so they survive restarts and are shared across replicas.
+	// Defaults to true.  Set via LLM_CACHE_PERSIST.
+	LLMCachePersist bool
+	// LLMPrices overrides or extends the built-in price table used to cost
+	// token usage, in USD per million input/output tokens.  Keys match by
+	// prefix.  Set via LLM_PRICES=gpt-4o=2.5/10,my-local-model=0/0.
+	LLMPrices map[string]ModelPrice
+	// LLMDailyTokensPerUser and LLMDailyTokensPerChannel cap the tokens a
+	// Slack user or channel may consume per day (UTC).  0 means unlimited,
+	// the default.  Set via LLM_DAILY_TOKENS_PER_USER and
+	// LLM_DAILY_TOKENS_PER_CHANNEL.
+	LLMDailyTokensPerUser    int
+	LLMDailyTokensPerChannel int
+
 	// ── Optional: Jira ───────────────────────────────────────────────────────
 	// Configure JIRA_BASE_URL + JIRA_EMAIL + JIRA_API_TOKEN to enable Jira
 	// integration (issue lookup, search, creation).
@@ -71,6 +217,41 @@ type Config struct {
 	// Used to construct download links for CSV exports. Set via PUBLIC_BASE_URL.
 	PublicBaseURL string
 
+	// ── Optional: Context sources ────────────────────────────────────────────
+	// SkillTimeout is the deadline of each context action (Slack search, JQL,
+	// wiki, Drive, CRM).  Independent sources run concurrently; one that
+	// misses its deadline degrades to an [AVISO: ...] marker and the answer is
+	// generated without it.  Defaults to 45s.  Set via SKILL_TIMEOUT=45s.
+	SkillTimeout time.Duration
+	// SkillTimeouts overrides the deadline per action kind, including the
+	// longer defaults some skills declare (metabase_query and show_sql use
+	// MetabaseQueryTimeout, channel_digest 3m).  Set via
+	// SKILL_TIMEOUTS=slack_search=20s,metabase_query=3m.
+	SkillTimeouts map[string]time.Duration
+
+	// ── Optional: Transcription ──────────────────────────────────────────────
+	// Audio and video attachments (voice notes, huddle clips, screen
+	// recordings) are transcribed through a Whisper-compatible
+	// /audio/transcriptions endpoint.  TranscriptionBaseURL defaults to
+	// OPENAI_BASE_URL (with OPENAI_API_KEY) when an OpenAI key is set; point it
+	// at a local server (faster-whisper, whisper.cpp) to keep audio in-house,
+	// in which case TranscriptionAPIKey is optional.  Set via
+	// TRANSCRIPTION_BASE_URL and TRANSCRIPTION_API_KEY.
+	TranscriptionBaseURL string
+	TranscriptionAPIKey  string
+	// TranscriptionModel is the transcription model.  Defaults to
+	// "whisper-1"; "off" disables transcription.  Set via TRANSCRIPTION_MODEL.
+	TranscriptionModel string
+	// TranscriptionLanguage is an optional ISO-639-1 hint ("pt") that improves
+	// accuracy and latency.  Empty lets the model detect the language.  Set
+	// via TRANSCRIPTION_LANGUAGE.
+	TranscriptionLanguage string
+	// TranscriptionMaxDuration caps the length of a transcribed recording:
+	// longer ones are skipped when Slack reports their duration up front and
+	// cut at the limit otherwise.  Defaults to 1h; 0 disables the cap.  Set
+	// via TRANSCRIPTION_MAX_DURATION=30m.
+	TranscriptionMaxDuration time.Duration
+
 	// ── Optional: Outline ────────────────────────────────────────────────────
 	// Configure OUTLINE_BASE_URL + OUTLINE_API_KEY to enable Outline wiki
 	// integration (documentation search, process docs, how-to guides).
@@ -80,6 +261,10 @@ type Config struct {
 	// OUTLINE_API_KEY is a personal access token from Outline → Settings → API.
 	OutlineBaseURL string
 	OutlineAPIKey  string
+	// OutlineCollectionID is the collection where Slack threads are
+	// published as documents (the "outline" reaction).  Set via
+	// OUTLINE_COLLECTION_ID.
+	OutlineCollectionID string
 
 	// ── Optional: Google Drive ────────────────────────────────────────────────
 	// Configure one of GOOGLE_DRIVE_CREDENTIALS_JSON or GOOGLE_DRIVE_CREDENTIALS_PATH
@@ -123,6 +308,28 @@ type Config struct {
 	// usage telemetry.  When empty, telemetry is silently disabled.
 	TelemetryDBURL string
 
+	// ── Optional: Scheduled questions ────────────────────────────────────────
+	// ScheduleTimezone is the IANA timezone of schedules created without an
+	// explicit one ("todo dia às 9h").  Empty means the server's local time.
+	// Set via SCHEDULE_TIMEZONE=America/Sao_Paulo.
+	ScheduleTimezone string
+	// ScheduleMaxPerUser caps the schedules each user may keep (paused ones
+	// included).  0 disables scheduling.  Defaults to 10.  Set via
+	// SCHEDULE_MAX_PER_USER.
+	ScheduleMaxPerUser int
+
+	// ── Optional: Identity mapping ───────────────────────────────────────────
+	// Slack users are linked to their Jira account and HubSpot owner by the
+	// email of their Slack profile.  IdentityJiraUsers and
+	// IdentityHubSpotOwners pin the mapping for users whose emails differ.
+	// Set via IDENTITY_JIRA_USERS=U0123=5b10ac8d82e05b22cc7d4ef5 and
+	// IDENTITY_HUBSPOT_OWNERS=U0123=12345678.
+	IdentityJiraUsers     map[string]string
+	IdentityHubSpotOwners map[string]string
+	// IdentityCacheTTL is how long a resolved identity is reused.  0
+	// disables caching.  Defaults to 24h.  Set via IDENTITY_CACHE_TTL.
+	IdentityCacheTTL time.Duration
+
 	// ChatAPIKey gates the /api/chat endpoint.  When empty the endpoint is
 	// open (no authentication required).  Set via CHAT_API_KEY.
 	ChatAPIKey string
@@ -145,6 +352,39 @@ func Load() Config {
 	cfg.OpenAILesserModel = os.Getenv("OPENAI_LESSER_MODEL")
 	cfg.BotName = getEnv("BOT_NAME", "Jarvis")
 
+	cfg.OpenAIBaseURL = strings.TrimRight(getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"), "/")
+	cfg.AnthropicAPIKey = os.Getenv("ANTHROPIC_API_KEY")
+	cfg.AnthropicBaseURL = strings.TrimRight(getEnv("ANTHROPIC_BASE_URL", "https://api.anthropic.com/v1"), "/")
+	cfg.AzureOpenAIEndpoint = strings.TrimRight(getEnv("AZURE_OPENAI_ENDPOINT", ""), "/")
+	cfg.AzureOpenAIAPIKey = os.Getenv("AZURE_OPENAI_API_KEY")
+	cfg.AzureOpenAIAPIVersion = getEnv("AZURE_OPENAI_API_VERSION", "2024-10-21")
+	cfg.LocalLLMBaseURL = strings.TrimRight(getEnv("LOCAL_LLM_BASE_URL", ""), "/")
+	cfg.LocalLLMAPIKey = os.Getenv("LOCAL_LLM_API_KEY")
+	cfg.LLMDefaultProvider = strings.ToLower(getEnv("LLM_DEFAULT_PROVIDER", "openai"))
+	cfg.LLMModelProviders = parseModelProviders(os.Getenv("LLM_MODEL_PROVIDERS"))
+	cfg.LLMRouterModel = os.Getenv("LLM_ROUTER_MODEL")
+	cfg.LLMNativeTools = !strings.EqualFold(strings.TrimSpace(getEnv("LLM_NATIVE_TOOLS", "true")), "false")
+	if n, err := strconv.Atoi(getEnv("LLM_AGENT_MAX_STEPS", "3")); err == nil && n > 0 {
+		cfg.LLMAgentMaxSteps = n
+	} else {
+		cfg.LLMAgentMaxSteps = 3
+	}
+	cfg.LLMContextWindows = parseIntMap(os.Getenv("LLM_CONTEXT_WINDOWS"))
+	if n, err := strconv.Atoi(getEnv("LLM_CACHE_SIZE", "1000")); err == nil && n >= 0 {
+		cfg.LLMCacheSize = n
+	} else {
+		cfg.LLMCacheSize = 1000
+	}
+	cfg.LLMCacheTTLs = parseDurationMap(os.Getenv("LLM_CACHE_TTLS"))
+	cfg.LLMCachePersist = !strings.EqualFold(strings.TrimSpace(getEnv("LLM_CACHE_PERSIST", "true")), "false")
+	cfg.LLMPrices = parsePrices(os.Getenv("LLM_PRICES"))
+	if n, err := strconv.Atoi(getEnv("LLM_DAILY_TOKENS_PER_USER", "0")); err == nil && n > 0 {
+		cfg.LLMDailyTokensPerUser = n
+	}
+	if n, err := strconv.Atoi(getEnv("LLM_DAILY_TOKENS_PER_CHANNEL", "0")); err == nil && n > 0 {
+		cfg.LLMDailyTokensPerChannel = n
+	}
+
 	cfg.JiraBaseURL = os.Getenv("JIRA_BASE_URL")
 	cfg.JiraEmail = os.Getenv("JIRA_EMAIL")
 	cfg.JiraAPIToken = os.Getenv("JIRA_API_TOKEN")
@@ -163,10 +403,36 @@ func Load() Config {
 		cfg.MetabaseQueryTimeout = 5 * time.Minute
 	}
 
+	if st, err := time.ParseDuration(getEnv("SKILL_TIMEOUT", "45s")); err == nil && st > 0 {
+		cfg.SkillTimeout = st
+	} else {
+		cfg.SkillTimeout = 45 * time.Second
+	}
+	cfg.SkillTimeouts = parseDurationMap(os.Getenv("SKILL_TIMEOUTS"))
+
 	cfg.PublicBaseURL = strings.TrimRight(getEnv("PUBLIC_BASE_URL", ""), "/")
 
+	cfg.TranscriptionBaseURL = strings.TrimRight(getEnv("TRANSCRIPTION_BASE_URL", ""), "/")
+	cfg.TranscriptionAPIKey = os.Getenv("TRANSCRIPTION_API_KEY")
+	if cfg.TranscriptionBaseURL == "" && cfg.OpenAIAPIKey != "" {
+		cfg.TranscriptionBaseURL = cfg.OpenAIBaseURL
+		if cfg.TranscriptionAPIKey == "" {
+			cfg.TranscriptionAPIKey = cfg.OpenAIAPIKey
+		}
+	}
+	if m := getEnv("TRANSCRIPTION_MODEL", "whisper-1"); !strings.EqualFold(m, "off") {
+		cfg.TranscriptionModel = m
+	}
+	cfg.TranscriptionLanguage = strings.TrimSpace(os.Getenv("TRANSCRIPTION_LANGUAGE"))
+	if d, err := time.ParseDuration(getEnv("TRANSCRIPTION_MAX_DURATION", "1h")); err == nil && d >= 0 {
+		cfg.TranscriptionMaxDuration = d
+	} else {
+		cfg.TranscriptionMaxDuration = time.Hour
+	}
+
 	cfg.OutlineBaseURL = strings.TrimRight(getEnv("OUTLINE_BASE_URL", ""), "/")
 	cfg.OutlineAPIKey = os.Getenv("OUTLINE_API_KEY")
+	cfg.OutlineCollectionID = strings.TrimSpace(os.Getenv("OUTLINE_COLLECTION_ID"))
 
 	cfg.GoogleDriveCredentialsJSON = os.Getenv("GOOGLE_DRIVE_CREDENTIALS_JSON")
 	cfg.GoogleDriveCredentialsPath = os.Getenv("GOOGLE_DRIVE_CREDENTIALS_PATH")
@@ -191,6 +457,70 @@ func Load() Config {
 	cfg.SQLHintsDir = getEnv("SQL_HINTS_DIR", "./docs/sql_hints")
 	cfg.TelemetryDBURL = os.Getenv("TELEMETRY_DB_URL")
 	cfg.ChatAPIKey = os.Getenv("CHAT_API_KEY")
+	cfg.ScheduleTimezone = strings.TrimSpace(os.Getenv("SCHEDULE_TIMEZONE"))
+	if n, err := strconv.Atoi(getEnv("SCHEDULE_MAX_PER_USER", "10")); err == nil && n >= 0 {
+		cfg.ScheduleMaxPerUser = n
+	} else {
+		cfg.ScheduleMaxPerUser = 10
+	}
+
+	cfg.IdentityJiraUsers = parseStringMap(os.Getenv("IDENTITY_JIRA_USERS"))
+	cfg.IdentityHubSpotOwners = parseStringMap(os.Getenv("IDENTITY_HUBSPOT_OWNERS"))
+	if d, err := time.ParseDuration(getEnv("IDENTITY_CACHE_TTL", "24h")); err == nil && d >= 0 {
+		cfg.IdentityCacheTTL = d
+	} else {
+		cfg.IdentityCacheTTL = 24 * time.Hour
+	}
+
+	cfg.SlackStreamAnswers = !strings.EqualFold(strings.TrimSpace(getEnv("SLACK_STREAM_ANSWERS", "true")), "false")
+	if d, err := time.ParseDuration(getEnv("SLACK_UPDATE_INTERVAL", "1500ms")); err == nil && d > 0 {
+		cfg.SlackUpdateInterval = d
+	} else {
+		cfg.SlackUpdateInterval = 1500 * time.Millisecond
+	}
+	cfg.SlackSocketMode = strings.EqualFold(strings.TrimSpace(getEnv("SLACK_SOCKET_MODE", "false")), "true")
+	cfg.SlackAppToken = os.Getenv("SLACK_APP_TOKEN")
+	if d, err := time.ParseDuration(getEnv("SLACK_DEDUP_TTL", "1h")); err == nil && d >= 0 {
+		cfg.SlackDedupTTL = d
+	} else {
+		cfg.SlackDedupTTL = time.Hour
+	}
+	cfg.SlackReactionWorkflows = parseReactionWorkflows(os.Getenv("SLACK_REACTION_WORKFLOWS"))
+	if d, err := time.ParseDuration(getEnv("SLACK_USER_DIRECTORY_REFRESH", "6h")); err == nil && d >= 0 {
+		cfg.SlackUserDirectoryRefresh = d
+	} else {
+		cfg.SlackUserDirectoryRefresh = 6 * time.Hour
+	}
+	if n, err := strconv.Atoi(getEnv("SLACK_RETRY_MAX", "3")); err == nil && n >= 0 {
+		cfg.SlackRetryMax = n
+	} else {
+		cfg.SlackRetryMax = 3
+	}
+	if d, err := time.ParseDuration(getEnv("SLACK_RETRY_MAX_WAIT", "30s")); err == nil && d >= 0 {
+		cfg.SlackRetryMaxWait = d
+	} else {
+		cfg.SlackRetryMaxWait = 30 * time.Second
+	}
+	cfg.SlackRateLimits = parseIntMap(os.Getenv("SLACK_RATE_LIMITS"))
+	cfg.SlackClientID = strings.TrimSpace(os.Getenv("SLACK_CLIENT_ID"))
+	cfg.SlackClientSecret = os.Getenv("SLACK_CLIENT_SECRET")
+	cfg.SlackAllowedTeams = parseProjectKeys(os.Getenv("SLACK_ALLOWED_TEAMS"))
+	cfg.SlackOAuthRedirectURL = strings.TrimSpace(os.Getenv("SLACK_OAUTH_REDIRECT_URL"))
+	if cfg.SlackOAuthRedirectURL == "" && cfg.PublicBaseURL != "" {
+		cfg.SlackOAuthRedirectURL = cfg.PublicBaseURL + "/slack/oauth/callback"
+	}
+	cfg.SlackOAuthBotScopes = getEnv("SLACK_OAUTH_BOT_SCOPES", "channels:history,channels:read,chat:write,groups:history,im:history,mpim:history,im:write,files:read,commands,reactions:read,links:read")
+	cfg.SlackOAuthUserScopes = getEnv("SLACK_OAUTH_USER_SCOPES", "channels:history,groups:history,im:history,mpim:history,search:read,users:read,users:read.email,files:read")
+	cfg.SlackTeamSkills = parseTeamLists(os.Getenv("SLACK_TEAM_SKILLS"))
+	cfg.SlackTeamJiraProjects = parseTeamLists(os.Getenv("SLACK_TEAM_JIRA_PROJECTS"))
+	cfg.SlackTeamMetabaseDBs = make(map[string][]int)
+	for team, ids := range parseTeamLists(os.Getenv("SLACK_TEAM_METABASE_DBS")) {
+		for _, id := range ids {
+			if n, err := strconv.Atoi(id); err == nil && n >= 0 {
+				cfg.SlackTeamMetabaseDBs[team] = append(cfg.SlackTeamMetabaseDBs[team], n)
+			}
+		}
+	}
 
 	pages := getEnv("SLACK_SEARCH_MAX_PAGES", "10")
 	if n, err := strconv.Atoi(pages); err == nil {
@@ -245,6 +575,191 @@ func parseProjectNameMap(s string) map[string]string {
 	return m
 }
 
+// parseModelProviders parses "model1=provider1,model2=provider2" into a map
+// from model name (or "prefix*" pattern) to a lowercase provider name.  The
+// "=" separator is used because local model names often contain ":".
+// Malformed or empty entries are silently ignored.
+func parseModelProviders(s string) map[string]string {
+	m := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			continue
+		}
+		model := strings.TrimSpace(entry[:i])
+		provider := strings.ToLower(strings.TrimSpace(entry[i+1:]))
+		if model != "" && provider != "" {
+			m[model] = provider
+		}
+	}
+	return m
+}
+
+// ModelPrice is the list price of a model in USD per million tokens.
+type ModelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// parsePrices parses "model1=2.5/10,model2=0.15/0.6" (input/output USD per
+// million tokens).  Malformed or empty entries are silently ignored.
+func parsePrices(s string) map[string]ModelPrice {
+	m := make(map[string]ModelPrice)
+	for _, entry := range strings.Split(s, ",") {
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			continue
+		}
+		model := strings.TrimSpace(entry[:i])
+		in, out, ok := strings.Cut(entry[i+1:], "/")
+		if !ok || model == "" {
+			continue
+		}
+		pi, err1 := strconv.ParseFloat(strings.TrimSpace(in), 64)
+		po, err2 := strconv.ParseFloat(strings.TrimSpace(out), 64)
+		if err1 != nil || err2 != nil || pi < 0 || po < 0 {
+			continue
+		}
+		m[model] = ModelPrice{Input: pi, Output: po}
+	}
+	return m
+}
+
+// parseTeamLists parses "T0123=A|B,T0456=C" into team ID → values.
+// Malformed or empty entries are silently ignored.
+func parseTeamLists(s string) map[string][]string {
+	m := make(map[string][]string)
+	for _, entry := range strings.Split(s, ",") {
+		team, list, ok := strings.Cut(entry, "=")
+		team = strings.TrimSpace(team)
+		if !ok || team == "" {
+			continue
+		}
+		for _, v := range strings.Split(list, "|") {
+			if v = strings.TrimSpace(v); v != "" {
+				m[team] = append(m[team], v)
+			}
+		}
+	}
+	return m
+}
+
+// parseIntMap parses "key1=8192,key2=32768" into a map of positive
+// integers.  Malformed or empty entries are silently ignored.
+func parseIntMap(s string) map[string]int {
+	m := make(map[string]int)
+	for _, entry := range strings.Split(s, ",") {
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			continue
+		}
+		key := strings.TrimSpace(entry[:i])
+		n, err := strconv.Atoi(strings.TrimSpace(entry[i+1:]))
+		if key == "" || err != nil || n <= 0 {
+			continue
+		}
+		m[key] = n
+	}
+	return m
+}
+
+// parseDurationMap parses "key1=30s,key2=2m" into a map of positive
+// durations.  Malformed or empty entries are silently ignored.
+func parseDurationMap(s string) map[string]time.Duration {
+	m := make(map[string]time.Duration)
+	for _, entry := range strings.Split(s, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(val))
+		if key = strings.TrimSpace(key); key == "" || err != nil || d <= 0 {
+			continue
+		}
+		m[key] = d
+	}
+	return m
+}
+
+// parseStringMap parses "key1=value1,key2=value2" into a map.  Malformed or
+// empty entries are silently ignored.
+func parseStringMap(s string) map[string]string {
+	m := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		if !ok || key == "" || val == "" {
+			continue
+		}
+		m[key] = val
+	}
+	return m
+}
+
+// parseReactionWorkflows parses "emoji=action,CHANNEL/emoji=action" into a
+// map.  Colons around emoji names are dropped; an empty action is kept so a
+// channel can disable a global mapping.
+func parseReactionWorkflows(s string) map[string]string {
+	m := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		key, action, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		channel, emoji, scoped := strings.Cut(strings.TrimSpace(key), "/")
+		if !scoped {
+			channel, emoji = "", channel
+		}
+		emoji = strings.ToLower(strings.Trim(strings.TrimSpace(emoji), ":"))
+		if emoji == "" {
+			continue
+		}
+		if scoped {
+			emoji = strings.TrimSpace(channel) + "/" + emoji
+		}
+		m[emoji] = strings.ToLower(strings.TrimSpace(action))
+	}
+	return m
+}
+
+// ReactionAction returns the workflow mapped to emoji in channel, or "" when
+// the reaction triggers nothing there.
+func (c Config) ReactionAction(channel, emoji string) string {
+	if action, ok := c.SlackReactionWorkflows[channel+"/"+emoji]; ok {
+		return action
+	}
+	return c.SlackReactionWorkflows[emoji]
+}
+
+// SkillDeadline returns the deadline of a context action of the given kind:
+// its SKILL_TIMEOUTS entry, else def (the skill's own default, 0 when it
+// declares none), else SKILL_TIMEOUT.
+func (c Config) SkillDeadline(kind string, def time.Duration) time.Duration {
+	if d, ok := c.SkillTimeouts[kind]; ok {
+		return d
+	}
+	if def > 0 {
+		return def
+	}
+	if c.SkillTimeout > 0 {
+		return c.SkillTimeout
+	}
+	return 45 * time.Second
+}
+
+// RoutingModel returns LLMRouterModel when configured, or def otherwise.
+// Callers pass the model they would use without the override.
+func (c Config) RoutingModel(def string) string {
+	if m := strings.TrimSpace(c.LLMRouterModel); m != "" {
+		return m
+	}
+	return def
+}
+
 // JiraEnabled reports whether Jira credentials have been provided.
 func (c Config) JiraEnabled() bool {
 	return strings.TrimSpace(c.JiraBaseURL) != ""
@@ -255,6 +770,88 @@ func (c Config) MetabaseEnabled() bool {
 	return strings.TrimSpace(c.MetabaseBaseURL) != ""
 }
 
+// SlackOAuthEnabled reports whether the OAuth install flow is configured:
+// it needs the app credentials and at least one allowed workspace.
+func (c Config) SlackOAuthEnabled() bool {
+	return c.SlackClientID != "" && c.SlackClientSecret != "" && len(c.SlackAllowedTeams) > 0
+}
+
+// SlackTeamAllowed reports whether teamID is listed in SLACK_ALLOWED_TEAMS.
+func (c Config) SlackTeamAllowed(teamID string) bool {
+	for _, t := range c.SlackAllowedTeams {
+		if teamID != "" && t == teamID {
+			return true
+		}
+	}
+	return false
+}
+
+// SkillGranted reports whether a workspace may use the skill of kind.  The
+// env tokens' workspace ("") may use every skill; an installed workspace only
+// those in its SLACK_TEAM_SKILLS entry.
+func (c Config) SkillGranted(teamID, kind string) bool {
+	if teamID == "" {
+		return true
+	}
+	for _, k := range c.SlackTeamSkills[teamID] {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// JiraProjectsFor returns the Jira project keys a workspace may use.  teamID
+// is the team of an OAuth installation, or "" for the env tokens' workspace,
+// which uses JiraProjectKeys.  An installed workspace gets only its
+// SLACK_TEAM_JIRA_PROJECTS entry, and none without one.
+func (c Config) JiraProjectsFor(teamID string) []string {
+	if teamID == "" {
+		return c.JiraProjectKeys
+	}
+	return c.SlackTeamJiraProjects[teamID]
+}
+
+// JiraProjectNameMapFor returns JiraProjectNameMap restricted to the
+// workspace's projects.
+func (c Config) JiraProjectNameMapFor(teamID string) map[string]string {
+	if teamID == "" {
+		return c.JiraProjectNameMap
+	}
+	allowed := make(map[string]bool)
+	for _, k := range c.JiraProjectsFor(teamID) {
+		allowed[strings.ToUpper(k)] = true
+	}
+	m := make(map[string]string)
+	for name, key := range c.JiraProjectNameMap {
+		if allowed[strings.ToUpper(key)] {
+			m[name] = key
+		}
+	}
+	return m
+}
+
+// MetabaseDBAllowed reports whether a workspace may query a Metabase
+// database.  The env tokens' workspace ("") may query every database; an
+// installed workspace only those in its SLACK_TEAM_METABASE_DBS entry.
+func (c Config) MetabaseDBAllowed(teamID string, dbID int) bool {
+	if teamID == "" {
+		return true
+	}
+	for _, id := range c.SlackTeamMetabaseDBs[teamID] {
+		if id == dbID {
+			return true
+		}
+	}
+	return false
+}
+
+// TranscriptionEnabled reports whether audio and video attachments can be
+// transcribed.
+func (c Config) TranscriptionEnabled() bool {
+	return strings.TrimSpace(c.TranscriptionBaseURL) != "" && strings.TrimSpace(c.TranscriptionModel) != ""
+}
+
 // OutlineEnabled reports whether Outline credentials have been provided.
 func (c Config) OutlineEnabled() bool {
 	return strings.TrimSpace(c.OutlineBaseURL) != "" && strings.TrimSpace(c.OutlineAPIKey) != ""
@@ -104,7 +104,7 @@ func NewClient(cfg config.Config, pdfParser, docxParser, xlsxParser func([]byte)
 }
 
 // SearchFiles queries Drive for files whose full-text content contains query.
-func (c *Client) SearchFiles(query string) ([]*SearchResult, error) {
+func (c *Client) SearchFiles(ctx context.Context, query string) ([]*SearchResult, error) {
 	q := fmt.Sprintf("fullText contains %q and trashed = false", query)
 	if c.folderID != "" {
 		q += fmt.Sprintf(" and %q in parents", c.folderID)
@@ -113,6 +113,7 @@ func (c *Client) SearchFiles(query string) ([]*SearchResult, error) {
 		Q(q).
 		Fields("files(id, name, mimeType, webViewLink)").
 		PageSize(int64(c.searchLimit)).
+		Context(ctx).
 		Do()
 	if err != nil {
 		return nil, fmt.Errorf("googledrive: files.list: %w", err)
@@ -166,19 +167,19 @@ func filterXLSXBySheet(data []byte, sheetName string) (content string, sheetName
 
 // FetchContent downloads or exports the file and populates r.Content with plain text.
 // For Google Sheets, sheetName filters to a specific tab; empty = ask when multiple sheets exist.
-func (c *Client) FetchContent(r *SearchResult, sheetName string) error {
+func (c *Client) FetchContent(ctx context.Context, r *SearchResult, sheetName string) error {
 	var data []byte
 	var err error
 
 	switch r.MimeType {
 	case "application/vnd.google-apps.document":
-		data, err = c.export(r.ID, "text/plain")
+		data, err = c.export(ctx, r.ID, "text/plain")
 	case "application/vnd.google-apps.spreadsheet":
-		data, err = c.export(r.ID, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		data, err = c.export(ctx, r.ID, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
 	case "application/vnd.google-apps.presentation":
-		data, err = c.export(r.ID, "text/plain")
+		data, err = c.export(ctx, r.ID, "text/plain")
 	default:
-		data, err = c.download(r.ID)
+		data, err = c.download(ctx, r.ID)
 	}
 	if err != nil {
 		return err
@@ -197,7 +198,7 @@ func (c *Client) FetchContent(r *SearchResult, sheetName string) error {
 			log.Printf("[GDRIVE] filterXLSXBySheet failed for %q: %v", r.Name, fErr)
 			// For native Google Sheets, fall back to CSV export (more reliable than XLSX)
 			if r.MimeType == "application/vnd.google-apps.spreadsheet" {
-				csvData, csvErr := c.export(r.ID, "text/csv")
+				csvData, csvErr := c.export(ctx, r.ID, "text/csv")
 				if csvErr == nil && len(csvData) > 0 {
 					log.Printf("[GDRIVE] CSV fallback succeeded for %q (%d bytes)", r.Name, len(csvData))
 					r.Content = string(csvData)
@@ -242,8 +243,8 @@ func (c *Client) FetchContent(r *SearchResult, sheetName string) error {
 	return nil
 }
 
-func (c *Client) export(fileID, mimeType string) ([]byte, error) {
-	resp, err := c.svc.Files.Export(fileID, mimeType).Download()
+func (c *Client) export(ctx context.Context, fileID, mimeType string) ([]byte, error) {
+	resp, err := c.svc.Files.Export(fileID, mimeType).Context(ctx).Download()
 	if err != nil {
 		return nil, fmt.Errorf("googledrive: export %s: %w", fileID, err)
 	}
@@ -255,8 +256,8 @@ func (c *Client) export(fileID, mimeType string) ([]byte, error) {
 	return data, nil
 }
 
-func (c *Client) download(fileID string) ([]byte, error) {
-	resp, err := c.svc.Files.Get(fileID).Download()
+func (c *Client) download(ctx context.Context, fileID string) ([]byte, error) {
+	resp, err := c.svc.Files.Get(fileID).Context(ctx).Download()
 	if err != nil {
 		return nil, fmt.Errorf("googledrive: download %s: %w", fileID, err)
 	}
@@ -270,13 +271,13 @@ func (c *Client) download(fileID string) ([]byte, error) {
 
 // SearchAndFetch combines SearchFiles and FetchContent into a single call.
 // sheetName is forwarded to FetchContent for Google Sheets tab filtering.
-func (c *Client) SearchAndFetch(query, sheetName string) ([]*SearchResult, error) {
-	results, err := c.SearchFiles(query)
+func (c *Client) SearchAndFetch(ctx context.Context, query, sheetName string) ([]*SearchResult, error) {
+	results, err := c.SearchFiles(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	for _, r := range results {
-		if fErr := c.FetchContent(r, sheetName); fErr != nil {
+		if fErr := c.FetchContent(ctx, r, sheetName); fErr != nil {
 			log.Printf("[GDRIVE] FetchContent %q failed: %v", r.Name, fErr)
 		}
 	}
@@ -319,8 +320,8 @@ func FormatContext(results []*SearchResult, maxCharsPerDoc int) string {
 
 // FetchByFileID retrieves a specific file by its Google Drive file ID.
 // sheetName filters to a specific tab for Google Sheets; empty = ask when multiple sheets exist.
-func (c *Client) FetchByFileID(fileID, sheetName string) (*SearchResult, error) {
-	f, err := c.svc.Files.Get(fileID).Fields("id, name, mimeType, webViewLink").Do()
+func (c *Client) FetchByFileID(ctx context.Context, fileID, sheetName string) (*SearchResult, error) {
+	f, err := c.svc.Files.Get(fileID).Fields("id, name, mimeType, webViewLink").Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("googledrive: get file %s: %w", fileID, err)
 	}
@@ -330,7 +331,7 @@ func (c *Client) FetchByFileID(fileID, sheetName string) (*SearchResult, error)
 		MimeType: f.MimeType,
 		WebURL:   f.WebViewLink,
 	}
-	if fErr := c.FetchContent(r, sheetName); fErr != nil {
+	if fErr := c.FetchContent(ctx, r, sheetName); fErr != nil {
 		return nil, fErr
 	}
 	return r, nil
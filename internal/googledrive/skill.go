@@ -0,0 +1,122 @@
+package googledrive
+
+import (
+	"context"
+	"log"
+	"regexp"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/skill"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// ActionSearch is the action kind of the Google Drive skill.
+const ActionSearch = "googledrive_search"
+
+// reSheetName matches "aba X" or "tab X" (Portuguese/English) to detect sheet name in user messages.
+var reSheetName = regexp.MustCompile(`(?i)\bab[ao]\s+([^\s,\.]+)`)
+
+// Skill searches Google Drive (googledrive_search) and reads Drive/Sheets
+// URLs pasted in the message before routing.
+type Skill struct {
+	client *Client
+}
+
+// NewSkill returns the Google Drive skill.  client is nil when Google Drive
+// is not configured.
+func NewSkill(client *Client) *Skill {
+	return &Skill{client: client}
+}
+
+func (*Skill) Kind() string                   { return ActionSearch }
+func (*Skill) Label() string                  { return "DOCUMENTOS DO GOOGLE DRIVE" }
+func (*Skill) DisplayName() string            { return "Arquivos do Google Drive" }
+func (*Skill) Enabled(cfg config.Config) bool { return cfg.GoogleDriveEnabled() }
+func (*Skill) Weight() float64                { return 1.5 }
+
+func (*Skill) Tools() []llm.ToolDef {
+	return []llm.ToolDef{{Kind: ActionSearch, Description: "Buscar arquivos no Google Drive (planilhas, documentos, apresentações).", Params: []llm.ToolParam{
+		{Name: "query", Type: "string", Check: llm.NonEmpty, Description: "2–4 termos-chave do arquivo ou conteúdo."},
+		{Name: "sheet_name", Type: "string", Optional: true, Description: "Nome exato da aba mencionada pelo usuário, ou null."},
+	}}}
+}
+
+func (*Skill) RouterPrompt(skill.Request) llm.RouterSnippet {
+	return llm.RouterSnippet{
+		Context:  "Google Drive está configurado e disponível para busca de documentos e arquivos internos.",
+		Source:   "- Google Drive: arquivos internos, planilhas, apresentações, documentos compartilhados, relatórios, contratos, propostas.",
+		Examples: []string{`{"kind": "googledrive_search", "query": "relatório vendas 2024", "sheet_name": "Transporte"}`},
+		Rules:    "- Inclua googledrive_search quando a resposta provavelmente está em um arquivo compartilhado no Drive (planilha, apresentação, PDF, relatório, contrato). Preencha query com 2–4 termos-chave descritivos do arquivo ou conteúdo buscado. Quando o usuário mencionar uma aba/sheet específica (ex: \"aba Transporte\", \"na aba X\", \"tab Y\"), preencha sheet_name com o nome exato mencionado; caso contrário, omita sheet_name.",
+	}
+}
+
+// AnswerHints tells the model to decline Drive questions when Drive is off.
+func (*Skill) AnswerHints(_ []llm.ContextSection, enabled bool) []string {
+	if enabled {
+		return nil
+	}
+	return []string{"GOOGLE DRIVE NÃO CONFIGURADO: A integração com o Google Drive não está habilitada nesta instalação. Se o usuário pedir arquivos, documentos ou planilhas do Drive, informe gentilmente que essa integração não está disponível e sugira que o administrador configure as variáveis GOOGLE_DRIVE_CREDENTIALS_JSON ou GOOGLE_DRIVE_CREDENTIALS_PATH."}
+}
+
+// Prefetch directly fetches any Google Drive/Sheets URLs present in the message.
+func (k *Skill) Prefetch(ctx context.Context, req skill.Request) (skill.ContextBlock, skill.Sources, bool) {
+	if k.client == nil {
+		return skill.ContextBlock{}, "", false
+	}
+	driveFileIDs := ExtractFileIDsFromText(req.Question)
+	if len(driveFileIDs) == 0 {
+		return skill.ContextBlock{}, "", false
+	}
+	detectedSheetName := ""
+	if m := reSheetName.FindStringSubmatch(req.Question); len(m) >= 2 {
+		detectedSheetName = m[1]
+	}
+	req.Report("lendo arquivo do Google Drive…")
+	var directResults []*SearchResult
+	for _, fileID := range driveFileIDs {
+		log.Printf("%s googleDriveDirectFetch fileID=%q sheetName=%q", req.Tag(), fileID, detectedSheetName)
+		r, err := k.client.FetchByFileID(ctx, fileID, detectedSheetName)
+		if err != nil {
+			log.Printf("%s googleDriveDirectFetch failed: %v", req.Warn(), err)
+			continue
+		}
+		directResults = append(directResults, r)
+	}
+	if len(directResults) == 0 {
+		return skill.ContextBlock{}, "", false
+	}
+	block := skill.ContextBlock{
+		Kind:  ActionSearch,
+		Text:  FormatContext(directResults, 50000),
+		Count: len(directResults),
+	}
+	log.Printf("%s googleDriveDirectFetch files=%d chars=%d", req.Tag(), len(directResults), len(block.Text))
+	return block, skill.Sources(FormatSources(directResults)), true
+}
+
+func (k *Skill) Execute(ctx context.Context, req skill.Request, action llm.ActionDescriptor) (skill.ContextBlock, skill.Sources, error) {
+	block := skill.ContextBlock{Kind: ActionSearch}
+	query := action.Query
+	if k.client == nil || query == "" {
+		return block, "", skill.ErrSkipped
+	}
+	sheetName := action.StringArg("sheet_name")
+	log.Printf("%s googleDriveSearch query=%q sheetName=%q", req.Tag(), query, sheetName)
+	req.Report("buscando no Google Drive…")
+	results, err := k.client.SearchAndFetch(ctx, query, sheetName)
+	if err != nil {
+		return block, "", err
+	}
+	block.Count = len(results)
+	block.Text = FormatContext(results, 30000)
+	log.Printf("%s googleDriveContext files=%d chars=%d", req.Tag(), len(results), len(block.Text))
+	if block.Text == "" {
+		block.Text = "[AVISO: A busca no Google Drive não retornou arquivos. Informe ao usuário que não foram encontrados documentos relevantes para a consulta realizada.]"
+		return block, "", nil
+	}
+	return block, skill.Sources(FormatSources(results)), nil
+}
+
+// Telemetry is a no-op: the events table has no Google Drive columns yet.
+func (*Skill) Telemetry(*telemetry.Event, skill.ContextBlock, error) {}
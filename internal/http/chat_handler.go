@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -13,7 +14,7 @@ import (
 )
 
 // ChatHandler handles POST /api/chat requests.  It accepts either
-// application/json or multipart/form-data, calls ProcessDirect synchronously,
+// application/json or multipart/form-data, calls AnswerChat synchronously,
 // and writes the answer as JSON.
 type ChatHandler struct {
 	Service *app.Service
@@ -96,7 +97,8 @@ func (h *ChatHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 						log.Printf("[CHAT][WARN] failed to open uploaded file %q: %v", fh.Filename, err)
 						continue
 					}
-					data, err := io.ReadAll(io.LimitReader(f, 20*1024*1024))
+					// 25 MB: the largest recording the transcription endpoint accepts.
+					data, err := io.ReadAll(io.LimitReader(f, 25*1024*1024))
 					f.Close()
 					if err != nil {
 						log.Printf("[CHAT][WARN] failed to read uploaded file %q: %v", fh.Filename, err)
@@ -134,9 +136,15 @@ func (h *ChatHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		req.ThreadID = fmt.Sprintf("direct-%d", time.Now().UnixNano())
 	}
 
-	answer, err := h.Service.ProcessDirect(req.Message, req.UserID, req.ThreadID, req.History, files)
+	answer, err := h.Service.AnswerChat(r.Context(), req.Message, req.UserID, req.ThreadID, req.History, files)
+	var budgetErr *app.BudgetError
+	if errors.As(err, &budgetErr) {
+		log.Printf("[CHAT] token budget exceeded user=%q dur=%s", req.UserID, time.Since(start))
+		writeJSON(w, http.StatusTooManyRequests, chatError{budgetErr.Message})
+		return
+	}
 	if err != nil {
-		log.Printf("[CHAT][ERR] ProcessDirect: %v dur=%s", err, time.Since(start))
+		log.Printf("[CHAT][ERR] AnswerChat: %v dur=%s", err, time.Since(start))
 		writeJSON(w, http.StatusInternalServerError, chatError{"internal error"})
 		return
 	}
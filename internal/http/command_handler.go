@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/app"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+)
+
+// CommandHandler handles POST /slack/commands, the slash command endpoint
+// (/jarvis ask, jira create, sql, export, agendamentos, help).  It verifies
+// the Slack signature, acks within the 3-second limit and delivers slow
+// answers through the command's response_url.
+type CommandHandler struct {
+	Slack   *slack.Client
+	Service *app.Service
+}
+
+// NewCommandHandler constructs a new CommandHandler.
+func NewCommandHandler(slackClient *slack.Client, service *app.Service) *CommandHandler {
+	return &CommandHandler{Slack: slackClient, Service: service}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *CommandHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	log.Printf("[HTTP] /slack/commands method=%s remote=%s", r.Method, r.RemoteAddr)
+	if r.Method != http.MethodPost {
+		http.Error(w, "method_not_allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rawBody, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		log.Printf("[ERR] read body: %v", err)
+		http.Error(w, "bad_request", 400)
+		return
+	}
+	if h.Slack == nil {
+		log.Printf("[SLACK] Slack client not configured — rejecting command")
+		http.Error(w, "service_unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if err := h.Slack.VerifySignature(r, rawBody); err != nil {
+		log.Printf("[SEC] signature verification failed: %v", err)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(rawBody))
+	if err != nil {
+		log.Printf("[ERR] parse command form: %v", err)
+		http.Error(w, "bad_request", 400)
+		return
+	}
+
+	ack := h.Handle(slack.ParseSlashCommand(form))
+	writeJSON(w, http.StatusOK, ack)
+	log.Printf("[HTTP] /slack/commands ack sent dur=%s", time.Since(start))
+}
+
+// HandleSocketEnvelope handles a "slash_commands" Socket Mode envelope and
+// returns the immediate reply, sent back as the envelope's ack payload.
+func (h *CommandHandler) HandleSocketEnvelope(se slack.SocketEnvelope) any {
+	var cmd slack.SlashCommand
+	if err := json.Unmarshal(se.Payload, &cmd); err != nil {
+		log.Printf("[ERR] unmarshal socket command: %v payload=%s", err, preview(string(se.Payload), 500))
+		return nil
+	}
+	return h.Handle(cmd)
+}
+
+// Handle returns the immediate reply to cmd and, when the subcommand needs
+// more than the ack window, starts it in the background.
+func (h *CommandHandler) Handle(cmd slack.SlashCommand) slack.CommandResponse {
+	log.Printf("[SLACK] command=%q text_len=%d user=%q channel=%q team=%q", cmd.Command, len(cmd.Text), cmd.UserID, cmd.ChannelID, cmd.TeamID)
+	if !h.Slack.KnowsTeam(cmd.TeamID) {
+		log.Printf("[SLACK] command from unknown team=%q (not installed) — ignoring", cmd.TeamID)
+		return slack.CommandResponse{ResponseType: "ephemeral", Text: "Este workspace não tem o app instalado. Peça a um admin para reinstalá-lo."}
+	}
+	ack, async := h.Service.CommandAck(cmd)
+	if async {
+		go h.Service.HandleCommand(slack.WithTeam(context.Background(), cmd.TeamID), cmd)
+	}
+	return ack
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// editDebounce is how long an edit waits for further edits before the
+	// question is answered again; only the last version is answered.
+	editDebounce = 3 * time.Second
+	// editMaxReanswers caps how many times one message is answered again
+	// within editWindow.
+	editMaxReanswers = 5
+	editWindow       = time.Hour
+)
+
+// editGuard protects against edit storms: it coalesces bursts of edits to
+// the same message and caps how often a message is re-answered.
+type editGuard struct {
+	mu      sync.Mutex
+	entries map[string]*editEntry
+}
+
+type editEntry struct {
+	timer *time.Timer
+	runs  int
+	first time.Time
+}
+
+func newEditGuard() *editGuard {
+	return &editGuard{entries: make(map[string]*editEntry)}
+}
+
+// schedule runs fn after editDebounce unless another edit of the same
+// message arrives first, in which case only the newer fn runs.  It reports
+// false, without scheduling, once the message hit editMaxReanswers.
+func (g *editGuard) schedule(channel, ts string, fn func()) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	for k, e := range g.entries {
+		if now.Sub(e.first) > editWindow {
+			delete(g.entries, k)
+		}
+	}
+	k := channel + ":" + ts
+	e := g.entries[k]
+	if e == nil {
+		e = &editEntry{first: now}
+		g.entries[k] = e
+	}
+	if e.timer != nil && e.timer.Stop() {
+		// The pending run was replaced before it started.
+		e.runs--
+	}
+	if e.runs >= editMaxReanswers {
+		return false
+	}
+	e.runs++
+	e.timer = time.AfterFunc(editDebounce, fn)
+	return true
+}
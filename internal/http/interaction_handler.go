@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/app"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+)
+
+// InteractionHandler handles POST /slack/interactions, where Slack sends
+// Block Kit button clicks, modal submissions and message shortcuts.  It verifies the signature
+// and resolves the pending state (long replies, Jira drafts) named by the
+// action payload.
+type InteractionHandler struct {
+	Slack   *slack.Client
+	Service *app.Service
+}
+
+// NewInteractionHandler constructs a new InteractionHandler.
+func NewInteractionHandler(slackClient *slack.Client, service *app.Service) *InteractionHandler {
+	return &InteractionHandler{Slack: slackClient, Service: service}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *InteractionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	log.Printf("[HTTP] /slack/interactions method=%s remote=%s", r.Method, r.RemoteAddr)
+	if r.Method != http.MethodPost {
+		http.Error(w, "method_not_allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rawBody, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		log.Printf("[ERR] read body: %v", err)
+		http.Error(w, "bad_request", 400)
+		return
+	}
+	if h.Slack == nil {
+		log.Printf("[SLACK] Slack client not configured — rejecting interaction")
+		http.Error(w, "service_unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if err := h.Slack.VerifySignature(r, rawBody); err != nil {
+		log.Printf("[SEC] signature verification failed: %v", err)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(rawBody))
+	if err != nil {
+		log.Printf("[ERR] parse interaction form: %v", err)
+		http.Error(w, "bad_request", 400)
+		return
+	}
+	var p slack.InteractionPayload
+	if err := json.Unmarshal([]byte(form.Get("payload")), &p); err != nil {
+		log.Printf("[ERR] unmarshal interaction: %v payload=%s", err, preview(form.Get("payload"), 500))
+		http.Error(w, "bad_request", 400)
+		return
+	}
+
+	if resp := h.Handle(p); resp != nil {
+		writeJSON(w, http.StatusOK, resp)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	log.Printf("[HTTP] /slack/interactions ack sent dur=%s", time.Since(start))
+}
+
+// HandleSocketEnvelope handles an "interactive" Socket Mode envelope and
+// returns the ack payload (modal validation errors), if any.
+func (h *InteractionHandler) HandleSocketEnvelope(se slack.SocketEnvelope) any {
+	var p slack.InteractionPayload
+	if err := json.Unmarshal(se.Payload, &p); err != nil {
+		log.Printf("[ERR] unmarshal socket interaction: %v payload=%s", err, preview(string(se.Payload), 500))
+		return nil
+	}
+	if resp := h.Handle(p); resp != nil {
+		return resp
+	}
+	return nil
+}
+
+// Handle routes an interaction.  Modal submissions are answered inline
+// (Slack waits for the validation result); button clicks are acknowledged
+// right away and resolved in the background.
+func (h *InteractionHandler) Handle(p slack.InteractionPayload) *slack.ViewResponse {
+	log.Printf("[SLACK] interaction type=%q user=%q team=%q actions=%d", p.Type, p.User.ID, p.Team.ID, len(p.Actions))
+	if !h.Slack.KnowsTeam(p.Team.ID) {
+		log.Printf("[SLACK] interaction from unknown team=%q (not installed) — ignoring", p.Team.ID)
+		return nil
+	}
+	base := slack.WithTeam(context.Background(), p.Team.ID)
+	switch p.Type {
+	case "view_submission":
+		ctx, cancel := context.WithTimeout(base, 2*time.Second)
+		defer cancel()
+		return h.Service.HandleViewSubmission(ctx, p)
+	case "block_actions":
+		go h.Service.HandleBlockAction(base, p)
+	case "message_action":
+		go h.Service.HandleShortcut(base, p)
+	default:
+		log.Printf("[SLACK] ignoring interaction type=%q", p.Type)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/DanielFillol/Jarvis/internal/slack"
+)
+
+// MetricsHandler handles GET /metrics/slack: the Slack client's rate-limit
+// counters per Web API method, as JSON.  It is protected by the same API key
+// as /api/chat.
+type MetricsHandler struct {
+	Slack  *slack.Client
+	APIKey string
+}
+
+// NewMetricsHandler constructs a new MetricsHandler.
+func NewMetricsHandler(slackClient *slack.Client, apiKey string) *MetricsHandler {
+	return &MetricsHandler{Slack: slackClient, APIKey: apiKey}
+}
+
+type metricsResponse struct {
+	Slack map[string]slack.MethodStats `json:"slack"`
+}
+
+// ServeHTTP implements http.Handler.
+func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, chatError{"method not allowed"})
+		return
+	}
+	if h.APIKey != "" && r.Header.Get("Authorization") != "Bearer "+h.APIKey {
+		writeJSON(w, http.StatusUnauthorized, chatError{"unauthorized"})
+		return
+	}
+	out := metricsResponse{Slack: map[string]slack.MethodStats{}}
+	if h.Slack != nil {
+		out.Slack = h.Slack.RateLimitStats()
+	}
+	writeJSON(w, http.StatusOK, out)
+}
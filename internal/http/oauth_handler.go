@@ -0,0 +1,200 @@
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+)
+
+// oauthStateTTL bounds the time between /slack/install and the callback.
+const oauthStateTTL = 10 * time.Minute
+
+// oauthStateCookie holds the nonce of the install started in this browser;
+// the callback only accepts a state carrying the same nonce.
+const oauthStateCookie = "jarvis_slack_oauth"
+
+// OAuthHandler serves the Slack OAuth v2 install flow: GET /slack/install
+// redirects to Slack's consent screen and GET /slack/oauth/callback trades
+// the returned code for the workspace's tokens and stores the installation.
+type OAuthHandler struct {
+	Slack        *slack.Client
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	BotScopes    string
+	UserScopes   string
+	AllowedTeams []string
+}
+
+// NewOAuthHandler constructs a new OAuthHandler from the SLACK_CLIENT_* and
+// SLACK_OAUTH_* settings.
+func NewOAuthHandler(slackClient *slack.Client, cfg config.Config) *OAuthHandler {
+	return &OAuthHandler{
+		Slack:        slackClient,
+		ClientID:     cfg.SlackClientID,
+		ClientSecret: cfg.SlackClientSecret,
+		RedirectURL:  cfg.SlackOAuthRedirectURL,
+		BotScopes:    cfg.SlackOAuthBotScopes,
+		UserScopes:   cfg.SlackOAuthUserScopes,
+		AllowedTeams: cfg.SlackAllowedTeams,
+	}
+}
+
+// Install handles GET /slack/install.
+func (h *OAuthHandler) Install(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[HTTP] /slack/install method=%s remote=%s", r.Method, r.RemoteAddr)
+	if r.Method != http.MethodGet {
+		http.Error(w, "method_not_allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := url.Values{}
+	q.Set("client_id", h.ClientID)
+	q.Set("scope", h.BotScopes)
+	if h.UserScopes != "" {
+		q.Set("user_scope", h.UserScopes)
+	}
+	if h.RedirectURL != "" {
+		q.Set("redirect_uri", h.RedirectURL)
+	}
+	state, nonce := h.newState(time.Now())
+	q.Set("state", state)
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    nonce,
+		Path:     "/slack/oauth",
+		MaxAge:   int(oauthStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "https://slack.com/oauth/v2/authorize?"+q.Encode(), http.StatusFound)
+}
+
+// Callback handles GET /slack/oauth/callback.
+func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[HTTP] /slack/oauth/callback method=%s remote=%s", r.Method, r.RemoteAddr)
+	if r.Method != http.MethodGet {
+		http.Error(w, "method_not_allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		log.Printf("[SLACK] oauth install denied: %s", e)
+		writeOAuthPage(w, http.StatusOK, "Instalação cancelada", "A instalação não foi autorizada no Slack.")
+		return
+	}
+	var nonce string
+	if c, err := r.Cookie(oauthStateCookie); err == nil {
+		nonce = c.Value
+	}
+	http.SetCookie(w, &http.Cookie{Name: oauthStateCookie, Path: "/slack/oauth", MaxAge: -1})
+	if err := h.checkState(q.Get("state"), nonce, time.Now()); err != nil {
+		log.Printf("[SEC] oauth state rejected: %v", err)
+		writeOAuthPage(w, http.StatusBadRequest, "Link expirado", "Comece a instalação de novo em /slack/install.")
+		return
+	}
+	code := q.Get("code")
+	if code == "" {
+		writeOAuthPage(w, http.StatusBadRequest, "Requisição inválida", "O Slack não enviou o código de autorização.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+	in, err := h.Slack.ExchangeOAuthCode(ctx, h.ClientID, h.ClientSecret, code, h.RedirectURL)
+	if err != nil {
+		log.Printf("[ERR] oauth exchange: %v", err)
+		writeOAuthPage(w, http.StatusBadGateway, "Falha na instalação", "Não consegui concluir a instalação com o Slack. Tente novamente.")
+		return
+	}
+	if !h.allowed(in.TeamID) {
+		log.Printf("[SEC] oauth install from team=%q name=%q by=%q is not in SLACK_ALLOWED_TEAMS — revoking", in.TeamID, in.TeamName, in.InstalledBy)
+		for _, token := range []string{in.BotToken, in.UserToken} {
+			if token == "" {
+				continue
+			}
+			if err := h.Slack.RevokeToken(ctx, token); err != nil {
+				log.Printf("[ERR] revoke token team=%q: %v", in.TeamID, err)
+			}
+		}
+		writeOAuthPage(w, http.StatusForbidden, "Workspace não autorizado", "Este workspace não está autorizado a instalar o app. Fale com o time responsável pelo Jarvis.")
+		return
+	}
+	if err := h.Slack.Installations.Save(ctx, in); err != nil {
+		log.Printf("[ERR] save installation team=%q: %v", in.TeamID, err)
+		writeOAuthPage(w, http.StatusInternalServerError, "Falha na instalação", "Não consegui salvar a instalação. Tente novamente.")
+		return
+	}
+	log.Printf("[SLACK] installed team=%q name=%q by=%q user_token=%t", in.TeamID, in.TeamName, in.InstalledBy, in.UserToken != "")
+	writeOAuthPage(w, http.StatusOK, "Instalado ✅", fmt.Sprintf("O app foi instalado no workspace %s. Já pode me mencionar por lá.", in.TeamName))
+}
+
+func (h *OAuthHandler) allowed(teamID string) bool {
+	for _, t := range h.AllowedTeams {
+		if teamID != "" && t == teamID {
+			return true
+		}
+	}
+	return false
+}
+
+// newState returns the state "<unix>.<nonce>.<hmac>" and its nonce, which
+// is also set as a cookie: the callback accepts the state until
+// oauthStateTTL and only in the browser that started the install.
+func (h *OAuthHandler) newState(now time.Time) (state, nonce string) {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	nonce = hex.EncodeToString(b)
+	payload := strconv.FormatInt(now.Unix(), 10) + "." + nonce
+	return payload + "." + h.sign(payload), nonce
+}
+
+func (h *OAuthHandler) checkState(state, nonce string, now time.Time) error {
+	i := strings.LastIndex(state, ".")
+	if i < 0 {
+		return fmt.Errorf("malformed state")
+	}
+	payload, sig := state[:i], state[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(h.sign(payload))) {
+		return fmt.Errorf("bad state signature")
+	}
+	ts, stateNonce, _ := strings.Cut(payload, ".")
+	if nonce == "" || !hmac.Equal([]byte(stateNonce), []byte(nonce)) {
+		return fmt.Errorf("state does not match the install cookie")
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed state timestamp")
+	}
+	if now.Sub(time.Unix(sec, 0)) > oauthStateTTL {
+		return fmt.Errorf("state expired")
+	}
+	return nil
+}
+
+func (h *OAuthHandler) sign(payload string) string {
+	mac := hmac.New(sha256.New, []byte(h.ClientSecret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// writeOAuthPage renders the minimal page shown at the end of the flow.
+func writeOAuthPage(w http.ResponseWriter, status int, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<!doctype html><html><head><meta charset=\"utf-8\"><title>%s</title></head><body style=\"font-family:sans-serif;max-width:40em;margin:4em auto\"><h1>%s</h1><p>%s</p></body></html>",
+		html.EscapeString(title), html.EscapeString(title), html.EscapeString(message))
+}
@@ -1,10 +1,12 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,14 +19,20 @@ import (
 // It performs signature verification, URL verification and routing
 // of message events to the Jarvis service.  Non-message events are
 // ignored.
+//
+// Deliveries are deduplicated through Dedup, shared by the HTTP endpoint and
+// Socket Mode, so a retried event never produces a second answer.
 type SlackHandler struct {
 	Slack   *slack.Client
 	Service *app.Service
+	Dedup   *slack.EventDedup
+
+	edits *editGuard
 }
 
 // NewSlackHandler constructs a new SlackHandler.
-func NewSlackHandler(slackClient *slack.Client, service *app.Service) *SlackHandler {
-	return &SlackHandler{Slack: slackClient, Service: service}
+func NewSlackHandler(slackClient *slack.Client, service *app.Service, dedup *slack.EventDedup) *SlackHandler {
+	return &SlackHandler{Slack: slackClient, Service: service, Dedup: dedup, edits: newEditGuard()}
 }
 
 // ServeHTTP implements http.Handler.  It acknowledges requests from
@@ -76,15 +84,89 @@ func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Printf("[SEC] signature OK")
 
-	// Ack immediately
+	// Ack immediately (flushed, so dedup lookups never delay it)
 	w.WriteHeader(200)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
 	log.Printf("[HTTP] /slack/events ack sent")
 
+	retry, _ := strconv.Atoi(r.Header.Get("X-Slack-Retry-Num"))
+	if retry > 0 {
+		log.Printf("[SLACK] retry num=%d reason=%q event_id=%q", retry, r.Header.Get("X-Slack-Retry-Reason"), env.EventID)
+	}
+	h.Dispatch(env, retry)
+	log.Printf("[HTTP] /slack/events done dur=%s", time.Since(start))
+}
+
+// HandleSocketEnvelope routes an already acknowledged Socket Mode envelope
+// through the same path as the Events API endpoint.
+func (h *SlackHandler) HandleSocketEnvelope(se slack.SocketEnvelope) {
+	if se.Type != "events_api" {
+		log.Printf("[SLACK] ignoring socket envelope type=%q", se.Type)
+		return
+	}
+	var env slack.EventEnvelope
+	if err := json.Unmarshal(se.Payload, &env); err != nil {
+		log.Printf("[ERR] unmarshal socket payload: %v payload=%s", err, preview(string(se.Payload), 500))
+		return
+	}
+	if se.RetryAttempt > 0 {
+		log.Printf("[SLACK] retry num=%d reason=%q event_id=%q", se.RetryAttempt, se.RetryReason, env.EventID)
+	}
+	h.Dispatch(env, se.RetryAttempt)
+}
+
+// Dispatch handles an authenticated, acknowledged event envelope: it cascades
+// message deletions and hands summoned messages to the service in the
+// background.  Both transports (HTTP and Socket Mode) end up here.  retry is
+// the delivery attempt Slack reported (0 for the first one).
+func (h *SlackHandler) Dispatch(env slack.EventEnvelope, retry int) {
 	if env.Type != "event_callback" {
 		log.Printf("[SLACK] ignoring non event_callback")
 		return
 	}
 
+	// Events are answered with the tokens of the workspace they come from.
+	if !h.Slack.KnowsTeam(env.TeamID) {
+		log.Printf("[SLACK] event from unknown team=%q (not installed) — ignoring", env.TeamID)
+		return
+	}
+	base := slack.WithTeam(context.Background(), env.TeamID)
+
+	// Retries and cross-transport copies of an event already accepted are
+	// dropped, whether its processing is still running or has finished.
+	if !h.Dedup.Claim(base, slack.EventKey(env.EventID)) {
+		log.Printf("[SLACK] duplicate event_id=%q retry=%d — ignoring", env.EventID, retry)
+		return
+	}
+
+	// user_change and team_join carry a user object instead of a message.
+	var head struct {
+		Type string `json:"type"`
+	}
+	_ = json.Unmarshal(env.Event, &head)
+	if head.Type == "user_change" || head.Type == "team_join" {
+		// The user directory covers the env tokens' workspace only.
+		if !h.Slack.DefaultTeam(base) {
+			return
+		}
+		if err := h.Slack.Users.ApplyEvent(env.Event); err != nil {
+			log.Printf("[ERR] apply %s: %v", head.Type, err)
+		}
+		return
+	}
+	if head.Type == "app_uninstalled" {
+		if _, ok := h.Slack.Installations.Get(env.TeamID); ok {
+			if err := h.Slack.Installations.Delete(base, env.TeamID); err != nil {
+				log.Printf("[ERR] delete installation team=%q: %v", env.TeamID, err)
+				return
+			}
+			log.Printf("[SLACK] app uninstalled from team=%q — installation removed", env.TeamID)
+		}
+		return
+	}
+
 	var msg slack.MessageEvent
 	if err := json.Unmarshal(env.Event, &msg); err != nil {
 		log.Printf("[ERR] unmarshal event: %v event=%s", err, preview(string(env.Event), 600))
@@ -94,6 +176,23 @@ func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[SLACK] event type=%q subtype=%q channel=%q user=%q ts=%q thread_ts=%q text_len=%d",
 		msg.Type, msg.Subtype, msg.Channel, msg.User, msg.Ts, msg.ThreadTs, len(msg.Text))
 
+	if msg.Type == "app_home_opened" {
+		var ev slack.AppHomeOpenedEvent
+		if err := json.Unmarshal(env.Event, &ev); err != nil {
+			log.Printf("[ERR] unmarshal app_home_opened: %v", err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(base, 20*time.Second)
+		defer cancel()
+		h.Service.HandleHomeOpened(ctx, ev.User, ev.Tab)
+		return
+	}
+
+	if msg.Type == "reaction_added" {
+		h.dispatchReaction(base, env)
+		return
+	}
+
 	if msg.Type != "message" {
 		log.Printf("[SLACK] ignoring non-message event")
 		return
@@ -110,11 +209,14 @@ func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		deletedTs = msg.Message.Ts
 	}
 	if deletedTs != "" {
+		if h.Service.Slack.Tracker.Cancel(msg.Channel, deletedTs) {
+			log.Printf("[SLACK] user deleted origin=%q — cancelled in-flight processing", deletedTs)
+		}
 		if botTimestamps := h.Service.Slack.Tracker.GetAll(msg.Channel, deletedTs); len(botTimestamps) > 0 {
 			log.Printf("[SLACK] user deleted origin=%q — deleting %d bot message(s)", deletedTs, len(botTimestamps))
 			go func() {
 				for _, botTs := range botTimestamps {
-					if err := h.Slack.DeleteMessage(msg.Channel, botTs); err != nil {
+					if err := h.Slack.DeleteMessage(base, msg.Channel, botTs); err != nil {
 						log.Printf("[WARN] delete bot reply ts=%q failed: %v", botTs, err)
 					}
 				}
@@ -124,6 +226,12 @@ func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A real edit of a question the bot answered re-runs it.
+	if msg.Subtype == "message_changed" && msg.Message != nil {
+		h.dispatchEdit(base, msg)
+		return
+	}
+
 	// Allow file_share subtype (user attached a file); block everything else.
 	isFileShare := msg.Subtype == "file_share"
 	if (msg.Subtype != "" && !isFileShare) || msg.BotID != "" {
@@ -151,7 +259,7 @@ func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// - In channels: accept ONLY on a direct Slack mention (<@BOT_ID>), no prefixes, no auto-followups.
 	summoned := isDM
 	if !summoned {
-		summoned = parse.LooksLikeDirectMention(text, h.Slack.BotUserID)
+		summoned = parse.LooksLikeDirectMention(text, h.Slack.BotUserIDFor(base))
 	}
 
 	if !summoned {
@@ -159,7 +267,7 @@ func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	question := parse.StripSummon(text, h.Slack.BotUserID, h.Service.Cfg.BotName)
+	question := parse.StripSummon(text, h.Slack.BotUserIDFor(base), h.Service.Cfg.BotName)
 	if question == "" && len(msg.Files) == 0 {
 		log.Printf("[BOT] summoned but empty after strip and no files; ignoring")
 		return
@@ -169,16 +277,109 @@ func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		question = "Analise o(s) arquivo(s) anexado(s) e me dê um resumo."
 	}
 
+	// The same message can reach us under different event IDs (e.g. an
+	// event redelivered after its ID was purged); answer it only once.
+	if !h.Dedup.Claim(base, slack.MessageKey(msg.Channel, originTs)) {
+		log.Printf("[SLACK] duplicate message channel=%q ts=%q retry=%d — ignoring", msg.Channel, originTs, retry)
+		return
+	}
+
 	log.Printf("[BOT] handling question=%q files=%d channel=%q thread=%q originTs=%q user=%q", preview(question, 220), len(msg.Files), msg.Channel, threadTs, originTs, msg.User)
 
+	// The request outlives this handler, so its context derives from
+	// Background; deleting the origin message cancels it (see above).
+	ctx, done := h.Service.Slack.Tracker.Begin(base, msg.Channel, originTs)
 	go func() {
-		if err := h.Service.HandleMessage(msg.Channel, threadTs, originTs, text, question, msg.User, msg.Files); err != nil {
+		defer done()
+		if err := h.Service.HandleMessage(ctx, msg.Channel, threadTs, originTs, text, question, msg.User, msg.Files); err != nil {
+			if ctx.Err() != nil {
+				log.Printf("[BOT] origin=%q cancelled: %v", originTs, err)
+				return
+			}
 			log.Printf("[ERR] handleQuestion: %v", err)
-			_ = h.Slack.PostMessage(msg.Channel, threadTs, "Não consegui gerar a resposta (erro interno).")
+			_ = h.Slack.PostMessage(ctx, msg.Channel, threadTs, "Não consegui gerar a resposta (erro interno).")
 		}
 	}()
+}
 
-	log.Printf("[HTTP] /slack/events done dur=%s", time.Since(start))
+// dispatchEdit re-answers an edited question in place.  Only messages the
+// bot answered (known to the MessageTracker) whose text actually changed are
+// considered; link unfurls also arrive as message_changed.  Bursts of edits
+// are coalesced by the editGuard, which also cancels a re-answer made stale
+// by a newer edit.
+func (h *SlackHandler) dispatchEdit(base context.Context, msg slack.MessageEvent) {
+	botUserID := h.Slack.BotUserIDFor(base)
+	edited := msg.Message
+	if edited.BotID != "" || edited.User == botUserID {
+		return
+	}
+	if msg.PreviousMessage != nil && strings.TrimSpace(msg.PreviousMessage.Text) == strings.TrimSpace(edited.Text) {
+		return
+	}
+	if len(h.Service.Slack.Tracker.GetAll(msg.Channel, edited.Ts)) == 0 {
+		return
+	}
+	text := strings.TrimSpace(edited.Text)
+	if !strings.HasPrefix(msg.Channel, "D") && !parse.LooksLikeDirectMention(text, botUserID) {
+		log.Printf("[BOT] edited message no longer mentions the bot; keeping answer origin=%q", edited.Ts)
+		return
+	}
+	question := parse.StripSummon(text, botUserID, h.Service.Cfg.BotName)
+	if question == "" {
+		return
+	}
+	threadTs := edited.ThreadTs
+	if threadTs == "" {
+		threadTs = edited.Ts
+	}
+
+	scheduled := h.edits.schedule(msg.Channel, edited.Ts, func() {
+		// A re-answer still running for an older version is now stale.
+		h.Service.Slack.Tracker.Cancel(msg.Channel, edited.Ts)
+		log.Printf("[BOT] re-answering edited question=%q channel=%q originTs=%q", preview(question, 220), msg.Channel, edited.Ts)
+		ctx, done := h.Service.Slack.Tracker.Begin(base, msg.Channel, edited.Ts)
+		defer done()
+		if err := h.Service.ReanswerEdited(ctx, msg.Channel, threadTs, edited.Ts, text, question, edited.User, edited.Files); err != nil {
+			if ctx.Err() != nil {
+				log.Printf("[BOT] re-answer origin=%q cancelled: %v", edited.Ts, err)
+				return
+			}
+			log.Printf("[ERR] re-answer: %v", err)
+		}
+	})
+	if !scheduled {
+		log.Printf("[BOT] edit limit reached for origin=%q — ignoring edit", edited.Ts)
+	}
+}
+
+// dispatchReaction runs the workflow mapped to a reaction_added event, once
+// per message (per user, for bookmarks).
+func (h *SlackHandler) dispatchReaction(base context.Context, env slack.EventEnvelope) {
+	var ev slack.ReactionEvent
+	if err := json.Unmarshal(env.Event, &ev); err != nil {
+		log.Printf("[ERR] unmarshal reaction_added: %v", err)
+		return
+	}
+	if ev.Item.Type != "message" || ev.User == h.Slack.BotUserIDFor(base) {
+		return
+	}
+	action := h.Service.Cfg.ReactionAction(ev.Item.Channel, ev.Emoji())
+	if action == "" {
+		return
+	}
+	scope := ""
+	if action == app.ReactionBookmark {
+		scope = ev.User
+	}
+	if !h.Dedup.Claim(base, slack.ReactionKey(ev.Item.Channel, ev.Item.Ts, action, scope)) {
+		log.Printf("[SLACK] reaction action=%s already ran on channel=%q ts=%q — ignoring", action, ev.Item.Channel, ev.Item.Ts)
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(base, 5*time.Minute)
+		defer cancel()
+		h.Service.HandleReaction(ctx, action, ev)
+	}()
 }
 
 // Helper preview returns a shortened version of a string for logging.
@@ -0,0 +1,23 @@
+package http
+
+import (
+	"github.com/DanielFillol/Jarvis/internal/slack"
+)
+
+// SocketRouter returns the Socket Mode envelope handler: each envelope type
+// goes to the handler that serves the equivalent HTTP endpoint, and what it
+// returns becomes the ack payload.
+func SocketRouter(events *SlackHandler, commands *CommandHandler, interactions *InteractionHandler) func(slack.SocketEnvelope) any {
+	return func(se slack.SocketEnvelope) any {
+		switch se.Type {
+		case "slash_commands":
+			return commands.HandleSocketEnvelope(se)
+		case "interactive":
+			return interactions.HandleSocketEnvelope(se)
+		default:
+			// Event processing may wait on the dedup store; never hold the ack.
+			go events.HandleSocketEnvelope(se)
+			return nil
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package hubspot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -30,9 +31,9 @@ type Pipeline struct {
 
 // FetchPipelines fetches all pipelines for the given objectType ("deals" or "tickets")
 // via GET /crm/v3/pipelines/{objectType}.
-func (c *Client) FetchPipelines(objectType string) ([]*Pipeline, error) {
+func (c *Client) FetchPipelines(ctx context.Context, objectType string) ([]*Pipeline, error) {
 	url := fmt.Sprintf("%s/crm/v3/pipelines/%s", c.baseURL, objectType)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -90,10 +91,10 @@ func (c *Client) FetchPipelines(objectType string) ([]*Pipeline, error) {
 
 // GenerateCatalog fetches deal and ticket pipelines, writes a full Markdown catalog
 // to filePath, and returns a compact one-liner string for LLM prompts.
-func (c *Client) GenerateCatalog(filePath string) string {
+func (c *Client) GenerateCatalog(ctx context.Context, filePath string) string {
 	var allPipelines []*Pipeline
 	for _, objType := range []string{"deals", "tickets"} {
-		pipelines, err := c.FetchPipelines(objType)
+		pipelines, err := c.FetchPipelines(ctx, objType)
 		if err != nil {
 			log.Printf("[HUBSPOT] FetchPipelines %s failed: %v", objType, err)
 			continue
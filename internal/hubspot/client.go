@@ -2,6 +2,7 @@ package hubspot
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -70,7 +71,7 @@ func NewClient(cfg config.Config) *Client {
 	}
 	catalogPath := cfg.HubSpotCatalogPath
 	go func() {
-		catalog := c.GenerateCatalog(catalogPath)
+		catalog := c.GenerateCatalog(context.Background(), catalogPath)
 		c.CatalogCompact = catalog
 		log.Printf("[HUBSPOT] catalog: %s", catalog)
 	}()
@@ -132,12 +133,12 @@ func (c *Client) recordURL(objectType, id string) string {
 
 // fetchAllProperties fetches all property names for an object type from the
 // HubSpot Properties API, caching the result after the first call.
-func (c *Client) fetchAllProperties(objectType string) ([]string, error) {
+func (c *Client) fetchAllProperties(ctx context.Context, objectType string) ([]string, error) {
 	if v, ok := c.propertiesCache.Load(objectType); ok {
 		return v.([]string), nil
 	}
 	url := fmt.Sprintf("%s/crm/v3/properties/%s", c.baseURL, objectType)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -173,8 +174,8 @@ func (c *Client) fetchAllProperties(objectType string) ([]string, error) {
 // FetchByID fetches a single CRM record by its numeric ID using the batch/read
 // endpoint with all available properties. Returns nil, nil when the record is
 // not found (404 or empty results) — callers should fall back to text search.
-func (c *Client) FetchByID(objectType, id string) (*SearchResult, error) {
-	allProps, err := c.fetchAllProperties(objectType)
+func (c *Client) FetchByID(ctx context.Context, objectType, id string) (*SearchResult, error) {
+	allProps, err := c.fetchAllProperties(ctx, objectType)
 	if err != nil {
 		// Fall back to standard property set on error.
 		allProps = objectProperties[objectType]
@@ -188,7 +189,7 @@ func (c *Client) FetchByID(objectType, id string) (*SearchResult, error) {
 	body, _ := json.Marshal(bodyMap)
 
 	url := fmt.Sprintf("%s/crm/v3/objects/%s/batch/read", c.baseURL, objectType)
-	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -279,8 +280,9 @@ func (c *Client) findPipelineByQuery(objectType, query string) *Pipeline {
 }
 
 // search performs POST /crm/v3/objects/{objectType}/search and returns parsed results.
-// after and before are optional ISO YYYY-MM-DD strings to filter by hs_lastmodifieddate.
-func (c *Client) search(objectType, query, after, before string) ([]*SearchResult, error) {
+// after and before are optional ISO YYYY-MM-DD strings to filter by hs_lastmodifieddate;
+// ownerID, when set, keeps only the records owned by that hubspot_owner_id.
+func (c *Client) search(ctx context.Context, objectType, query, after, before, ownerID string) ([]*SearchResult, error) {
 	props, ok := objectProperties[objectType]
 	if !ok {
 		return nil, fmt.Errorf("unknown hubspot object type: %s", objectType)
@@ -302,6 +304,11 @@ func (c *Client) search(objectType, query, after, before string) ([]*SearchResul
 		query = "" // replace text search with property filter
 	}
 
+	if ownerID != "" {
+		filters = append(filters, map[string]interface{}{
+			"propertyName": "hubspot_owner_id", "operator": "EQ", "value": ownerID,
+		})
+	}
 	if after != "" {
 		if ms, err := isoToMillis(after); err == nil {
 			filters = append(filters, map[string]interface{}{
@@ -328,7 +335,7 @@ func (c *Client) search(objectType, query, after, before string) ([]*SearchResul
 	body, _ := json.Marshal(bodyMap)
 
 	url := fmt.Sprintf("%s/crm/v3/objects/%s/search", c.baseURL, objectType)
-	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -374,20 +381,21 @@ func (c *Client) search(objectType, query, after, before string) ([]*SearchResul
 }
 
 // Search searches a specific object type. When objectType is empty, searches all types.
-// after and before are optional ISO YYYY-MM-DD date bounds (hs_lastmodifieddate filter).
-func (c *Client) Search(objectType, query, after, before string) ([]*SearchResult, error) {
+// after and before are optional ISO YYYY-MM-DD date bounds (hs_lastmodifieddate filter);
+// ownerID optionally restricts the results to one owner ("meus deals").
+func (c *Client) Search(ctx context.Context, objectType, query, after, before, ownerID string) ([]*SearchResult, error) {
 	if strings.TrimSpace(objectType) == "" {
-		return c.searchAllTypes(query, after, before)
+		return c.searchAllTypes(ctx, query, after, before, ownerID)
 	}
-	return c.search(objectType, query, after, before)
+	return c.search(ctx, objectType, query, after, before, ownerID)
 }
 
 // searchAllTypes runs Search across all object types and merges results.
-func (c *Client) searchAllTypes(query, after, before string) ([]*SearchResult, error) {
+func (c *Client) searchAllTypes(ctx context.Context, query, after, before, ownerID string) ([]*SearchResult, error) {
 	var all []*SearchResult
 	var lastErr error
 	for _, ot := range allObjectTypes {
-		res, err := c.search(ot, query, after, before)
+		res, err := c.search(ctx, ot, query, after, before, ownerID)
 		if err != nil {
 			lastErr = err
 			continue
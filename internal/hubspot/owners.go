@@ -0,0 +1,69 @@
+package hubspot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Owner is a HubSpot user that can own CRM records (hubspot_owner_id).
+type Owner struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+}
+
+// Name returns the owner's full name, or the email when it is empty.
+func (o Owner) Name() string {
+	if n := strings.TrimSpace(o.FirstName + " " + o.LastName); n != "" {
+		return n
+	}
+	return o.Email
+}
+
+// FindOwnerByEmail returns the owner with the given email, or nil when there
+// is none.  It requires the crm.objects.owners.read scope.
+func (c *Client) FindOwnerByEmail(ctx context.Context, email string) (*Owner, error) {
+	u := fmt.Sprintf("%s/crm/v3/owners?email=%s&limit=1", c.baseURL, url.QueryEscape(strings.TrimSpace(email)))
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	rb, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("hubspot owners status=%d body=%s", resp.StatusCode, preview(string(rb), 300))
+	}
+	var out struct {
+		Results []Owner `json:"results"`
+	}
+	if err := json.Unmarshal(rb, &out); err != nil {
+		return nil, fmt.Errorf("hubspot decode: %w", err)
+	}
+	for i, o := range out.Results {
+		if strings.EqualFold(o.Email, strings.TrimSpace(email)) {
+			return &out.Results[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// OwnerIDByEmail returns the ID of the owner with the given email, or ""
+// when there is none.
+func (c *Client) OwnerIDByEmail(ctx context.Context, email string) (string, error) {
+	o, err := c.FindOwnerByEmail(ctx, email)
+	if err != nil || o == nil {
+		return "", err
+	}
+	return o.ID, nil
+}
@@ -0,0 +1,183 @@
+package hubspot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/skill"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// ActionSearch is the action kind of the HubSpot skill.
+const ActionSearch = "hubspot_search"
+
+// Skill searches HubSpot CRM records (hubspot_search).
+type Skill struct {
+	client       *Client
+	llm          *llm.Client
+	variantModel string
+}
+
+// NewSkill returns the HubSpot skill.  client is nil when HubSpot is not
+// configured; variantModel generates alternative queries when a search comes
+// back empty.
+func NewSkill(client *Client, llmClient *llm.Client, variantModel string) *Skill {
+	return &Skill{client: client, llm: llmClient, variantModel: variantModel}
+}
+
+func (*Skill) Kind() string                   { return ActionSearch }
+func (*Skill) Label() string                  { return "CONTEXTO DO HUBSPOT CRM" }
+func (*Skill) DisplayName() string            { return "HubSpot CRM" }
+func (*Skill) Enabled(cfg config.Config) bool { return cfg.HubSpotEnabled() }
+func (*Skill) Weight() float64                { return 1.5 }
+
+func (*Skill) Tools() []llm.ToolDef {
+	return []llm.ToolDef{{Kind: ActionSearch, Description: "Buscar dados de CRM no HubSpot (contatos, empresas, negociações, tickets).", Params: []llm.ToolParam{
+		{Name: "hubspot_object_type", Type: "string", Optional: true, Enum: []string{"contacts", "companies", "deals", "tickets"}, Description: "Tipo de objeto, ou null para todos."},
+		{Name: "hubspot_query", Type: "string", Optional: true, Description: "Termo de busca, ou null quando hubspot_record_id é informado."},
+		{Name: "hubspot_after", Type: "string", Optional: true, Check: llm.ISODate, Description: "Data inicial inclusiva YYYY-MM-DD, ou null."},
+		{Name: "hubspot_before", Type: "string", Optional: true, Check: llm.ISODate, Description: "Data final exclusiva YYYY-MM-DD, ou null."},
+		{Name: "hubspot_record_id", Type: "string", Optional: true, Check: llm.DigitsOnly, Description: "ID numérico do registro, ou null."},
+		{Name: "hubspot_owner_id", Type: "string", Optional: true, Check: llm.DigitsOnly, Description: "ID do owner para filtrar registros de um responsável (\"meus deals\"), ou null."},
+	}}}
+}
+
+func (k *Skill) RouterPrompt(req skill.Request) llm.RouterSnippet {
+	sn := llm.RouterSnippet{
+		Context: "HubSpot CRM está configurado e disponível para busca de contatos, empresas, negociações e tickets.",
+		Source:  "- HubSpot CRM: contatos, empresas, negociações (deals), tickets de suporte, dados de clientes e pipeline comercial.",
+		Examples: []string{
+			`{"kind": "hubspot_search", "hubspot_object_type": "companies", "hubspot_query": "Acme Corp", "hubspot_after": "2026-01-01"}`,
+			`{"kind": "hubspot_search", "hubspot_object_type": "tickets", "hubspot_record_id": "47919961660"}`,
+		},
+		Rules: "- Inclua hubspot_search APENAS quando a pergunta envolve dados de CRM (pipeline comercial, status de negociação, contato, empresa, deal, ticket de suporte, lead). NÃO use hubspot_search para dados operacionais como faturamento, preço de coleta, billing_cycle, transações, rotas ou histórico de serviço — esses dados estão no banco de dados (Metabase). Preencha hubspot_object_type com um de: contacts, companies, deals, tickets (ou deixe vazio para buscar em todos). Preencha hubspot_query com o termo de busca mais relevante.\n" +
+			"- Se a mesma mensagem pedir explicitamente dados do banco de dados (Metabase) além do CRM, inclua TAMBÉM metabase_query — as duas ações devem aparecer juntas.\n" +
+			"- Quando o usuário especificar um intervalo de datas, preencha hubspot_after (data inicial inclusiva) e/ou hubspot_before (data final exclusiva) no formato YYYY-MM-DD. Use a data atual fornecida acima para calcular expressões relativas (\"últimos dois meses\", \"mês passado\", \"essa semana\").\n" +
+			"- Quando o usuário mencionar um estágio ou pipeline específico (ex: 'enterprise 3.0', 'fechado ganho', 'proposta'), use esse nome como parte do hubspot_query para filtrar negócios naquele estágio. Consulte os Pipelines HubSpot disponíveis listados acima para identificar o nome correto do estágio.\n" +
+			"- Quando o usuário fornecer um ID numérico de registro HubSpot (ex: \"47919961660\", \"ticket 12345678\", \"demanda 98765\", \"demanda de serviço 47919961660\"), preencha hubspot_record_id com esse número e hubspot_object_type com o tipo inferido (tickets, deals, contacts, companies) ou deixe vazio para tentar todos os tipos. Quando hubspot_record_id estiver preenchido, hubspot_query pode ser omitido.",
+	}
+	if k.client != nil && strings.TrimSpace(k.client.CatalogCompact) != "" {
+		sn.Context += fmt.Sprintf("\nPipelines HubSpot disponíveis:\n%s", k.client.CatalogCompact)
+	}
+	if req.Sender.HubSpotOwnerID != "" {
+		sn.Context += fmt.Sprintf("\nOwner HubSpot de quem está perguntando: hubspot_owner_id %q. Para \"meus deals\", \"minhas negociações\", \"meus tickets\", \"meus clientes\" preencha hubspot_owner_id com esse valor (e hubspot_query só se houver um termo além do dono).", req.Sender.HubSpotOwnerID)
+	}
+	return sn
+}
+
+// AnswerHints tells the model to decline CRM questions when HubSpot is off.
+func (*Skill) AnswerHints(_ []llm.ContextSection, enabled bool) []string {
+	if enabled {
+		return nil
+	}
+	return []string{"HUBSPOT NÃO CONFIGURADO: A integração com o HubSpot CRM não está habilitada nesta instalação. Se o usuário pedir dados de CRM (contatos, empresas, deals, tickets, clientes), informe gentilmente que essa integração não está disponível e sugira que o administrador configure a variável HUBSPOT_API_KEY."}
+}
+
+// Execute looks a record up by ID when one is given, then falls back to a
+// text search, retrying with LLM-generated query variants when it is empty.
+func (k *Skill) Execute(ctx context.Context, req skill.Request, action llm.ActionDescriptor) (skill.ContextBlock, skill.Sources, error) {
+	block := skill.ContextBlock{Kind: ActionSearch}
+	if k.client == nil {
+		return block, "", skill.ErrSkipped
+	}
+	objectType := action.StringArg("hubspot_object_type")
+	query := action.StringArg("hubspot_query")
+	recordID := action.StringArg("hubspot_record_id")
+	after, before := action.StringArg("hubspot_after"), action.StringArg("hubspot_before")
+	ownerID := action.StringArg("hubspot_owner_id")
+	if query == "" && ownerID == "" {
+		query = req.Question
+	}
+	req.Report("consultando HubSpot…")
+	// ID-based lookup: try FetchByID first when a record ID is provided.
+	if recordID != "" {
+		typesToTry := []string{objectType}
+		if objectType == "" {
+			typesToTry = []string{"tickets", "deals", "contacts", "companies"}
+		}
+		log.Printf("%s hubspotFetchByID id=%q types=%v", req.Tag(), recordID, typesToTry)
+		for _, ot := range typesToTry {
+			if ctx.Err() != nil {
+				break
+			}
+			r, fErr := k.client.FetchByID(ctx, ot, recordID)
+			if fErr != nil {
+				log.Printf("%s hubspot FetchByID type=%s id=%s: %v", req.Warn(), ot, recordID, fErr)
+				continue
+			}
+			if r != nil {
+				found := []*SearchResult{r}
+				block.Count = 1
+				block.Text = FormatContext(found, 8000)
+				log.Printf("%s hubspotFetchByID found type=%s id=%s chars=%d", req.Tag(), ot, recordID, len(block.Text))
+				return block, skill.Sources(FormatSources(found)), nil
+			}
+		}
+		// Fall through to text search. Only use the bare record ID when no
+		// richer query is available; if the LLM omitted hubspot_query (leaving
+		// query == question), keep the full question so text search has more
+		// tokens to match against.
+		if query == "" {
+			query = recordID
+		}
+	}
+	log.Printf("%s hubspotSearch object_type=%q query=%q after=%q before=%q owner=%q", req.Tag(), objectType, query, after, before, ownerID)
+	results, err := k.client.Search(ctx, objectType, query, after, before, ownerID)
+	if err != nil {
+		block.Text = "[HUBSPOT_ERROR: busca falhou. NÃO invente dados de CRM.]"
+		return block, "", err
+	}
+	if len(results) == 0 {
+		// Ask LLM to generate alternative query variants and retry.
+		variants := k.llm.GenerateHubSpotQueryVariants(ctx, query, req.QuestionForLLM, k.variantModel)
+		log.Printf("%s hubspotSearch empty, LLM variants=%v", req.Tag(), variants)
+		for _, v := range variants {
+			if strings.TrimSpace(v) == "" || v == query {
+				continue
+			}
+			if ctx.Err() != nil {
+				log.Printf("%s hubspotSearch deadline reached, skipping remaining variants", req.Warn())
+				break
+			}
+			log.Printf("%s hubspotSearch retry variant=%q", req.Tag(), v)
+			results, err = k.client.Search(ctx, objectType, v, after, before, ownerID)
+			if err != nil {
+				log.Printf("%s hubspot search failed variant=%q: %v", req.Warn(), v, err)
+				break
+			}
+			if len(results) > 0 {
+				break
+			}
+		}
+		if len(results) == 0 {
+			block.Text = "[HUBSPOT_EMPTY: nenhum resultado encontrado no HubSpot.]"
+			return block, "", nil
+		}
+	}
+	block.Count = len(results)
+	block.Text = FormatContext(results, 4000)
+	log.Printf("%s hubspotContext records=%d chars=%d", req.Tag(), len(results), len(block.Text))
+	return block, skill.Sources(FormatSources(results)), nil
+}
+
+// Telemetry is a no-op: the events table has no HubSpot columns yet.
+func (*Skill) Telemetry(*telemetry.Event, skill.ContextBlock, error) {}
+
+// Finish prepends the pipeline/stage ID→label catalog so the LLM can decode
+// numeric dealstage/pipeline IDs in the search results.
+func (k *Skill) Finish(ctx context.Context, _ skill.Request, blocks []skill.ContextBlock) []skill.ContextBlock {
+	if k.client == nil || strings.TrimSpace(k.client.CatalogForLLM) == "" {
+		return blocks
+	}
+	for _, b := range blocks {
+		if b.Count > 0 {
+			catalog := skill.ContextBlock{Kind: ActionSearch, Text: k.client.CatalogForLLM}
+			return append([]skill.ContextBlock{catalog}, blocks...)
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,176 @@
+// Package identity links Slack users to their accounts in the other
+// integrations — the Jira account and the HubSpot owner — so requests like
+// "meus cards" or "meus deals" can be filtered by real IDs.  Accounts are
+// matched by the email of the Slack profile and can be pinned in config
+// (IDENTITY_JIRA_USERS, IDENTITY_HUBSPOT_OWNERS).
+package identity
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/jira"
+	"github.com/DanielFillol/Jarvis/internal/slack"
+)
+
+// Identity is a Slack user with their accounts in Jira and HubSpot.  Fields
+// that could not be resolved are empty.
+type Identity struct {
+	SlackUserID    string
+	Name           string
+	Email          string
+	JiraAccountID  string
+	JiraName       string
+	HubSpotOwnerID string
+}
+
+// OwnerFinder looks a HubSpot owner up by email; *hubspot.Client implements
+// it.  The skill contract depends on this package, so it must not import the
+// integrations whose skills live next to their clients.
+type OwnerFinder interface {
+	OwnerIDByEmail(ctx context.Context, email string) (string, error)
+}
+
+// Resolver resolves and caches identities.  Its methods are safe for
+// concurrent use; a nil *Resolver resolves nothing.
+type Resolver struct {
+	slack   *slack.Client
+	jira    *jira.Client // nil when Jira is not configured
+	hubspot OwnerFinder  // nil when HubSpot is not configured
+
+	jiraUsers     map[string]string // Slack user ID → Jira accountId
+	hubspotOwners map[string]string // Slack user ID → HubSpot owner ID
+	ttl           time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedIdentity
+}
+
+type cachedIdentity struct {
+	id      Identity
+	expires time.Time
+}
+
+// NewResolver builds a resolver over the configured integrations.  Any
+// client may be nil.
+func NewResolver(cfg config.Config, slackClient *slack.Client, jiraClient *jira.Client, hubspotClient OwnerFinder) *Resolver {
+	r := &Resolver{
+		slack:         slackClient,
+		hubspot:       hubspotClient,
+		jiraUsers:     cfg.IdentityJiraUsers,
+		hubspotOwners: cfg.IdentityHubSpotOwners,
+		ttl:           cfg.IdentityCacheTTL,
+		cache:         make(map[string]cachedIdentity),
+	}
+	if cfg.JiraEnabled() {
+		r.jira = jiraClient
+	}
+	log.Printf("[BOOT] identity mapping jira=%t hubspot=%t overrides jira=%d hubspot=%d ttl=%s",
+		r.jira != nil, r.hubspot != nil, len(r.jiraUsers), len(r.hubspotOwners), r.ttl)
+	return r
+}
+
+// Resolve returns the identity of slackUserID.  Lookups that fail are
+// logged and left empty; the result is cached for the configured TTL only
+// when every lookup completed, so transient errors are retried.
+func (r *Resolver) Resolve(ctx context.Context, slackUserID string) Identity {
+	slackUserID = strings.TrimSpace(slackUserID)
+	id := Identity{SlackUserID: slackUserID}
+	if r == nil || slackUserID == "" {
+		return id
+	}
+	if cached, ok := r.cached(slackUserID); ok {
+		return cached
+	}
+
+	id.JiraAccountID = r.jiraUsers[slackUserID]
+	id.HubSpotOwnerID = r.hubspotOwners[slackUserID]
+	needJira := r.jira != nil && id.JiraAccountID == ""
+	needHubSpot := r.hubspot != nil && id.HubSpotOwnerID == ""
+	if !needJira && !needHubSpot {
+		r.store(id)
+		return id
+	}
+
+	complete := true
+	if r.slack != nil {
+		u, err := r.slack.GetUser(ctx, slackUserID)
+		if err != nil {
+			log.Printf("[WARN] identity user=%s: slack profile: %v", slackUserID, err)
+			return id
+		}
+		id.Name, id.Email = u.Name(), u.Email
+	}
+	if id.Email == "" {
+		log.Printf("[WARN] identity user=%s: no email on the Slack profile (users:read.email scope?) — set IDENTITY_JIRA_USERS/IDENTITY_HUBSPOT_OWNERS to map it", slackUserID)
+		r.store(id)
+		return id
+	}
+
+	if needJira {
+		users, err := r.jira.SearchUsers(ctx, id.Email, 5)
+		if err != nil {
+			log.Printf("[WARN] identity user=%s: jira user search: %v", slackUserID, err)
+			complete = false
+		} else if u := pickJiraUser(users, id.Email); u != nil {
+			id.JiraAccountID, id.JiraName = u.AccountID, u.DisplayName
+		}
+	}
+	if needHubSpot {
+		ownerID, err := r.hubspot.OwnerIDByEmail(ctx, id.Email)
+		if err != nil {
+			log.Printf("[WARN] identity user=%s: hubspot owners: %v", slackUserID, err)
+			complete = false
+		} else {
+			id.HubSpotOwnerID = ownerID
+		}
+	}
+
+	log.Printf("[JARVIS] identity user=%s jira=%q hubspot=%q", slackUserID, id.JiraAccountID, id.HubSpotOwnerID)
+	if complete {
+		r.store(id)
+	}
+	return id
+}
+
+// pickJiraUser returns the active account whose email matches, or the only
+// active result when Jira hides emails (the query was the email itself).
+func pickJiraUser(users []jira.JiraUser, email string) *jira.JiraUser {
+	var active []*jira.JiraUser
+	for i, u := range users {
+		if !u.Active {
+			continue
+		}
+		if strings.EqualFold(u.EmailAddress, email) {
+			return &users[i]
+		}
+		active = append(active, &users[i])
+	}
+	if len(active) == 1 && active[0].EmailAddress == "" {
+		return active[0]
+	}
+	return nil
+}
+
+func (r *Resolver) cached(slackUserID string) (Identity, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.cache[slackUserID]
+	if !ok || time.Now().After(c.expires) {
+		return Identity{}, false
+	}
+	return c.id, true
+}
+
+func (r *Resolver) store(id Identity) {
+	if r.ttl <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache[id.SlackUserID] = cachedIdentity{id: id, expires: time.Now().Add(r.ttl)}
+}
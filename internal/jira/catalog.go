@@ -1,6 +1,7 @@
 package jira
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -26,12 +27,12 @@ type ProjectMeta struct {
 
 // GetProjectStatuses fetches the available statuses for each issue type in a
 // project via GET /rest/api/3/project/{key}/statuses.
-func (c *Client) GetProjectStatuses(key string) (map[string][]string, error) {
+func (c *Client) GetProjectStatuses(ctx context.Context, key string) (map[string][]string, error) {
 	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
 		return nil, nil
 	}
 	u := fmt.Sprintf("%s/rest/api/3/project/%s/statuses", c.BaseURL, key)
-	req, _ := http.NewRequest("GET", u, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 	req.Header.Set("Accept", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
 	req.Header.Set("Authorization", "Basic "+cred)
@@ -77,12 +78,12 @@ func (c *Client) GetProjectStatuses(key string) (map[string][]string, error) {
 
 // GetProjectMeta fetches a project's name, description, and available issue
 // types from the Jira REST API (GET /rest/api/3/project/{key}).
-func (c *Client) GetProjectMeta(key string) (ProjectMeta, error) {
+func (c *Client) GetProjectMeta(ctx context.Context, key string) (ProjectMeta, error) {
 	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
 		return ProjectMeta{Key: key}, nil
 	}
 	u := fmt.Sprintf("%s/rest/api/3/project/%s", c.BaseURL, key)
-	req, _ := http.NewRequest("GET", u, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 	req.Header.Set("Accept", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
 	req.Header.Set("Authorization", "Basic "+cred)
@@ -126,21 +127,21 @@ func (c *Client) GetProjectMeta(key string) (ProjectMeta, error) {
 // Markdown catalog to filePath (if non-empty), and returns a compact
 // one-line summary string suitable for use in LLM prompts.
 // On any per-project error the key is still included with whatever was fetched.
-func (c *Client) GenerateCatalog(filePath string) string {
+func (c *Client) GenerateCatalog(ctx context.Context, filePath string) string {
 	if c.BaseURL == "" || len(c.Projects) == 0 {
 		return strings.Join(c.Projects, ", ")
 	}
 	workflowStatuses := make(map[string][]string)
 	var projects []ProjectMeta
 	for _, key := range c.Projects {
-		meta, err := c.GetProjectMeta(key)
+		meta, err := c.GetProjectMeta(ctx, key)
 		if err != nil {
 			log.Printf("[JIRA] GetProjectMeta %s failed: %v", key, err)
 		}
 		if meta.Key == "" {
 			meta.Key = key
 		}
-		statuses, err := c.GetProjectStatuses(key)
+		statuses, err := c.GetProjectStatuses(ctx, key)
 		if err != nil {
 			log.Printf("[JIRA] GetProjectStatuses %s failed: %v", key, err)
 		}
@@ -2,6 +2,7 @@ package jira
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -58,7 +59,7 @@ func NewClient(cfg config.Config) *Client {
 
 	if cfg.JiraEnabled() {
 		go func() {
-			catalog := c.GenerateCatalog(cfg.JiraProjectsPath)
+			catalog := c.GenerateCatalog(context.Background(), cfg.JiraProjectsPath)
 			c.CatalogCompact = catalog
 		}()
 	}
@@ -198,7 +199,7 @@ type CreateIssueResp struct {
 
 // ListProjects fetches all accessible Jira projects and returns up to 50,
 // each as a ProjectInfo with key and name.
-func (c *Client) ListProjects() ([]ProjectInfo, error) {
+func (c *Client) ListProjects(ctx context.Context) ([]ProjectInfo, error) {
 	if c.BaseURL == "" {
 		return nil, errors.New("missing Jira base URL")
 	}
@@ -208,7 +209,7 @@ func (c *Client) ListProjects() ([]ProjectInfo, error) {
 	}
 	u := c.BaseURL + "/rest/api/3/project?maxResults=50"
 
-	req, _ := http.NewRequest("GET", u, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 	req.Header.Set("Accept", "application/json")
 
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
@@ -248,7 +249,7 @@ func (c *Client) ListProjects() ([]ProjectInfo, error) {
 //
 // Errors from individual issues (e.g., GetIssue fails for a key) are silently ignored;
 // only errors from the initial JQL search are propagated.
-func (c *Client) FetchExampleIssues(project, issueType string, limit int) ([]string, error) {
+func (c *Client) FetchExampleIssues(ctx context.Context, project, issueType string, limit int) ([]string, error) {
 	if strings.TrimSpace(project) == "" || strings.TrimSpace(issueType) == "" {
 		return nil, nil
 	}
@@ -261,14 +262,14 @@ func (c *Client) FetchExampleIssues(project, issueType string, limit int) ([]str
 		project, issueType,
 	)
 
-	issues, err := c.FetchAll(jql, limit)
+	issues, err := c.FetchAll(ctx, jql, limit)
 	if err != nil {
 		return nil, fmt.Errorf("fetchExampleIssues jql failed: %w", err)
 	}
 
 	var examples []string
 	for _, it := range issues {
-		full, err := c.GetIssue(it.Key)
+		full, err := c.GetIssue(ctx, it.Key)
 		if err != nil {
 			log.Printf("[JIRA] fetchExampleIssues: GetIssue %s failed: %v", it.Key, err)
 			continue
@@ -306,7 +307,7 @@ func (c *Client) FetchExampleIssues(project, issueType string, limit int) ([]str
 // maxTotal issues.  It flattens each issue into a SearchJQLRespIssue
 // for convenient use elsewhere.  If maxTotal <= 0, a default of 200 is
 // used.
-func (c *Client) FetchAll(jql string, maxTotal int) ([]SearchJQLRespIssue, error) {
+func (c *Client) FetchAll(ctx context.Context, jql string, maxTotal int) ([]SearchJQLRespIssue, error) {
 	if maxTotal <= 0 {
 		maxTotal = 200
 	}
@@ -314,7 +315,7 @@ func (c *Client) FetchAll(jql string, maxTotal int) ([]SearchJQLRespIssue, error
 	startAt := 0
 	pageSize := 50
 	for {
-		resp, err := c.SearchJQL(jql, startAt, pageSize, []string{"summary", "status", "issuetype", "updated", "created", "project", "priority", "assignee", "customfield_10020"})
+		resp, err := c.SearchJQL(ctx, jql, startAt, pageSize, []string{"summary", "status", "issuetype", "updated", "created", "project", "priority", "assignee", "customfield_10020"})
 		if err != nil {
 			if startAt > 0 {
 				// If a further page fails, return what we've accumulated so far
@@ -362,7 +363,7 @@ func (c *Client) FetchAll(jql string, maxTotal int) ([]SearchJQLRespIssue, error
 // GetIssue fetches a single Jira issue by key.  The renderedFields are
 // requested along with selected fields.  An error is returned if the
 // request fails.
-func (c *Client) GetIssue(key string) (IssueResp, error) {
+func (c *Client) GetIssue(ctx context.Context, key string) (IssueResp, error) {
 	if c.BaseURL == "" {
 		return IssueResp{}, errors.New("missing Jira base URL")
 	}
@@ -370,7 +371,7 @@ func (c *Client) GetIssue(key string) (IssueResp, error) {
 		return IssueResp{}, errors.New("missing Jira credentials")
 	}
 	u := fmt.Sprintf("%s/rest/api/3/issue/%s?expand=renderedFields&fields=summary,description,status,issuetype,priority,assignee,subtasks,parent", c.BaseURL, url.PathEscape(key))
-	req, _ := http.NewRequest("GET", u, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 	req.Header.Set("Accept", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
 	req.Header.Set("Authorization", "Basic "+cred)
@@ -393,7 +394,7 @@ func (c *Client) GetIssue(key string) (IssueResp, error) {
 
 // SearchJQL performs a Jira JQL search.  It returns a SearchJQLResp
 // containing issues.  JQL syntax is not validated by this method.
-func (c *Client) SearchJQL(jql string, startAt, maxResults int, fields []string) (SearchJQLResp, error) {
+func (c *Client) SearchJQL(ctx context.Context, jql string, startAt, maxResults int, fields []string) (SearchJQLResp, error) {
 	if c.BaseURL == "" {
 		return SearchJQLResp{}, errors.New("missing Jira base URL")
 	}
@@ -408,7 +409,7 @@ func (c *Client) SearchJQL(jql string, startAt, maxResults int, fields []string)
 	}
 	b, _ := json.Marshal(reqBody)
 	u := c.BaseURL + "/rest/api/3/search/jql"
-	req, _ := http.NewRequest("POST", u, bytes.NewReader(b))
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(b))
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
@@ -433,7 +434,7 @@ func (c *Client) SearchJQL(jql string, startAt, maxResults int, fields []string)
 // CreateIssue creates a new issue in Jira from a draft.  The draft
 // fields must include Project and IssueType; Summary and Description
 // must also be populated.  On success, the new issue-key and ID are returned.
-func (c *Client) CreateIssue(d IssueDraft) (CreateIssueResp, error) {
+func (c *Client) CreateIssue(ctx context.Context, d IssueDraft) (CreateIssueResp, error) {
 	d.Project = strings.TrimSpace(d.Project)
 	d.IssueType = strings.TrimSpace(d.IssueType)
 	d.Summary = strings.TrimSpace(d.Summary)
@@ -472,7 +473,7 @@ func (c *Client) CreateIssue(d IssueDraft) (CreateIssueResp, error) {
 	previewBytes, _ := json.Marshal(payloadPreview)
 	log.Printf("[JIRA] create issue payload preview: %s", string(previewBytes))
 	u := c.BaseURL + "/rest/api/3/issue"
-	req, _ := http.NewRequest("POST", u, bytes.NewReader(b))
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(b))
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
@@ -500,7 +501,7 @@ func (c *Client) CreateIssue(d IssueDraft) (CreateIssueResp, error) {
 // AttachFileToIssue uploads a file as an attachment to an existing Jira issue.
 // The Jira attachment API requires the X-Atlassian-Token: no-check header to
 // bypass XSRF verification.
-func (c *Client) AttachFileToIssue(issueKey, filename string, data []byte) error {
+func (c *Client) AttachFileToIssue(ctx context.Context, issueKey, filename string, data []byte) error {
 	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
 		return errors.New("missing Jira credentials or base URL")
 	}
@@ -515,7 +516,7 @@ func (c *Client) AttachFileToIssue(issueKey, filename string, data []byte) error
 	}
 	w.Close()
 	u := fmt.Sprintf("%s/rest/api/3/issue/%s/attachments", c.BaseURL, url.PathEscape(issueKey))
-	req, _ := http.NewRequest("POST", u, &buf)
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, &buf)
 	req.Header.Set("X-Atlassian-Token", "no-check")
 	req.Header.Set("Content-Type", w.FormDataContentType())
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
@@ -2,6 +2,7 @@ package jira
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -63,13 +64,13 @@ type Board struct {
 }
 
 // GetBoards returns the Agile boards associated with a project key.
-func (c *Client) GetBoards(projectKey string) ([]Board, error) {
+func (c *Client) GetBoards(ctx context.Context, projectKey string) ([]Board, error) {
 	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
 		return nil, errors.New("missing Jira credentials or base URL")
 	}
 	u := fmt.Sprintf("%s/rest/agile/1.0/board?projectKeyOrId=%s&maxResults=10",
 		c.BaseURL, url.QueryEscape(projectKey))
-	req, _ := http.NewRequest("GET", u, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 	req.Header.Set("Accept", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
 	req.Header.Set("Authorization", "Basic "+cred)
@@ -100,7 +101,7 @@ func (c *Client) GetBoards(projectKey string) ([]Board, error) {
 }
 
 // GetSprints returns sprints for a board filtered by state ("active", "future", or "").
-func (c *Client) GetSprints(boardID int, state string) ([]Sprint, error) {
+func (c *Client) GetSprints(ctx context.Context, boardID int, state string) ([]Sprint, error) {
 	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
 		return nil, errors.New("missing Jira credentials or base URL")
 	}
@@ -108,7 +109,7 @@ func (c *Client) GetSprints(boardID int, state string) ([]Sprint, error) {
 	if state != "" {
 		u += "&state=" + url.QueryEscape(state)
 	}
-	req, _ := http.NewRequest("GET", u, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 	req.Header.Set("Accept", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
 	req.Header.Set("Authorization", "Basic "+cred)
@@ -132,14 +133,14 @@ func (c *Client) GetSprints(boardID int, state string) ([]Sprint, error) {
 }
 
 // MoveIssueToSprint moves an issue into the given sprint via the Agile API.
-func (c *Client) MoveIssueToSprint(sprintID int, issueKey string) error {
+func (c *Client) MoveIssueToSprint(ctx context.Context, sprintID int, issueKey string) error {
 	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
 		return errors.New("missing Jira credentials or base URL")
 	}
 	payload := map[string]any{"issues": []string{issueKey}}
 	b, _ := json.Marshal(payload)
 	u := fmt.Sprintf("%s/rest/agile/1.0/sprint/%d/issue", c.BaseURL, sprintID)
-	req, _ := http.NewRequest("POST", u, bytes.NewReader(b))
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(b))
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
@@ -158,12 +159,12 @@ func (c *Client) MoveIssueToSprint(sprintID int, issueKey string) error {
 }
 
 // GetTransitions returns the available status transitions for a Jira issue.
-func (c *Client) GetTransitions(issueKey string) ([]Transition, error) {
+func (c *Client) GetTransitions(ctx context.Context, issueKey string) ([]Transition, error) {
 	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
 		return nil, errors.New("missing Jira credentials or base URL")
 	}
 	u := fmt.Sprintf("%s/rest/api/3/issue/%s/transitions", c.BaseURL, url.PathEscape(issueKey))
-	req, _ := http.NewRequest("GET", u, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 	req.Header.Set("Accept", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
 	req.Header.Set("Authorization", "Basic "+cred)
@@ -194,14 +195,14 @@ func (c *Client) GetTransitions(issueKey string) ([]Transition, error) {
 }
 
 // TransitionIssue moves a Jira issue to the state identified by transitionID.
-func (c *Client) TransitionIssue(issueKey, transitionID string) error {
+func (c *Client) TransitionIssue(ctx context.Context, issueKey, transitionID string) error {
 	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
 		return errors.New("missing Jira credentials or base URL")
 	}
 	payload := map[string]any{"transition": map[string]any{"id": transitionID}}
 	b, _ := json.Marshal(payload)
 	u := fmt.Sprintf("%s/rest/api/3/issue/%s/transitions", c.BaseURL, url.PathEscape(issueKey))
-	req, _ := http.NewRequest("POST", u, bytes.NewReader(b))
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(b))
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
@@ -220,7 +221,7 @@ func (c *Client) TransitionIssue(issueKey, transitionID string) error {
 }
 
 // SearchAssignableUsers searches for Jira users that can be assigned to issueKey.
-func (c *Client) SearchAssignableUsers(issueKey, query string, maxResults int) ([]JiraUser, error) {
+func (c *Client) SearchAssignableUsers(ctx context.Context, issueKey, query string, maxResults int) ([]JiraUser, error) {
 	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
 		return nil, errors.New("missing Jira credentials or base URL")
 	}
@@ -229,7 +230,7 @@ func (c *Client) SearchAssignableUsers(issueKey, query string, maxResults int) (
 	}
 	u := fmt.Sprintf("%s/rest/api/3/user/assignable/search?issueKey=%s&query=%s&maxResults=%d",
 		c.BaseURL, url.QueryEscape(issueKey), url.QueryEscape(query), maxResults)
-	req, _ := http.NewRequest("GET", u, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 	req.Header.Set("Accept", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
 	req.Header.Set("Authorization", "Basic "+cred)
@@ -250,9 +251,41 @@ func (c *Client) SearchAssignableUsers(issueKey, query string, maxResults int) (
 	return users, nil
 }
 
+// SearchUsers searches Jira users by email or name (user/search).  Email
+// queries match exactly even when the account hides its email address.
+func (c *Client) SearchUsers(ctx context.Context, query string, maxResults int) ([]JiraUser, error) {
+	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
+		return nil, errors.New("missing Jira credentials or base URL")
+	}
+	if maxResults <= 0 {
+		maxResults = 5
+	}
+	u := fmt.Sprintf("%s/rest/api/3/user/search?query=%s&maxResults=%d",
+		c.BaseURL, url.QueryEscape(query), maxResults)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+	req.Header.Set("Accept", "application/json")
+	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
+	req.Header.Set("Authorization", "Basic "+cred)
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	rb, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("jira user search status=%d body=%s", resp.StatusCode, preview(string(rb), 400))
+	}
+	var users []JiraUser
+	if err := json.Unmarshal(rb, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // AssignIssue assigns issueKey to the user identified by accountID.
 // Pass an empty accountID to unassign.
-func (c *Client) AssignIssue(issueKey, accountID string) error {
+func (c *Client) AssignIssue(ctx context.Context, issueKey, accountID string) error {
 	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
 		return errors.New("missing Jira credentials or base URL")
 	}
@@ -264,7 +297,7 @@ func (c *Client) AssignIssue(issueKey, accountID string) error {
 	}
 	b, _ := json.Marshal(payload)
 	u := fmt.Sprintf("%s/rest/api/3/issue/%s/assignee", c.BaseURL, url.PathEscape(issueKey))
-	req, _ := http.NewRequest("PUT", u, bytes.NewReader(b))
+	req, _ := http.NewRequestWithContext(ctx, "PUT", u, bytes.NewReader(b))
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
@@ -283,14 +316,14 @@ func (c *Client) AssignIssue(issueKey, accountID string) error {
 }
 
 // UpdateIssue applies arbitrary field updates to an existing Jira issue.
-func (c *Client) UpdateIssue(issueKey string, fields map[string]any) error {
+func (c *Client) UpdateIssue(ctx context.Context, issueKey string, fields map[string]any) error {
 	if c.BaseURL == "" || c.Email == "" || c.Token == "" {
 		return errors.New("missing Jira credentials or base URL")
 	}
 	payload := map[string]any{"fields": fields}
 	b, _ := json.Marshal(payload)
 	u := fmt.Sprintf("%s/rest/api/3/issue/%s", c.BaseURL, url.PathEscape(issueKey))
-	req, _ := http.NewRequest("PUT", u, bytes.NewReader(b))
+	req, _ := http.NewRequestWithContext(ctx, "PUT", u, bytes.NewReader(b))
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 	cred := base64.StdEncoding.EncodeToString([]byte(c.Email + ":" + c.Token))
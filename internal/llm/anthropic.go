@@ -0,0 +1,340 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// anthropicVersion is the Messages API version header sent on every call.
+const anthropicVersion = "2023-06-01"
+
+// anthropicProvider adapts the OpenAI-shaped ChatRequest to Anthropic's
+// Messages API (POST /v1/messages).
+type anthropicProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// newAnthropicProvider returns a provider for the Anthropic Messages API
+// rooted at baseURL (e.g. https://api.anthropic.com/v1).
+func newAnthropicProvider(baseURL, apiKey string) *anthropicProvider {
+	return &anthropicProvider{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 90 * time.Second},
+	}
+}
+
+// anthropicRequest is the request payload for /v1/messages.
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature *float64           `json:"temperature,omitempty"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+// anthropicTool is a client tool declaration.
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+// anthropicMessage is a single user or assistant turn.
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+// anthropicBlock is a text, image, tool_use or tool_result content block.
+type anthropicBlock struct {
+	Type   string                `json:"type"`
+	Text   string                `json:"text,omitempty"`
+	Source *anthropicImageSource `json:"source,omitempty"`
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// tool_result
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+// anthropicImageSource holds either inline base64 data or a remote URL.
+type anthropicImageSource struct {
+	Type      string `json:"type"` // "base64" | "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// anthropicResponse models the fields of the Messages API response used here.
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		ID    string          `json:"id"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	StopReason string          `json:"stop_reason"`
+	Usage      *anthropicUsage `json:"usage,omitempty"`
+	Error      *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// anthropicUsage is the token accounting block of a message.
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// anthropicStreamEvent is a single server-sent event of a streamed message.
+// Input tokens arrive with message_start, output tokens with message_delta.
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Message struct {
+		Usage *anthropicUsage `json:"usage,omitempty"`
+	} `json:"message"`
+	Usage *anthropicUsage `json:"usage,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Name implements Provider.
+func (p *anthropicProvider) Name() string { return ProviderAnthropic }
+
+// Complete implements Provider.  System messages are hoisted into the
+// top-level system field, and vision parts are converted into image blocks.
+func (p *anthropicProvider) Complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	resp, err := p.post(ctx, toAnthropicRequest(req))
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	defer resp.Body.Close()
+	rb, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return ChatResponse{}, fmt.Errorf("anthropic status=%d body=%s", resp.StatusCode, preview(string(rb), 400))
+	}
+	var out anthropicResponse
+	if err := json.Unmarshal(rb, &out); err != nil {
+		return ChatResponse{}, err
+	}
+	if out.Error != nil {
+		return ChatResponse{}, fmt.Errorf("anthropic: %s", out.Error.Message)
+	}
+	var sb strings.Builder
+	var calls []ToolCall
+	for _, c := range out.Content {
+		switch c.Type {
+		case "text":
+			sb.WriteString(c.Text)
+		case "tool_use":
+			calls = append(calls, ToolCall{ID: c.ID, Name: c.Name, Arguments: string(c.Input)})
+		}
+	}
+	result := ChatResponse{Content: sb.String(), FinishReason: anthropicFinishReason(out.StopReason), ToolCalls: calls}
+	if out.Usage != nil {
+		result.Usage = Usage{PromptTokens: out.Usage.InputTokens, CompletionTokens: out.Usage.OutputTokens}
+	}
+	return result, nil
+}
+
+// Stream implements Streamer using the Messages API event stream.
+func (p *anthropicProvider) Stream(ctx context.Context, req ChatRequest, onText func(string)) (ChatResponse, error) {
+	req.Tools = nil
+	body := toAnthropicRequest(req)
+	body.Stream = true
+	resp, err := p.post(ctx, body)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		rb, _ := io.ReadAll(resp.Body)
+		return ChatResponse{}, fmt.Errorf("anthropic status=%d body=%s", resp.StatusCode, preview(string(rb), 400))
+	}
+	var sb strings.Builder
+	var stopReason string
+	var usage Usage
+	var streamErr error
+	err = readSSE(resp.Body, func(_, data string) bool {
+		var ev anthropicStreamEvent
+		if json.Unmarshal([]byte(data), &ev) != nil {
+			return true
+		}
+		switch ev.Type {
+		case "message_start":
+			if ev.Message.Usage != nil {
+				usage.PromptTokens = ev.Message.Usage.InputTokens
+			}
+		case "content_block_delta":
+			if ev.Delta.Type == "text_delta" && ev.Delta.Text != "" {
+				sb.WriteString(ev.Delta.Text)
+				onText(ev.Delta.Text)
+			}
+		case "message_delta":
+			if ev.Delta.StopReason != "" {
+				stopReason = ev.Delta.StopReason
+			}
+			if ev.Usage != nil {
+				usage.CompletionTokens = ev.Usage.OutputTokens
+			}
+		case "message_stop":
+			return false
+		case "error":
+			if ev.Error != nil {
+				streamErr = fmt.Errorf("anthropic: %s", ev.Error.Message)
+			} else {
+				streamErr = errors.New("anthropic: stream error")
+			}
+			return false
+		}
+		return true
+	})
+	if err == nil {
+		err = streamErr
+	}
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	return ChatResponse{Content: sb.String(), FinishReason: anthropicFinishReason(stopReason), Usage: usage}, nil
+}
+
+// post sends body to /messages with the API key and version headers.
+func (p *anthropicProvider) post(ctx context.Context, body anthropicRequest) (*http.Response, error) {
+	if p.apiKey == "" {
+		return nil, errors.New("missing ANTHROPIC_API_KEY")
+	}
+	b, _ := json.Marshal(body)
+	httpReq, _ := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/messages", bytes.NewReader(b))
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+	httpReq.Header.Set("Content-Type", "application/json")
+	return p.httpClient.Do(httpReq)
+}
+
+// anthropicFinishReason maps a stop_reason to the OpenAI vocabulary.
+func anthropicFinishReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	}
+	return "stop"
+}
+
+// toAnthropicRequest converts an OpenAI-shaped request.  Anthropic requires
+// max_tokens and caps temperature at 1.0; a zero temperature is left unset
+// so the API default applies, mirroring the OpenAI omitempty behaviour.
+// Consecutive "tool" messages are merged into a single user turn of
+// tool_result blocks, as the Messages API expects.
+func toAnthropicRequest(req ChatRequest) anthropicRequest {
+	out := anthropicRequest{Model: req.Model, MaxTokens: req.MaxTokens}
+	if out.MaxTokens <= 0 {
+		out.MaxTokens = 4096
+	}
+	if req.Temperature > 0 {
+		t := req.Temperature
+		if t > 1 {
+			t = 1
+		}
+		out.Temperature = &t
+	}
+	var system []string
+	for _, m := range req.Messages {
+		if m.Role == "system" {
+			if s := strings.TrimSpace(m.Content); s != "" {
+				system = append(system, s)
+			}
+			continue
+		}
+		if m.Role == "tool" {
+			block := anthropicBlock{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Content}
+			if n := len(out.Messages); n > 0 && out.Messages[n-1].Role == "user" &&
+				out.Messages[n-1].Content[0].Type == "tool_result" {
+				out.Messages[n-1].Content = append(out.Messages[n-1].Content, block)
+			} else {
+				out.Messages = append(out.Messages, anthropicMessage{Role: "user", Content: []anthropicBlock{block}})
+			}
+			continue
+		}
+		role := m.Role
+		if role != "assistant" {
+			role = "user"
+		}
+		out.Messages = append(out.Messages, anthropicMessage{Role: role, Content: toAnthropicBlocks(m)})
+	}
+	out.System = strings.Join(system, "\n\n")
+	for _, t := range req.Tools {
+		out.Tools = append(out.Tools, anthropicTool{Name: t.Name, Description: t.Description, InputSchema: t.Parameters})
+	}
+	return out
+}
+
+// toAnthropicBlocks converts the content of a single message.
+func toAnthropicBlocks(m OpenAIMessage) []anthropicBlock {
+	if len(m.ToolCalls) > 0 {
+		var blocks []anthropicBlock
+		if strings.TrimSpace(m.Content) != "" {
+			blocks = append(blocks, anthropicBlock{Type: "text", Text: m.Content})
+		}
+		for _, tc := range m.ToolCalls {
+			input := json.RawMessage(tc.Arguments)
+			if strings.TrimSpace(tc.Arguments) == "" {
+				input = json.RawMessage("{}")
+			}
+			blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: tc.ID, Name: tc.Name, Input: input})
+		}
+		return blocks
+	}
+	if len(m.ContentParts) == 0 {
+		return []anthropicBlock{{Type: "text", Text: m.Content}}
+	}
+	blocks := make([]anthropicBlock, 0, len(m.ContentParts))
+	for _, part := range m.ContentParts {
+		switch {
+		case part.Type == "text":
+			blocks = append(blocks, anthropicBlock{Type: "text", Text: part.Text})
+		case part.Type == "image_url" && part.ImageURL != nil:
+			blocks = append(blocks, anthropicBlock{Type: "image", Source: toAnthropicImageSource(part.ImageURL.URL)})
+		}
+	}
+	return blocks
+}
+
+// toAnthropicImageSource converts a data URL ("data:image/png;base64,...")
+// into an inline base64 source, or passes a remote URL through.
+func toAnthropicImageSource(u string) *anthropicImageSource {
+	if rest, ok := strings.CutPrefix(u, "data:"); ok {
+		meta, data, found := strings.Cut(rest, ",")
+		if found {
+			return &anthropicImageSource{
+				Type:      "base64",
+				MediaType: strings.TrimSuffix(meta, ";base64"),
+				Data:      data,
+			}
+		}
+	}
+	return &anthropicImageSource{Type: "url", URL: u}
+}
@@ -0,0 +1,315 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// answerMaxTokens is the completion budget requested for answers.  Models
+// with small context windows get at most a quarter of their window.
+const answerMaxTokens = 20000
+
+// defaultContextWindow is assumed for models that are neither in
+// knownContextWindows nor in LLM_CONTEXT_WINDOWS.
+const defaultContextWindow = 128_000
+
+// knownContextWindows maps model-name prefixes to their context window in
+// tokens.  The longest matching prefix wins.
+var knownContextWindows = map[string]int{
+	"gpt-3.5-turbo": 16_385,
+	"gpt-4":         8_192,
+	"gpt-4-turbo":   128_000,
+	"gpt-4o":        128_000,
+	"gpt-4.1":       1_047_576,
+	"gpt-5":         400_000,
+	"o1":            200_000,
+	"o3":            200_000,
+	"o4":            200_000,
+	"claude":        200_000,
+}
+
+// Budget parts that are not skill sections, with their base share of the
+// budget.  Files were attached on purpose, so they lose data last; skill
+// sections bring their own weight (see ContextSection).
+const (
+	budgetThread = "thread"
+	budgetFiles  = "files"
+
+	threadWeight = 2
+	filesWeight  = 3
+)
+
+// ContextWindow returns the context window, in tokens, assumed for model:
+// an LLM_CONTEXT_WINDOWS entry first, then the built-in table, then 128k.
+func (c *Client) ContextWindow(model string) int {
+	if n, ok := c.contextWindows[model]; ok {
+		return n
+	}
+	best, window := -1, 0
+	for pattern, n := range c.contextWindows {
+		prefix, isPrefix := strings.CutSuffix(pattern, "*")
+		if isPrefix && strings.HasPrefix(model, prefix) && len(prefix) > best {
+			best, window = len(prefix), n
+		}
+	}
+	if window > 0 {
+		return window
+	}
+	for prefix, n := range knownContextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > best {
+			best, window = len(prefix), n
+		}
+	}
+	if window > 0 {
+		return window
+	}
+	return defaultContextWindow
+}
+
+// EstimateTokens approximates how many BPE tokens s costs.  Words cost one
+// token per ~4 letters, numbers one per ~3 digits and each punctuation mark
+// or symbol one token; whitespace is folded into the following word.
+//
+// It is a heuristic, not a tokenizer: counting exactly would need each
+// model's vocabulary (megabytes per OpenAI encoding, and Claude's is not
+// published) for a number that only sizes the prompt.  It tends to run high
+// on Portuguese prose and low on text the tokenizers split finely — rare or
+// accented words, identifiers, base64, non-Latin scripts.  Budgeting assumes
+// it undercounts by up to estimateErrorMargin (see contextBudget).
+func EstimateTokens(s string) int {
+	n, letters, digits := 0, 0, 0
+	flush := func() {
+		n += (letters+3)/4 + (digits+2)/3
+		letters, digits = 0, 0
+	}
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r):
+			if digits > 0 {
+				flush()
+			}
+			letters++
+		case unicode.IsDigit(r):
+			if letters > 0 {
+				flush()
+			}
+			digits++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			n++
+		}
+	}
+	flush()
+	return n
+}
+
+// estimateErrorMargin is how much EstimateTokens is assumed to undercount
+// real tokenizers.  Prompts sized with the estimate keep this share of the
+// window free so an undercount does not overflow the model's context.
+const estimateErrorMargin = 0.15
+
+// contextBudget returns the estimated tokens left for the thread, skill
+// sections and files of an answer prompt, given the model's window, the
+// completion budget, the number of images and the estimated size of the
+// instructions and question.  Estimated tokens are scaled by
+// estimateErrorMargin; the completion, images and fixed prompt overhead are
+// not estimates and are taken as they are.
+func contextBudget(window, maxTokens, images, fixedTokens int) int {
+	room := window - maxTokens - promptOverheadTokens - images*imageTokens
+	return int(float64(room)/(1+estimateErrorMargin)) - fixedTokens
+}
+
+// ContextTrim records one context part that was cut to fit the budget.
+type ContextTrim struct {
+	Name   string // "thread", "files" or the section's action kind
+	Tokens int    // estimated size before trimming
+	Kept   int    // tokens allotted
+}
+
+func (t ContextTrim) String() string {
+	return fmt.Sprintf("%s %d→%d", t.Name, t.Tokens, t.Kept)
+}
+
+// budgetPart is one piece of context competing for the prompt budget.
+type budgetPart struct {
+	name   string
+	text   string
+	tail   bool // keep the end instead of the beginning (conversation history)
+	weight float64
+	tokens int
+	alloc  int
+}
+
+// fitContext shrinks the thread history, skill sections and file context so
+// their estimated total stays within available tokens.  The budget is shared
+// by weight — the part's base weight, boosted by how many of the question's
+// terms the part mentions — and parts smaller than their share give the
+// remainder back to the others.  Trimmed parts carry an [AVISO: …] marker;
+// the returned trims list what was cut.
+func fitContext(question, threadHistory string, sections []ContextSection, fileCtx string, available int) (string, []ContextSection, string, []ContextTrim) {
+	terms := questionTerms(question)
+	thread := newBudgetPart(budgetThread, threadHistory, threadWeight, terms)
+	thread.tail = true
+	files := newBudgetPart(budgetFiles, fileCtx, filesWeight, terms)
+	parts := []*budgetPart{thread, files}
+	secParts := make([]*budgetPart, len(sections))
+	for i, sec := range sections {
+		secParts[i] = newBudgetPart(sec.Kind, sec.Text, sec.Weight, terms)
+		parts = append(parts, secParts[i])
+	}
+
+	total := 0
+	for _, p := range parts {
+		total += p.tokens
+	}
+	if total <= available {
+		return threadHistory, sections, fileCtx, nil
+	}
+	allocate(parts, available)
+
+	var trims []ContextTrim
+	for _, p := range parts {
+		if p.alloc >= p.tokens {
+			continue
+		}
+		trims = append(trims, ContextTrim{Name: p.name, Tokens: p.tokens, Kept: p.alloc})
+		p.text = trimToTokens(p.text, p.alloc, p.tail)
+	}
+	sort.Slice(trims, func(i, j int) bool { return trims[i].Tokens-trims[i].Kept > trims[j].Tokens-trims[j].Kept })
+
+	out := make([]ContextSection, len(sections))
+	for i, sec := range sections {
+		sec.Text = secParts[i].text
+		out[i] = sec
+	}
+	return thread.text, out, files.text, trims
+}
+
+func newBudgetPart(name, text string, weight float64, terms []string) *budgetPart {
+	p := &budgetPart{name: name, text: text, tokens: EstimateTokens(text)}
+	if p.tokens == 0 {
+		return p
+	}
+	p.weight = weight
+	if p.weight == 0 {
+		p.weight = 1
+	}
+	if len(terms) > 0 {
+		lower := strings.ToLower(text)
+		hits := 0
+		for _, t := range terms {
+			if strings.Contains(lower, t) {
+				hits++
+			}
+		}
+		p.weight *= 1 + float64(hits)/float64(len(terms))
+	}
+	return p
+}
+
+// allocate distributes available tokens across parts by weight.  Parts that
+// fit in their share are granted in full and leave the rest to the others.
+func allocate(parts []*budgetPart, available int) {
+	var pending []*budgetPart
+	for _, p := range parts {
+		if p.tokens > 0 {
+			pending = append(pending, p)
+		}
+	}
+	remaining := max(available, 0)
+	for len(pending) > 0 {
+		sum := 0.0
+		for _, p := range pending {
+			sum += p.weight
+		}
+		var next []*budgetPart
+		granted := 0
+		for _, p := range pending {
+			if share := float64(remaining) * p.weight / sum; float64(p.tokens) <= share {
+				p.alloc = p.tokens
+				granted += p.tokens
+			} else {
+				next = append(next, p)
+			}
+		}
+		if granted == 0 {
+			for _, p := range pending {
+				p.alloc = int(float64(remaining) * p.weight / sum)
+			}
+			return
+		}
+		remaining -= granted
+		pending = next
+	}
+}
+
+// trimToTokens cuts s to roughly maxTokens, keeping the beginning (or the
+// end when tail is set) and breaking at a line boundary when one is close.
+func trimToTokens(s string, maxTokens int, tail bool) string {
+	const markerTokens = 40
+	total := EstimateTokens(s)
+	keep := maxTokens - markerTokens
+	if keep <= 0 {
+		return fmt.Sprintf("[AVISO: contexto omitido — ~%d tokens não cabem no limite do modelo]", total)
+	}
+	n := len(s) * keep / total
+	for {
+		cut := cutAt(s, n, tail)
+		if EstimateTokens(cut) <= keep || n == 0 {
+			if tail {
+				return fmt.Sprintf("[AVISO: início omitido — mantidos ~%d de %d tokens para caber no limite do modelo]\n", keep, total) + cut
+			}
+			return cut + fmt.Sprintf("\n[AVISO: contexto truncado — mantidos ~%d de %d tokens para caber no limite do modelo]", keep, total)
+		}
+		n = n * 9 / 10
+	}
+}
+
+// cutAt returns the first (or last, when tail is set) n bytes of s, moved to
+// the nearest line break within the final 10% and to a rune boundary.
+func cutAt(s string, n int, tail bool) string {
+	if n >= len(s) {
+		return s
+	}
+	slack := n / 10
+	if tail {
+		start := len(s) - n
+		if i := strings.IndexByte(s[start:min(start+slack, len(s))], '\n'); i >= 0 {
+			start += i + 1
+		}
+		for start < len(s) && !utf8.RuneStart(s[start]) {
+			start++
+		}
+		return s[start:]
+	}
+	end := n
+	if i := strings.LastIndexByte(s[end-slack:end], '\n'); i >= 0 {
+		end = end - slack + i
+	}
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end]
+}
+
+// questionTerms returns the distinct lowercase words of at least four
+// letters in the question, used to rank context parts by relevance.
+func questionTerms(question string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if utf8.RuneCountInString(w) < 4 || seen[w] {
+			continue
+		}
+		seen[w] = true
+		terms = append(terms, w)
+	}
+	return terms
+}
@@ -0,0 +1,25 @@
+package llm
+
+import "testing"
+
+func TestContextBudgetKeepsErrorMargin(t *testing.T) {
+	for _, tt := range []struct {
+		window, maxTokens, images, fixed int
+	}{
+		{8_192, 2_048, 0, 1_200},
+		{128_000, 20_000, 2, 3_000},
+		{1_047_576, 20_000, 0, 3_000},
+	} {
+		budget := contextBudget(tt.window, tt.maxTokens, tt.images, tt.fixed)
+		// The estimated part of the prompt, undercounted by the full margin,
+		// must still fit next to the completion, images and overhead.
+		worst := float64(budget+tt.fixed)*(1+estimateErrorMargin) +
+			float64(tt.maxTokens+promptOverheadTokens+tt.images*imageTokens)
+		if worst > float64(tt.window) {
+			t.Errorf("window=%d: budget %d overflows the window in the worst case (%.0f tokens)", tt.window, budget, worst)
+		}
+		if worst < float64(tt.window)-2 {
+			t.Errorf("window=%d: budget %d leaves %.0f tokens unused", tt.window, budget, float64(tt.window)-worst)
+		}
+	}
+}
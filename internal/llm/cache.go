@@ -0,0 +1,345 @@
+package llm
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+// defaultCacheTTLs lists the cached call sites and their TTLs;
+// LLM_CACHE_TTLS overrides them by site name.
+var defaultCacheTTLs = map[string]time.Duration{
+	siteEnhance: 24 * time.Hour,
+	siteRouter:  6 * time.Hour,
+	siteIntent:  24 * time.Hour,
+	siteSQL:     12 * time.Hour,
+}
+
+const cacheMigrateSQL = `
+CREATE TABLE IF NOT EXISTS llm_cache (
+    key        TEXT        PRIMARY KEY,
+    site       TEXT        NOT NULL,
+    value      TEXT        NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS llm_cache_expires_at ON llm_cache (expires_at);
+`
+
+// Cache memoises deterministic LLM calls (routing, prompt enhancement, intent
+// classification, SQL generation) keyed on the call site, the model and a hash
+// of the normalised prompt.  Entries live in an in-memory LRU and, when
+// TELEMETRY_DB_URL is set, in a Postgres table shared by every replica.
+//
+// A nil *Cache is valid and never hits.
+type Cache struct {
+	size int
+	ttls map[string]time.Duration
+	db   *sql.DB
+
+	mu    sync.Mutex
+	ll    *list.List // front = most recently used
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key, site, value string
+	expires          time.Time
+}
+
+// NewCache builds the response cache from LLM_CACHE_* settings.  It returns
+// nil when LLM_CACHE_SIZE is 0.  A Postgres failure only disables the
+// persistent layer.
+func NewCache(cfg config.Config) *Cache {
+	if cfg.LLMCacheSize <= 0 {
+		return nil
+	}
+	c := &Cache{
+		size:  cfg.LLMCacheSize,
+		ttls:  make(map[string]time.Duration, len(defaultCacheTTLs)),
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+	for site, ttl := range defaultCacheTTLs {
+		c.ttls[site] = ttl
+	}
+	for site, ttl := range cfg.LLMCacheTTLs {
+		c.ttls[site] = ttl
+	}
+	if cfg.LLMCachePersist && cfg.TelemetryDBURL != "" {
+		c.db = openCacheDB(cfg.TelemetryDBURL)
+	}
+	log.Printf("[BOOT] LLM cache size=%d persistent=%t ttls=%v", c.size, c.db != nil, c.ttls)
+	return c
+}
+
+func openCacheDB(dsn string) *sql.DB {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		log.Printf("[LLM][cache] open failed: %v — using memory only", err)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		log.Printf("[LLM][cache] ping failed: %v — using memory only", err)
+		_ = db.Close()
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, cacheMigrateSQL); err != nil {
+		log.Printf("[LLM][cache] migrate failed: %v — using memory only", err)
+		_ = db.Close()
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, `DELETE FROM llm_cache WHERE expires_at < now()`); err != nil {
+		log.Printf("[LLM][cache] purge failed: %v", err)
+	}
+	return db
+}
+
+// get returns the cached value for key.  Memory misses fall through to
+// Postgres with a short timeout so a slow database never delays a message.
+func (c *Cache) get(ctx context.Context, key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*cacheEntry)
+		if time.Now().Before(e.expires) {
+			c.ll.MoveToFront(el)
+			c.mu.Unlock()
+			return e.value, true
+		}
+		c.ll.Remove(el)
+		delete(c.items, key)
+	}
+	c.mu.Unlock()
+
+	if c.db == nil {
+		return "", false
+	}
+	dbCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	var e cacheEntry
+	err := c.db.QueryRowContext(dbCtx,
+		`SELECT site, value, expires_at FROM llm_cache WHERE key = $1 AND expires_at > now()`, key,
+	).Scan(&e.site, &e.value, &e.expires)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("[LLM][cache] lookup failed: %v", err)
+		}
+		return "", false
+	}
+	e.key = key
+	c.put(&e)
+	return e.value, true
+}
+
+// set stores value under key for the TTL of site.  The Postgres write runs in
+// the background.
+func (c *Cache) set(key, site, value string) {
+	ttl := c.ttl(site)
+	if ttl <= 0 {
+		return
+	}
+	e := &cacheEntry{key: key, site: site, value: value, expires: time.Now().Add(ttl)}
+	c.put(e)
+	if c.db == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_, err := c.db.ExecContext(ctx, `
+INSERT INTO llm_cache (key, site, value, expires_at) VALUES ($1, $2, $3, $4)
+ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at`,
+			e.key, e.site, e.value, e.expires)
+		if err != nil {
+			log.Printf("[LLM][cache] store failed: %v", err)
+		}
+	}()
+}
+
+func (c *Cache) put(e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[e.key]; ok {
+		el.Value = e
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[e.key] = c.ll.PushFront(e)
+	for c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// forget drops every entry of site whose output is value — used when a
+// cached output turned out to be wrong (e.g. SQL that no longer runs).  Callers
+// see the output after post-processing (code fences stripped, trimmed), so a
+// stored value also matches once it is post-processed the same way; anything
+// else, including a longer output that merely contains value, is kept.
+func (c *Cache) forget(site, value string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	for el := c.ll.Front(); el != nil; {
+		next := el.Next()
+		if e := el.Value.(*cacheEntry); e.site == site && sameOutput(e.value, value) {
+			c.ll.Remove(el)
+			delete(c.items, e.key)
+		}
+		el = next
+	}
+	c.mu.Unlock()
+	if c.db == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		// strpos narrows the scan; the exact match is decided here.
+		rows, err := c.db.QueryContext(ctx, `SELECT key, value FROM llm_cache WHERE site = $1 AND strpos(value, $2) > 0`, site, value)
+		if err != nil {
+			log.Printf("[LLM][cache] forget failed: %v", err)
+			return
+		}
+		var keys []string
+		for rows.Next() {
+			var key, stored string
+			if err := rows.Scan(&key, &stored); err != nil {
+				log.Printf("[LLM][cache] forget failed: %v", err)
+				break
+			}
+			if sameOutput(stored, value) {
+				keys = append(keys, key)
+			}
+		}
+		rows.Close()
+		for _, key := range keys {
+			if _, err := c.db.ExecContext(ctx, `DELETE FROM llm_cache WHERE key = $1`, key); err != nil {
+				log.Printf("[LLM][cache] forget failed: %v", err)
+			}
+		}
+	}()
+}
+
+// sameOutput reports whether the stored output is value, either verbatim or
+// after the post-processing callers apply to it.
+func sameOutput(stored, value string) bool {
+	return stored == value || stripCodeFences(stored) == value
+}
+
+func (c *Cache) ttl(site string) time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.ttls[site]
+}
+
+// cacheKey hashes everything that can change the output of a call.  Prompt
+// text is whitespace-normalised so re-indented templates keep their entries.
+func cacheKey(site, model string, temperature float64, maxTokens int, messages []OpenAIMessage, tools []ToolSpec) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%g\x00%d", site, model, temperature, maxTokens)
+	for _, m := range messages {
+		fmt.Fprintf(h, "\x00%s\x00%s\x00%s", m.Role, normalizePrompt(m.Content), m.ToolCallID)
+		for _, tc := range m.ToolCalls {
+			fmt.Fprintf(h, "\x00%s\x00%s\x00%s", tc.ID, tc.Name, tc.Arguments)
+		}
+	}
+	if len(tools) > 0 {
+		b, _ := json.Marshal(tools)
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func normalizePrompt(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// relativeTimeRe matches questions whose meaning depends on when they are
+// asked ("hoje", "semana passada", "últimos 7 dias", "agora"...).
+var relativeTimeRe = regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}])(hoje|ontem|amanh[ãa]|agora|atualmente|neste momento|essa semana|esta semana|semana (passada|que vem|anterior)|(este|esse|neste|nesse) m[êe]s|m[êe]s (passado|anterior|atual)|(este|esse|neste|nesse) ano|ano (passado|anterior|atual)|[úu]ltim[oa]s? \d*\s*(minutos?|horas?|dias?|semanas?|meses|m[êe]s|anos?)|pr[óo]xim[oa]s? \d*\s*(dias?|semanas?|meses|m[êe]s)|recentes?|recentemente|today|yesterday|now|this (week|month|year)|last (week|month|year))(?:$|[^\p{L}\p{N}])`)
+
+// cacheBypass reports whether a call must skip the cache: the question uses a
+// relative time expression and the prompt does not pin today's date (prompts
+// that do — routing, SQL — get a new key every day by construction).
+func cacheBypass(question string, messages []OpenAIMessage) bool {
+	if !relativeTimeRe.MatchString(question) {
+		return false
+	}
+	today := time.Now().Format("2006-01-02")
+	for _, m := range messages {
+		if strings.Contains(m.Content, today) {
+			return false
+		}
+	}
+	return true
+}
+
+// cachedChat is Chat behind the response cache for site.  question is the
+// user's message, used for the date-sensitivity bypass.  Only successful,
+// non-empty completions are stored.
+func (c *Client) cachedChat(ctx context.Context, site, question string, messages []OpenAIMessage, model string, temperature float64, maxTokens int) (string, error) {
+	ctx = withSite(ctx, site)
+	if c.cache == nil || c.cache.ttl(site) <= 0 || cacheBypass(question, messages) {
+		return c.Chat(ctx, messages, model, temperature, maxTokens)
+	}
+	key := cacheKey(site, model, temperature, maxTokens, messages, nil)
+	if out, ok := c.cache.get(ctx, key); ok {
+		log.Printf("[LLM][cache] hit site=%s model=%s", site, model)
+		return out, nil
+	}
+	out, err := c.Chat(ctx, messages, model, temperature, maxTokens)
+	if err == nil && strings.TrimSpace(out) != "" {
+		c.cache.set(key, site, out)
+	}
+	return out, err
+}
+
+// cachedChatWithTools is ChatWithTools behind the response cache for site.
+func (c *Client) cachedChatWithTools(ctx context.Context, site, question string, messages []OpenAIMessage, tools []ToolSpec, model string, temperature float64, maxTokens int) (ChatResponse, error) {
+	ctx = withSite(ctx, site)
+	if c.cache == nil || c.cache.ttl(site) <= 0 || cacheBypass(question, messages) {
+		return c.ChatWithTools(ctx, messages, tools, model, temperature, maxTokens)
+	}
+	key := cacheKey(site, model, temperature, maxTokens, messages, tools)
+	if raw, ok := c.cache.get(ctx, key); ok {
+		var resp ChatResponse
+		if err := json.Unmarshal([]byte(raw), &resp); err == nil {
+			log.Printf("[LLM][cache] hit site=%s model=%s", site, model)
+			return resp, nil
+		}
+	}
+	resp, err := c.ChatWithTools(ctx, messages, tools, model, temperature, maxTokens)
+	if err == nil && (len(resp.ToolCalls) > 0 || strings.TrimSpace(resp.Content) != "") {
+		if b, mErr := json.Marshal(resp); mErr == nil {
+			c.cache.set(key, site, string(b))
+		}
+	}
+	return resp, err
+}
+
+// ForgetSQL evicts a generated query from the cache after it failed to run,
+// so the next identical question asks the model again.
+func (c *Client) ForgetSQL(query string) {
+	c.cache.forget(siteSQL, query)
+}
@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+func TestCacheForgetMatchesWholeOutput(t *testing.T) {
+	c := NewCache(config.Config{LLMCacheSize: 16})
+	c.set("k1", siteSQL, "SELECT * FROM issues WHERE project = PROJ-1")
+	c.set("k12", siteSQL, "SELECT * FROM issues WHERE project = PROJ-12")
+	c.set("fenced", siteSQL, "```\nSELECT 2\n```")
+	c.set("other", siteIntent, "SELECT * FROM issues WHERE project = PROJ-1")
+
+	c.forget(siteSQL, "SELECT * FROM issues WHERE project = PROJ-1")
+	c.forget(siteSQL, "SELECT 2")
+
+	ctx := context.Background()
+	if _, ok := c.get(ctx, "k1"); ok {
+		t.Error("forgotten query is still cached")
+	}
+	if _, ok := c.get(ctx, "fenced"); ok {
+		t.Error("fenced query still cached after forgetting its stripped form")
+	}
+	if _, ok := c.get(ctx, "k12"); !ok {
+		t.Error("forgetting PROJ-1 evicted the PROJ-12 query")
+	}
+	if _, ok := c.get(ctx, "other"); !ok {
+		t.Error("forget evicted an entry of another site")
+	}
+}
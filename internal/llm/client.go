@@ -1,13 +1,9 @@
 package llm
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
 	"strings"
 	"time"
 
@@ -18,97 +14,95 @@ import (
 // LLM needs additional information from the user before generating a query.
 const ClarificationPrefix = "CLARIFICATION_NEEDED:"
 
-// Client encapsulates credentials and capability flags used across all LLM calls.
+// schemaMaxTokens caps the schema context sent to GenerateSQL.
+const schemaMaxTokens = 32000
+
+// Client encapsulates credentials and settings used across all LLM calls.
 type Client struct {
-	APIKey      string
-	JiraBaseURL string
-	BotName     string
-	// JiraEnabled, MetabaseEnabled, OutlineEnabled, GoogleDriveEnabled, and
-	// HubSpotEnabled tell the answer LLM which integrations are active so it
-	// can respond accurately when asked.
-	JiraEnabled        bool
-	MetabaseEnabled    bool
-	OutlineEnabled     bool
-	GoogleDriveEnabled bool
-	HubSpotEnabled     bool
+	APIKey  string
+	BotName string
+
+	// providers holds every configured chat backend keyed by provider name;
+	// modelProviders and defaultProvider decide which one serves a model.
+	providers       map[string]Provider
+	modelProviders  map[string]string
+	defaultProvider string
+
+	// nativeTools routes actions through provider tool calling instead of a
+	// bare JSON array; agentMaxSteps bounds the router rounds of an ActionPlan.
+	nativeTools   bool
+	agentMaxSteps int
+
+	// contextWindows overrides the built-in context window table used to
+	// size the answer prompt (see ContextWindow).
+	contextWindows map[string]int
+
+	// cache memoises routing, enhancement, intent and SQL generation calls;
+	// nil when LLM_CACHE_SIZE=0.
+	cache *Cache
+
+	// prices is knownPrices merged with LLM_PRICES, used to cost usage.
+	prices map[string]config.ModelPrice
 }
 
 // NewClient constructs a new LLM client from the provided configuration.
 func NewClient(cfg config.Config) *Client {
+	prices := make(map[string]config.ModelPrice, len(knownPrices)+len(cfg.LLMPrices))
+	for model, p := range knownPrices {
+		prices[model] = p
+	}
+	for model, p := range cfg.LLMPrices {
+		prices[model] = p
+	}
 	return &Client{
-		APIKey:             cfg.OpenAIAPIKey,
-		JiraBaseURL:        cfg.JiraBaseURL,
-		BotName:            cfg.BotName,
-		JiraEnabled:        cfg.JiraEnabled(),
-		MetabaseEnabled:    cfg.MetabaseEnabled(),
-		OutlineEnabled:     cfg.OutlineEnabled(),
-		GoogleDriveEnabled: cfg.GoogleDriveEnabled(),
-		HubSpotEnabled:     cfg.HubSpotEnabled(),
+		APIKey:          cfg.OpenAIAPIKey,
+		BotName:         cfg.BotName,
+		providers:       newProviders(cfg),
+		modelProviders:  cfg.LLMModelProviders,
+		defaultProvider: cfg.LLMDefaultProvider,
+		nativeTools:     cfg.LLMNativeTools,
+		agentMaxSteps:   cfg.LLMAgentMaxSteps,
+		contextWindows:  cfg.LLMContextWindows,
+		cache:           NewCache(cfg),
+		prices:          prices,
 	}
 }
 
-// Chat calls the OpenAI chat completions endpoint with the supplied
-// messages and model.  Temperature and maxTokens control the
+// Chat sends the supplied messages to the provider configured for model
+// (see LLM_MODEL_PROVIDERS).  Temperature and maxTokens control the
 // creativity and length of the response.  The content of the first
 // choice is returned on success.  Errors include HTTP failures,
 // decoding failures and API-level errors.
-func (c *Client) Chat(messages []OpenAIMessage, model string, temperature float64, maxTokens int) (string, error) {
-	if c.APIKey == "" {
-		return "", errors.New("missing OPENAI_API_KEY")
-	}
+func (c *Client) Chat(ctx context.Context, messages []OpenAIMessage, model string, temperature float64, maxTokens int) (string, error) {
 	if strings.TrimSpace(model) == "" {
 		model = "gpt-4o-mini"
 	}
-	return c.chatWithTemperature(messages, model, temperature, maxTokens)
-}
-
-// chatWithTemperature performs the actual HTTP call.  If the model rejects
-// the requested temperature (e.g., gpt-5-mini only accepts the default),
-// it retries once without a custom temperature.
-func (c *Client) chatWithTemperature(messages []OpenAIMessage, model string, temperature float64, maxTokens int) (string, error) {
-	reqBody := openAIChatRequest{
-		Model:               model,
-		Messages:            messages,
-		Temperature:         temperature,
-		MaxCompletionTokens: maxTokens,
-	}
-	b, _ := json.Marshal(reqBody)
-	req, _ := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewReader(b))
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
-	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{Timeout: 90 * time.Second}
-	resp, err := client.Do(req)
+	p, err := c.providerFor(model)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-	rb, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode >= 300 {
-		bodyStr := string(rb)
-		// Retry without temperature when the model doesn't support a custom value.
-		if resp.StatusCode == 400 && strings.Contains(bodyStr, "\"temperature\"") && temperature != 0 {
-			log.Printf("[LLM] model %s rejected temperature=%.1f — retrying with default", model, temperature)
-			return c.chatWithTemperature(messages, model, 0, maxTokens)
-		}
-		return "", fmt.Errorf("openai status=%d body=%s", resp.StatusCode, preview(bodyStr, 400))
-	}
-	var out openAIChatResponse
-	if err := json.Unmarshal(rb, &out); err != nil {
+	resp, err := p.Complete(ctx, ChatRequest{
+		Model:       model,
+		Messages:    messages,
+		Temperature: temperature,
+		MaxTokens:   maxTokens,
+	})
+	if err != nil {
 		return "", err
 	}
-	if out.Error != nil {
-		return "", fmt.Errorf("openai: %s", out.Error.Message)
-	}
-	if len(out.Choices) == 0 {
-		return "", errors.New("openai: no choices")
-	}
-	content := strings.TrimSpace(out.Choices[0].Message.Content)
-	finishReason := out.Choices[0].FinishReason
+	c.recordUsage(ctx, model, messages, resp)
+	return responseText(p, model, resp)
+}
+
+// responseText returns the trimmed content of a text completion, flagging
+// answers cut off by max_tokens.
+func responseText(p Provider, model string, resp ChatResponse) (string, error) {
+	content := strings.TrimSpace(resp.Content)
 	// If response truncated due to length and no content, return error
-	if finishReason == "length" && content == "" {
-		return "", fmt.Errorf("openai: response truncated at max_tokens with no content (model=%s)", model)
+	if resp.FinishReason == "length" && content == "" {
+		return "", fmt.Errorf("%s: response truncated at max_tokens with no content (model=%s)", p.Name(), model)
 	}
-	if finishReason == "length" {
+	if resp.FinishReason == "length" {
 		// Append a note if truncated in the middle of a sentence
 		if !strings.HasSuffix(content, ".") && !strings.HasSuffix(content, "!") && !strings.HasSuffix(content, "?") {
 			content += "\n_(report truncada - tente uma pergunta mais especifica)_"
@@ -117,10 +111,38 @@ func (c *Client) chatWithTemperature(messages []OpenAIMessage, model string, tem
 	return content, nil
 }
 
+// ChatWithTools is like Chat but declares tools the model may call.  The
+// raw response is returned so callers can read ToolCalls; Content is left
+// untouched because an empty content is normal when tools are requested.
+func (c *Client) ChatWithTools(ctx context.Context, messages []OpenAIMessage, tools []ToolSpec, model string, temperature float64, maxTokens int) (ChatResponse, error) {
+	if strings.TrimSpace(model) == "" {
+		model = "gpt-4o-mini"
+	}
+	p, err := c.providerFor(model)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	resp, err := p.Complete(ctx, ChatRequest{
+		Model:       model,
+		Messages:    messages,
+		Temperature: temperature,
+		MaxTokens:   maxTokens,
+		Tools:       tools,
+	})
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	c.recordUsage(ctx, model, messages, resp)
+	if resp.FinishReason == "length" && len(resp.ToolCalls) == 0 && strings.TrimSpace(resp.Content) == "" {
+		return ChatResponse{}, fmt.Errorf("%s: response truncated at max_tokens with no content (model=%s)", p.Name(), model)
+	}
+	return resp, nil
+}
+
 // ActionDescriptor is a single skill the bot must execute for a given message.
 // Fields are optional and only populated for the kinds that need them.
 type ActionDescriptor struct {
-	Kind string `json:"kind"` // one of the Action* constants below
+	Kind string `json:"kind"` // a tool name declared by a skill
 
 	// slack_search, outline_search, googledrive_search
 	Query string `json:"query,omitempty"`
@@ -134,55 +156,67 @@ type ActionDescriptor struct {
 	WantsAllRows       bool `json:"wants_all_rows,omitempty"`
 	WantsCSVExport     bool `json:"wants_csv_export,omitempty"`
 
-	// hubspot_search
-	HubSpotObjectType string `json:"hubspot_object_type,omitempty"`
-	HubSpotQuery      string `json:"hubspot_query,omitempty"`
-	HubSpotAfter      string `json:"hubspot_after,omitempty"`     // ISO YYYY-MM-DD, inclusive
-	HubSpotBefore     string `json:"hubspot_before,omitempty"`    // ISO YYYY-MM-DD, exclusive
-	HubSpotRecordID   string `json:"hubspot_record_id,omitempty"` // numeric record ID for direct lookup
+	// Args holds every validated tool argument by name, including those
+	// without a dedicated field above (used by skills outside package llm).
+	Args map[string]any `json:"-"`
 
-	// googledrive_search
-	GoogleDriveSheetName string `json:"sheet_name,omitempty"`
+	// CallID identifies the tool call that produced this action so its
+	// result can be reported back to the model (see ActionPlan.Continue).
+	CallID string `json:"-"`
 }
 
-const (
-	ActionJiraCreate        = "jira_create"
-	ActionJiraEdit          = "jira_edit"
-	ActionJiraSearch        = "jira_search"
-	ActionSlackSearch       = "slack_search"
-	ActionMetabaseQuery     = "metabase_query"
-	ActionShowSQL           = "show_sql"
-	ActionOutlineSearch     = "outline_search"
-	ActionGoogleDriveSearch = "googledrive_search"
-	ActionHubSpotSearch     = "hubspot_search"
-)
+// StringArg returns the string argument name, or "" when it is absent.
+func (a ActionDescriptor) StringArg(name string) string {
+	v, _ := a.Args[name].(string)
+	return v
+}
+
+// RouterSnippet is a skill's contribution to the routing prompt.  Every field
+// is optional; empty fields are simply left out of the prompt.
+type RouterSnippet struct {
+	// Context describes the integration's current state (catalogs, databases,
+	// follow-up hints for the thread).
+	Context string
+	// Source is the "- Nome: ..." line listed under "Fontes disponíveis".
+	Source string
+	// Rules are the routing rules specific to the integration's tools.
+	Rules string
+	// Examples are sample action objects shown in the output format block.
+	Examples []string
+}
+
+// RouterInput is everything PlanActions needs to route a message: the
+// question and, for every enabled skill, its tools and prompt snippet.
+type RouterInput struct {
+	Question      string
+	ThreadHistory string
+	SenderUserID  string
+	Tools         []ToolDef
+	Snippets      []RouterSnippet
+}
 
 // DecideActions performs a single LLM call to determine all actions the bot must
-// execute for the given message. It replaces the prior two-call approach of
-// DetectJiraActions + DecideRetrieval.
+// execute for the given message.
 //
 // The returned slice is ordered — execution order matters (jira_create before
 // jira_edit). An empty slice means no external actions are needed. On error,
-// callers should use fallbackActions.
-func (c *Client) DecideActions(
-	question, threadHistory, model string,
-	jiraEnabled bool,
-	jiraCatalog string,
-	senderUserID string,
-	metabaseDatabases []string,
-	storedDBID int,
-	outlineEnabled bool,
-	googleDriveEnabled bool,
-	hubspotEnabled bool,
-	hubspotCatalog string,
-) ([]ActionDescriptor, error) {
-	projectsCtx := ""
-	if jiraEnabled && strings.TrimSpace(jiraCatalog) != "" {
-		projectsCtx = fmt.Sprintf("\nProjetos Jira disponíveis (formato CHAVE=Nome [tipos de issue]):\n%s\n", jiraCatalog)
+// callers should use fallbackActions.  Callers that want to feed results back
+// to the model for follow-up actions should use PlanActions instead.
+func (c *Client) DecideActions(ctx context.Context, model string, in RouterInput) ([]ActionDescriptor, error) {
+	plan, err := c.PlanActions(ctx, model, in)
+	if err != nil {
+		return nil, err
 	}
+	return plan.Actions, nil
+}
+
+// routerPrompt builds the routing prompt shared by the native tool-calling
+// and the JSON-array modes of PlanActions.  Integration-specific context,
+// sources, rules and examples come from the skills' snippets.
+func routerPrompt(in RouterInput, native bool) string {
 	senderCtx := ""
-	if strings.TrimSpace(senderUserID) != "" {
-		senderCtx = fmt.Sprintf("\nUsuário que está perguntando: <@%s> — quando a pergunta usar \"eu\", \"meu\", \"minha\", \"minhas\", \"me\" refira-se a este usuário.\n", senderUserID)
+	if strings.TrimSpace(in.SenderUserID) != "" {
+		senderCtx = fmt.Sprintf("\nUsuário que está perguntando: <@%s> — quando a pergunta usar \"eu\", \"meu\", \"minha\", \"minhas\", \"me\" refira-se a este usuário.\n", in.SenderUserID)
 	}
 
 	now := time.Now()
@@ -196,271 +230,86 @@ func (c *Client) DecideActions(
 		monday.Format("2006-01-02"),
 	)
 
-	metabaseCtx := ""
-	if len(metabaseDatabases) > 0 {
-		metabaseCtx = fmt.Sprintf("\nBancos de dados Metabase disponíveis:\n- %s\n", strings.Join(metabaseDatabases, "\n- "))
-	}
-	outlineCtxStr := ""
-	if outlineEnabled {
-		outlineCtxStr = "\nOutline Wiki está configurado e disponível para busca de documentação.\n"
-	}
-	googleDriveCtxStr := ""
-	if googleDriveEnabled {
-		googleDriveCtxStr = "\nGoogle Drive está configurado e disponível para busca de documentos e arquivos internos.\n"
-	}
-	hubspotCtxStr := ""
-	if hubspotEnabled {
-		hubspotCtxStr = "\nHubSpot CRM está configurado e disponível para busca de contatos, empresas, negociações e tickets.\n"
-		if strings.TrimSpace(hubspotCatalog) != "" {
-			hubspotCtxStr += fmt.Sprintf("Pipelines HubSpot disponíveis:\n%s\n", hubspotCatalog)
+	var contexts, sources, rules, examples []string
+	for _, sn := range in.Snippets {
+		if strings.TrimSpace(sn.Context) != "" {
+			contexts = append(contexts, "\n"+strings.TrimSpace(sn.Context)+"\n")
+		}
+		if strings.TrimSpace(sn.Source) != "" {
+			sources = append(sources, strings.TrimSpace(sn.Source))
+		}
+		if strings.TrimSpace(sn.Rules) != "" {
+			rules = append(rules, strings.TrimSpace(sn.Rules))
+		}
+		for _, ex := range sn.Examples {
+			examples = append(examples, "  "+ex)
 		}
-	}
-	metabaseFollowUpCtx := ""
-	if storedDBID > 0 {
-		metabaseFollowUpCtx = fmt.Sprintf(
-			"\nEste thread já executou uma consulta no banco de dados Metabase ID=%d. "+
-				"Se a pergunta atual for um follow-up, refinamento ou pedido de execução da consulta anterior, "+
-				"inclua {\"kind\":\"metabase_query\",\"database_id\":%d} no array.\n",
-			storedDBID, storedDBID,
-		)
-	}
-
-	// Build example items for the output format block (only enabled integrations).
-	var exampleItems []string
-	if jiraEnabled {
-		exampleItems = append(exampleItems, `  {"kind": "jira_create"}`)
-		exampleItems = append(exampleItems, `  {"kind": "jira_edit"}`)
-		exampleItems = append(exampleItems, `  {"kind": "jira_search", "jql": "project = X AND sprint in openSprints()", "jira_intent": "default"}`)
-	}
-	exampleItems = append(exampleItems, `  {"kind": "slack_search", "query": "deploy produção after:2026-03-01"}`)
-	if len(metabaseDatabases) > 0 {
-		exampleItems = append(exampleItems, `  {"kind": "metabase_query", "database_id": 1, "wants_all_rows": false, "wants_csv_export": false}`)
-		exampleItems = append(exampleItems, `  {"kind": "show_sql", "database_id": 1}`)
-	}
-	if outlineEnabled {
-		exampleItems = append(exampleItems, `  {"kind": "outline_search", "query": "processo deploy produção"}`)
-	}
-	if googleDriveEnabled {
-		exampleItems = append(exampleItems, `  {"kind": "googledrive_search", "query": "relatório vendas 2024", "sheet_name": "Transporte"}`)
-	}
-	if hubspotEnabled {
-		exampleItems = append(exampleItems, `  {"kind": "hubspot_search", "hubspot_object_type": "companies", "hubspot_query": "Acme Corp", "hubspot_after": "2026-01-01"}`)
-		exampleItems = append(exampleItems, `  {"kind": "hubspot_search", "hubspot_object_type": "tickets", "hubspot_record_id": "47919961660"}`)
-	}
-
-	// Jira-specific routing rules.
-	jiraSourceLine := ""
-	jiraRules := ""
-	jiraIntentBlock := ""
-	if jiraEnabled {
-		jiraSourceLine = "- Jira: tickets, status, roadmap, bugs, histórias, épicos, progresso de tarefas.\n"
-		jiraRules = `1. Roadmap, escopo, "o que foi feito", "está no ar", bugs abertos → jira_search.
-2. "onde falamos", "qual foi a decisão", "me manda o link", "thread do slack" → slack_search.
-3. Resumo/retrospectiva de processo (sprint, fechamento, entrega) → jira_search E slack_search.
-4. Se jira_search para pergunta substantiva (não apenas listagem de tickets), considere slack_search também.
-5. Perguntas curtas (≤ 2 palavras) ou com resposta no histórico → sem jira_search, sem slack_search.
-6. Criar card no Jira → sem slack_search, sem jira_search.
-7. jira_create e jira_edit NUNCA devem aparecer juntos no mesmo array, a menos que o edit use o card recém-criado (override). Se a intent for criar um card novo, use apenas jira_create.`
-		jiraIntentBlock = `
-Valores de jira_intent (campo de jira_search):
-- "listar_bugs_abertos": perguntas sobre bugs em aberto, falhas, erros.
-- "busca_texto": pesquisa de contexto sobre um tema específico no Jira.
-- "default": listagem geral ou roadmap.
-
-Regras para jql (campo de jira_search):
-- Se souber exatamente o JQL, preencha. Caso contrário, deixe "" e use jira_intent.
-- Use apenas campos padrão do Jira Cloud: project, issuetype, status, statusCategory, text, assignee, priority, labels, sprint, fixVersion, updated, created.
-- Para busca por texto: text ~ "termo"
-- Para bugs abertos: issuetype = Bug AND statusCategory != Done
-- Para busca por sprint: sprint = "Sprint N" ou sprint in openSprints() para sprints ativas.
-- SEMPRE agrupe condições OR com parênteses quando combinadas com AND: project = X AND (text ~ "a" OR text ~ "b") — NUNCA escreva: project = X AND text ~ "a" OR text ~ "b"
-- Para perguntas sobre cards "abertos", "criados" ou "registrados" em um período: use o campo created no JQL, NUNCA updated. Exemplos: "abertos em fevereiro/2026" → created >= "2026-02-01" AND created <= "2026-02-29"; "criados em março" → created >= "2026-03-01" AND created <= "2026-03-31".
-- Se o usuário fornecer diretamente uma string JQL na mensagem (ex: "use esse JQL: ...", "rode o JQL:", "execute esse JQL:"), extraia-a exatamente como escrita e use como valor do campo jql. Isso deve sempre gerar uma ação jira_search.
-`
-	} else {
-		jiraRules = `1. "onde falamos", "qual foi a decisão", "me manda o link" → slack_search.
-2. Perguntas curtas (≤ 2 palavras) ou com resposta no histórico → sem slack_search.`
-	}
-
-	outlineSourceLine := ""
-	outlineRule := ""
-	if outlineEnabled {
-		outlineSourceLine = "- Outline Wiki: documentação interna, processos, guias, runbooks, especificações de produto, onboarding, políticas.\n"
-		outlineRule = "12. Quando Outline está configurado: inclua outline_search quando a resposta depende principalmente de documentação interna (processos, guias, runbooks, políticas, onboarding, RH, benefícios). Preencha query com 2–4 termos-chave do assunto, sem artigos ou preposições. NUNCA inclua o nome da própria empresa/organização na query — o Outline já é o wiki interno da empresa, então o nome dela não filtra nada útil.\n"
-	}
-	googleDriveSourceLine := ""
-	googleDriveRule := ""
-	if googleDriveEnabled {
-		googleDriveSourceLine = "- Google Drive: arquivos internos, planilhas, apresentações, documentos compartilhados, relatórios, contratos, propostas.\n"
-		googleDriveRule = "13. Quando Google Drive está configurado: inclua googledrive_search quando a resposta provavelmente está em um arquivo compartilhado no Drive (planilha, apresentação, PDF, relatório, contrato). Preencha query com 2–4 termos-chave descritivos do arquivo ou conteúdo buscado. Quando o usuário mencionar uma aba/sheet específica (ex: \"aba Transporte\", \"na aba X\", \"tab Y\"), preencha sheet_name com o nome exato mencionado; caso contrário, omita sheet_name.\n"
-	}
-	hubspotSourceLine := ""
-	hubspotRule := ""
-	if hubspotEnabled {
-		hubspotSourceLine = "- HubSpot CRM: contatos, empresas, negociações (deals), tickets de suporte, dados de clientes e pipeline comercial.\n"
-		hubspotRule = "14. Quando HubSpot está configurado: inclua hubspot_search APENAS quando a pergunta envolve dados de CRM (pipeline comercial, status de negociação, contato, empresa, deal, ticket de suporte, lead). NÃO use hubspot_search para dados operacionais como faturamento, preço de coleta, billing_cycle, transações, rotas ou histórico de serviço — esses dados estão no banco de dados (Metabase). Preencha hubspot_object_type com um de: contacts, companies, deals, tickets (ou deixe vazio para buscar em todos). Preencha hubspot_query com o termo de busca mais relevante.\n" +
-			"14a. Se a mesma mensagem pedir explicitamente dados do banco de dados (Metabase) além do CRM, inclua TAMBÉM metabase_query no array — as duas ações devem aparecer juntas.\n" +
-			"14b. Quando o usuário especificar um intervalo de datas, preencha hubspot_after (data inicial inclusiva) e/ou hubspot_before (data final exclusiva) no formato YYYY-MM-DD. Use a data atual fornecida acima para calcular expressões relativas (\"últimos dois meses\", \"mês passado\", \"essa semana\").\n" +
-			"14c. Quando o usuário mencionar um estágio ou pipeline específico (ex: 'enterprise 3.0', 'fechado ganho', 'proposta'), use esse nome como parte do hubspot_query para filtrar negócios naquele estágio. Consulte os Pipelines HubSpot disponíveis listados acima para identificar o nome correto do estágio.\n" +
-			"14d. Quando o usuário fornecer um ID numérico de registro HubSpot (ex: \"47919961660\", \"ticket 12345678\", \"demanda 98765\", \"demanda de serviço 47919961660\"), preencha hubspot_record_id com esse número e hubspot_object_type com o tipo inferido (tickets, deals, contacts, companies) ou deixe vazio para tentar todos os tipos. Quando hubspot_record_id estiver preenchido, hubspot_query pode ser omitido.\n"
 	}
 
 	// Multi-source rule: encourage combining sources when multiple are configured.
-	activeSources := 1 // slack is always available
-	if jiraEnabled {
-		activeSources++
-	}
-	if len(metabaseDatabases) > 0 {
-		activeSources++
-	}
-	if outlineEnabled {
-		activeSources++
-	}
-	if googleDriveEnabled {
-		activeSources++
-	}
-	if hubspotEnabled {
-		activeSources++
-	}
 	multiSourceRule := ""
-	if activeSources >= 3 {
-		multiSourceRule = `REGRA GERAL DE MULTI-FONTE: Quando múltiplas fontes estão configuradas, escolha as fontes certas para cada tipo de pergunta:
+	if len(in.Snippets) >= 3 {
+		multiSourceRule = `
+REGRA GERAL DE MULTI-FONTE: Quando múltiplas fontes estão configuradas, escolha as fontes certas para cada tipo de pergunta:
 - Pergunta sobre processo ou entrega: inclua jira_search E slack_search.
 - Pergunta MISTA (dados comerciais/CRM + dados operacionais): inclua hubspot_search E metabase_query.
 - Pergunta PURAMENTE operacional (coletas, faturamento, preços, billing, transações, rotas, contratos) → apenas metabase_query, NÃO inclua hubspot_search.
 - Pergunta PURAMENTE de CRM (pipeline, funil de vendas, status de negociação, dados de contato, leads) → apenas hubspot_search, NÃO inclua metabase_query.
 - Dúvida sobre onde os dados estão: prefira metabase_query para dados numéricos/operacionais, hubspot_search para dados relacionais/comerciais.
+(Considere apenas as fontes disponíveis acima.)
 `
 	}
 
-	prompt := fmt.Sprintf(`Você é um roteador de ações de um assistente de Slack.
-Analise a mensagem e retorne um JSON array com TODAS as ações necessárias, na ordem certa.
-%s%s%s%s%s%s%s%s
-Retorne APENAS um JSON array válido, sem markdown fences. Retorne [] quando nenhuma ação for necessária.
+	// Output format: native tool calls, or the legacy bare JSON array.
+	taskLine := "Analise a mensagem e retorne um JSON array com TODAS as ações necessárias, na ordem certa."
+	outputLine := "Retorne APENAS um JSON array válido, sem markdown fences. Retorne [] quando nenhuma ação for necessária."
+	exampleLine := "Exemplo de array (inclua apenas as ações necessárias):"
+	if native {
+		taskLine = "Analise a mensagem e chame uma ferramenta para CADA ação necessária, na ordem certa."
+		outputLine = "Chame as ferramentas diretamente, uma chamada por ação. Quando nenhuma ação for necessária, não chame ferramentas e responda apenas [].\n" +
+			"Depois de receber os resultados das ferramentas, chame novas ferramentas SOMENTE se os resultados vierem vazios, com erro ou insuficientes para responder — prefira fontes alternativas e nunca repita uma chamada com os mesmos argumentos. Caso contrário, responda apenas []."
+		exampleLine = "Exemplo de ações (cada item corresponde a uma chamada de ferramenta com esses argumentos; inclua apenas as necessárias):"
+	}
+
+	return fmt.Sprintf(`Você é um roteador de ações de um assistente de Slack.
+%s
+%s%s%s
+%s
 
-Exemplo de array (inclua apenas as ações necessárias):
+%s
 [
 %s
 ]
 
 Fontes disponíveis:
-%s%s%s%s- Slack: discussões, decisões, links de threads, contexto operacional.
-- Metabase (banco de dados): dados estruturados do banco operacional — coletas, faturamento, billing, preços, transações, pedidos, contratos, rotas, materiais, geradores, transportadores, métricas e histórico de qualquer entidade. Se a pergunta envolve métricas, preços, quantidades, status operacional, datas de coleta/entrega/fatura, use metabase_query. NÃO use slack_search para dados que vivem no banco. NÃO use hubspot_search para dados operacionais (fatura, preço, billing_cycle, coleta, transação).
-
-Regras para jira_create e jira_edit:
-- "jira_create": verbo de criação EXPLÍCITO (criar/cria/abre/abrir/gera/gerar) + tipo de issue Jira (tarefa, bug, história, épico, spike), pedido AGORA
-- "jira_create" NÃO se aplica quando o usuário pede criação de conteúdo textual (checklists, documentos, planos, textos, relatórios, listas) para ser exibido na conversa — nesses casos retorne [].
-- "jira_create" NÃO se aplica quando o usuário diz explicitamente que quer o resultado na thread/chat ("em texto aqui", "quero aqui na thread", "responde aqui", "me manda aqui", "só me diz", "me mostra aqui").
-- "jira_edit": mudar status, atribuir, alterar campos, mover para sprint, "adicione para", "atribuir", "assign"
-- Hipóteses ("estou pensando em criar") → sem jira_create
-- Negações ("não quero criar") → sem jira_create
-- Criação + atribuição na mesma mensagem → jira_create ANTES de jira_edit no array
-- Criar um card SEM pedido explícito de dados externos → sem jira_search, sem slack_search
-- jira_create/jira_edit PODEM coexistir com metabase_query, slack_search ou jira_search quando o usuário pede EXPLICITAMENTE dados externos para incluir no card ou na resposta (ex: "escreva nesse card o total de X", "adicione as threads onde X foi mencionada", "busque o total de registros")
 %s
-%sRegras de roteamento de contexto:
 %s
-7. Se o histórico contém SQL ou resultados de banco E o usuário faz follow-up → metabase_query. Use database_id da linha "Query executada (db=N):" do histórico.
-8. show_sql SOMENTE quando usuário pede EXPLICITAMENTE o SQL/query/código usado ("SQL", "query", "consulta que você rodou", "me mostra o código"). Pedidos de dados → metabase_query, não show_sql. Inclua database_id do "Query executada (db=N):" do histórico (ou 0).
-9. Follow-ups com pronomes ("dessas", "desses") referindo entidades já consultadas → metabase_query com mesmo database_id do turno anterior.
-10. wants_all_rows=true: usuário quer TODOS OS REGISTROS de uma entidade de dados — mesmo que filtrado por data ou outro critério. Sinais: "todas as coletas", "todos os pedidos", "todos os registros", "sem limite", "lista completa", "traz tudo", "quero todos", "detalhamento de todas", "me traz todas". NÃO use true quando "todos" se refere a tópicos/aspectos de análise (ex: "analise todos os aspectos", "todas as categorias") — nesses casos o usuário quer uma análise agregada, não um dump de registros individuais.
-11. wants_csv_export=true: exportação explícita ("exportar", "csv", "planilha", "download", "baixar", "excel") OU pedido de todos os dados. Quando true, também wants_all_rows=true.
-%s%s%s
-Regras para query em slack_search (IMPORTANTE):
-- query NUNCA vazio quando kind="slack_search" — sempre gere uma query útil.
-- Se mencionar canais (#nome), inclua in:#nome-do-canal.
-- Use 2–4 palavras-chave, sem has:thread, has:link, has:reaction.
-- "o que X falou/disse" → from:@username.
-- Usuário Slack (<@USERID>) em busca → inclua o identificador EXATO: ex. "<@U09FJSKP407>".
-
-Regras para datas na query slack_search:
-- NÃO use "essa semana" como termo — a API não interpreta.
-- Converta expressões de tempo:
-  - "essa semana" → after:SEGUNDA-DESTA-SEMANA (use a data calculada acima)
-  - "ontem" → after:DATA-ONTEM before:DATA-HOJE
-  - "mês passado" → after:ANO-MÊS-01 before:ANO-MÊS-01 do mês atual
-- Exemplo: "o que foi dito essa semana" → query: "termo-relevante after:2026-02-17"
+Regras de roteamento de contexto:
+- Perguntas curtas (≤ 2 palavras) ou com resposta no histórico → nenhuma ação de busca.
+
+%s
 
 Thread (contexto recente):
 %s
 
 Pergunta:
 %s
-`, dateCtx, senderCtx, projectsCtx, metabaseCtx, metabaseFollowUpCtx, outlineCtxStr, googleDriveCtxStr, hubspotCtxStr,
-		strings.Join(exampleItems, ",\n"),
-		jiraSourceLine, outlineSourceLine, googleDriveSourceLine, hubspotSourceLine,
-		jiraIntentBlock,
+`, taskLine,
+		dateCtx, senderCtx, strings.Join(contexts, ""),
+		outputLine,
+		exampleLine,
+		strings.Join(examples, ",\n"),
+		strings.Join(sources, "\n"),
 		multiSourceRule,
-		jiraRules,
-		outlineRule, googleDriveRule, hubspotRule,
-		clip(threadHistory, 1200), question)
-
-	messages := []OpenAIMessage{{Role: "user", Content: prompt}}
-	out, err := c.Chat(messages, model, 0.2, 4000)
-	if err != nil {
-		return nil, err
-	}
-	out = strings.TrimSpace(stripCodeFences(out))
-
-	var actions []ActionDescriptor
-	if err := json.Unmarshal([]byte(out), &actions); err != nil {
-		return nil, fmt.Errorf("bad actions json: %v raw=%q", err, preview(out, 300))
-	}
-
-	// Post-process: normalize fields and enforce integration constraints.
-	var result []ActionDescriptor
-	for _, a := range actions {
-		switch a.Kind {
-		case ActionJiraCreate, ActionJiraEdit, ActionJiraSearch:
-			if !jiraEnabled {
-				continue
-			}
-			a.JiraIntent = strings.TrimSpace(a.JiraIntent)
-			a.JQL = strings.TrimSpace(a.JQL)
-		case ActionSlackSearch:
-			a.Query = normalizeSlackQuery(strings.TrimSpace(a.Query))
-		case ActionOutlineSearch:
-			if !outlineEnabled {
-				continue
-			}
-			a.Query = strings.TrimSpace(a.Query)
-		case ActionGoogleDriveSearch:
-			if !googleDriveEnabled {
-				continue
-			}
-			a.Query = strings.TrimSpace(a.Query)
-			a.GoogleDriveSheetName = strings.TrimSpace(a.GoogleDriveSheetName)
-		case ActionHubSpotSearch:
-			if !hubspotEnabled {
-				continue
-			}
-			a.HubSpotObjectType = strings.TrimSpace(a.HubSpotObjectType)
-			a.HubSpotQuery = strings.TrimSpace(a.HubSpotQuery)
-			a.HubSpotAfter = strings.TrimSpace(a.HubSpotAfter)
-			a.HubSpotBefore = strings.TrimSpace(a.HubSpotBefore)
-			a.HubSpotRecordID = strings.TrimSpace(a.HubSpotRecordID)
-		case ActionMetabaseQuery, ActionShowSQL:
-			if len(metabaseDatabases) == 0 {
-				continue
-			}
-		default:
-			continue // unknown kind — skip
-		}
-		result = append(result, a)
-	}
-
-	var kinds []string
-	for _, a := range result {
-		kinds = append(kinds, a.Kind)
-	}
-	log.Printf("[LLM] decideActions=%v raw=%q", kinds, preview(out, 120))
-	return result, nil
+		strings.Join(rules, "\n\n"),
+		clip(in.ThreadHistory, 1200), in.Question)
 }
 
 // GenerateHubSpotQueryVariants asks the LLM to suggest up to 2 alternative search
 // queries for a HubSpot CRM search that returned no results on the first attempt.
 // Returns an empty slice on error or when no useful variants can be generated.
-func (c *Client) GenerateHubSpotQueryVariants(originalQuery, question, model string) []string {
+func (c *Client) GenerateHubSpotQueryVariants(ctx context.Context, originalQuery, question, model string) []string {
+	ctx = withSite(ctx, siteSearchQuery)
 	if strings.TrimSpace(model) == "" {
 		model = "gpt-4o-mini"
 	}
@@ -475,7 +324,7 @@ Retorne APENAS um JSON array de strings com as variações, sem markdown. Ex: ["
 Se não houver variações úteis, retorne: []`, originalQuery, question)
 
 	msgs := []OpenAIMessage{{Role: "user", Content: prompt}}
-	out, err := c.Chat(msgs, model, 0, 100)
+	out, err := c.Chat(ctx, msgs, model, 0, 100)
 	if err != nil {
 		return nil
 	}
@@ -491,7 +340,8 @@ Se não houver variações úteis, retorne: []`, originalQuery, question)
 // query (2–4 keywords) for the Outline wiki from the user's question.
 // It extracts the core subject, stripping greetings, articles, prepositions,
 // and conversational filler.  Returns an empty string on error.
-func (c *Client) GenerateOutlineQuery(question, model string) string {
+func (c *Client) GenerateOutlineQuery(ctx context.Context, question, model string) string {
+	ctx = withSite(ctx, siteSearchQuery)
 	if strings.TrimSpace(model) == "" {
 		model = "gpt-4o-mini"
 	}
@@ -513,7 +363,7 @@ Regras:
 Pergunta: %s`, question)
 
 	msgs := []OpenAIMessage{{Role: "user", Content: prompt}}
-	out, err := c.Chat(msgs, model, 0, 50)
+	out, err := c.Chat(ctx, msgs, model, 0, 50)
 	if err != nil {
 		return ""
 	}
@@ -527,10 +377,15 @@ Pergunta: %s`, question)
 // will use it to correct the query rather than regenerating from scratch.
 // Returns the SQL string, or a string prefixed with ClarificationPrefix when the
 // LLM needs more information from the user before it can generate a valid query.
-func (c *Client) GenerateSQL(question, threadHist, schemaCtx, baseSQL, lastErr, dbEngine, hintsCtx string, wantsAllRows bool, model string) (string, error) {
+func (c *Client) GenerateSQL(ctx context.Context, question, threadHist, schemaCtx, baseSQL, lastErr, dbEngine, hintsCtx string, wantsAllRows bool, model string) (string, error) {
 	if strings.TrimSpace(model) == "" {
 		model = "gpt-4o-mini"
 	}
+	// The schema doc is the bulk of the prompt: give it at most half of the
+	// model's window so small self-hosted models still leave room for the rest.
+	if limit := min(c.ContextWindow(model)/2, schemaMaxTokens); EstimateTokens(schemaCtx) > limit {
+		schemaCtx = trimToTokens(schemaCtx, limit, false)
+	}
 	baseCtx := ""
 	if strings.TrimSpace(baseSQL) != "" {
 		if strings.TrimSpace(lastErr) != "" {
@@ -613,10 +468,10 @@ REGRAS DE SQL:
 - Datas: use os tipos corretos da coluna (Date vs DateTime) e filtre com >= / < ou BETWEEN conforme a sintaxe do engine acima.
 - Use as referências de data acima para expressões relativas como "semana passada", "hoje", "mês passado".
 - CONTEXTO DE ENTIDADE: Se a pergunta não especificar explicitamente um filtro de entidade (gerador, cliente, empresa, transportador) mas o histórico da conversa mostra que o assunto está focado em uma entidade específica (ex: "Liv Up-Saúde", "Empresa X"), APLIQUE esse filtro na query como cláusula WHERE mesmo que não seja mencionado na pergunta atual. Nunca retorne dados gerais de todo o banco quando o contexto indica uma entidade específica.`,
-		engineCtx, dateCtx, clip(schemaCtx, 120000), baseCtx, hintsSection, clip(threadHist, 800), question, ClarificationPrefix, limitSection, queryTypeSection)
+		engineCtx, dateCtx, schemaCtx, baseCtx, hintsSection, clip(threadHist, 800), question, ClarificationPrefix, limitSection, queryTypeSection)
 
 	msgs := []OpenAIMessage{{Role: "user", Content: prompt}}
-	out, err := c.Chat(msgs, model, 0.1, 2000)
+	out, err := c.cachedChat(ctx, siteSQL, question, msgs, model, 0.1, 2000)
 	if err != nil {
 		return "", err
 	}
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -10,7 +11,8 @@ import (
 // from the provided Jira, Metabase, and Outline documentation.
 // The output is a short (~1200 chars) Markdown reference in Portuguese that
 // will be injected into every answer call.  Returns "" on error.
-func (c *Client) GenerateCompanyContext(jiraDoc, metabaseDoc, outlineDocs, hubspotDoc, model string) string {
+func (c *Client) GenerateCompanyContext(ctx context.Context, jiraDoc, metabaseDoc, outlineDocs, hubspotDoc, model string) string {
+	ctx = withSite(ctx, siteCompanyContext)
 	if strings.TrimSpace(model) == "" {
 		model = "gpt-4o-mini"
 	}
@@ -58,7 +60,7 @@ FONTES:
 %s`, sources)
 
 	msgs := []OpenAIMessage{{Role: "user", Content: prompt}}
-	out, err := c.Chat(msgs, model, 0.2, 600)
+	out, err := c.Chat(ctx, msgs, model, 0.2, 600)
 	if err != nil {
 		log.Printf("[LLM] GenerateCompanyContext error: %v", err)
 		return ""
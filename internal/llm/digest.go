@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// DigestChunk is the map step of a channel digest: it condenses one slice of
+// a channel transcript into notes.  Messages in the transcript are tagged
+// [mN] and the notes keep those tags so the final digest can link back to
+// the original messages.
+func (c *Client) DigestChunk(ctx context.Context, channelName, period, transcript, model string) (string, error) {
+	ctx = withSite(ctx, siteSummary)
+	system := `Você condensa trechos de canais do Slack em notas para um resumo posterior.
+Responda em português brasileiro, em texto simples com bullets (-).`
+	user := fmt.Sprintf(`Trecho do canal #%s (%s).  Cada mensagem começa com um identificador [mN]; respostas de thread aparecem indentadas com ↳ abaixo da mensagem original.
+
+Escreva notas curtas sobre o trecho, agrupadas em:
+- Assuntos: o que foi discutido.
+- Decisões: o que foi decidido, por quem.
+- Perguntas em aberto: dúvidas que ficaram sem resposta.
+- Ações: tarefas combinadas, com responsável e prazo quando citados.
+
+Regras:
+- Termine CADA nota com o identificador da mensagem que a sustenta, ex: "deploy adiado para sexta [m12]".
+- NÃO invente fatos, nomes ou identificadores.
+- Ignore conversa social e mensagens sem conteúdo.
+- Omita grupos vazios.
+
+TRECHO:
+%s`, channelName, period, transcript)
+
+	messages := []OpenAIMessage{
+		{Role: "system", Content: system},
+		{Role: "user", Content: user},
+	}
+	out, err := c.Chat(ctx, messages, model, 0.1, 1500)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("[LLM] DigestChunk channel=%s in=%d out=%d", channelName, len(transcript), len(out))
+	return strings.TrimSpace(out), nil
+}
+
+// ReduceDigest is the reduce step of a channel digest: it merges the notes
+// of every chunk (or the whole transcript, when it fit in one) into the
+// final answer in Slack mrkdwn.  The [mN] tags are kept so the caller can
+// turn them into permalinks.
+func (c *Client) ReduceDigest(ctx context.Context, channelName, period, question string, notes []string, model string) (string, error) {
+	ctx = withSite(ctx, siteSummary)
+	system := `Você escreve resumos de canais do Slack para quem não acompanhou.
+Responda em português brasileiro, em Slack mrkdwn (*negrito*, bullets com •), sem blocos de código.`
+	var b strings.Builder
+	for i, n := range notes {
+		fmt.Fprintf(&b, "--- PARTE %d/%d ---\n%s\n\n", i+1, len(notes), strings.TrimSpace(n))
+	}
+	user := fmt.Sprintf(`Pedido do usuário: %s
+
+Abaixo estão as notas (ou a transcrição) do canal #%s no período %s, em ordem cronológica.
+
+Escreva o resumo no formato:
+*Resumo* — 2 a 4 frases com os principais assuntos do período.
+*Decisões* — bullets.
+*Perguntas em aberto* — bullets.
+*Ações* — bullets com responsável e prazo quando citados.
+
+Regras:
+- Mantenha no fim de cada bullet os identificadores [mN] que o sustentam (no máximo 3 por bullet), exatamente como aparecem nas notas.
+- Junte itens repetidos entre partes; se uma pergunta foi respondida ou uma ação concluída mais adiante, reflita o estado final.
+- Omita seções sem itens (exceto *Resumo*).
+- NÃO invente fatos, nomes ou identificadores.
+- Se o pedido do usuário tiver um foco (um tema, uma pessoa), priorize o que for relevante a ele.
+
+NOTAS:
+%s`, clip(question, 500), channelName, period, clip(b.String(), 60000))
+
+	messages := []OpenAIMessage{
+		{Role: "system", Content: system},
+		{Role: "user", Content: user},
+	}
+	out, err := c.Chat(ctx, messages, model, 0.2, 2500)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("[LLM] ReduceDigest channel=%s parts=%d out=%d", channelName, len(notes), len(out))
+	return strings.TrimSpace(out), nil
+}
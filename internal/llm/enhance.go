@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -13,7 +14,7 @@ import (
 // The function always returns a non-empty string: on any error or empty LLM
 // response it returns the original question unchanged so the pipeline continues
 // normally.
-func (c *Client) EnhancePrompt(question, threadHistory, availableSources, model string) string {
+func (c *Client) EnhancePrompt(ctx context.Context, question, threadHistory, availableSources, model string) string {
 	if strings.TrimSpace(question) == "" {
 		return question
 	}
@@ -46,7 +47,7 @@ Regras:
 Pergunta original: %s`, sourcesSection, histSection, question)
 
 	msgs := []OpenAIMessage{{Role: "user", Content: prompt}}
-	out, err := c.Chat(msgs, model, 0.2, 400)
+	out, err := c.cachedChat(ctx, siteEnhance, question, msgs, model, 0.2, 400)
 	if err != nil {
 		log.Printf("[LLM][enhance] failed: %v — using original question", err)
 		return question
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -12,7 +13,8 @@ import (
 // generated docs (jira_projects.md, metabase schema, etc.) so the LLM can
 // create realistic examples using real project/table names.
 // Falls back to fallback on any error.
-func (c *Client) GenerateIntroMessage(botName, featuresDesc, docsContext, model, fallback string) string {
+func (c *Client) GenerateIntroMessage(ctx context.Context, botName, featuresDesc, docsContext, model, fallback string) string {
+	ctx = withSite(ctx, siteIntro)
 	if botName == "" {
 		botName = "Jarvis"
 	}
@@ -63,7 +65,7 @@ func (c *Client) GenerateIntroMessage(botName, featuresDesc, docsContext, model,
 	)
 
 	messages := []OpenAIMessage{{Role: "user", Content: prompt}}
-	out, err := c.Chat(messages, model, 0.3, 1500)
+	out, err := c.Chat(ctx, messages, model, 0.3, 1500)
 	if err != nil {
 		log.Printf("[LLM] GenerateIntroMessage error: %v — using fallback", err)
 		return fallback
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -15,7 +16,7 @@ import (
 // from hypothetical or contextual mentions of creation.
 // The lesserModel is tried first (cheaper/faster); the primaryModel is used on
 // failure.  Returns false on any error to avoid creating unwanted issues.
-func (c *Client) ConfirmJiraCreateIntent(question, threadHistory, lesserModel, primaryModel string) bool {
+func (c *Client) ConfirmJiraCreateIntent(ctx context.Context, question, threadHistory, lesserModel, primaryModel string) bool {
 	threadSection := ""
 	if t := strings.TrimSpace(threadHistory); t != "" {
 		threadSection = fmt.Sprintf("\nContexto da conversa (use para entender se o pedido é imediato ou hipotético):\n%s\n", clip(t, 2000))
@@ -48,9 +49,9 @@ Mensagem: %q`, threadSection, question)
 	if model == "" {
 		model = primaryModel
 	}
-	out, err := c.Chat(messages, model, 0, 10)
+	out, err := c.cachedChat(ctx, siteIntent, question, messages, model, 0, 10)
 	if err != nil && model != primaryModel {
-		out, err = c.Chat(messages, primaryModel, 0, 10)
+		out, err = c.cachedChat(ctx, siteIntent, question, messages, primaryModel, 0, 10)
 	}
 	if err != nil {
 		log.Printf("[LLM] confirmJiraCreateIntent error: %v — defaulting false", err)
@@ -67,9 +68,12 @@ Mensagem: %q`, threadSection, question)
 // command from the user (e.g. "crie um card no jira…").  The
 // threadHistory contains recent messages in the thread.  The model
 // parameter allows specifying the LLM model; callers typically pass
-// the primary model from configuration.  If the call or JSON parse
+// the primary model from configuration.  transcripts holds the timestamped
+// transcriptions of audio/video attached to the thread (may be empty), so a
+// recorded bug report can become the card.  If the call or JSON parse
 // fails, an error is returned.
-func (c *Client) ExtractIssueFromThread(threadHistory, userInstruction, model string, exampleIssues []string, projectNameMap map[string]string) (jira.IssueDraft, error) {
+func (c *Client) ExtractIssueFromThread(ctx context.Context, threadHistory, transcripts, userInstruction, model string, exampleIssues []string, projectNameMap map[string]string) (jira.IssueDraft, error) {
+	ctx = withSite(ctx, siteDescription)
 	system := `Você é um Product Manager sênior especializado em escrever issues Jira de alta qualidade.
 Sua tarefa é extrair um rascunho de issue a partir de uma conversa no Slack.
 Retorne SOMENTE JSON válido, sem markdown fences.`
@@ -96,13 +100,22 @@ Exemplos de cards bem escritos do mesmo projeto (use como referência de estilo
 `, strings.Join(exampleIssues, "\n---\n"))
 	}
 
+	// Transcriptions of recorded bug reports (voice notes, screen recordings)
+	transcriptsBlock := ""
+	if strings.TrimSpace(transcripts) != "" {
+		transcriptsBlock = fmt.Sprintf(`
+Transcrições de áudios/vídeos anexados à thread (timestamps [mm:ss]):
+%s
+`, clip(transcripts, 6000))
+	}
+
 	user := fmt.Sprintf(`
 Instrução do usuário (respeite SEMPRE os campos informados explicitamente — projeto, tipo, título, prioridade, labels):
 %s
 %s%s
 Thread do Slack:
 %s
-
+%s
 Retorne JSON exatamente neste formato:
 {
   "project": "",
@@ -118,19 +131,20 @@ Regras CRÍTICAS:
 - Se o usuário não informou um campo → deixe vazio (""), o sistema pedirá depois.
 - summary <= 110 chars, direto ao ponto, sem prefixos como "[Bug]".
 - NÃO invente fatos. Se faltar informação escreva "A confirmar:" seguido de bullets.
+- Se houver transcrições, trate-as como parte do relato: use o que foi dito para passos, resultado atual e esperado, e cite os trechos relevantes em "## Evidências" com o timestamp (ex: "[01:23] ...").
 
 Estrutura da description por tipo:
 - Bug: ## Contexto\n## Evidências\n## Ambiente / Onde ocorreu\n## Passos para reproduzir\n## Resultado atual\n## Resultado esperado\n## Impacto
 - História (Story): ## Contexto\n## Objetivo\n## Escopo (MVP)\n## Critérios de aceitação (lista "- [ ] ...")
 - Epic: ## Contexto\n## Objetivo\n## Escopo (MVP)\n## Fora de escopo\n## KPIs\n## Fluxos-chave\n## Requisitos não-funcionais\n## Riscos\n## DoD
 - Tipo desconhecido: ## Contexto\n## Problema\n## Impacto\n## Critérios de aceite\n## Links da thread
-`, userInstruction, projectMapBlock, examplesBlock, clip(threadHistory, 4500))
+`, userInstruction, projectMapBlock, examplesBlock, clip(threadHistory, 4500), transcriptsBlock)
 
 	messages := []OpenAIMessage{
 		{Role: "system", Content: system},
 		{Role: "user", Content: user},
 	}
-	out, err := c.Chat(messages, model, 0.2, 2000)
+	out, err := c.Chat(ctx, messages, model, 0.2, 2000)
 	if err != nil {
 		return jira.IssueDraft{}, err
 	}
@@ -156,7 +170,7 @@ Estrutura da description por tipo:
 // ConfirmJiraEditIntent returns true when the message clearly intends to edit
 // an existing Jira issue (transition, assign, update fields, set parent).
 // Returns false on any error so no unwanted edit is triggered.
-func (c *Client) ConfirmJiraEditIntent(question, threadHistory, lesserModel, primaryModel string) bool {
+func (c *Client) ConfirmJiraEditIntent(ctx context.Context, question, threadHistory, lesserModel, primaryModel string) bool {
 	threadSection := ""
 	if t := strings.TrimSpace(threadHistory); t != "" {
 		threadSection = fmt.Sprintf("\nContexto da conversa:\n%s\n", clip(t, 2000))
@@ -186,9 +200,9 @@ Mensagem: %q`, threadSection, question)
 	if model == "" {
 		model = primaryModel
 	}
-	out, err := c.Chat(messages, model, 0, 10)
+	out, err := c.cachedChat(ctx, siteIntent, question, messages, model, 0, 10)
 	if err != nil && model != primaryModel {
-		out, err = c.Chat(messages, primaryModel, 0, 10)
+		out, err = c.cachedChat(ctx, siteIntent, question, messages, primaryModel, 0, 10)
 	}
 	if err != nil {
 		log.Printf("[LLM] confirmJiraEditIntent error: %v — defaulting false", err)
@@ -202,7 +216,8 @@ Mensagem: %q`, threadSection, question)
 // ExtractJiraEditRequest uses the LLM to parse the user message and produce
 // a structured EditRequest.  senderName is the Slack display name of the
 // requester and is used to resolve "@me" assignments.
-func (c *Client) ExtractJiraEditRequest(question, threadHistory, senderName, model string) (jira.EditRequest, error) {
+func (c *Client) ExtractJiraEditRequest(ctx context.Context, question, threadHistory, senderName, model string) (jira.EditRequest, error) {
+	ctx = withSite(ctx, siteDescription)
 	threadSection := ""
 	if t := strings.TrimSpace(threadHistory); t != "" {
 		threadSection = fmt.Sprintf("\nContexto da conversa:\n%s\n", clip(t, 2000))
@@ -258,7 +273,7 @@ Regras:
 - labels: array de strings, vazio [] quando não mencionado.`, threadSection, senderLine, question)
 
 	messages := []OpenAIMessage{{Role: "user", Content: prompt}}
-	out, err := c.Chat(messages, model, 0, 300)
+	out, err := c.Chat(ctx, messages, model, 0, 300)
 	if err != nil {
 		return jira.EditRequest{}, err
 	}
@@ -281,7 +296,8 @@ Regras:
 // Jira issue based on the user instruction and thread history.
 // issueKey, issueType, and currentSummary provide context about the card.
 // currentDesc may be empty or contain an existing (possibly thin) description.
-func (c *Client) GenerateIssueDescription(issueKey, issueType, currentSummary, currentDesc, instruction, threadHistory, model string) (string, error) {
+func (c *Client) GenerateIssueDescription(ctx context.Context, issueKey, issueType, currentSummary, currentDesc, instruction, threadHistory, model string) (string, error) {
+	ctx = withSite(ctx, siteDescription)
 	system := `Você é um Product Manager sênior especializado em escrever issues Jira de alta qualidade.
 Escreva uma descrição completa e bem estruturada para o card indicado.
 Retorne APENAS o texto da descrição em markdown. Não inclua o título nem metadados do card.`
@@ -327,7 +343,7 @@ Regras:
 		{Role: "system", Content: system},
 		{Role: "user", Content: user},
 	}
-	out, err := c.Chat(messages, model, 0.3, 1500)
+	out, err := c.Chat(ctx, messages, model, 0.3, 1500)
 	if err != nil {
 		return "", err
 	}
@@ -336,7 +352,8 @@ Regras:
 
 // PickBestSprintByName selects the sprint ID from candidates that best matches
 // the user's desired sprint name or number.  Returns 0 when no match is found.
-func (c *Client) PickBestSprintByName(sprints []jira.Sprint, desired string, model string) int {
+func (c *Client) PickBestSprintByName(ctx context.Context, sprints []jira.Sprint, desired string, model string) int {
+	ctx = withSite(ctx, siteJiraLookup)
 	if len(sprints) == 0 || desired == "" {
 		return 0
 	}
@@ -352,7 +369,7 @@ Sprints disponíveis:
 %s`, desired, strings.Join(lines, "\n"))
 
 	messages := []OpenAIMessage{{Role: "user", Content: prompt}}
-	out, err := c.Chat(messages, model, 0, 10)
+	out, err := c.Chat(ctx, messages, model, 0, 10)
 	if err != nil {
 		log.Printf("[LLM] pickBestSprintByName error: %v", err)
 		return 0
@@ -367,7 +384,8 @@ Sprints disponíveis:
 // MapStatusName maps a user-provided status name (possibly in a different language
 // or informal phrasing) to the best-matching status name from the project's actual
 // workflow statuses.  Returns the matched name, or desired unchanged on failure.
-func (c *Client) MapStatusName(available []string, desired, model string) string {
+func (c *Client) MapStatusName(ctx context.Context, available []string, desired, model string) string {
+	ctx = withSite(ctx, siteJiraLookup)
 	if len(available) == 0 || desired == "" {
 		return desired
 	}
@@ -384,7 +402,7 @@ Status pedido pelo usuário: %q
 Status disponíveis no projeto: %s`, desired, strings.Join(available, ", "))
 
 	messages := []OpenAIMessage{{Role: "user", Content: prompt}}
-	out, err := c.Chat(messages, model, 0, 30)
+	out, err := c.Chat(ctx, messages, model, 0, 30)
 	if err != nil {
 		log.Printf("[LLM] mapStatusName error: %v", err)
 		return desired
@@ -405,7 +423,8 @@ Status disponíveis no projeto: %s`, desired, strings.Join(available, ", "))
 // best moves the issue toward the desired status.  If the desired status is
 // directly available it is preferred; otherwise the best intermediate step is
 // returned.  Returns "" only when no transition at all makes sense.
-func (c *Client) PickBestTransition(transitions []jira.Transition, desired string, model string) string {
+func (c *Client) PickBestTransition(ctx context.Context, transitions []jira.Transition, desired string, model string) string {
+	ctx = withSite(ctx, siteJiraLookup)
 	if len(transitions) == 0 || desired == "" {
 		return ""
 	}
@@ -427,7 +446,7 @@ Regras:
 4. Retorne vazio APENAS se nenhuma transição fizer sentido algum (lista vazia ou destino já alcançado).`, desired, strings.Join(names, "\n"))
 
 	messages := []OpenAIMessage{{Role: "user", Content: prompt}}
-	out, err := c.Chat(messages, model, 0, 20)
+	out, err := c.Chat(ctx, messages, model, 0, 20)
 	if err != nil {
 		log.Printf("[LLM] pickBestTransition error: %v", err)
 		return ""
@@ -1,14 +1,30 @@
 package llm
 
-import "encoding/json"
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
 
 // OpenAIMessage defines the role and content for a message in the
 // Chat Completions API.  When ContentParts are non-empty (vision messages),
 // the content is serialized as an array; otherwise Content is used as a string.
+// ToolCalls is set on assistant messages that requested tools, and
+// ToolCallID on the "tool" messages that carry their results.
 type OpenAIMessage struct {
 	Role         string
 	Content      string        // used for plain-text messages
 	ContentParts []ContentPart // used for vision messages
+	ToolCalls    []ToolCall
+	ToolCallID   string
 }
 
 // MarshalJSON serializes the message with lowercase field names as required by
@@ -21,6 +37,21 @@ func (m OpenAIMessage) MarshalJSON() ([]byte, error) {
 			Content []ContentPart `json:"content"`
 		}{Role: m.Role, Content: m.ContentParts})
 	}
+	if len(m.ToolCalls) > 0 || m.ToolCallID != "" {
+		calls := make([]openAIToolCall, len(m.ToolCalls))
+		for i, tc := range m.ToolCalls {
+			calls[i].ID = tc.ID
+			calls[i].Type = "function"
+			calls[i].Function.Name = tc.Name
+			calls[i].Function.Arguments = tc.Arguments
+		}
+		return json.Marshal(struct {
+			Role       string           `json:"role"`
+			Content    string           `json:"content"`
+			ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+			ToolCallID string           `json:"tool_call_id,omitempty"`
+		}{Role: m.Role, Content: m.Content, ToolCalls: calls, ToolCallID: m.ToolCallID})
+	}
 	return json.Marshal(struct {
 		Role    string `json:"role"`
 		Content string `json:"content"`
@@ -50,6 +81,39 @@ type openAIChatRequest struct {
 	Messages            []OpenAIMessage `json:"messages"`
 	Temperature         float64         `json:"temperature,omitempty"`
 	MaxCompletionTokens int             `json:"max_completion_tokens,omitempty"`
+	// MaxTokens is the legacy limit field still expected by most
+	// self-hosted OpenAI-compatible servers.
+	MaxTokens int          `json:"max_tokens,omitempty"`
+	Tools     []openAITool `json:"tools,omitempty"`
+	Stream    bool         `json:"stream,omitempty"`
+	// StreamOptions asks for a final chunk carrying the token usage.
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// openAITool is a function tool declaration.  Strict mode makes the API
+// guarantee that arguments match the JSON Schema.
+type openAITool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description,omitempty"`
+		Parameters  map[string]any `json:"parameters"`
+		Strict      bool           `json:"strict"`
+	} `json:"function"`
+}
+
+// openAIToolCall is a function call in an assistant message.
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
 }
 
 // openAIChatResponse models the top-level response from the chat
@@ -59,9 +123,11 @@ type openAIChatResponse struct {
 	Choices []struct {
 		FinishReason string `json:"finish_reason,omitempty"`
 		Message      struct {
-			Content string `json:"content"`
+			Content   string           `json:"content"`
+			ToolCalls []openAIToolCall `json:"tool_calls,omitempty"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage *openAIUsage `json:"usage,omitempty"`
 	Error *struct {
 		Message string `json:"message"`
 		Type    string `json:"type"`
@@ -69,3 +135,243 @@ type openAIChatResponse struct {
 		Code    string `json:"code"`
 	} `json:"error,omitempty"`
 }
+
+// openAIUsage is the token accounting block of a completion.
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u *openAIUsage) usage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	return Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+}
+
+// openAIStreamChunk is a single server-sent event of a streamed completion.
+type openAIStreamChunk struct {
+	// Usage is only set on the final chunk, when stream_options.include_usage
+	// was requested.
+	Usage   *openAIUsage `json:"usage,omitempty"`
+	Choices []struct {
+		FinishReason string `json:"finish_reason,omitempty"`
+		Delta        struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// openAIProvider talks to any OpenAI-compatible chat completions endpoint:
+// api.openai.com, Azure OpenAI deployments and self-hosted servers such as
+// vLLM or Ollama.
+type openAIProvider struct {
+	name       string
+	baseURL    string
+	apiKey     string
+	requireKey bool
+	// azureAPIVersion switches URL and auth to the Azure deployment layout.
+	azureAPIVersion string
+	// legacyMaxTokens sends max_tokens instead of max_completion_tokens.
+	legacyMaxTokens bool
+	httpClient      *http.Client
+}
+
+// newOpenAIProvider returns a provider for an OpenAI-compatible base URL
+// (e.g. https://api.openai.com/v1) that requires an API key.
+func newOpenAIProvider(name, baseURL, apiKey string) *openAIProvider {
+	return &openAIProvider{
+		name:       name,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		requireKey: true,
+		httpClient: &http.Client{Timeout: 90 * time.Second},
+	}
+}
+
+// newLocalProvider returns a provider for a self-hosted OpenAI-compatible
+// server.  The API key is optional and the legacy max_tokens field is used.
+func newLocalProvider(baseURL, apiKey string) *openAIProvider {
+	p := newOpenAIProvider(ProviderLocal, baseURL, apiKey)
+	p.requireKey = false
+	p.legacyMaxTokens = true
+	return p
+}
+
+// newAzureProvider returns a provider for an Azure OpenAI resource.  The
+// model passed to Complete is used as the deployment name.
+func newAzureProvider(endpoint, apiKey, apiVersion string) *openAIProvider {
+	p := newOpenAIProvider(ProviderAzure, endpoint, apiKey)
+	p.azureAPIVersion = apiVersion
+	return p
+}
+
+// Name implements Provider.
+func (p *openAIProvider) Name() string { return p.name }
+
+// Complete implements Provider.  If the model rejects the requested
+// temperature (e.g., gpt-5-mini only accepts the default), it retries once
+// without a custom temperature.
+func (p *openAIProvider) Complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	resp, err := p.post(ctx, p.request(req))
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	defer resp.Body.Close()
+	rb, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		if p.rejectsTemperature(resp.StatusCode, string(rb), req) {
+			req.Temperature = 0
+			return p.Complete(ctx, req)
+		}
+		return ChatResponse{}, fmt.Errorf("%s status=%d body=%s", p.name, resp.StatusCode, preview(string(rb), 400))
+	}
+	var out openAIChatResponse
+	if err := json.Unmarshal(rb, &out); err != nil {
+		return ChatResponse{}, err
+	}
+	if out.Error != nil {
+		return ChatResponse{}, fmt.Errorf("%s: %s", p.name, out.Error.Message)
+	}
+	if len(out.Choices) == 0 {
+		return ChatResponse{}, fmt.Errorf("%s: no choices", p.name)
+	}
+	msg := out.Choices[0].Message
+	var calls []ToolCall
+	for _, tc := range msg.ToolCalls {
+		calls = append(calls, ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+	}
+	return ChatResponse{
+		Content:      msg.Content,
+		FinishReason: out.Choices[0].FinishReason,
+		ToolCalls:    calls,
+		Usage:        out.Usage.usage(),
+	}, nil
+}
+
+// Stream implements Streamer using server-sent events.
+func (p *openAIProvider) Stream(ctx context.Context, req ChatRequest, onText func(string)) (ChatResponse, error) {
+	body := p.request(req)
+	body.Stream = true
+	body.Tools = nil
+	if p.name != ProviderLocal {
+		body.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+	resp, err := p.post(ctx, body)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		rb, _ := io.ReadAll(resp.Body)
+		if p.rejectsTemperature(resp.StatusCode, string(rb), req) {
+			req.Temperature = 0
+			return p.Stream(ctx, req, onText)
+		}
+		return ChatResponse{}, fmt.Errorf("%s status=%d body=%s", p.name, resp.StatusCode, preview(string(rb), 400))
+	}
+	var out ChatResponse
+	var sb strings.Builder
+	var streamErr error
+	err = readSSE(resp.Body, func(_, data string) bool {
+		if data == "[DONE]" {
+			return false
+		}
+		var chunk openAIStreamChunk
+		if json.Unmarshal([]byte(data), &chunk) != nil {
+			return true
+		}
+		if chunk.Error != nil {
+			streamErr = fmt.Errorf("%s: %s", p.name, chunk.Error.Message)
+			return false
+		}
+		if chunk.Usage != nil {
+			out.Usage = chunk.Usage.usage()
+		}
+		for _, ch := range chunk.Choices {
+			if ch.Delta.Content != "" {
+				sb.WriteString(ch.Delta.Content)
+				onText(ch.Delta.Content)
+			}
+			if ch.FinishReason != "" {
+				out.FinishReason = ch.FinishReason
+			}
+		}
+		return true
+	})
+	if err == nil {
+		err = streamErr
+	}
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	out.Content = sb.String()
+	return out, nil
+}
+
+// request builds the chat completions payload for req.
+func (p *openAIProvider) request(req ChatRequest) openAIChatRequest {
+	body := openAIChatRequest{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Temperature: req.Temperature,
+	}
+	if p.legacyMaxTokens {
+		body.MaxTokens = req.MaxTokens
+	} else {
+		body.MaxCompletionTokens = req.MaxTokens
+	}
+	for _, t := range req.Tools {
+		var tool openAITool
+		tool.Type = "function"
+		tool.Function.Name = t.Name
+		tool.Function.Description = t.Description
+		tool.Function.Parameters = t.Parameters
+		tool.Function.Strict = true
+		body.Tools = append(body.Tools, tool)
+	}
+	return body
+}
+
+// post sends body to the chat completions endpoint with the provider's auth.
+func (p *openAIProvider) post(ctx context.Context, body openAIChatRequest) (*http.Response, error) {
+	if p.requireKey && p.apiKey == "" {
+		if p.name == ProviderOpenAI {
+			return nil, errors.New("missing OPENAI_API_KEY")
+		}
+		return nil, fmt.Errorf("%s: missing API key", p.name)
+	}
+	b, _ := json.Marshal(body)
+	httpReq, _ := http.NewRequestWithContext(ctx, "POST", p.endpoint(body.Model), bytes.NewReader(b))
+	switch {
+	case p.azureAPIVersion != "":
+		httpReq.Header.Set("api-key", p.apiKey)
+	case p.apiKey != "":
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	return p.httpClient.Do(httpReq)
+}
+
+// rejectsTemperature reports whether an error response means the model does
+// not support a custom temperature (e.g., gpt-5-mini only accepts the
+// default), in which case the call is retried without one.
+func (p *openAIProvider) rejectsTemperature(status int, body string, req ChatRequest) bool {
+	if status == 400 && strings.Contains(body, "\"temperature\"") && req.Temperature != 0 {
+		log.Printf("[LLM] model %s rejected temperature=%.1f — retrying with default", req.Model, req.Temperature)
+		return true
+	}
+	return false
+}
+
+// endpoint returns the chat completions URL for model.
+func (p *openAIProvider) endpoint(model string) string {
+	if p.azureAPIVersion != "" {
+		return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+			p.baseURL, url.PathEscape(model), url.QueryEscape(p.azureAPIVersion))
+	}
+	return p.baseURL + "/chat/completions"
+}
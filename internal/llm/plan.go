@@ -0,0 +1,252 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ActionResult reports the outcome of an executed action back to the router.
+// Summary is a short, model-facing description of what the source returned
+// (counts, the first lines of context, or the error/empty marker).
+type ActionResult struct {
+	Action  ActionDescriptor
+	Summary string
+}
+
+// ActionPlan is a multi-step routing conversation.  The first step is run by
+// PlanActions; after the caller executes Actions it reports the results with
+// Continue, and the model may request further tools — e.g. an alternative
+// source when the first one came back empty — until the step limit.
+//
+// A nil *ActionPlan is valid and never yields further actions.
+type ActionPlan struct {
+	// Actions are the validated actions requested by the latest step.
+	Actions []ActionDescriptor
+
+	c        *Client
+	model    string
+	question string
+	native   bool
+	defs     []ToolDef
+	tools    []ToolSpec
+	messages []OpenAIMessage
+	// calls are the tool calls of the latest step awaiting a result;
+	// rejected maps a call ID to the validation error reported back instead.
+	calls    []ToolCall
+	rejected map[string]string
+	seen     map[string]bool
+	step     int
+	maxSteps int
+}
+
+// PlanActions starts an ActionPlan for the message.  The input carries the
+// tools and prompt snippets of every enabled skill.  When native tools are
+// enabled each action kind is exposed as a tool with a strict JSON schema; if
+// the provider rejects tool calling the plan falls back to the legacy
+// JSON-array protocol.
+func (c *Client) PlanActions(ctx context.Context, model string, in RouterInput) (*ActionPlan, error) {
+	p := &ActionPlan{
+		c:        c,
+		model:    model,
+		question: in.Question,
+		native:   c.nativeTools,
+		defs:     in.Tools,
+		tools:    toolSpecs(in.Tools),
+		rejected: map[string]string{},
+		seen:     map[string]bool{},
+		maxSteps: c.agentMaxSteps,
+	}
+	if p.maxSteps <= 0 {
+		p.maxSteps = 1
+	}
+	prompt := func(native bool) string { return routerPrompt(in, native) }
+
+	p.messages = []OpenAIMessage{{Role: "user", Content: prompt(p.native)}}
+	actions, err := p.next(ctx)
+	if err != nil && p.native && ctx.Err() == nil {
+		log.Printf("[LLM] native tool routing failed, falling back to JSON array: %v", err)
+		p.native = false
+		p.step = 0
+		p.messages = []OpenAIMessage{{Role: "user", Content: prompt(false)}}
+		actions, err = p.next(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Actions = actions
+	return p, nil
+}
+
+// Continue reports the results of the latest Actions to the model and returns
+// any additional actions it requests.  Tool calls that were rejected, repeated
+// or not executed are reported as such so the model can correct itself.  It
+// returns nil once the step limit is reached or the model requests nothing.
+func (p *ActionPlan) Continue(ctx context.Context, results []ActionResult) ([]ActionDescriptor, error) {
+	if p == nil || p.step >= p.maxSteps {
+		return nil, nil
+	}
+	byCall := make(map[string]string, len(results))
+	for _, r := range results {
+		byCall[r.Action.CallID] = r.Summary
+	}
+
+	if p.native {
+		if len(p.calls) == 0 {
+			return nil, nil
+		}
+		for _, call := range p.calls {
+			summary, ok := byCall[call.ID]
+			switch {
+			case p.rejected[call.ID] != "":
+				summary = "ERRO: chamada rejeitada — " + p.rejected[call.ID]
+			case !ok:
+				summary = "não executada nesta etapa."
+			}
+			p.messages = append(p.messages, OpenAIMessage{Role: "tool", ToolCallID: call.ID, Content: clip(summary, 1500)})
+		}
+	} else {
+		var sb strings.Builder
+		sb.WriteString("[SISTEMA: resultados das ações executadas:\n")
+		for _, a := range p.Actions {
+			summary, ok := byCall[a.CallID]
+			if !ok {
+				summary = "não executada nesta etapa."
+			}
+			fmt.Fprintf(&sb, "- %s: %s\n", a.Kind, clip(summary, 800))
+		}
+		for _, reason := range p.rejected {
+			fmt.Fprintf(&sb, "- ação rejeitada: %s\n", reason)
+		}
+		sb.WriteString("]\nSe os resultados forem vazios, com erro ou insuficientes, retorne um JSON array APENAS com as novas ações (fontes alternativas, sem repetir ações). Caso contrário, retorne [].")
+		p.messages = append(p.messages, OpenAIMessage{Role: "user", Content: sb.String()})
+	}
+
+	actions, err := p.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.Actions = actions
+	return actions, nil
+}
+
+// next runs one router round on the current conversation and returns the
+// validated, normalised and previously unseen actions.
+func (p *ActionPlan) next(ctx context.Context) ([]ActionDescriptor, error) {
+	p.step++
+	p.calls = nil
+	p.rejected = map[string]string{}
+
+	var actions []ActionDescriptor
+	var raw string
+	if p.native {
+		resp, err := p.chatWithTools(ctx)
+		if err != nil {
+			return nil, err
+		}
+		raw = resp.Content
+		if len(resp.ToolCalls) > 0 {
+			p.messages = append(p.messages, OpenAIMessage{Role: "assistant", Content: resp.Content, ToolCalls: resp.ToolCalls})
+			p.calls = resp.ToolCalls
+			for _, call := range resp.ToolCalls {
+				a, vErr := validateToolArgs(p.defs, call.Name, call.Arguments)
+				if vErr != nil {
+					log.Printf("[LLM] tool call rejected name=%s err=%v args=%q", call.Name, vErr, preview(call.Arguments, 200))
+					p.rejected[call.ID] = vErr.Error()
+					continue
+				}
+				a.CallID = call.ID
+				actions = append(actions, a)
+			}
+		} else {
+			// Models that ignore the tools and answer in text still get the
+			// JSON-array parser; anything else on a follow-up step means "done".
+			parsed, err := p.parseArray(raw)
+			if err != nil && p.step == 1 && strings.TrimSpace(raw) != "" {
+				return nil, err
+			}
+			if err == nil {
+				p.messages = append(p.messages, OpenAIMessage{Role: "assistant", Content: raw})
+				p.native = false
+			}
+			actions = parsed
+		}
+	} else {
+		out, err := p.chat(ctx)
+		if err != nil {
+			return nil, err
+		}
+		raw = out
+		p.messages = append(p.messages, OpenAIMessage{Role: "assistant", Content: out})
+		actions, err = p.parseArray(out)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var result []ActionDescriptor
+	for _, a := range actions {
+		key := actionKey(a)
+		if p.seen[key] {
+			if a.CallID != "" {
+				p.rejected[a.CallID] = "ação repetida: já executada com os mesmos argumentos."
+			}
+			continue
+		}
+		p.seen[key] = true
+		result = append(result, a)
+	}
+
+	kinds := make([]string, 0, len(result))
+	for _, a := range result {
+		kinds = append(kinds, a.Kind)
+	}
+	log.Printf("[LLM] decideActions step=%d native=%t actions=%v rejected=%d raw=%q", p.step, p.native, kinds, len(p.rejected), preview(raw, 120))
+	return result, nil
+}
+
+// parseArray parses a JSON-array answer, assigning synthetic call IDs so
+// results can be matched in Continue.
+func (p *ActionPlan) parseArray(out string) ([]ActionDescriptor, error) {
+	actions, rejected, err := parseActionArray(p.defs, out)
+	if err != nil {
+		return nil, err
+	}
+	for i, rErr := range rejected {
+		log.Printf("[LLM] action rejected err=%v", rErr)
+		p.rejected[fmt.Sprintf("rejected-%d-%d", p.step, i)] = rErr.Error()
+	}
+	for i := range actions {
+		actions[i].CallID = fmt.Sprintf("step%d-%d", p.step, i)
+	}
+	return actions, nil
+}
+
+// actionKey identifies an action by kind and arguments, ignoring CallID.
+// Args is included so that skill-specific parameters without a dedicated
+// field still tell two calls apart.
+func actionKey(a ActionDescriptor) string {
+	b, _ := json.Marshal(struct {
+		ActionDescriptor
+		Args map[string]any `json:"args,omitempty"`
+	}{a, a.Args})
+	return string(b)
+}
+
+// chatWithTools and chat run one router round.  Only the first round is
+// cached: later rounds carry the results of the executed actions.
+func (p *ActionPlan) chatWithTools(ctx context.Context) (ChatResponse, error) {
+	if p.step == 1 {
+		return p.c.cachedChatWithTools(ctx, siteRouter, p.question, p.messages, p.tools, p.model, 0.2, 4000)
+	}
+	return p.c.ChatWithTools(withSite(ctx, siteRouter), p.messages, p.tools, p.model, 0.2, 4000)
+}
+
+func (p *ActionPlan) chat(ctx context.Context) (string, error) {
+	if p.step == 1 {
+		return p.c.cachedChat(ctx, siteRouter, p.question, p.messages, p.model, 0.2, 4000)
+	}
+	return p.c.Chat(withSite(ctx, siteRouter), p.messages, p.model, 0.2, 4000)
+}
@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+// Provider names accepted in LLM_DEFAULT_PROVIDER and LLM_MODEL_PROVIDERS.
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAzure     = "azure"
+	ProviderLocal     = "local"
+	ProviderAnthropic = "anthropic"
+)
+
+// ChatRequest is the provider-neutral input of a single chat completion.
+// Messages use the OpenAI shape; adapters translate them as needed.
+type ChatRequest struct {
+	Model       string
+	Messages    []OpenAIMessage
+	Temperature float64
+	MaxTokens   int
+	// Tools, when non-empty, lets the model answer with ToolCalls instead
+	// of (or in addition to) text.
+	Tools []ToolSpec
+}
+
+// ChatResponse is the provider-neutral result of a chat completion.
+// FinishReason is normalised to the OpenAI vocabulary ("stop", "length",
+// "tool_calls").
+type ChatResponse struct {
+	Content      string
+	FinishReason string
+	ToolCalls    []ToolCall
+	// Usage is the token count reported by the provider; zero when the
+	// server does not report it.
+	Usage Usage
+}
+
+// Usage is the token consumption of a single completion.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Provider is a chat-completion backend.  Implementations must be safe for
+// concurrent use.
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, req ChatRequest) (ChatResponse, error)
+}
+
+// Streamer is implemented by providers that can stream a completion.  onText
+// is called with each text fragment as it arrives; the assembled response is
+// returned once the stream ends.  Tool calls are not streamed.
+type Streamer interface {
+	Stream(ctx context.Context, req ChatRequest, onText func(fragment string)) (ChatResponse, error)
+}
+
+// newProviders builds every provider that has enough configuration to be
+// usable.  The "openai" provider is always registered so that the historical
+// OPENAI_API_KEY-only setup keeps working.
+func newProviders(cfg config.Config) map[string]Provider {
+	out := map[string]Provider{
+		ProviderOpenAI: newOpenAIProvider(ProviderOpenAI, cfg.OpenAIBaseURL, cfg.OpenAIAPIKey),
+	}
+	if strings.TrimSpace(cfg.AzureOpenAIEndpoint) != "" && strings.TrimSpace(cfg.AzureOpenAIAPIKey) != "" {
+		out[ProviderAzure] = newAzureProvider(cfg.AzureOpenAIEndpoint, cfg.AzureOpenAIAPIKey, cfg.AzureOpenAIAPIVersion)
+	}
+	if strings.TrimSpace(cfg.LocalLLMBaseURL) != "" {
+		out[ProviderLocal] = newLocalProvider(cfg.LocalLLMBaseURL, cfg.LocalLLMAPIKey)
+	}
+	if strings.TrimSpace(cfg.AnthropicAPIKey) != "" {
+		out[ProviderAnthropic] = newAnthropicProvider(cfg.AnthropicBaseURL, cfg.AnthropicAPIKey)
+	}
+
+	names := make([]string, 0, len(out))
+	for name := range out {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	log.Printf("[BOOT] LLM providers=%v default=%q model_map=%v", names, cfg.LLMDefaultProvider, cfg.LLMModelProviders)
+	return out
+}
+
+// providerFor resolves the provider that serves model.  Exact entries in the
+// model map win over "prefix*" patterns; the longest matching prefix wins
+// among patterns.  Unmapped models use the default provider.
+func (c *Client) providerFor(model string) (Provider, error) {
+	name := c.defaultProvider
+	if p, ok := c.modelProviders[model]; ok {
+		name = p
+	} else {
+		best := -1
+		for pattern, p := range c.modelProviders {
+			prefix, isPrefix := strings.CutSuffix(pattern, "*")
+			if isPrefix && strings.HasPrefix(model, prefix) && len(prefix) > best {
+				best = len(prefix)
+				name = p
+			}
+		}
+	}
+	if name == "" {
+		name = ProviderOpenAI
+	}
+	p, ok := c.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("llm: provider %q for model %q is not configured", name, model)
+	}
+	return p, nil
+}
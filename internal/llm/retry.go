@@ -1,8 +1,10 @@
 package llm
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
+	"log"
 	"strings"
 	"time"
 
@@ -21,21 +23,48 @@ func (a ImageAttachment) DataURL() string {
 	return "data:" + a.MimeType + ";base64," + base64.StdEncoding.EncodeToString(a.Data)
 }
 
+// ContextSection is one labelled block of external context for the answer
+// prompt, typically the merged output of a skill.  Kind is the skill's action
+// kind; Label is the header written above Text.  Weight is the section's
+// share of the context budget relative to the thread (2) and attached files
+// (3); zero counts as 1.  Hints are instructions the skill adds to the system
+// prompt, e.g. that its integration is not configured; a section may carry
+// hints with no Text.
+type ContextSection struct {
+	Kind   string
+	Label  string
+	Text   string
+	Weight float64
+	Hints  []string
+}
+
+// SectionText returns the text of the section with the given kind, or "".
+func SectionText(sections []ContextSection, kind string) string {
+	for _, sec := range sections {
+		if sec.Kind == kind {
+			return sec.Text
+		}
+	}
+	return ""
+}
+
+// promptOverheadTokens covers the section labels and closing instructions of
+// the answer prompt; imageTokens is a conservative cost per vision image.
+const (
+	promptOverheadTokens = 500
+	imageTokens          = 1100
+)
+
 // answerWithModel assembles the prompt and calls the Chat API with the
 // specified model.  It converts Markdown into Slack Markdown before
-// returning the result.
-func (c *Client) answerWithModel(companyCtx, question, threadHistory, slackCtx, jiraCtx, dbCtx, fileCtx, outlineCtx, googleDriveCtx, hubspotCtx string, images []ImageAttachment, model string) (string, error) {
+// returning the result.  When onPartial is non-nil the completion is
+// streamed and onPartial receives the raw Markdown generated so far.
+func (c *Client) answerWithModel(ctx context.Context, companyCtx, question, threadHistory string, sections []ContextSection, fileCtx string, images []ImageAttachment, onPartial func(string), model string) (string, error) {
+	ctx = withSite(ctx, siteAnswer)
 	botName := c.BotName
 	if strings.TrimSpace(botName) == "" {
 		botName = "Jarvis"
 	}
-	sqlOnlyCtx := dbCtx != "" && jiraCtx == ""
-
-	contextHint := "Se o contexto não for suficiente, diga o que falta e sugira como achar (JQL/links)."
-	if sqlOnlyCtx {
-		contextHint = "Se os dados não forem suficientes para responder, informe o usuário e sugira como refinar a pergunta. Nunca mencione JQL — este contexto é exclusivamente de banco de dados SQL."
-	}
-
 	systemParts := []string{}
 	if strings.TrimSpace(companyCtx) != "" {
 		systemParts = append(systemParts,
@@ -46,7 +75,7 @@ func (c *Client) answerWithModel(companyCtx, question, threadHistory, slackCtx,
 	systemParts = append(systemParts,
 		"Você é o "+botName+", assistente do Slack.",
 		"Responda em português brasileiro, direto, sem enrolação, usando o contexto quando existir.",
-		contextHint,
+		"Se o contexto não for suficiente, diga o que falta e sugira como encontrar.",
 		"Não invente fatos.",
 		"Quando a pergunta for ambígua ou faltar informação essencial para uma boa resposta, prefira fazer uma pergunta de esclarecimento direta ao usuário em vez de adivinhar ou dar uma resposta genérica.",
 		"MENÇÕES DE USUÁRIOS SLACK: Ao mencionar um usuário pelo ID (ex: U067UM4LRGB), SEMPRE use o formato de mention <@USERID> (ex: <@U067UM4LRGB>). O Slack renderiza automaticamente como o nome de exibição. NUNCA escreva @U067UM4LRGB ou o ID puro — use sempre <@ID>.",
@@ -74,58 +103,47 @@ func (c *Client) answerWithModel(companyCtx, question, threadHistory, slackCtx,
 		"",
 		"LIMITAÇÃO IMPORTANTE: Você não consegue enviar arquivos, anexos ou downloads no Slack. Quando o usuário pedir dados em CSV, Excel ou qualquer outro formato de arquivo para download, informe claramente que essa funcionalidade não está disponível no momento e ofereça apresentar os dados diretamente na mensagem (tabela em bloco de código, lista, etc.).",
 	)
-	if strings.TrimSpace(c.JiraBaseURL) != "" {
-		baseURL := strings.TrimRight(strings.TrimSpace(c.JiraBaseURL), "/")
-		systemParts = append(systemParts, "",
-			"IMPORTANTE - Links do Jira:",
-			"- Quando precisar gerar um link completo de uma issue Jira, use SEMPRE este base URL: "+baseURL,
-			"- Formato: "+baseURL+"/browse/KEY (ex: "+baseURL+"/browse/PROJ-123)",
-			"- NUNCA use outros domínios além do base URL fornecido acima.")
-	}
-	if sqlOnlyCtx {
-		systemParts = append(systemParts, "",
-			"CONTEXTO: Esta pergunta é respondida com dados de banco de dados SQL.",
-			"- NÃO mencione JQL em nenhuma hipótese — JQL é exclusivo para perguntas sobre o Jira.",
-			"- NÃO oriente o usuário a usar o Jira ou a fazer buscas no Jira.",
-			"- Se os dados forem insuficientes, pergunte ao usuário como refinar a consulta.")
-	}
-	// When the Jira context contains a sentinel (error or empty result), reinforce
-	// the anti-hallucination instruction so the LLM never invents issue data.
-	if strings.Contains(jiraCtx, "[JIRA_ERROR:") || strings.Contains(jiraCtx, "[JIRA_EMPTY:") {
-		systemParts = append(systemParts, "",
-			"DADOS JIRA AUSENTES: A busca no Jira falhou ou não retornou resultados.",
-			"- NÃO invente issues, títulos, assignees, chaves (PROJ-NNN) ou links.",
-			"- Use apenas o que está no CONTEXTO DO JIRA acima.",
-			"- Se não houver dados, informe o usuário claramente e sugira refinar a busca.")
-	}
-	// Inform the LLM which optional integrations are active so it responds
-	// honestly when users ask about capabilities that are not configured.
-	if !c.JiraEnabled {
-		systemParts = append(systemParts, "",
-			"JIRA NÃO CONFIGURADO: A integração com Jira não está habilitada nesta instalação. Se o usuário pedir algo relacionado a Jira (criar card, buscar issue, roadmap etc.), informe gentilmente que essa integração não está disponível e sugira que o administrador configure as variáveis JIRA_BASE_URL, JIRA_EMAIL e JIRA_API_TOKEN.")
-	}
-	if !c.MetabaseEnabled {
-		systemParts = append(systemParts, "",
-			"METABASE NÃO CONFIGURADO: A integração com Metabase (banco de dados) não está habilitada nesta instalação. Se o usuário pedir consultas de dados, métricas ou relatórios que requeiram SQL, informe gentilmente que essa integração não está disponível e sugira que o administrador configure as variáveis METABASE_BASE_URL e METABASE_API_KEY.")
-	}
-	if c.MetabaseEnabled && strings.TrimSpace(dbCtx) == "" {
-		systemParts = append(systemParts, "",
-			"DADOS DE BANCO AUSENTES: Nenhum resultado de consulta SQL está disponível para esta resposta.",
-			"- Se a pergunta requer dados do banco de dados, informe o usuário que não foi possível buscar os dados.",
-			"- NÃO invente, estime ou fabrique registros, nomes, status, valores ou qualquer dado operacional.",
-			"- Responda apenas com o que está no contexto da thread ou nos outros contextos fornecidos.")
+	// Skills add their own instructions: how to read their data, what to do
+	// when it is missing, or that the integration is not available.
+	for _, sec := range sections {
+		for _, h := range sec.Hints {
+			systemParts = append(systemParts, "", h)
+		}
 	}
-	if !c.OutlineEnabled {
-		systemParts = append(systemParts, "",
-			"OUTLINE NÃO CONFIGURADO: A integração com o Outline Wiki não está habilitada nesta instalação. Se o usuário pedir documentação interna, processos ou guias que provavelmente estão na wiki, informe gentilmente que essa integração não está disponível e sugira que o administrador configure as variáveis OUTLINE_BASE_URL e OUTLINE_API_KEY.")
+	// Slack messages and Jira issues carry source IDs ([[S:…]], [[J:KEY]]);
+	// the app turns the model's markers into numbered footnotes and drops any
+	// ID that was not retrieved.
+	cited := false
+	for _, sec := range sections {
+		if strings.Contains(sec.Text, "[[S:") || strings.Contains(sec.Text, "[[J:") {
+			cited = true
+			break
+		}
 	}
-	if !c.GoogleDriveEnabled {
+	if cited {
 		systemParts = append(systemParts, "",
-			"GOOGLE DRIVE NÃO CONFIGURADO: A integração com o Google Drive não está habilitada nesta instalação. Se o usuário pedir arquivos, documentos ou planilhas do Drive, informe gentilmente que essa integração não está disponível e sugira que o administrador configure as variáveis GOOGLE_DRIVE_CREDENTIALS_JSON ou GOOGLE_DRIVE_CREDENTIALS_PATH.")
+			"CITAÇÕES: Cada mensagem do Slack e issue do Jira no contexto começa com um ID de fonte entre colchetes duplos (ex: [[S:3fa9c1]], [[J:PROJ-123]]).",
+			"- Ao afirmar algo que veio dessas fontes, cite o ID logo após a frase, no mesmo formato: \"O deploy foi adiado para sexta [[S:3fa9c1]].\" Várias fontes: [[J:PROJ-1]][[S:3fa9c1]].",
+			"- Use SOMENTE IDs que aparecem no contexto. Nunca invente ou altere um ID.",
+			"- NÃO escreva a lista de fontes nem links para essas citações — ela é adicionada automaticamente.")
 	}
-	if !c.HubSpotEnabled {
+	// Size the context to the model: whatever is left of the window after the
+	// instructions, the question, images and the completion is shared among
+	// the thread, the skill sections and the attached files.
+	window := c.ContextWindow(model)
+	maxTokens := min(answerMaxTokens, window/4)
+	available := contextBudget(window, maxTokens, len(images),
+		EstimateTokens(strings.Join(systemParts, "\n"))+EstimateTokens(question))
+	threadHistory, sections, fileCtx, trims := fitContext(question, threadHistory, sections, fileCtx, available)
+	if len(trims) > 0 {
+		names := make([]string, len(trims))
+		for i, t := range trims {
+			names[i] = t.String()
+		}
+		log.Printf("[LLM] context over budget model=%s window=%d available=%d trimmed: %s", model, window, available, strings.Join(names, ", "))
 		systemParts = append(systemParts, "",
-			"HUBSPOT NÃO CONFIGURADO: A integração com o HubSpot CRM não está habilitada nesta instalação. Se o usuário pedir dados de CRM (contatos, empresas, deals, tickets, clientes), informe gentilmente que essa integração não está disponível e sugira que o administrador configure a variável HUBSPOT_API_KEY.")
+			"CONTEXTO TRUNCADO: Parte do contexto abaixo foi cortada para caber no limite do modelo (trechos marcados com [AVISO: …]).",
+			"- Se a resposta depender de dados que podem ter ficado de fora, diga ao usuário que o resultado pode estar incompleto e sugira uma pergunta mais específica.")
 	}
 	system := strings.Join(systemParts, "\n")
 	var u strings.Builder
@@ -134,19 +152,13 @@ func (c *Client) answerWithModel(companyCtx, question, threadHistory, slackCtx,
 		u.WriteString(threadHistory)
 		u.WriteString("\n\n")
 	}
-	if slackCtx != "" {
-		u.WriteString("CONTEXTO DO SLACK (busca):\n")
-		u.WriteString(slackCtx)
-		u.WriteString("\n\n")
-	}
-	if jiraCtx != "" {
-		u.WriteString("CONTEXTO DO JIRA:\n")
-		u.WriteString(jiraCtx)
-		u.WriteString("\n\n")
-	}
-	if dbCtx != "" {
-		u.WriteString("DADOS DO BANCO DE DADOS (resultado de query SQL):\n")
-		u.WriteString(dbCtx)
+	for _, sec := range sections {
+		if strings.TrimSpace(sec.Text) == "" {
+			continue
+		}
+		u.WriteString(sec.Label)
+		u.WriteString(":\n")
+		u.WriteString(sec.Text)
 		u.WriteString("\n\n")
 	}
 	if fileCtx != "" {
@@ -154,21 +166,6 @@ func (c *Client) answerWithModel(companyCtx, question, threadHistory, slackCtx,
 		u.WriteString(fileCtx)
 		u.WriteString("\n\n")
 	}
-	if outlineCtx != "" {
-		u.WriteString("DOCUMENTAÇÃO INTERNA (Outline Wiki):\n")
-		u.WriteString(outlineCtx)
-		u.WriteString("\n\n")
-	}
-	if googleDriveCtx != "" {
-		u.WriteString("DOCUMENTOS DO GOOGLE DRIVE:\n")
-		u.WriteString(googleDriveCtx)
-		u.WriteString("\n\n")
-	}
-	if hubspotCtx != "" {
-		u.WriteString("CONTEXTO DO HUBSPOT CRM:\n")
-		u.WriteString(hubspotCtx)
-		u.WriteString("\n\n")
-	}
 	u.WriteString("PERGUNTA:\n")
 	u.WriteString(question)
 	u.WriteString("\n\n")
@@ -191,7 +188,13 @@ func (c *Client) answerWithModel(companyCtx, question, threadHistory, slackCtx,
 		{Role: "system", Content: system},
 		userMsg,
 	}
-	out, err := c.Chat(msgs, model, 0.7, 20000)
+	var out string
+	var err error
+	if onPartial != nil {
+		out, err = c.ChatStream(ctx, msgs, model, 0.7, maxTokens, onPartial)
+	} else {
+		out, err = c.Chat(ctx, msgs, model, 0.7, maxTokens)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -203,10 +206,16 @@ func (c *Client) answerWithModel(companyCtx, question, threadHistory, slackCtx,
 // AnswerWithRetry generates an answer using primaryModel, retrying on transient
 // failures, then falls back to lesserModel when configured and different.
 // This makes answer generation resilient to flaky networking, 429s, and 5xxs.
+// onPartial, when non-nil, receives the answer as it streams in; a retry
+// starts the text over.
 func (c *Client) AnswerWithRetry(
+	ctx context.Context,
 	companyCtx,
-	question, threadHistory, slackCtx, jiraCtx, dbCtx, fileCtx, outlineCtx, googleDriveCtx, hubspotCtx string,
+	question, threadHistory string,
+	sections []ContextSection,
+	fileCtx string,
 	images []ImageAttachment,
+	onPartial func(partial string),
 	primaryModel, lesserModel string,
 	maxAttempts int,
 	baseDelay time.Duration,
@@ -219,14 +228,14 @@ func (c *Client) AnswerWithRetry(
 	}
 
 	// Try primary first.
-	out, err := c.answerWithRetrySingleModel(companyCtx, question, threadHistory, slackCtx, jiraCtx, dbCtx, fileCtx, outlineCtx, googleDriveCtx, hubspotCtx, images, primaryModel, maxAttempts, baseDelay)
+	out, err := c.answerWithRetrySingleModel(ctx, companyCtx, question, threadHistory, sections, fileCtx, images, onPartial, primaryModel, maxAttempts, baseDelay)
 	if err == nil && strings.TrimSpace(out) != "" {
 		return out, nil
 	}
 
 	// Fall back to the lesser model if configured and different from the primary.
-	if lesserModel != "" && lesserModel != primaryModel {
-		out2, err2 := c.answerWithRetrySingleModel(companyCtx, question, threadHistory, slackCtx, jiraCtx, dbCtx, fileCtx, outlineCtx, googleDriveCtx, hubspotCtx, images, lesserModel, maxAttempts, baseDelay)
+	if lesserModel != "" && lesserModel != primaryModel && ctx.Err() == nil {
+		out2, err2 := c.answerWithRetrySingleModel(ctx, companyCtx, question, threadHistory, sections, fileCtx, images, onPartial, lesserModel, maxAttempts, baseDelay)
 		if err2 == nil && strings.TrimSpace(out2) != "" {
 			return out2, nil
 		}
@@ -243,16 +252,20 @@ func (c *Client) AnswerWithRetry(
 }
 
 func (c *Client) answerWithRetrySingleModel(
+	ctx context.Context,
 	companyCtx,
-	question, threadHistory, slackCtx, jiraCtx, dbCtx, fileCtx, outlineCtx, googleDriveCtx, hubspotCtx string,
+	question, threadHistory string,
+	sections []ContextSection,
+	fileCtx string,
 	images []ImageAttachment,
+	onPartial func(partial string),
 	model string,
 	maxAttempts int,
 	baseDelay time.Duration,
 ) (string, error) {
 	var lastErr error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		out, err := c.answerWithModel(companyCtx, question, threadHistory, slackCtx, jiraCtx, dbCtx, fileCtx, outlineCtx, googleDriveCtx, hubspotCtx, images, model)
+		out, err := c.answerWithModel(ctx, companyCtx, question, threadHistory, sections, fileCtx, images, onPartial, model)
 		if err == nil && strings.TrimSpace(out) != "" {
 			return out, nil
 		}
@@ -262,10 +275,16 @@ func (c *Client) answerWithRetrySingleModel(
 			lastErr = errors.New("empty content from openai")
 		}
 
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
 		// backoff with jitter
 		if attempt < maxAttempts {
-			sleep := backoffWithJitter(baseDelay, attempt)
-			time.Sleep(sleep)
+			select {
+			case <-time.After(backoffWithJitter(baseDelay, attempt)):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
 		}
 	}
 	return "", lastErr
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+)
+
+// ChatStream is like Chat but streams the completion when the provider
+// supports it: onPartial is called with the text generated so far each time
+// a new fragment arrives.  Providers without streaming call onPartial once
+// with the full content.  The returned string is the same as Chat's.
+func (c *Client) ChatStream(ctx context.Context, messages []OpenAIMessage, model string, temperature float64, maxTokens int, onPartial func(partial string)) (string, error) {
+	if strings.TrimSpace(model) == "" {
+		model = "gpt-4o-mini"
+	}
+	p, err := c.providerFor(model)
+	if err != nil {
+		return "", err
+	}
+	req := ChatRequest{
+		Model:       model,
+		Messages:    messages,
+		Temperature: temperature,
+		MaxTokens:   maxTokens,
+	}
+	var resp ChatResponse
+	if sp, ok := p.(Streamer); ok {
+		var sb strings.Builder
+		resp, err = sp.Stream(ctx, req, func(fragment string) {
+			sb.WriteString(fragment)
+			onPartial(sb.String())
+		})
+	} else {
+		resp, err = p.Complete(ctx, req)
+		if err == nil && resp.Content != "" {
+			onPartial(resp.Content)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	c.recordUsage(ctx, model, messages, resp)
+	return responseText(p, model, resp)
+}
+
+// readSSE reads a server-sent event stream and calls fn with the event name
+// and data of each event until fn returns false or the stream ends.
+func readSSE(r io.Reader, fn func(event, data string) bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	var event string
+	var data []string
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 && !fn(event, strings.Join(data, "\n")) {
+				return nil
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+			// comment / keep-alive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		fn(event, strings.Join(data, "\n"))
+	}
+	return nil
+}
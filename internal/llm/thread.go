@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// SummarizeThread writes a short TL;DR of a Slack thread in Slack mrkdwn:
+// the outcome first, then the key points, decisions and open items.
+func (c *Client) SummarizeThread(ctx context.Context, threadHistory, model string) (string, error) {
+	ctx = withSite(ctx, siteSummary)
+	system := `Você resume conversas do Slack para quem não acompanhou.
+Responda em português brasileiro, em Slack mrkdwn (*negrito*, bullets com •), sem blocos de código.`
+	user := fmt.Sprintf(`Resuma a conversa abaixo em um TL;DR.
+
+Formato:
+- Uma frase com a conclusão ou o estado atual.
+- Até 5 bullets com os pontos principais.
+- *Decisões:* e *Pendências:* (com responsáveis, quando citados), só se houver.
+
+Regras:
+- NÃO invente fatos que não estejam na conversa.
+- Cite pessoas pelo nome ou ID como aparecem na conversa.
+- Seja curto: no máximo ~150 palavras.
+
+CONVERSA:
+%s`, clip(threadHistory, 30000))
+
+	messages := []OpenAIMessage{
+		{Role: "system", Content: system},
+		{Role: "user", Content: user},
+	}
+	out, err := c.Chat(ctx, messages, model, 0.2, 600)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("[LLM] SummarizeThread len=%d", len(out))
+	return strings.TrimSpace(out), nil
+}
+
+// ThreadToDocument turns a Slack thread into a wiki page: a title and a
+// Markdown body organised by topic rather than by message.
+func (c *Client) ThreadToDocument(ctx context.Context, threadHistory, model string) (title, body string, err error) {
+	ctx = withSite(ctx, siteSummary)
+	system := `Você transforma conversas do Slack em páginas de documentação interna.
+Responda em português brasileiro, em Markdown.`
+	user := fmt.Sprintf(`Escreva uma página de wiki a partir da conversa abaixo.
+
+Formato:
+- A PRIMEIRA linha é o título, no formato "# Título" (curto e descritivo).
+- Depois, seções ## organizadas por assunto (ex: ## Contexto, ## Decisões, ## Como fazer, ## Pendências).
+- Só inclua seções que tenham conteúdo.
+
+Regras:
+- NÃO invente fatos. Se algo ficou em aberto, registre em ## Pendências.
+- Escreva como documentação, não como transcrição: sem "fulano disse".
+- Preserve comandos, links e nomes de sistemas exatamente como aparecem.
+
+CONVERSA:
+%s`, clip(threadHistory, 30000))
+
+	messages := []OpenAIMessage{
+		{Role: "system", Content: system},
+		{Role: "user", Content: user},
+	}
+	out, err := c.Chat(ctx, messages, model, 0.2, 2500)
+	if err != nil {
+		return "", "", err
+	}
+	out = stripCodeFences(strings.TrimPrefix(strings.TrimSpace(out), "```markdown"))
+	first, rest, _ := strings.Cut(out, "\n")
+	if t := strings.TrimSpace(strings.TrimLeft(first, "# ")); strings.HasPrefix(first, "#") && t != "" {
+		title, body = t, strings.TrimSpace(rest)
+	} else {
+		title, body = "Conversa do Slack", out
+	}
+	log.Printf("[LLM] ThreadToDocument title=%q len=%d", title, len(body))
+	return title, body, nil
+}
@@ -0,0 +1,275 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ToolSpec describes a function the model may call.  Parameters is a JSON
+// Schema object; providers send it as-is (OpenAI with strict mode enabled).
+type ToolSpec struct {
+	Name        string
+	Description string
+	Parameters  map[string]any
+}
+
+// ToolCall is a function call requested by the model.  Arguments holds the
+// raw JSON object produced by the model, before validation.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// ToolParam is a single argument of an action tool.  The same definition
+// produces the JSON Schema sent to the provider and the validator applied to
+// the arguments the model returns, so both can never drift apart.
+type ToolParam struct {
+	Name        string
+	Type        string // "string" | "integer" | "boolean"
+	Description string
+	Enum        []string
+	// Optional parameters are still listed as required in the schema (strict
+	// mode demands it) but accept null.
+	Optional bool
+	// Normalize rewrites a string value after it is trimmed and before it
+	// is checked, fixing formatting mistakes the model tends to make.
+	Normalize func(string) string
+	// Check runs after type validation on non-null values.  Values are
+	// string, int64 or bool according to Type.
+	Check func(v any) error
+}
+
+// ToolDef is the schema of one ActionDescriptor kind exposed as a tool.
+// Skills declare their tools with it (see package skill).
+type ToolDef struct {
+	Kind        string
+	Description string
+	Params      []ToolParam
+}
+
+var reDigits = regexp.MustCompile(`^[0-9]+$`)
+
+// NonEmpty rejects blank strings.
+func NonEmpty(v any) error {
+	if strings.TrimSpace(v.(string)) == "" {
+		return fmt.Errorf("não pode ser vazio")
+	}
+	return nil
+}
+
+// ISODate requires a YYYY-MM-DD date.
+func ISODate(v any) error {
+	if _, err := time.Parse("2006-01-02", v.(string)); err != nil {
+		return fmt.Errorf("deve estar no formato YYYY-MM-DD")
+	}
+	return nil
+}
+
+// DigitsOnly requires a numeric identifier.
+func DigitsOnly(v any) error {
+	if !reDigits.MatchString(v.(string)) {
+		return fmt.Errorf("deve conter apenas dígitos")
+	}
+	return nil
+}
+
+// NonNegative rejects negative integers.
+func NonNegative(v any) error {
+	if v.(int64) < 0 {
+		return fmt.Errorf("não pode ser negativo")
+	}
+	return nil
+}
+
+// toolSpecs converts definitions into provider-neutral tool specs.
+func toolSpecs(defs []ToolDef) []ToolSpec {
+	specs := make([]ToolSpec, 0, len(defs))
+	for _, d := range defs {
+		props := make(map[string]any, len(d.Params))
+		required := make([]string, 0, len(d.Params))
+		for _, p := range d.Params {
+			prop := map[string]any{"type": p.Type, "description": p.Description}
+			if p.Optional {
+				prop["type"] = []string{p.Type, "null"}
+			}
+			if len(p.Enum) > 0 {
+				enum := make([]any, 0, len(p.Enum)+1)
+				for _, e := range p.Enum {
+					enum = append(enum, e)
+				}
+				if p.Optional {
+					enum = append(enum, nil)
+				}
+				prop["enum"] = enum
+			}
+			props[p.Name] = prop
+			required = append(required, p.Name)
+		}
+		specs = append(specs, ToolSpec{
+			Name:        d.Kind,
+			Description: d.Description,
+			Parameters: map[string]any{
+				"type":                 "object",
+				"properties":           props,
+				"required":             required,
+				"additionalProperties": false,
+			},
+		})
+	}
+	return specs
+}
+
+// validateToolArgs checks raw arguments against the definition of kind and
+// returns the resulting ActionDescriptor.  Unknown tools, unknown fields,
+// missing required fields, wrong types and out-of-enum values are rejected.
+// Every validated argument is also kept in Args so skills outside this
+// package can read parameters that have no dedicated field.
+func validateToolArgs(defs []ToolDef, kind, args string) (ActionDescriptor, error) {
+	var def *ToolDef
+	for i := range defs {
+		if defs[i].Kind == kind {
+			def = &defs[i]
+			break
+		}
+	}
+	if def == nil {
+		return ActionDescriptor{}, fmt.Errorf("ferramenta desconhecida ou desabilitada: %q", kind)
+	}
+
+	raw := map[string]json.RawMessage{}
+	if s := strings.TrimSpace(args); s != "" {
+		dec := json.NewDecoder(strings.NewReader(s))
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil {
+			return ActionDescriptor{}, fmt.Errorf("argumentos inválidos: %v", err)
+		}
+	}
+	known := make(map[string]ToolParam, len(def.Params))
+	for _, p := range def.Params {
+		known[p.Name] = p
+	}
+	var unknown []string
+	for k := range raw {
+		if _, ok := known[k]; !ok {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return ActionDescriptor{}, fmt.Errorf("campos desconhecidos: %s", strings.Join(unknown, ", "))
+	}
+
+	params := map[string]any{}
+	for _, p := range def.Params {
+		v, ok := raw[p.Name]
+		if !ok || bytes.Equal(bytes.TrimSpace(v), []byte("null")) {
+			if !p.Optional {
+				return ActionDescriptor{}, fmt.Errorf("campo obrigatório ausente: %s", p.Name)
+			}
+			continue
+		}
+		val, err := decodeParam(p, v)
+		if err != nil {
+			return ActionDescriptor{}, fmt.Errorf("%s: %v", p.Name, err)
+		}
+		params[p.Name] = val
+	}
+
+	clean := map[string]any{"kind": kind}
+	for k, v := range params {
+		clean[k] = v
+	}
+	b, _ := json.Marshal(clean)
+	var a ActionDescriptor
+	if err := json.Unmarshal(b, &a); err != nil {
+		return ActionDescriptor{}, err
+	}
+	a.Args = params
+	return a, nil
+}
+
+// decodeParam decodes and checks a single non-null argument value.
+func decodeParam(p ToolParam, v json.RawMessage) (any, error) {
+	var val any
+	switch p.Type {
+	case "string":
+		var s string
+		if err := json.Unmarshal(v, &s); err != nil {
+			return nil, fmt.Errorf("deve ser string")
+		}
+		s = strings.TrimSpace(s)
+		if p.Normalize != nil {
+			s = p.Normalize(s)
+		}
+		if len(p.Enum) > 0 {
+			found := false
+			for _, e := range p.Enum {
+				if s == e {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("valor %q fora de %v", s, p.Enum)
+			}
+		}
+		val = s
+	case "integer":
+		var n json.Number
+		if err := json.Unmarshal(v, &n); err != nil {
+			return nil, fmt.Errorf("deve ser inteiro")
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("deve ser inteiro")
+		}
+		val = i
+	case "boolean":
+		var b bool
+		if err := json.Unmarshal(v, &b); err != nil {
+			return nil, fmt.Errorf("deve ser booleano")
+		}
+		val = b
+	}
+	if p.Check != nil {
+		if err := p.Check(val); err != nil {
+			return nil, err
+		}
+	}
+	return val, nil
+}
+
+// parseActionArray parses the legacy bare-JSON-array router output.  Text
+// around the array (code fences, trailing comments) is ignored, and every
+// element goes through the same validation as a native tool call.
+func parseActionArray(defs []ToolDef, out string) ([]ActionDescriptor, []error, error) {
+	s := stripCodeFences(out)
+	if i, j := strings.Index(s, "["), strings.LastIndex(s, "]"); i >= 0 && j > i {
+		s = s[i : j+1]
+	}
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(s), &items); err != nil {
+		return nil, nil, fmt.Errorf("bad actions json: %v raw=%q", err, preview(out, 300))
+	}
+	var actions []ActionDescriptor
+	var rejected []error
+	for _, item := range items {
+		var kind string
+		_ = json.Unmarshal(item["kind"], &kind)
+		delete(item, "kind")
+		args, _ := json.Marshal(item)
+		a, err := validateToolArgs(defs, kind, string(args))
+		if err != nil {
+			rejected = append(rejected, fmt.Errorf("%s: %v", kind, err))
+			continue
+		}
+		actions = append(actions, a)
+	}
+	return actions, rejected, nil
+}
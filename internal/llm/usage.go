@@ -0,0 +1,170 @@
+package llm
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+// Call sites used to label token usage (and, for the deterministic ones,
+// cache entries).
+const (
+	siteRouter         = "router"
+	siteEnhance        = "enhance"
+	siteSQL            = "sql"
+	siteAnswer         = "answer"
+	siteIntent         = "intent"
+	siteDescription    = "description"
+	siteIntro          = "intro"
+	siteCompanyContext = "company_context"
+	siteSearchQuery    = "search_query"
+	siteJiraLookup     = "jira_lookup"
+	siteSummary        = "summary"
+	siteOther          = "other"
+)
+
+// knownPrices maps model-name prefixes to list prices in USD per million
+// tokens.  LLM_PRICES overrides or extends it; the longest prefix wins.
+var knownPrices = map[string]config.ModelPrice{
+	"gpt-4o":            {Input: 2.5, Output: 10},
+	"gpt-4o-mini":       {Input: 0.15, Output: 0.6},
+	"gpt-4.1":           {Input: 2, Output: 8},
+	"gpt-4.1-mini":      {Input: 0.4, Output: 1.6},
+	"gpt-4.1-nano":      {Input: 0.1, Output: 0.4},
+	"gpt-5":             {Input: 1.25, Output: 10},
+	"gpt-5-mini":        {Input: 0.25, Output: 2},
+	"gpt-5-nano":        {Input: 0.05, Output: 0.4},
+	"o3":                {Input: 2, Output: 8},
+	"o4-mini":           {Input: 1.1, Output: 4.4},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4},
+	"claude-haiku":      {Input: 1, Output: 5},
+	"claude-3-5-sonnet": {Input: 3, Output: 15},
+	"claude-3-7-sonnet": {Input: 3, Output: 15},
+	"claude-sonnet":     {Input: 3, Output: 15},
+	"claude-opus":       {Input: 15, Output: 75},
+}
+
+// CallUsage is the token consumption and cost of one LLM call.
+type CallUsage struct {
+	Site             string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+	// Estimated is set when the provider did not report usage and the
+	// counts come from EstimateTokens.
+	Estimated bool
+}
+
+// UsageMeter collects the CallUsage of every LLM call made with a context
+// carrying it (see WithUsageMeter).  Safe for concurrent use: skills running
+// in parallel record into the same meter.
+type UsageMeter struct {
+	mu    sync.Mutex
+	calls []CallUsage
+}
+
+// NewUsageMeter returns an empty meter.
+func NewUsageMeter() *UsageMeter { return &UsageMeter{} }
+
+// Calls returns a copy of the recorded calls.
+func (m *UsageMeter) Calls() []CallUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]CallUsage(nil), m.calls...)
+}
+
+// Totals returns the summed tokens and cost of the recorded calls.
+func (m *UsageMeter) Totals() (prompt, completion int, costUSD float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.calls {
+		prompt += c.PromptTokens
+		completion += c.CompletionTokens
+		costUSD += c.CostUSD
+	}
+	return prompt, completion, costUSD
+}
+
+func (m *UsageMeter) add(u CallUsage) {
+	m.mu.Lock()
+	m.calls = append(m.calls, u)
+	m.mu.Unlock()
+}
+
+type meterKey struct{}
+type siteKey struct{}
+
+// WithUsageMeter returns a context whose LLM calls are recorded into m.
+func WithUsageMeter(ctx context.Context, m *UsageMeter) context.Context {
+	return context.WithValue(ctx, meterKey{}, m)
+}
+
+// withSite labels the LLM calls made with ctx.
+func withSite(ctx context.Context, site string) context.Context {
+	return context.WithValue(ctx, siteKey{}, site)
+}
+
+func siteFrom(ctx context.Context) string {
+	if s, ok := ctx.Value(siteKey{}).(string); ok {
+		return s
+	}
+	return siteOther
+}
+
+// recordUsage prices a completed call and adds it to the context's meter.
+// Calls without a meter (boot-time catalog and glossary generation) are
+// logged instead.
+func (c *Client) recordUsage(ctx context.Context, model string, messages []OpenAIMessage, resp ChatResponse) {
+	u := CallUsage{
+		Site:             siteFrom(ctx),
+		Model:            model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}
+	if u.PromptTokens == 0 && u.CompletionTokens == 0 {
+		u.Estimated = true
+		for _, m := range messages {
+			u.PromptTokens += EstimateTokens(m.Content)
+			for _, part := range m.ContentParts {
+				if part.Type == "text" {
+					u.PromptTokens += EstimateTokens(part.Text)
+				} else {
+					u.PromptTokens += imageTokens
+				}
+			}
+		}
+		u.CompletionTokens = EstimateTokens(resp.Content)
+		for _, tc := range resp.ToolCalls {
+			u.CompletionTokens += EstimateTokens(tc.Name) + EstimateTokens(tc.Arguments)
+		}
+	}
+	if price, ok := lookupModel(c.prices, model); ok {
+		u.CostUSD = (float64(u.PromptTokens)*price.Input + float64(u.CompletionTokens)*price.Output) / 1e6
+	}
+	if m, ok := ctx.Value(meterKey{}).(*UsageMeter); ok && m != nil {
+		m.add(u)
+		return
+	}
+	log.Printf("[LLM] usage site=%s model=%s prompt=%d completion=%d cost=$%.4f", u.Site, model, u.PromptTokens, u.CompletionTokens, u.CostUSD)
+}
+
+// lookupModel returns the entry of m for model: an exact key first, then the
+// longest key that is a prefix of model (a trailing "*" is optional).
+func lookupModel[T any](m map[string]T, model string) (T, bool) {
+	if v, ok := m[model]; ok {
+		return v, true
+	}
+	var out T
+	best := -1
+	for pattern, v := range m {
+		prefix := strings.TrimSuffix(pattern, "*")
+		if strings.HasPrefix(model, prefix) && len(prefix) > best {
+			best, out = len(prefix), v
+		}
+	}
+	return out, best >= 0
+}
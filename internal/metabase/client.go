@@ -2,6 +2,7 @@ package metabase
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -46,8 +47,8 @@ func NewClient(cfg config.Config) *Client {
 }
 
 // Get performs an authenticated GET and JSON-decodes the response into out.
-func (c *Client) Get(path string, out any) error {
-	req, err := http.NewRequest("GET", c.baseURL+path, nil)
+func (c *Client) Get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
 
 	if err != nil {
 		return fmt.Errorf("metabase: build GET %s: %w", path, err)
@@ -74,12 +75,12 @@ func (c *Client) Get(path string, out any) error {
 
 // Post performs an authenticated POST with a JSON body using the supplied
 // http.Client, and JSON-decodes the response into out.
-func (c *Client) Post(hc *http.Client, path string, payload, out any) error {
+func (c *Client) Post(ctx context.Context, hc *http.Client, path string, payload, out any) error {
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("metabase: encode POST %s: %w", path, err)
 	}
-	req, err := http.NewRequest("POST", c.baseURL+path, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("metabase: build POST %s: %w", path, err)
 	}
@@ -103,9 +104,9 @@ func (c *Client) Post(hc *http.Client, path string, payload, out any) error {
 }
 
 // ListDatabases returns all Databases visible to the API key.
-func (c *Client) ListDatabases() ([]Database, error) {
+func (c *Client) ListDatabases(ctx context.Context) ([]Database, error) {
 	var r DatabasesResp
-	if err := c.Get("/api/database", &r); err != nil {
+	if err := c.Get(ctx, "/api/database", &r); err != nil {
 		return nil, err
 	}
 	return r.Data, nil
@@ -117,7 +118,7 @@ func (c *Client) ListDatabases() ([]Database, error) {
 // Redshift exposes externally shared and late-binding-view schemas only via
 // svv_tables (not information_schema.tables), so we try svv_tables first and
 // fall back to information_schema.tables for non-Redshift Databases.
-func (c *Client) ListAccessibleSchemas(databaseID int) ([]string, error) {
+func (c *Client) ListAccessibleSchemas(ctx context.Context, databaseID int) ([]string, error) {
 	const svvQ = `SELECT DISTINCT table_schema FROM svv_tables
 WHERE table_schema NOT IN ('pg_catalog','information_schema','pg_internal','pg_toast','pg_aoseg','pg_automv')
 ORDER BY table_schema`
@@ -126,22 +127,22 @@ ORDER BY table_schema`
 WHERE table_schema NOT IN ('pg_catalog','information_schema','pg_internal','pg_toast','pg_aoseg')
 ORDER BY table_schema`
 
-	schemas, err := c.RunSchemaDiscovery(databaseID, svvQ)
+	schemas, err := c.RunSchemaDiscovery(ctx, databaseID, svvQ)
 	if err != nil || len(schemas) == 0 {
 		if err != nil {
 			log.Printf("[METABASE] svv_tables query failed db=%d (%v) — trying information_schema", databaseID, err)
 		}
-		schemas, err = c.RunSchemaDiscovery(databaseID, stdQ)
+		schemas, err = c.RunSchemaDiscovery(ctx, databaseID, stdQ)
 	}
 	return schemas, err
 }
 
 // RunSchemaDiscovery executes a single-column schema-listing query using the
 // fast httpClient and returns the distinct schema name strings.
-func (c *Client) RunSchemaDiscovery(databaseID int, q string) ([]string, error) {
+func (c *Client) RunSchemaDiscovery(ctx context.Context, databaseID int, q string) ([]string, error) {
 	payload := QueryRequest{Database: databaseID, Type: "native", Native: NativeQuery{Query: q}}
 	var result QueryResult
-	if err := c.Post(c.httpClient, "/api/dataset", payload, &result); err != nil {
+	if err := c.Post(ctx, c.httpClient, "/api/dataset", payload, &result); err != nil {
 		return nil, err
 	}
 	if result.Error != "" {
@@ -161,9 +162,9 @@ func (c *Client) RunSchemaDiscovery(databaseID int, q string) ([]string, error)
 // ListCards returns all non-archived saved questions visible to the API key.
 // Note: the list endpoint typically omits the dataset_query SQL.  Use
 // GetCard(id) to retrieve the native SQL for a specific card.
-func (c *Client) ListCards() ([]Card, error) {
+func (c *Client) ListCards(ctx context.Context) ([]Card, error) {
 	var cards []Card
-	if err := c.Get("/api/card", &cards); err != nil {
+	if err := c.Get(ctx, "/api/card", &cards); err != nil {
 		return nil, err
 	}
 	// Filter out archived Cards up-front so callers never see stale entries.
@@ -178,10 +179,10 @@ func (c *Client) ListCards() ([]Card, error) {
 
 // ExecuteNativeQuery runs a raw SQL query against the specified Metabase database
 // using the queryClient (which carries the configured MetabaseQueryTimeout).
-func (c *Client) ExecuteNativeQuery(databaseID int, sql string) (*QueryResult, error) {
+func (c *Client) ExecuteNativeQuery(ctx context.Context, databaseID int, sql string) (*QueryResult, error) {
 	payload := QueryRequest{Database: databaseID, Type: "native", Native: NativeQuery{Query: sql}}
 	var result QueryResult
-	if err := c.Post(c.queryClient, "/api/dataset", payload, &result); err != nil {
+	if err := c.Post(ctx, c.queryClient, "/api/dataset", payload, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -189,10 +190,10 @@ func (c *Client) ExecuteNativeQuery(databaseID int, sql string) (*QueryResult, e
 
 // GetDatabaseMetadata fetches detailed metadata for a single database,
 // including all its tables and their fields.
-func (c *Client) GetDatabaseMetadata(id int) (DatabaseMetadata, error) {
+func (c *Client) GetDatabaseMetadata(ctx context.Context, id int) (DatabaseMetadata, error) {
 	var m DatabaseMetadata
 	path := fmt.Sprintf("/api/database/%d/metadata", id)
-	if err := c.Get(path, &m); err != nil {
+	if err := c.Get(ctx, path, &m); err != nil {
 		return DatabaseMetadata{}, err
 	}
 	return m, nil
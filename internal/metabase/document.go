@@ -1,6 +1,7 @@
 package metabase
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -59,7 +60,7 @@ func (f Field) IsFK() bool { return f.SemanticType == "type/FK" }
 //
 // If outputPath is empty, it defaults to "./docs/metabase_schema.md".
 // The parent directory is created automatically when it does not exist.
-func generateSchemaDoc(client *Client, outputPath, environment string) error {
+func generateSchemaDoc(ctx context.Context, client *Client, outputPath, environment string) error {
 	if outputPath == "" {
 		outputPath = "./docs/metabase_schema.md"
 	}
@@ -68,7 +69,7 @@ func generateSchemaDoc(client *Client, outputPath, environment string) error {
 	}
 
 	log.Printf("[METABASE] fetching database list…")
-	databases, err := client.ListDatabases()
+	databases, err := client.ListDatabases(ctx)
 	if err != nil {
 		return fmt.Errorf("metabase: list Databases: %w", err)
 	}
@@ -77,7 +78,7 @@ func generateSchemaDoc(client *Client, outputPath, environment string) error {
 	var metas []DatabaseMetadata
 	for _, db := range databases {
 		log.Printf("[METABASE] fetching metadata for db id=%d name=%q", db.ID, db.Name)
-		meta, err := client.GetDatabaseMetadata(db.ID)
+		meta, err := client.GetDatabaseMetadata(ctx, db.ID)
 		if err != nil {
 			log.Printf("[METABASE] warning: metadata for db %d failed: %v — skipping", db.ID, err)
 			continue
@@ -1,6 +1,7 @@
 package metabase
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"log"
@@ -82,7 +83,8 @@ func clip(s string, n int) string {
 }
 
 func complementClient(c *Client, cfg config.Config) *Client {
-	dbs, err := c.ListDatabases()
+	ctx := context.Background()
+	dbs, err := c.ListDatabases(ctx)
 	if err != nil {
 		log.Printf("[METABASE] ListDatabases failed: %v — Metabase integration disabled", err)
 	}
@@ -94,7 +96,7 @@ func complementClient(c *Client, cfg config.Config) *Client {
 	// filter the compact schema and prevent the LLM from using phantom schemas.
 	accessibleSchemas := make(map[int][]string)
 	for _, db := range dbs {
-		schemas, err := c.ListAccessibleSchemas(db.ID)
+		schemas, err := c.ListAccessibleSchemas(ctx, db.ID)
 		if err != nil {
 			log.Printf("[METABASE] ListAccessibleSchemas db=%d failed: %v — schema filtering disabled for this db", db.ID, err)
 			continue
@@ -105,7 +107,7 @@ func complementClient(c *Client, cfg config.Config) *Client {
 
 	// Load saved questions (Cards) for use as SQL examples during query generation.
 	// The list endpoint returns names/IDs only; individual card SQL is fetched on demand.
-	cards, err := c.ListCards()
+	cards, err := c.ListCards(ctx)
 	if err != nil {
 		log.Printf("[METABASE] ListCards failed: %v — saved questions will not be used as examples", err)
 	} else {
@@ -114,7 +116,7 @@ func complementClient(c *Client, cfg config.Config) *Client {
 
 	// Generate schema documentation asynchronously so the startup is not blocked.
 	go func() {
-		if err := generateSchemaDoc(c, cfg.MetabaseSchemaPath, cfg.MetabaseEnv); err != nil {
+		if err := generateSchemaDoc(ctx, c, cfg.MetabaseSchemaPath, cfg.MetabaseEnv); err != nil {
 			log.Printf("[METABASE] schema generation failed: %v", err)
 		}
 	}()
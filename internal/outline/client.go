@@ -2,6 +2,7 @@ package outline
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,7 +15,8 @@ import (
 	"github.com/DanielFillol/Jarvis/internal/config"
 )
 
-// Client is a minimal Outline API client that supports document search.
+// Client is a minimal Outline API client that supports document search and
+// publishing.
 type Client struct {
 	BaseURL    string
 	Origin     string // scheme + host only, e.g. "https://musa.getoutline.com"
@@ -69,7 +71,7 @@ type listResponse struct {
 
 // ListDocuments returns up to limit recently-updated published documents.
 // Results are wrapped as SearchResult so FormatContext can be reused.
-func (c *Client) ListDocuments(limit int) ([]SearchResult, error) {
+func (c *Client) ListDocuments(ctx context.Context, limit int) ([]SearchResult, error) {
 	if limit <= 0 {
 		limit = 15
 	}
@@ -78,7 +80,7 @@ func (c *Client) ListDocuments(limit int) ([]SearchResult, error) {
 		Sort:      "updatedAt",
 		Direction: "DESC",
 	})
-	req, err := http.NewRequest("POST", c.BaseURL+"/documents.list", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/documents.list", bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +142,7 @@ type searchResponse struct {
 
 // SearchDocuments queries the Outline search API and returns up to limit results
 // ordered by relevance.  Only published documents are included.
-func (c *Client) SearchDocuments(query string, limit int) ([]SearchResult, error) {
+func (c *Client) SearchDocuments(ctx context.Context, query string, limit int) ([]SearchResult, error) {
 	if limit <= 0 {
 		limit = 5
 	}
@@ -149,7 +151,7 @@ func (c *Client) SearchDocuments(query string, limit int) ([]SearchResult, error
 		Limit:        limit,
 		StatusFilter: []string{"published"},
 	})
-	req, err := http.NewRequest("POST", c.BaseURL+"/documents.search", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/documents.search", bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -192,6 +194,52 @@ func (c *Client) SearchDocuments(query string, limit int) ([]SearchResult, error
 	return results, nil
 }
 
+type createRequest struct {
+	Title        string `json:"title"`
+	Text         string `json:"text"`
+	CollectionID string `json:"collectionId"`
+	Publish      bool   `json:"publish"`
+}
+
+type createResponse struct {
+	Data searchDocResult `json:"data"`
+}
+
+// CreateDocument publishes a new Markdown document in collectionID and
+// returns it with an absolute URL.
+func (c *Client) CreateDocument(ctx context.Context, title, text, collectionID string) (Document, error) {
+	body, _ := json.Marshal(createRequest{
+		Title:        title,
+		Text:         text,
+		CollectionID: collectionID,
+		Publish:      true,
+	})
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/documents.create", bytes.NewReader(body))
+	if err != nil {
+		return Document{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return Document{}, err
+	}
+	defer resp.Body.Close()
+	rb, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return Document{}, fmt.Errorf("outline: create status=%d body=%s", resp.StatusCode, previewStr(string(rb), 200))
+	}
+	var cr createResponse
+	if err := json.Unmarshal(rb, &cr); err != nil {
+		return Document{}, fmt.Errorf("outline: create decode: %w", err)
+	}
+	docURL := cr.Data.URL
+	if docURL != "" && !strings.HasPrefix(docURL, "http") {
+		docURL = c.Origin + docURL
+	}
+	return Document{ID: cr.Data.ID, Title: cr.Data.Title, Text: cr.Data.Text, URL: docURL}, nil
+}
+
 // FormatContext formats search results into a compact Markdown block suitable
 // for LLM context injection.  maxCharsPerDoc limits how many characters of the
 // full document text are included; pass 0 to include all.
@@ -0,0 +1,91 @@
+package outline
+
+import (
+	"context"
+	"log"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/skill"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// ActionSearch is the action kind of the Outline skill.
+const ActionSearch = "outline_search"
+
+// Skill searches the Outline wiki (outline_search).
+type Skill struct {
+	client     *Client
+	llm        *llm.Client
+	queryModel string
+}
+
+// NewSkill returns the Outline skill.  client is nil when Outline is not
+// configured; queryModel generates a search query when the router left it
+// empty.
+func NewSkill(client *Client, llmClient *llm.Client, queryModel string) *Skill {
+	return &Skill{client: client, llm: llmClient, queryModel: queryModel}
+}
+
+func (*Skill) Kind() string                   { return ActionSearch }
+func (*Skill) Label() string                  { return "DOCUMENTAÇÃO INTERNA (Outline Wiki)" }
+func (*Skill) DisplayName() string            { return "Documentação interna (Outline)" }
+func (*Skill) Enabled(cfg config.Config) bool { return cfg.OutlineEnabled() }
+func (*Skill) Weight() float64                { return 1.5 }
+
+func (*Skill) Tools() []llm.ToolDef {
+	return []llm.ToolDef{{Kind: ActionSearch, Description: "Buscar documentação interna no Outline Wiki.", Params: []llm.ToolParam{
+		{Name: "query", Type: "string", Check: llm.NonEmpty, Description: "2–4 termos-chave do assunto."},
+	}}}
+}
+
+func (*Skill) RouterPrompt(skill.Request) llm.RouterSnippet {
+	return llm.RouterSnippet{
+		Context:  "Outline Wiki está configurado e disponível para busca de documentação.",
+		Source:   "- Outline Wiki: documentação interna, processos, guias, runbooks, especificações de produto, onboarding, políticas.",
+		Examples: []string{`{"kind": "outline_search", "query": "processo deploy produção"}`},
+		Rules:    "- Inclua outline_search quando a resposta depende principalmente de documentação interna (processos, guias, runbooks, políticas, onboarding, RH, benefícios). Preencha query com 2–4 termos-chave do assunto, sem artigos ou preposições. NUNCA inclua o nome da própria empresa/organização na query — o Outline já é o wiki interno da empresa, então o nome dela não filtra nada útil.",
+	}
+}
+
+// AnswerHints tells the model to decline wiki questions when Outline is off.
+func (*Skill) AnswerHints(_ []llm.ContextSection, enabled bool) []string {
+	if enabled {
+		return nil
+	}
+	return []string{"OUTLINE NÃO CONFIGURADO: A integração com o Outline Wiki não está habilitada nesta instalação. Se o usuário pedir documentação interna, processos ou guias que provavelmente estão na wiki, informe gentilmente que essa integração não está disponível e sugira que o administrador configure as variáveis OUTLINE_BASE_URL e OUTLINE_API_KEY."}
+}
+
+// Execute searches the wiki.  An empty query is generated from the question.
+func (k *Skill) Execute(ctx context.Context, req skill.Request, action llm.ActionDescriptor) (skill.ContextBlock, skill.Sources, error) {
+	block := skill.ContextBlock{Kind: ActionSearch}
+	if k.client == nil {
+		return block, "", skill.ErrSkipped
+	}
+	query := action.Query
+	if query == "" {
+		query = k.llm.GenerateOutlineQuery(ctx, req.QuestionForLLM, k.queryModel)
+		if query == "" {
+			return block, "", skill.ErrSkipped
+		}
+		log.Printf("%s outlineQuery generated=%q", req.Tag(), query)
+	}
+	log.Printf("%s outlineSearch query=%q", req.Tag(), query)
+	req.Report("buscando na documentação (Outline)…")
+	results, err := k.client.SearchDocuments(ctx, query, 5)
+	if err != nil {
+		return block, "", err
+	}
+	block.Count = len(results)
+	block.Text = FormatContext(results, 8000)
+	log.Printf("%s outlineContext docs=%d chars=%d", req.Tag(), len(results), len(block.Text))
+	if block.Text == "" {
+		block.Text = "[AVISO: A busca no Outline não retornou documentos. Informe ao usuário que não foram encontrados docs relevantes para a consulta realizada.]"
+		return block, "", nil
+	}
+	return block, skill.Sources(FormatSources(results)), nil
+}
+
+func (*Skill) Telemetry(ev *telemetry.Event, _ skill.ContextBlock, _ error) {
+	ev.OutlineSearched = true
+}
@@ -0,0 +1,188 @@
+// Package schedule stores recurring questions and runs them on a cron
+// schedule.  Schedules live in Postgres when TELEMETRY_DB_URL is set, so they
+// survive restarts and each run is claimed by a single replica; without it
+// they are kept in memory.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spec is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week (0 or 7 = Sunday).  Fields accept *, lists (1,15),
+// ranges (1-5) and steps (*/15, 8-18/2).  As in cron, when both day fields
+// are restricted a day matches either of them.
+type Spec struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64
+	domRestricted, dowRestricted  bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = [5]cronField{
+	{"minuto", 0, 59},
+	{"hora", 0, 23},
+	{"dia do mês", 1, 31},
+	{"mês", 1, 12},
+	{"dia da semana", 0, 7},
+}
+
+// Parse parses a five-field cron expression.
+func Parse(expr string) (Spec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Spec{}, fmt.Errorf("a expressão cron precisa de 5 campos (minuto hora dia mês dia-da-semana), recebi %d", len(fields))
+	}
+	s := Spec{expr: strings.Join(fields, " ")}
+	sets := [5]*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, f := range fields {
+		bits, err := parseCronField(f, cronFields[i])
+		if err != nil {
+			return Spec{}, err
+		}
+		*sets[i] = bits
+	}
+	// Sunday may be written as 0 or 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domRestricted = !strings.HasPrefix(fields[2], "*")
+	s.dowRestricted = !strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("passo inválido em %s: %q", f.name, part)
+			}
+			step = n
+		}
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var errA, errB error
+			lo, errA = strconv.Atoi(a)
+			hi, errB = strconv.Atoi(b)
+			if errA != nil || errB != nil || lo > hi {
+				return 0, fmt.Errorf("intervalo inválido em %s: %q", f.name, part)
+			}
+		default:
+			n, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("valor inválido em %s: %q", f.name, part)
+			}
+			lo = n
+			if !hasStep {
+				hi = n
+			}
+		}
+		if lo < f.min || hi > f.max {
+			return 0, fmt.Errorf("%s fora do intervalo %d-%d: %q", f.name, f.min, f.max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// String returns the normalised expression.
+func (s Spec) String() string { return s.expr }
+
+// Next returns the first time strictly after t that matches the spec, in
+// t's location.  It returns the zero time when nothing matches within five
+// years (e.g. 30 February).
+//
+// The search walks the wall clock rather than elapsed time, so DST changes
+// neither loop nor double-fire: a time that falls in a spring-forward gap
+// runs shifted by the gap (00:30 becomes 01:30), and a wall-clock time that
+// repeats when clocks fall back runs only at its first occurrence.
+func (s Spec) Next(t time.Time) time.Time {
+	loc := t.Location()
+	w := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC).Add(time.Minute)
+	limit := w.AddDate(5, 0, 0)
+	for w.Before(limit) {
+		switch {
+		case s.month&(1<<uint(w.Month())) == 0:
+			w = time.Date(w.Year(), w.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.dayMatches(w):
+			w = time.Date(w.Year(), w.Month(), w.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<uint(w.Hour())) == 0:
+			w = w.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(w.Minute())) == 0:
+			w = w.Add(time.Minute)
+		default:
+			if at := wallTime(w, loc); at.After(t) {
+				return at
+			}
+			w = w.Add(time.Minute)
+		}
+	}
+	return time.Time{}
+}
+
+// wallTime returns the instant the clocks in loc show w's date and time.  A
+// time inside a DST gap does not exist and time.Date resolves it to an
+// instant before the jump, so it is moved forward by the difference.
+func wallTime(w time.Time, loc *time.Location) time.Time {
+	at := time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), 0, 0, loc)
+	shown := time.Date(at.Year(), at.Month(), at.Day(), at.Hour(), at.Minute(), 0, 0, time.UTC)
+	if d := w.Sub(shown); d > 0 {
+		at = at.Add(d)
+	}
+	return at
+}
+
+func (s Spec) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// NextRun parses expr and returns its first run after t in the timezone tz
+// (an IANA name; empty means the server's local time).
+func NextRun(expr, tz string, t time.Time) (time.Time, error) {
+	spec, err := Parse(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := LoadLocation(tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := spec.Next(t.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("a expressão %q nunca dispara", expr)
+	}
+	return next, nil
+}
+
+// LoadLocation resolves an IANA timezone name; empty means time.Local.
+func LoadLocation(tz string) (*time.Location, error) {
+	if strings.TrimSpace(tz) == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(strings.TrimSpace(tz))
+	if err != nil {
+		return nil, fmt.Errorf("fuso horário desconhecido: %q", tz)
+	}
+	return loc, nil
+}
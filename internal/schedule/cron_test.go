@@ -0,0 +1,164 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func bitsOf(vals ...int) uint64 {
+	var b uint64
+	for _, v := range vals {
+		b |= 1 << uint(v)
+	}
+	return b
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr   string
+		minute uint64
+		hour   uint64
+		dow    uint64
+	}{
+		{"*/15 * * * *", bitsOf(0, 15, 30, 45), 1<<24 - 1, bitsOf(0, 1, 2, 3, 4, 5, 6, 7)},
+		{"5/15 * * * *", bitsOf(5, 20, 35, 50), 1<<24 - 1, bitsOf(0, 1, 2, 3, 4, 5, 6, 7)},
+		{"0 */2 * * *", bitsOf(0), bitsOf(0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22), bitsOf(0, 1, 2, 3, 4, 5, 6, 7)},
+		{"0,30 8-18/2 * * 1-5", bitsOf(0, 30), bitsOf(8, 10, 12, 14, 16, 18), bitsOf(1, 2, 3, 4, 5)},
+		{"0 9 * * 0", bitsOf(0), bitsOf(9), bitsOf(0)},
+		{"0 9 * * 7", bitsOf(0), bitsOf(9), bitsOf(0, 7)},
+		{"0 9 * * 5-7", bitsOf(0), bitsOf(9), bitsOf(0, 5, 6, 7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if s.minute != tt.minute || s.hour != tt.hour || s.dow != tt.dow {
+				t.Errorf("minute=%b hour=%b dow=%b, want %b %b %b", s.minute, s.hour, s.dow, tt.minute, tt.hour, tt.dow)
+			}
+		})
+	}
+
+	if s, _ := Parse("  0   9 * *  1-5 "); s.String() != "0 9 * * 1-5" {
+		t.Errorf("String() = %q", s.String())
+	}
+
+	for _, expr := range []string{
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"0 0 0 * *",
+		"0 0 32 * *",
+		"0 0 * 13 *",
+		"0 0 * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q): want error", expr)
+		}
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	utc := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		name string
+		expr string
+		tz   string
+		from string
+		want string
+	}{
+		{"step", "*/15 * * * *", "UTC", "2024-09-04T10:07:00Z", "2024-09-04T10:15:00Z"},
+		{"offset step", "5/15 * * * *", "UTC", "2024-09-04T10:50:30Z", "2024-09-04T11:05:00Z"},
+		{"hour step", "0 */2 * * *", "UTC", "2024-09-04T09:00:00Z", "2024-09-04T10:00:00Z"},
+		{"strictly after", "0 9 * * *", "UTC", "2024-09-04T09:00:00Z", "2024-09-05T09:00:00Z"},
+		{"weekdays skip the weekend", "0 9 * * 1-5", "UTC", "2024-09-06T10:00:00Z", "2024-09-09T09:00:00Z"},
+		{"month rolls over the year", "0 0 1 1 *", "UTC", "2024-09-04T00:00:00Z", "2025-01-01T00:00:00Z"},
+		{"leap day", "0 0 29 2 *", "UTC", "2024-03-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		// When both day fields are restricted either one matches.
+		{"dom or dow: day of month first", "0 9 10 * 5", "UTC", "2024-09-07T00:00:00Z", "2024-09-10T09:00:00Z"},
+		{"dom or dow: weekday first", "0 9 10 * 5", "UTC", "2024-09-11T00:00:00Z", "2024-09-13T09:00:00Z"},
+		{"dom only", "0 9 10 * *", "UTC", "2024-09-11T00:00:00Z", "2024-10-10T09:00:00Z"},
+		{"dow only", "0 9 * * 5", "UTC", "2024-09-07T00:00:00Z", "2024-09-13T09:00:00Z"},
+		{"sunday as 0", "0 9 * * 0", "UTC", "2024-09-04T00:00:00Z", "2024-09-08T09:00:00Z"},
+		{"sunday as 7", "0 9 * * 7", "UTC", "2024-09-04T00:00:00Z", "2024-09-08T09:00:00Z"},
+		{"timezone", "0 9 * * *", "America/Sao_Paulo", "2024-09-04T11:59:00Z", "2024-09-04T12:00:00Z"},
+		{"timezone next day", "0 9 * * *", "America/Sao_Paulo", "2024-09-04T12:00:00Z", "2024-09-05T12:00:00Z"},
+		// São Paulo sprang forward at 2018-11-04 00:00 (-03 → -02): midnight
+		// did not exist that day.
+		{"dst gap: later time", "0 12 * * *", "America/Sao_Paulo", "2018-11-03T16:00:00Z", "2018-11-04T14:00:00Z"},
+		{"dst gap: skipped time runs shifted", "30 0 * * *", "America/Sao_Paulo", "2018-11-03T15:00:00Z", "2018-11-04T03:30:00Z"},
+		{"dst gap: midnight", "0 0 * * *", "America/Sao_Paulo", "2018-11-03T15:00:00Z", "2018-11-04T03:00:00Z"},
+		{"dst gap: next day back to normal", "30 0 * * *", "America/Sao_Paulo", "2018-11-04T03:30:00Z", "2018-11-05T02:30:00Z"},
+		// It fell back at 2019-02-17 00:00 (-02 → -03): 23:00-23:59 of the
+		// 16th happened twice and runs only the first time.
+		{"dst overlap: first occurrence", "30 23 * * *", "America/Sao_Paulo", "2019-02-17T01:00:00Z", "2019-02-17T01:30:00Z"},
+		{"dst overlap: no repeat", "30 23 * * *", "America/Sao_Paulo", "2019-02-17T01:30:00Z", "2019-02-18T02:30:00Z"},
+		{"dst gap: new york", "30 2 * * *", "America/New_York", "2024-03-09T12:00:00Z", "2024-03-10T07:30:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextRun(tt.expr, tt.tz, utc(tt.from))
+			if err != nil {
+				t.Fatalf("NextRun: %v", err)
+			}
+			if want := utc(tt.want); !got.Equal(want) {
+				t.Errorf("NextRun(%q, %q, %s) = %s, want %s", tt.expr, tt.tz, tt.from, got.UTC().Format(time.RFC3339), tt.want)
+			}
+			if got.Location().String() != tt.tz {
+				t.Errorf("location = %s, want %s", got.Location(), tt.tz)
+			}
+		})
+	}
+}
+
+func TestNextRunErrors(t *testing.T) {
+	from := time.Date(2024, 9, 4, 0, 0, 0, 0, time.UTC)
+	if _, err := NextRun("0 0 30 2 *", "UTC", from); err == nil || !strings.Contains(err.Error(), "nunca dispara") {
+		t.Errorf("30 February: err = %v", err)
+	}
+	if _, err := NextRun("0 9 * * *", "America/Nowhere", from); err == nil {
+		t.Error("unknown timezone: want error")
+	}
+	if _, err := NextRun("0 9 * *", "UTC", from); err == nil {
+		t.Error("four fields: want error")
+	}
+}
+
+func TestNextRunIsMonotonicAcrossDST(t *testing.T) {
+	loc, err := LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, _ := Parse("*/20 * * * *")
+	for _, from := range []time.Time{
+		time.Date(2018, 11, 3, 20, 0, 0, 0, loc),
+		time.Date(2019, 2, 16, 20, 0, 0, 0, loc),
+	} {
+		prev := from
+		for i := 0; i < 30; i++ {
+			next := spec.Next(prev)
+			if !next.After(prev) {
+				t.Fatalf("Next(%s) = %s, not after", prev, next)
+			}
+			if next.Sub(prev) > 2*time.Hour {
+				t.Fatalf("Next(%s) = %s, skipped too far", prev, next)
+			}
+			prev = next
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+const migrateSQL = `
+CREATE TABLE IF NOT EXISTS schedules (
+    id          BIGSERIAL   PRIMARY KEY,
+    user_id     TEXT        NOT NULL,
+    channel_id  TEXT        NOT NULL,
+    question    TEXT        NOT NULL,
+    cron        TEXT        NOT NULL,
+    timezone    TEXT        NOT NULL DEFAULT '',
+    paused      BOOLEAN     NOT NULL DEFAULT FALSE,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS team_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS schedules_user_id     ON schedules (user_id);
+CREATE INDEX IF NOT EXISTS schedules_next_run_at ON schedules (next_run_at) WHERE NOT paused;
+`
+
+const selectColumns = `id, team_id, user_id, channel_id, question, cron, timezone, paused, next_run_at, last_run_at, created_at`
+
+// Schedule is a recurring question.  Channel is where the answer is posted:
+// a channel ID, or the owner's user ID for a DM.
+type Schedule struct {
+	ID        int64
+	TeamID    string // workspace the schedule was created in; empty for the env tokens' one
+	UserID    string
+	Channel   string
+	Question  string
+	Cron      string
+	Timezone  string // IANA name; empty means the server's local time
+	Paused    bool
+	NextRun   time.Time
+	LastRun   time.Time // zero until the first run
+	CreatedAt time.Time
+}
+
+// Store keeps schedules in Postgres or, without a database, in memory.
+type Store struct {
+	db *sql.DB
+
+	mu     sync.Mutex
+	mem    map[int64]*Schedule
+	nextID int64
+}
+
+// NewStore opens the schedules table in TELEMETRY_DB_URL.  A Postgres
+// failure falls back to memory, so schedules are lost on restart.
+func NewStore(cfg config.Config) *Store {
+	s := &Store{mem: make(map[int64]*Schedule)}
+	if cfg.TelemetryDBURL != "" {
+		s.db = openDB(cfg.TelemetryDBURL)
+	}
+	log.Printf("[BOOT] schedules persistent=%t", s.db != nil)
+	return s
+}
+
+func openDB(dsn string) *sql.DB {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		log.Printf("[SCHEDULE] open failed: %v — using memory only", err)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		log.Printf("[SCHEDULE] ping failed: %v — using memory only", err)
+		_ = db.Close()
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, migrateSQL); err != nil {
+		log.Printf("[SCHEDULE] migrate failed: %v — using memory only", err)
+		_ = db.Close()
+		return nil
+	}
+	return db
+}
+
+// Persistent reports whether schedules survive a restart.
+func (s *Store) Persistent() bool { return s.db != nil }
+
+// Create stores sc and returns it with its ID and creation time set.
+func (s *Store) Create(ctx context.Context, sc Schedule) (Schedule, error) {
+	sc.CreatedAt = time.Now()
+	if s.db != nil {
+		err := s.db.QueryRowContext(ctx, `
+INSERT INTO schedules (team_id, user_id, channel_id, question, cron, timezone, paused, next_run_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`,
+			sc.TeamID, sc.UserID, sc.Channel, sc.Question, sc.Cron, sc.Timezone, sc.Paused, sc.NextRun,
+		).Scan(&sc.ID, &sc.CreatedAt)
+		return sc, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	sc.ID = s.nextID
+	cp := sc
+	s.mem[sc.ID] = &cp
+	return sc, nil
+}
+
+// ListByUser returns userID's schedules, oldest first.
+func (s *Store) ListByUser(ctx context.Context, userID string) ([]Schedule, error) {
+	if s.db != nil {
+		return s.query(ctx, `SELECT `+selectColumns+` FROM schedules WHERE user_id = $1 ORDER BY id`, userID)
+	}
+	return s.memSelect(func(sc *Schedule) bool { return sc.UserID == userID }), nil
+}
+
+// Due returns the active schedules whose next run is not after now.
+func (s *Store) Due(ctx context.Context, now time.Time) ([]Schedule, error) {
+	if s.db != nil {
+		return s.query(ctx, `SELECT `+selectColumns+` FROM schedules WHERE NOT paused AND next_run_at <= $1 ORDER BY next_run_at`, now)
+	}
+	return s.memSelect(func(sc *Schedule) bool { return !sc.Paused && !sc.NextRun.After(now) }), nil
+}
+
+// Claim moves a due schedule's next run from prev to next and reports
+// whether this caller won it.  Replicas racing on the same tick see the
+// update of the first one and skip the run.
+func (s *Store) Claim(ctx context.Context, id int64, prev, next time.Time) (bool, error) {
+	if s.db != nil {
+		res, err := s.db.ExecContext(ctx, `
+UPDATE schedules SET next_run_at = $3, last_run_at = now()
+WHERE id = $1 AND next_run_at = $2 AND NOT paused`, id, prev, next)
+		if err != nil {
+			return false, err
+		}
+		n, err := res.RowsAffected()
+		return n == 1, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc, ok := s.mem[id]
+	if !ok || sc.Paused || !sc.NextRun.Equal(prev) {
+		return false, nil
+	}
+	sc.NextRun, sc.LastRun = next, time.Now()
+	return true, nil
+}
+
+// SetPaused pauses or resumes one of userID's schedules.  Resuming sets the
+// next run to next, so runs missed while paused are skipped.  ok is false
+// when the schedule does not exist or belongs to someone else.
+func (s *Store) SetPaused(ctx context.Context, id int64, userID string, paused bool, next time.Time) (ok bool, err error) {
+	if s.db != nil {
+		var res sql.Result
+		if paused {
+			res, err = s.db.ExecContext(ctx, `UPDATE schedules SET paused = TRUE WHERE id = $1 AND user_id = $2`, id, userID)
+		} else {
+			res, err = s.db.ExecContext(ctx, `UPDATE schedules SET paused = FALSE, next_run_at = $3 WHERE id = $1 AND user_id = $2`, id, userID, next)
+		}
+		if err != nil {
+			return false, err
+		}
+		n, err := res.RowsAffected()
+		return n == 1, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc, found := s.mem[id]
+	if !found || sc.UserID != userID {
+		return false, nil
+	}
+	sc.Paused = paused
+	if !paused {
+		sc.NextRun = next
+	}
+	return true, nil
+}
+
+// Get returns one of userID's schedules.
+func (s *Store) Get(ctx context.Context, id int64, userID string) (Schedule, bool, error) {
+	if s.db != nil {
+		out, err := s.query(ctx, `SELECT `+selectColumns+` FROM schedules WHERE id = $1 AND user_id = $2`, id, userID)
+		if err != nil || len(out) == 0 {
+			return Schedule{}, false, err
+		}
+		return out[0], true, nil
+	}
+	out := s.memSelect(func(sc *Schedule) bool { return sc.ID == id && sc.UserID == userID })
+	if len(out) == 0 {
+		return Schedule{}, false, nil
+	}
+	return out[0], true, nil
+}
+
+// Delete removes one of userID's schedules.
+func (s *Store) Delete(ctx context.Context, id int64, userID string) (bool, error) {
+	if s.db != nil {
+		res, err := s.db.ExecContext(ctx, `DELETE FROM schedules WHERE id = $1 AND user_id = $2`, id, userID)
+		if err != nil {
+			return false, err
+		}
+		n, err := res.RowsAffected()
+		return n == 1, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc, ok := s.mem[id]
+	if !ok || sc.UserID != userID {
+		return false, nil
+	}
+	delete(s.mem, id)
+	return true, nil
+}
+
+func (s *Store) query(ctx context.Context, q string, args ...any) ([]Schedule, error) {
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Schedule
+	for rows.Next() {
+		var sc Schedule
+		var last sql.NullTime
+		if err := rows.Scan(&sc.ID, &sc.TeamID, &sc.UserID, &sc.Channel, &sc.Question, &sc.Cron, &sc.Timezone,
+			&sc.Paused, &sc.NextRun, &last, &sc.CreatedAt); err != nil {
+			return nil, err
+		}
+		sc.LastRun = last.Time
+		out = append(out, sc)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) memSelect(match func(*Schedule) bool) []Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Schedule
+	for _, sc := range s.mem {
+		if match(sc) {
+			out = append(out, *sc)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Run checks for due schedules every interval until ctx is cancelled.  Each
+// due schedule is advanced to its next run before fn is called in its own
+// goroutine, so a slow answer never delays the others and a run missed
+// during downtime fires once, not once per missed tick.
+func (s *Store) Run(ctx context.Context, interval time.Duration, fn func(Schedule)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.tick(ctx, now, fn)
+		}
+	}
+}
+
+func (s *Store) tick(ctx context.Context, now time.Time, fn func(Schedule)) {
+	qctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	due, err := s.Due(qctx, now)
+	if err != nil {
+		log.Printf("[SCHEDULE] load due schedules: %v", err)
+		return
+	}
+	for _, sc := range due {
+		next, err := NextRun(sc.Cron, sc.Timezone, now)
+		if err != nil {
+			// Stored expressions were validated on creation; pause it rather
+			// than retrying every tick.
+			log.Printf("[SCHEDULE] id=%d invalid: %v — pausing", sc.ID, err)
+			_, _ = s.SetPaused(qctx, sc.ID, sc.UserID, true, time.Time{})
+			continue
+		}
+		won, err := s.Claim(qctx, sc.ID, sc.NextRun, next)
+		if err != nil {
+			log.Printf("[SCHEDULE] claim id=%d: %v", sc.ID, err)
+			continue
+		}
+		if !won {
+			continue
+		}
+		log.Printf("[SCHEDULE] run id=%d user=%q channel=%q next=%s", sc.ID, sc.UserID, sc.Channel, next.Format(time.RFC3339))
+		go fn(sc)
+	}
+}
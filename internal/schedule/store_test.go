@@ -0,0 +1,94 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+func TestTickRunsDueScheduleOnceAndRearms(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(config.Config{})
+	now := time.Date(2024, 9, 4, 10, 0, 0, 0, time.UTC)
+	sc, err := s.Create(ctx, Schedule{UserID: "U1", Channel: "C1", Question: "q", Cron: "*/5 * * * *", Timezone: "UTC", NextRun: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paused, _ := s.Create(ctx, Schedule{UserID: "U1", Channel: "C1", Question: "p", Cron: "* * * * *", Timezone: "UTC", Paused: true, NextRun: now})
+
+	runs := make(chan Schedule, 4)
+	fn := func(sc Schedule) { runs <- sc }
+	expectRuns := func(want int) {
+		t.Helper()
+		for i := 0; i < want; i++ {
+			select {
+			case got := <-runs:
+				if got.ID != sc.ID {
+					t.Errorf("ran schedule %d, want %d", got.ID, sc.ID)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("got %d runs, want %d", i, want)
+			}
+		}
+		select {
+		case got := <-runs:
+			t.Fatalf("unexpected run of schedule %d", got.ID)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	s.tick(ctx, now.Add(30*time.Second), fn)
+	expectRuns(1)
+	got, _, _ := s.Get(ctx, sc.ID, "U1")
+	if want := now.Add(5 * time.Minute); !got.NextRun.Equal(want) {
+		t.Errorf("next run = %s, want %s", got.NextRun, want)
+	}
+	if got.LastRun.IsZero() {
+		t.Error("last run not set")
+	}
+
+	// Not due again until the next slot.
+	s.tick(ctx, now.Add(time.Minute), fn)
+	expectRuns(0)
+	s.tick(ctx, now.Add(5*time.Minute), fn)
+	expectRuns(1)
+
+	// A run missed during downtime fires once, not once per missed slot.
+	s.tick(ctx, now.Add(time.Hour), fn)
+	expectRuns(1)
+	got, _, _ = s.Get(ctx, sc.ID, "U1")
+	if want := now.Add(time.Hour + 5*time.Minute); !got.NextRun.Equal(want) {
+		t.Errorf("next run after downtime = %s, want %s", got.NextRun, want)
+	}
+
+	if p, _, _ := s.Get(ctx, paused.ID, "U1"); !p.NextRun.Equal(now) {
+		t.Errorf("paused schedule was claimed: next run = %s", p.NextRun)
+	}
+}
+
+func TestClaimOnlyOnce(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(config.Config{})
+	now := time.Date(2024, 9, 4, 10, 0, 0, 0, time.UTC)
+	sc, _ := s.Create(ctx, Schedule{UserID: "U1", Cron: "0 * * * *", NextRun: now})
+	next := now.Add(time.Hour)
+
+	if won, err := s.Claim(ctx, sc.ID, now, next); err != nil || !won {
+		t.Fatalf("first claim: won=%t err=%v", won, err)
+	}
+	// A replica that loaded the schedule before the first claim loses.
+	if won, _ := s.Claim(ctx, sc.ID, now, next); won {
+		t.Error("second claim with a stale next run won")
+	}
+	if won, _ := s.Claim(ctx, 999, now, next); won {
+		t.Error("claim of an unknown schedule won")
+	}
+	if ok, _ := s.SetPaused(ctx, sc.ID, "U1", true, time.Time{}); !ok {
+		t.Fatal("pause failed")
+	}
+	if won, _ := s.Claim(ctx, sc.ID, next, next.Add(time.Hour)); won {
+		t.Error("claim of a paused schedule won")
+	}
+}
@@ -0,0 +1,92 @@
+package skill
+
+import (
+	"fmt"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+)
+
+// Registry holds the registered skills in registration order, which is also
+// the order of their sections in the routing and answer prompts.
+type Registry struct {
+	skills []Skill
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a skill.  It fails when the skill's kind or one of its tool
+// names is already claimed by another skill.
+func (r *Registry) Register(s Skill) error {
+	for _, existing := range r.skills {
+		if existing.Kind() == s.Kind() {
+			return fmt.Errorf("skill: kind %q already registered", s.Kind())
+		}
+		for _, t := range s.Tools() {
+			if handles(existing, t.Kind) {
+				return fmt.Errorf("skill: tool %q already registered by %q", t.Kind, existing.Kind())
+			}
+		}
+	}
+	r.skills = append(r.skills, s)
+	return nil
+}
+
+// All returns every registered skill.
+func (r *Registry) All() []Skill {
+	return r.skills
+}
+
+// Enabled returns the skills whose integration is configured and granted to
+// the workspace teamID.
+func (r *Registry) Enabled(cfg config.Config, teamID string) []Skill {
+	var out []Skill
+	for _, s := range r.skills {
+		if s.Enabled(cfg) && Granted(s, cfg, teamID) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// ForKind returns the enabled skill that handles the action kind for the
+// workspace teamID, or nil.
+func (r *Registry) ForKind(kind string, cfg config.Config, teamID string) Skill {
+	for _, s := range r.skills {
+		if handles(s, kind) && s.Enabled(cfg) && Granted(s, cfg, teamID) {
+			return s
+		}
+	}
+	return nil
+}
+
+// Router builds the router input for req from every skill enabled for the
+// request's workspace, so the router never offers a tool it may not use.
+func (r *Registry) Router(cfg config.Config, req Request) llm.RouterInput {
+	in := llm.RouterInput{
+		Question:      req.QuestionForLLM,
+		ThreadHistory: req.ThreadHistory,
+		SenderUserID:  req.SenderUserID,
+	}
+	for _, s := range r.Enabled(cfg, req.TeamID) {
+		in.Tools = append(in.Tools, s.Tools()...)
+		in.Snippets = append(in.Snippets, s.RouterPrompt(req))
+	}
+	return in
+}
+
+// handles reports whether kind is the skill's kind or one of its tools.
+func handles(s Skill, kind string) bool {
+	if s.Kind() == kind {
+		return true
+	}
+	for _, t := range s.Tools() {
+		if t.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+// Package skill defines the interface Jarvis integrations implement.  A skill
+// describes one data source: the tools it exposes to the router, its slice of
+// the routing prompt, how an action is executed and which telemetry fields it
+// fills.  Every entry point (Slack events, /api/chat and the prompt-library
+// smoke tests) dispatches actions through the same Registry.
+//
+// Only the contract lives here.  An integration lives in its own package next
+// to its API client (internal/outline, internal/googledrive,
+// internal/hubspot), declares its action kinds there and is added with one
+// line in registerBuiltinSkills.  Skills that share the *Service's per-thread
+// state (Slack, Jira, Metabase, digests, schedules) are implemented in
+// internal/app.  Package llm knows no action kinds: arguments are trimmed
+// and normalised as their ToolParam says, and each skill weighs its context
+// and adds its answer instructions through Answerer.
+package skill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+	"github.com/DanielFillol/Jarvis/internal/identity"
+	"github.com/DanielFillol/Jarvis/internal/llm"
+	"github.com/DanielFillol/Jarvis/internal/telemetry"
+)
+
+// ErrSkipped is returned by Execute when an action does not apply in the
+// current request (missing client or token, empty query, test mode, ...).
+// Skipped actions are reported to the router as empty and are excluded from
+// telemetry.
+var ErrSkipped = errors.New("skill: action skipped")
+
+// Request is the per-message state shared by every skill of a run.
+type Request struct {
+	// Channel and ThreadTs identify the thread the context belongs to (the
+	// permalinked thread when the user pasted one).  Both are empty for
+	// stateless /api/chat calls.
+	Channel  string
+	ThreadTs string
+	// TeamID is the OAuth-installed Slack workspace the request comes from,
+	// or "" for the env tokens' one; per-workspace grants (skills, Jira
+	// projects, Metabase databases) are keyed by it.
+	TeamID string
+
+	// Question is the user's original text; QuestionForLLM has mentions
+	// resolved and has been rewritten by EnhancePrompt.
+	Question       string
+	QuestionForLLM string
+	ThreadHistory  string
+	SenderUserID   string
+	// Sender is the asker's Jira account and HubSpot owner, resolved before
+	// routing so "meus cards" and "meus deals" filter by real IDs.  Its
+	// fields are empty when unknown.
+	Sender identity.Identity
+
+	// ThreadPermalink is set when the user pasted a Slack thread link: the
+	// thread itself is the authoritative context.
+	ThreadPermalink bool
+	// Direct marks the transport-agnostic /api/chat path: no Slack posts,
+	// plain-text links and no show_sql replies.
+	Direct bool
+	// Test marks the prompt-library smoke test: no side effects on Slack or
+	// on per-thread state.
+	Test bool
+
+	// Progress, when set, receives short status lines ("consultando Jira…")
+	// shown to the user while the skills run.  It may be called from
+	// several goroutines at once.
+	Progress func(line string)
+}
+
+// Report sends a progress line when the entry point displays them.
+func (r Request) Report(format string, args ...any) {
+	if r.Progress != nil {
+		r.Progress(fmt.Sprintf(format, args...))
+	}
+}
+
+// Tag returns the log prefix for the request's entry point.
+func (r Request) Tag() string {
+	switch {
+	case r.Test:
+		return "[TEST]"
+	case r.Direct:
+		return "[DIRECT]"
+	}
+	return "[JARVIS]"
+}
+
+// Warn returns the warning log prefix for the request's entry point.
+func (r Request) Warn() string {
+	if r.Direct {
+		return "[DIRECT][WARN]"
+	}
+	return "[WARN]"
+}
+
+// ContextBlock is the result of one executed action.
+type ContextBlock struct {
+	// Kind is the action kind that produced the block.
+	Kind string
+	// Text is the context handed to the answer LLM.  It may be an
+	// [AVISO: ...] / [ERRO: ...] style marker when the source failed or came
+	// back empty.
+	Text string
+	// Count is the number of items found (messages, issues, rows, records).
+	Count int
+	// Reply, when set, is sent to the user as the final answer and the run
+	// stops (e.g. a Metabase clarification question or a show_sql reply).
+	Reply string
+	// Footer is appended verbatim to the answer (e.g. a CSV download link).
+	Footer string
+	// Table replaces the [TABLE] marker in the answer, or is appended in a
+	// code block when the model omitted the marker.
+	Table string
+	// Citations are the items of Text tagged with a source ID the answer
+	// may cite; citations of IDs not listed here are dropped.
+	Citations []Citation
+}
+
+// Citation is one citable item of a block's context.  ID is the tag written
+// in the context ("S:3fa9c1", "J:PROJ-123"); Label and URL render the
+// numbered footnote.
+type Citation struct {
+	ID    string
+	Label string
+	URL   string
+}
+
+// Sources is a preformatted list of links appended to the answer when the
+// block's context was used.
+type Sources string
+
+// Skill is a single integration.  Implementations must be safe for
+// concurrent use.
+type Skill interface {
+	// Kind is the primary action kind, used to name the skill in logs.
+	Kind() string
+	// Label is the section header of the skill's context in the answer prompt.
+	Label() string
+	// DisplayName names the integration to users, e.g. in the App Home.
+	DisplayName() string
+	// Enabled reports whether the integration is configured.
+	Enabled(cfg config.Config) bool
+	// Tools lists the action kinds the skill handles, exposed to the router.
+	Tools() []llm.ToolDef
+	// RouterPrompt returns the skill's contribution to the routing prompt.
+	RouterPrompt(req Request) llm.RouterSnippet
+	// Execute runs one action.  A non-nil error other than ErrSkipped is
+	// logged; the returned block is used either way, so skills report
+	// failures to the answer LLM through an error marker in Text.  ctx
+	// carries the source's deadline: skills should stop optional work
+	// (retries, fallbacks) once it expires, and may return what they have
+	// with context.DeadlineExceeded to hand over a partial result.
+	Execute(ctx context.Context, req Request, action llm.ActionDescriptor) (ContextBlock, Sources, error)
+	// Telemetry records the outcome of an executed action on the event.
+	Telemetry(ev *telemetry.Event, block ContextBlock, err error)
+}
+
+// Prefetcher is implemented by skills that can gather context before the
+// router runs, e.g. from a document URL pasted in the message.
+type Prefetcher interface {
+	Prefetch(ctx context.Context, req Request) (ContextBlock, Sources, bool)
+}
+
+// Finisher is implemented by skills that post-process their blocks once all
+// rounds are done (fallback fetches, a single "no results" marker, catalog
+// headers).  blocks holds only the skill's own blocks, in execution order;
+// the returned slice replaces them.
+type Finisher interface {
+	Finish(ctx context.Context, req Request, blocks []ContextBlock) []ContextBlock
+}
+
+// Deadliner is implemented by skills whose actions need a default deadline
+// other than SKILL_TIMEOUT (long SQL queries, multi-call summaries).  It
+// returns 0 for kinds that use the default; SKILL_TIMEOUTS still overrides
+// the result per kind.
+type Deadliner interface {
+	Deadline(cfg config.Config, kind string) time.Duration
+}
+
+// Answerer is implemented by skills that shape the answer prompt.  Weight is
+// the share of the context budget the skill's section competes with when the
+// prompt is over the model's window (the thread weighs 2, attached files 3,
+// skills without Answerer 1).  AnswerHints returns instructions for the
+// system prompt; it sees the final sections of the run, so it can react to
+// its own context (empty results, error markers) or to the others', and
+// enabled reports whether the integration is configured and granted, so the
+// model can say it is unavailable instead of guessing.
+type Answerer interface {
+	Weight() float64
+	AnswerHints(sections []llm.ContextSection, enabled bool) []string
+}
+
+// Local is implemented by skills that only use the requesting workspace's
+// own Slack data (search, digests, schedules).  They are available to every
+// workspace; other skills must be granted to installed workspaces in
+// SLACK_TEAM_SKILLS.
+type Local interface {
+	Local() bool
+}
+
+// Granted reports whether the workspace teamID may use s.
+func Granted(s Skill, cfg config.Config, teamID string) bool {
+	if l, ok := s.(Local); ok && l.Local() {
+		return true
+	}
+	return cfg.SkillGranted(teamID, s.Kind())
+}
@@ -0,0 +1,271 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Block is a Block Kit element (block, button, input, view...).  Only a
+// handful of shapes are needed, so they are built as plain JSON objects by
+// the helpers below.  See https://api.slack.com/block-kit.
+type Block map[string]any
+
+// Section returns a section block with mrkdwn text.
+func Section(text string) Block {
+	return Block{"type": "section", "text": Block{"type": "mrkdwn", "text": text}}
+}
+
+// Header returns a header block (large bold plain text).
+func Header(text string) Block {
+	return Block{"type": "header", "text": Block{"type": "plain_text", "text": text, "emoji": true}}
+}
+
+// Divider returns a divider block.
+func Divider() Block {
+	return Block{"type": "divider"}
+}
+
+// Context returns a context block (small grey text).
+func Context(text string) Block {
+	return Block{"type": "context", "elements": []Block{{"type": "mrkdwn", "text": text}}}
+}
+
+// Actions returns an actions block holding elements (usually buttons).
+func Actions(blockID string, elements ...Block) Block {
+	return Block{"type": "actions", "block_id": blockID, "elements": elements}
+}
+
+// Button returns a button element.  style is "", "primary" or "danger".
+func Button(actionID, text, value, style string) Block {
+	b := Block{
+		"type":      "button",
+		"action_id": actionID,
+		"text":      Block{"type": "plain_text", "text": text, "emoji": true},
+		"value":     value,
+	}
+	if style != "" {
+		b["style"] = style
+	}
+	return b
+}
+
+// TextInput returns an input block with a plain-text field.
+func TextInput(blockID, label, initial string, optional, multiline bool) Block {
+	el := Block{"type": "plain_text_input", "action_id": blockID, "multiline": multiline}
+	if initial != "" {
+		el["initial_value"] = initial
+	}
+	return Block{
+		"type":     "input",
+		"block_id": blockID,
+		"optional": optional,
+		"label":    Block{"type": "plain_text", "text": label},
+		"element":  el,
+	}
+}
+
+// SelectInput returns an input block with a static select.  initial must be
+// one of options to be preselected.
+func SelectInput(blockID, label string, options []string, initial string, optional bool) Block {
+	opts := make([]Block, 0, len(options))
+	var selected Block
+	for _, o := range options {
+		opt := Block{"text": Block{"type": "plain_text", "text": o}, "value": o}
+		opts = append(opts, opt)
+		if strings.EqualFold(o, initial) {
+			selected = opt
+		}
+	}
+	el := Block{"type": "static_select", "action_id": blockID, "options": opts}
+	if selected != nil {
+		el["initial_option"] = selected
+	}
+	return Block{
+		"type":     "input",
+		"block_id": blockID,
+		"optional": optional,
+		"label":    Block{"type": "plain_text", "text": label},
+		"element":  el,
+	}
+}
+
+// Modal returns a modal view.  An empty submit makes a read-only modal with
+// just a close button.
+func Modal(callbackID, title, submit, privateMetadata string, blocks []Block) Block {
+	m := Block{
+		"type":             "modal",
+		"callback_id":      callbackID,
+		"title":            Block{"type": "plain_text", "text": title},
+		"close":            Block{"type": "plain_text", "text": "Fechar"},
+		"private_metadata": privateMetadata,
+		"blocks":           blocks,
+	}
+	if submit != "" {
+		m["submit"] = Block{"type": "plain_text", "text": submit}
+		m["close"] = Block{"type": "plain_text", "text": "Cancelar"}
+	}
+	return m
+}
+
+// InteractionPayload is the payload of a Block Kit interaction (button
+// click or modal submission), POSTed form-encoded as "payload" to the
+// interactivity URL or delivered in an "interactive" Socket Mode envelope.
+type InteractionPayload struct {
+	Type        string `json:"type"`        // "block_actions", "view_submission", "message_action", ...
+	CallbackID  string `json:"callback_id"` // shortcut callback ID (message_action)
+	TriggerID   string `json:"trigger_id"`
+	ResponseURL string `json:"response_url"`
+	User        struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Team struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	Message struct {
+		Ts       string `json:"ts"`
+		ThreadTs string `json:"thread_ts"`
+		Text     string `json:"text"`
+		User     string `json:"user"`
+	} `json:"message"`
+	Actions []BlockAction `json:"actions"`
+	View    View          `json:"view"`
+}
+
+// BlockAction is one element interaction inside a block_actions payload.
+type BlockAction struct {
+	ActionID string `json:"action_id"`
+	BlockID  string `json:"block_id"`
+	Value    string `json:"value"`
+}
+
+// View is the part of a submitted modal (or of the App Home, for clicks
+// made there) we read back.
+type View struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"` // "modal" or "home"
+	CallbackID      string `json:"callback_id"`
+	PrivateMetadata string `json:"private_metadata"`
+	State           struct {
+		Values map[string]map[string]ViewValue `json:"values"`
+	} `json:"state"`
+}
+
+// ViewValue is the state of one input element of a submitted modal.
+type ViewValue struct {
+	Type           string `json:"type"`
+	Value          string `json:"value"`
+	SelectedOption *struct {
+		Value string `json:"value"`
+	} `json:"selected_option"`
+}
+
+// Value returns the submitted value of the input in blockID, whether it is
+// a text field or a select.
+func (v View) Value(blockID string) string {
+	for _, el := range v.State.Values[blockID] {
+		if el.SelectedOption != nil {
+			return el.SelectedOption.Value
+		}
+		return strings.TrimSpace(el.Value)
+	}
+	return ""
+}
+
+// ViewResponse is the synchronous answer to a view_submission.  A nil
+// response closes the modal.
+type ViewResponse struct {
+	ResponseAction string            `json:"response_action"` // "errors"
+	Errors         map[string]string `json:"errors,omitempty"`
+}
+
+// PostBlocks posts a Block Kit message and returns its timestamp.  text is
+// the notification and accessibility fallback.
+func (c *Client) PostBlocks(ctx context.Context, channel, threadTs, text string, blocks []Block) (string, error) {
+	var out struct {
+		Ts string `json:"ts"`
+	}
+	payload := map[string]any{"channel": channel, "text": text, "blocks": blocks}
+	if threadTs != "" {
+		payload["thread_ts"] = threadTs
+	}
+	err := c.postJSON(ctx, "chat.postMessage", payload, &out)
+	return out.Ts, err
+}
+
+// UpdateBlocks replaces a message's text and blocks.  A nil blocks removes
+// the existing ones (e.g. buttons that were already used).
+func (c *Client) UpdateBlocks(ctx context.Context, channel, ts, text string, blocks []Block) error {
+	if blocks == nil {
+		blocks = []Block{}
+	}
+	return c.postJSON(ctx, "chat.update", map[string]any{
+		"channel": channel,
+		"ts":      ts,
+		"text":    text,
+		"blocks":  blocks,
+	}, nil)
+}
+
+// OpenView opens a modal in response to an interaction (views.open).
+func (c *Client) OpenView(ctx context.Context, triggerID string, view Block) error {
+	return c.postJSON(ctx, "views.open", map[string]any{"trigger_id": triggerID, "view": view}, nil)
+}
+
+// OpenViewAndGetID opens a modal like OpenView and returns its view ID, so
+// it can be filled in later with UpdateView (e.g. after a slow LLM call —
+// trigger IDs expire after 3 seconds).
+func (c *Client) OpenViewAndGetID(ctx context.Context, triggerID string, view Block) (string, error) {
+	var out struct {
+		View struct {
+			ID string `json:"id"`
+		} `json:"view"`
+	}
+	err := c.postJSON(ctx, "views.open", map[string]any{"trigger_id": triggerID, "view": view}, &out)
+	return out.View.ID, err
+}
+
+// UpdateView replaces the content of an open modal (views.update).
+func (c *Client) UpdateView(ctx context.Context, viewID string, view Block) error {
+	return c.postJSON(ctx, "views.update", map[string]any{"view_id": viewID, "view": view}, nil)
+}
+
+// postJSON calls a Web API method with the bot token and decodes the
+// response into out (when non-nil).
+func (c *Client) postJSON(ctx context.Context, method string, payload any, out any) error {
+	if c.botToken(ctx) == "" {
+		return errors.New("missing Slack bot token")
+	}
+	b, _ := json.Marshal(payload)
+	req, _ := http.NewRequestWithContext(ctx, "POST", c.APIBaseURL+"/"+method, bytes.NewReader(b))
+	req.Header.Set("Authorization", "Bearer "+c.botToken(ctx))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	resp, err := c.Do(req, 15*time.Second)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	rb, _ := io.ReadAll(resp.Body)
+	var slackResp struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error,omitempty"`
+	}
+	_ = json.Unmarshal(rb, &slackResp)
+	if !slackResp.OK {
+		return fmt.Errorf("slack %s error: %s", method, slackResp.Error)
+	}
+	if out != nil {
+		return json.Unmarshal(rb, out)
+	}
+	return nil
+}
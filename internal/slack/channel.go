@@ -1,6 +1,7 @@
 package slack
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,13 +16,13 @@ import (
 // ListChannels returns the public channels the bot is a member of (up to 200).
 // It tries the bot token first; if that fails with missing_scope, retries with
 // the user token which typically has broader channel access.
-func (c *Client) ListChannels() ([]ChannelInfo, error) {
+func (c *Client) ListChannels(ctx context.Context) ([]ChannelInfo, error) {
 	var tokens []string
-	if c.BotToken != "" {
-		tokens = append(tokens, c.BotToken)
+	if t := c.botToken(ctx); t != "" {
+		tokens = append(tokens, t)
 	}
-	if c.UserToken != "" {
-		tokens = append(tokens, c.UserToken)
+	if t := c.userToken(ctx); t != "" {
+		tokens = append(tokens, t)
 	}
 	if len(tokens) == 0 {
 		return nil, errors.New("missing Slack token")
@@ -40,7 +41,7 @@ func (c *Client) ListChannels() ([]ChannelInfo, error) {
 	u := fmt.Sprintf("%s/conversations.list?types=public_channel&exclude_archived=true&limit=200", c.APIBaseURL)
 
 	for _, token := range tokens {
-		req, _ := http.NewRequest("GET", u, nil)
+		req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		resp, err := c.Do(req, 10*time.Second)
 		if err != nil {
@@ -79,7 +80,7 @@ func (c *Client) ListChannels() ([]ChannelInfo, error) {
 // in text with #channel-name, fetching the name from the Slack API when
 // the name is not embedded in the mention.  This allows the router LLM
 // to generate correct in:#channel-name search filters.
-func (c *Client) ResolveChannelMentions(text string) string {
+func (c *Client) ResolveChannelMentions(ctx context.Context, text string) string {
 	return reSlackChannelMention.ReplaceAllStringFunc(text, func(m string) string {
 		sub := reSlackChannelMention.FindStringSubmatch(m)
 		if len(sub) < 3 {
@@ -90,7 +91,7 @@ func (c *Client) ResolveChannelMentions(text string) string {
 		if name != "" {
 			return "#" + name
 		}
-		if resolved := c.GetChannelName(id); resolved != "" {
+		if resolved := c.GetChannelName(ctx, id); resolved != "" {
 			log.Printf("[SLACK] resolved channel %s → #%s", id, resolved)
 			return "#" + resolved
 		}
@@ -102,7 +103,7 @@ func (c *Client) ResolveChannelMentions(text string) string {
 // GetChannelName resolves a Slack channel ID to its display name via
 // conversations.info.  Returns an empty string on failure so callers
 // can fall back gracefully.
-func (c *Client) GetChannelName(channelID string) string {
+func (c *Client) GetChannelName(ctx context.Context, channelID string) string {
 	channelID = strings.TrimSpace(channelID)
 	if channelID == "" {
 		return ""
@@ -110,11 +111,11 @@ func (c *Client) GetChannelName(channelID string) string {
 	// Prefer user token: broader channel access (bot may not be a member).
 	// Fall back to bot token if the user token is unavailable.
 	var tokens []string
-	if c.UserToken != "" {
-		tokens = append(tokens, c.UserToken)
+	if t := c.userToken(ctx); t != "" {
+		tokens = append(tokens, t)
 	}
-	if c.BotToken != "" {
-		tokens = append(tokens, c.BotToken)
+	if t := c.botToken(ctx); t != "" {
+		tokens = append(tokens, t)
 	}
 	if len(tokens) == 0 {
 		return ""
@@ -128,7 +129,7 @@ func (c *Client) GetChannelName(channelID string) string {
 		} `json:"channel"`
 	}
 	for _, token := range tokens {
-		req, _ := http.NewRequest("GET", u, nil)
+		req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		resp, err := c.Do(req, 10*time.Second)
 		if err != nil {
@@ -165,7 +166,7 @@ func (c *Client) GetChannelName(channelID string) string {
 // scope.  The user token is tried first (broader public-channel access), then
 // the bot token.  The channel name in the returned messages is set to channelID
 // since we may not have channels:read to resolve it.
-func (c *Client) GetChannelHistoryForPeriod(channelID string, oldest, latest time.Time, limit int) ([]SearchMessage, error) {
+func (c *Client) GetChannelHistoryForPeriod(ctx context.Context, channelID string, oldest, latest time.Time, limit int) ([]SearchMessage, error) {
 	if limit <= 0 {
 		limit = 100
 	}
@@ -173,11 +174,11 @@ func (c *Client) GetChannelHistoryForPeriod(channelID string, oldest, latest tim
 		limit = 200
 	}
 	var tokens []string
-	if c.UserToken != "" {
-		tokens = append(tokens, c.UserToken)
+	if t := c.userToken(ctx); t != "" {
+		tokens = append(tokens, t)
 	}
-	if c.BotToken != "" {
-		tokens = append(tokens, c.BotToken)
+	if t := c.botToken(ctx); t != "" {
+		tokens = append(tokens, t)
 	}
 	if len(tokens) == 0 {
 		return nil, errors.New("missing Slack token")
@@ -189,7 +190,7 @@ func (c *Client) GetChannelHistoryForPeriod(channelID string, oldest, latest tim
 		c.APIBaseURL, url.QueryEscape(channelID), oldestTs, latestTs, limit)
 
 	for _, token := range tokens {
-		req, _ := http.NewRequest("GET", u, nil)
+		req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		resp, err := c.Do(req, 20*time.Second)
 		if err != nil {
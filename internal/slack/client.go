@@ -31,6 +31,18 @@ type Client struct {
 	Tracker           *MessageTracker
 	UserTokenUserID   string // user ID of the xoxp token owner (populated by AuthTestUserToken)
 	UserTokenUsername string // username handle of the xoxp token owner
+	Users             *UserDirectory
+	// TeamID is the workspace of the env tokens (from auth.test).
+	TeamID string
+	// Installations holds the workspaces installed through OAuth; events
+	// of those teams use their own tokens (see WithTeam).  nil when the
+	// OAuth install flow is not configured.
+	Installations *InstallationStore
+	// AllowedTeams are the workspaces (SLACK_ALLOWED_TEAMS) whose
+	// installations are honoured.
+	AllowedTeams map[string]bool
+
+	limiter *rateLimiter // nil: calls are neither paced nor retried
 }
 
 // NewClient constructs a Slack client from the supplied configuration.  The
@@ -42,7 +54,16 @@ func NewClient(cfg config.Config) *Client {
 		SigningSecret:  cfg.SlackSigningSecret,
 		SearchMaxPages: cfg.SlackSearchMaxPages,
 		Tracker:        NewMessageTracker(),
+		Users:          NewUserDirectory(),
 		APIBaseURL:     "https://slack.com/api",
+		limiter:        newRateLimiter(cfg.SlackRateLimits, cfg.SlackRetryMax, cfg.SlackRetryMaxWait),
+	}
+	if cfg.SlackOAuthEnabled() {
+		c.Installations = NewInstallationStore(cfg)
+		c.AllowedTeams = make(map[string]bool)
+		for _, t := range cfg.SlackAllowedTeams {
+			c.AllowedTeams[t] = true
+		}
 	}
 	// Authenticate Slack bot to get bot user ID
 	if err := c.AuthTest(); err != nil {
@@ -53,18 +74,81 @@ func NewClient(cfg config.Config) *Client {
 	return c
 }
 
+// Do sends req.  Web API calls are paced by their method's rate-limit tier;
+// a 429 is retried after its Retry-After delay, and reads (GET/HEAD) are also
+// retried on network errors and 5xx with jittered backoff.  When retries run
+// out the last response is returned unchanged, so callers still see the 429.
 func (c *Client) Do(req *http.Request, timeout time.Duration) (*http.Response, error) {
-	if c.HTTPClient != nil {
-		return c.HTTPClient.Do(req)
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: timeout}
+	}
+	if c.limiter == nil {
+		return client.Do(req)
+	}
+
+	ctx := req.Context()
+	method := c.apiMethod(req)
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	for attempt := 0; ; attempt++ {
+		if method != "" {
+			if err := c.limiter.wait(ctx, method); err != nil {
+				return nil, err
+			}
+		}
+		c.limiter.update(method, func(st *MethodStats) { st.Requests++ })
+		resp, err := client.Do(req)
+
+		var delay time.Duration
+		retryable := false
+		switch {
+		case err != nil:
+			retryable = idempotent && ctx.Err() == nil
+		case resp.StatusCode == http.StatusTooManyRequests:
+			delay = retryAfter(resp)
+			c.limiter.update(method, func(st *MethodStats) { st.RateLimited++ })
+			if method != "" {
+				c.limiter.bucket(method).pause(time.Now().Add(delay))
+			}
+			retryable = delay <= c.limiter.maxWait
+		case resp.StatusCode >= 500:
+			retryable = idempotent
+		}
+		if !retryable {
+			return resp, err
+		}
+		if delay == 0 {
+			delay = backoff(attempt)
+		}
+		next := retryRequest(req)
+		if attempt >= c.limiter.maxRetries || next == nil || !fitsDeadline(ctx, delay) {
+			c.limiter.update(method, func(st *MethodStats) { st.Failures++ })
+			return resp, err
+		}
+
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		log.Printf("[SLACK] retry method=%s attempt=%d status=%d err=%v delay=%s", method, attempt+1, status, err, delay)
+		c.limiter.update(method, func(st *MethodStats) { st.Retries++ })
+		// A 429 pauses the bucket, so the limiter's wait covers Retry-After.
+		if method == "" || status != http.StatusTooManyRequests {
+			if err := sleepCtx(ctx, delay); err != nil {
+				return nil, err
+			}
+		}
+		req = next
 	}
-	client := &http.Client{Timeout: timeout}
-	return client.Do(req)
 }
 
 type Auth struct {
 	OK     bool   `json:"ok"`
 	UserID string `json:"user_id"`
 	User   string `json:"user"`
+	TeamID string `json:"team_id"`
 	Error  string `json:"error"`
 }
 
@@ -113,6 +197,7 @@ func (c *Client) AuthTest() error {
 	}
 
 	c.BotUserID = bot.UserID
+	c.TeamID = bot.TeamID
 	c.UserTokenUserID = user.UserID
 	c.UserTokenUsername = user.User
 	return nil
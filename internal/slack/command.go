@@ -0,0 +1,72 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SlashCommand is a slash command invocation.  Over HTTP Slack sends it
+// form-encoded (see ParseSlashCommand); over Socket Mode it is the JSON
+// payload of a "slash_commands" envelope.
+type SlashCommand struct {
+	Command     string `json:"command"`
+	Text        string `json:"text"`
+	UserID      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	ChannelID   string `json:"channel_id"`
+	TeamID      string `json:"team_id"`
+	ResponseURL string `json:"response_url"`
+	TriggerID   string `json:"trigger_id"`
+}
+
+// ParseSlashCommand reads a slash command from the request form.
+func ParseSlashCommand(form url.Values) SlashCommand {
+	return SlashCommand{
+		Command:     form.Get("command"),
+		Text:        form.Get("text"),
+		UserID:      form.Get("user_id"),
+		UserName:    form.Get("user_name"),
+		ChannelID:   form.Get("channel_id"),
+		TeamID:      form.Get("team_id"),
+		ResponseURL: form.Get("response_url"),
+		TriggerID:   form.Get("trigger_id"),
+	}
+}
+
+// CommandResponse is a reply to a slash command, either in the HTTP ack or
+// POSTed to its response_url.  ResponseType is "ephemeral" (only the caller
+// sees it, the default) or "in_channel".
+type CommandResponse struct {
+	ResponseType    string `json:"response_type,omitempty"`
+	Text            string `json:"text"`
+	ReplaceOriginal bool   `json:"replace_original,omitempty"`
+}
+
+// Respond posts a delayed reply to a slash command's response_url.  Slack
+// accepts up to five replies per invocation within 30 minutes.
+func (c *Client) Respond(ctx context.Context, responseURL string, r CommandResponse) error {
+	if strings.TrimSpace(responseURL) == "" {
+		return errors.New("missing response_url")
+	}
+	b, _ := json.Marshal(r)
+	req, _ := http.NewRequestWithContext(ctx, "POST", responseURL, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	resp, err := c.Do(req, 15*time.Second)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	rb, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack response_url status=%d body=%s", resp.StatusCode, preview(string(rb), 400))
+	}
+	return nil
+}
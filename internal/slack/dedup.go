@@ -0,0 +1,159 @@
+package slack
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+const dedupMigrateSQL = `
+CREATE TABLE IF NOT EXISTS slack_events (
+    key        TEXT        PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS slack_events_expires_at ON slack_events (expires_at);
+`
+
+// EventDedup remembers which Slack deliveries were already accepted so that
+// redeliveries (X-Slack-Retry-Num, Socket Mode retry_attempt, or the same
+// message arriving over both transports) are processed once.  Keys are the
+// event_id and the message's channel/ts; entries expire after the TTL.
+//
+// When TELEMETRY_DB_URL is set the keys are also claimed in Postgres, so a
+// retry that lands on another replica — or on the pod that replaced the one
+// restarted mid-answer — is still recognised.  A nil *EventDedup accepts
+// everything.
+type EventDedup struct {
+	ttl time.Duration
+	db  *sql.DB
+
+	mu        sync.Mutex
+	seen      map[string]time.Time // key → expiry
+	lastSweep time.Time
+}
+
+// NewEventDedup builds the dedup store from SLACK_DEDUP_TTL.  It returns nil
+// when the TTL is 0.  A Postgres failure only disables the shared layer.
+func NewEventDedup(cfg config.Config) *EventDedup {
+	if cfg.SlackDedupTTL <= 0 {
+		return nil
+	}
+	d := &EventDedup{ttl: cfg.SlackDedupTTL, seen: make(map[string]time.Time), lastSweep: time.Now()}
+	if cfg.TelemetryDBURL != "" {
+		d.db = openDedupDB(cfg.TelemetryDBURL)
+	}
+	log.Printf("[BOOT] Slack event dedup ttl=%s persistent=%t", d.ttl, d.db != nil)
+	return d
+}
+
+func openDedupDB(dsn string) *sql.DB {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		log.Printf("[SLACK][dedup] open failed: %v — using memory only", err)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		log.Printf("[SLACK][dedup] ping failed: %v — using memory only", err)
+		_ = db.Close()
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, dedupMigrateSQL); err != nil {
+		log.Printf("[SLACK][dedup] migrate failed: %v — using memory only", err)
+		_ = db.Close()
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, `DELETE FROM slack_events WHERE expires_at < now()`); err != nil {
+		log.Printf("[SLACK][dedup] purge failed: %v", err)
+	}
+	return db
+}
+
+// EventKey is the dedup key of an event_id, or "" (no dedup) when the
+// delivery carries none.
+func EventKey(eventID string) string {
+	if eventID == "" {
+		return ""
+	}
+	return "event:" + eventID
+}
+
+// MessageKey is the dedup key of a message, independent of the event that
+// delivered it; "" when the message cannot be identified.
+func MessageKey(channel, ts string) string {
+	if channel == "" || ts == "" {
+		return ""
+	}
+	return "msg:" + channel + ":" + ts
+}
+
+// ReactionKey is the dedup key of a reaction workflow run on a message.
+// scope narrows it (e.g. to the reacting user) for workflows that run once
+// per person rather than once per message.
+func ReactionKey(channel, ts, action, scope string) string {
+	return "reaction:" + channel + ":" + ts + ":" + action + ":" + scope
+}
+
+// Claim records key and reports whether it is new.  A false result means the
+// delivery is a duplicate — in flight or finished — and must be dropped.
+// Empty keys are always new.  Postgres errors fail open: a rare duplicate
+// answer beats a lost one.
+func (d *EventDedup) Claim(ctx context.Context, key string) bool {
+	if d == nil || key == "" {
+		return true
+	}
+	now := time.Now()
+	d.mu.Lock()
+	d.sweep(now)
+	if exp, ok := d.seen[key]; ok && now.Before(exp) {
+		d.mu.Unlock()
+		return false
+	}
+	d.seen[key] = now.Add(d.ttl)
+	d.mu.Unlock()
+
+	if d.db == nil {
+		return true
+	}
+	dbCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	res, err := d.db.ExecContext(dbCtx, `
+INSERT INTO slack_events (key, expires_at) VALUES ($1, $2)
+ON CONFLICT (key) DO UPDATE SET expires_at = EXCLUDED.expires_at
+WHERE slack_events.expires_at < now()`, key, now.Add(d.ttl))
+	if err != nil {
+		log.Printf("[SLACK][dedup] claim failed: %v", err)
+		return true
+	}
+	n, err := res.RowsAffected()
+	return err != nil || n > 0
+}
+
+// sweep drops expired keys, at most once per TTL.  Callers hold d.mu.
+func (d *EventDedup) sweep(now time.Time) {
+	if now.Sub(d.lastSweep) < d.ttl {
+		return
+	}
+	d.lastSweep = now
+	for k, exp := range d.seen {
+		if !now.Before(exp) {
+			delete(d.seen, k)
+		}
+	}
+	if d.db != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if _, err := d.db.ExecContext(ctx, `DELETE FROM slack_events WHERE expires_at < now()`); err != nil {
+				log.Printf("[SLACK][dedup] purge failed: %v", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,58 @@
+package slack
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+func TestDedupKeys(t *testing.T) {
+	for got, want := range map[string]string{
+		EventKey("Ev123"):                      "event:Ev123",
+		EventKey(""):                           "",
+		MessageKey("C1", "1.2"):                "msg:C1:1.2",
+		MessageKey("C1", ""):                   "",
+		MessageKey("", "1.2"):                  "",
+		ReactionKey("C1", "1.2", "jira", "U1"): "reaction:C1:1.2:jira:U1",
+	} {
+		if got != want {
+			t.Errorf("key = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestEventDedupClaim(t *testing.T) {
+	ctx := context.Background()
+	d := NewEventDedup(config.Config{SlackDedupTTL: time.Hour})
+
+	if !d.Claim(ctx, EventKey("Ev1")) {
+		t.Fatal("first delivery rejected")
+	}
+	if d.Claim(ctx, EventKey("Ev1")) {
+		t.Error("redelivery accepted")
+	}
+	// Deliveries without an event_id are never deduplicated against each other.
+	for i := 0; i < 2; i++ {
+		if !d.Claim(ctx, EventKey("")) {
+			t.Errorf("delivery %d without event_id rejected", i)
+		}
+	}
+
+	// Expired keys are claimable again.
+	d.mu.Lock()
+	d.seen[EventKey("Ev1")] = time.Now().Add(-time.Second)
+	d.mu.Unlock()
+	if !d.Claim(ctx, EventKey("Ev1")) {
+		t.Error("expired key still rejected")
+	}
+
+	var off *EventDedup
+	if !off.Claim(ctx, EventKey("Ev1")) || !off.Claim(ctx, EventKey("Ev1")) {
+		t.Error("nil dedup rejected a delivery")
+	}
+	if NewEventDedup(config.Config{}) != nil {
+		t.Error("TTL 0 should disable dedup")
+	}
+}
@@ -0,0 +1,461 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// User is a workspace member as kept by the UserDirectory.
+type User struct {
+	ID          string
+	Handle      string // "name", e.g. "fernanda.souza"
+	RealName    string
+	DisplayName string
+	Email       string // empty without the users:read.email scope
+	IsBot       bool
+	Deleted     bool
+}
+
+// Name returns the best human-readable name of the user.
+func (u User) Name() string {
+	switch {
+	case u.DisplayName != "":
+		return u.DisplayName
+	case u.RealName != "":
+		return u.RealName
+	}
+	return u.Handle
+}
+
+// apiUser is the user object of users.list, users.info, users.lookupByEmail
+// and the user_change/team_join events.
+type apiUser struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+	Deleted  bool   `json:"deleted"`
+	IsBot    bool   `json:"is_bot"`
+	Profile  struct {
+		RealName    string `json:"real_name"`
+		DisplayName string `json:"display_name"`
+		Email       string `json:"email"`
+	} `json:"profile"`
+}
+
+func (a apiUser) user() User {
+	realName := a.Profile.RealName
+	if realName == "" {
+		realName = a.RealName
+	}
+	return User{
+		ID:          a.ID,
+		Handle:      a.Name,
+		RealName:    strings.TrimSpace(realName),
+		DisplayName: strings.TrimSpace(a.Profile.DisplayName),
+		Email:       strings.ToLower(strings.TrimSpace(a.Profile.Email)),
+		IsBot:       a.IsBot || a.ID == "USLACKBOT",
+		Deleted:     a.Deleted,
+	}
+}
+
+// UserDirectory is an in-memory index of the workspace members by ID, handle
+// and email.  It is filled by users.list (RefreshUsers), kept current by
+// user_change/team_join events and also caches single users.info lookups,
+// so mention resolution rarely reaches the API.
+type UserDirectory struct {
+	mu      sync.RWMutex
+	byID    map[string]User
+	byLogin map[string]string // handle → ID
+	byEmail map[string]string // email → ID
+	loaded  time.Time
+}
+
+// NewUserDirectory returns an empty directory.
+func NewUserDirectory() *UserDirectory {
+	return &UserDirectory{
+		byID:    make(map[string]User),
+		byLogin: make(map[string]string),
+		byEmail: make(map[string]string),
+	}
+}
+
+// Put adds or replaces a user.
+func (d *UserDirectory) Put(u User) {
+	if u.ID == "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.putLocked(u)
+}
+
+func (d *UserDirectory) putLocked(u User) {
+	if old, ok := d.byID[u.ID]; ok {
+		delete(d.byLogin, strings.ToLower(old.Handle))
+		delete(d.byEmail, old.Email)
+	}
+	d.byID[u.ID] = u
+	if u.Handle != "" {
+		d.byLogin[strings.ToLower(u.Handle)] = u.ID
+	}
+	if u.Email != "" {
+		d.byEmail[u.Email] = u.ID
+	}
+}
+
+// replace swaps the whole index for a fresh users.list snapshot.
+func (d *UserDirectory) replace(users []User) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.byID = make(map[string]User, len(users))
+	d.byLogin = make(map[string]string, len(users))
+	d.byEmail = make(map[string]string, len(users))
+	for _, u := range users {
+		d.putLocked(u)
+	}
+	d.loaded = time.Now()
+}
+
+// Loaded reports whether a full users.list snapshot has been indexed.
+func (d *UserDirectory) Loaded() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return !d.loaded.IsZero()
+}
+
+// Len returns the number of indexed users.
+func (d *UserDirectory) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.byID)
+}
+
+// ByID returns the user with the given ID.
+func (d *UserDirectory) ByID(id string) (User, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	u, ok := d.byID[strings.TrimSpace(id)]
+	return u, ok
+}
+
+// ByHandle returns the user with the given handle ("@" optional).
+func (d *UserDirectory) ByHandle(handle string) (User, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	id, ok := d.byLogin[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))]
+	if !ok {
+		return User{}, false
+	}
+	return d.byID[id], true
+}
+
+// ByEmail returns the user with the given email (case-insensitive).
+func (d *UserDirectory) ByEmail(email string) (User, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	id, ok := d.byEmail[strings.ToLower(strings.TrimSpace(email))]
+	if !ok {
+		return User{}, false
+	}
+	return d.byID[id], true
+}
+
+// FindByName returns the active, non-bot users that best match name, which
+// may be a handle, a display name, a real name or just a first name
+// ("Fernanda").  Case and accents are ignored.  Exact matches win over
+// first-name matches, which win over word-prefix matches; callers should
+// only trust a single result.
+func (d *UserDirectory) FindByName(name string) []User {
+	q := foldName(strings.TrimPrefix(strings.TrimSpace(name), "@"))
+	if q == "" {
+		return nil
+	}
+	qWords := strings.Fields(q)
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	best, bestScore := []User(nil), 0
+	for _, u := range d.byID {
+		if u.Deleted || u.IsBot {
+			continue
+		}
+		score := 0
+		for _, cand := range []string{u.Handle, u.DisplayName, u.RealName} {
+			if s := nameScore(qWords, q, foldName(cand)); s > score {
+				score = s
+			}
+		}
+		switch {
+		case score == 0 || score < bestScore:
+		case score > bestScore:
+			best, bestScore = []User{u}, score
+		default:
+			best = append(best, u)
+		}
+	}
+	sort.Slice(best, func(i, j int) bool { return best[i].ID < best[j].ID })
+	return best
+}
+
+// nameScore rates how well the folded query matches a folded name: 3 for
+// the whole name, 2 for its leading words ("fernanda" in "fernanda souza"),
+// 1 when every query word prefixes some word of the name.
+func nameScore(qWords []string, q, name string) int {
+	if name == "" {
+		return 0
+	}
+	if name == q {
+		return 3
+	}
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == ' ' || r == '.' || r == '_' || r == '-' })
+	if len(words) >= len(qWords) && strings.Join(words[:len(qWords)], " ") == strings.Join(qWords, " ") {
+		return 2
+	}
+	for _, qw := range qWords {
+		found := false
+		for _, w := range words {
+			if strings.HasPrefix(w, qw) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0
+		}
+	}
+	return 1
+}
+
+var accentFolder = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// foldName lowercases s, removes accents and collapses spaces.
+func foldName(s string) string {
+	return strings.Join(strings.Fields(accentFolder.Replace(strings.ToLower(s))), " ")
+}
+
+// ApplyEvent updates the directory from a user_change or team_join event.
+func (d *UserDirectory) ApplyEvent(event json.RawMessage) error {
+	var ev struct {
+		Type string  `json:"type"`
+		User apiUser `json:"user"`
+	}
+	if err := json.Unmarshal(event, &ev); err != nil {
+		return err
+	}
+	if ev.User.ID == "" {
+		return fmt.Errorf("%s event without user", ev.Type)
+	}
+	d.Put(ev.User.user())
+	log.Printf("[SLACK] user directory %s user=%s handle=%q", ev.Type, ev.User.ID, ev.User.Name)
+	return nil
+}
+
+// userTokens returns the tokens used for users.* calls in the workspace ctx
+// is scoped to, user token first.
+func (c *Client) userTokens(ctx context.Context) []string {
+	var tokens []string
+	if t := c.userToken(ctx); t != "" {
+		tokens = append(tokens, t)
+	}
+	if t := c.botToken(ctx); t != "" {
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+// RefreshUsers reloads the whole directory with users.list.  It requires
+// users:read on one of the tokens; emails also need users:read.email.
+func (c *Client) RefreshUsers(ctx context.Context) error {
+	tokens := c.userTokens(ctx)
+	if len(tokens) == 0 {
+		return errors.New("missing Slack token")
+	}
+	var lastErr error
+	for _, token := range tokens {
+		users, err := c.listUsers(ctx, token)
+		if err != nil {
+			lastErr = err
+			log.Printf("[SLACK] users.list failed: %v — trying next token", err)
+			continue
+		}
+		c.Users.replace(users)
+		log.Printf("[SLACK] user directory loaded users=%d", len(users))
+		return nil
+	}
+	return lastErr
+}
+
+func (c *Client) listUsers(ctx context.Context, token string) ([]User, error) {
+	var users []User
+	cursor := ""
+	for {
+		u := fmt.Sprintf("%s/users.list?limit=200", c.APIBaseURL)
+		if cursor != "" {
+			u += "&cursor=" + url.QueryEscape(cursor)
+		}
+		req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := c.Do(req, 20*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		rb, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("slack status=%d body=%s", resp.StatusCode, preview(string(rb), 300))
+		}
+		var out struct {
+			OK       bool      `json:"ok"`
+			Error    string    `json:"error"`
+			Members  []apiUser `json:"members"`
+			Metadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		if err := json.Unmarshal(rb, &out); err != nil {
+			return nil, err
+		}
+		if !out.OK {
+			return nil, fmt.Errorf("users.list error: %s", out.Error)
+		}
+		for _, m := range out.Members {
+			users = append(users, m.user())
+		}
+		cursor = out.Metadata.NextCursor
+		if cursor == "" {
+			return users, nil
+		}
+	}
+}
+
+// RunUserDirectory loads the directory and refreshes it every interval until
+// ctx is cancelled.  Failed loads are retried on the next tick; lookups fall
+// back to the API meanwhile.
+func (c *Client) RunUserDirectory(ctx context.Context, interval time.Duration) {
+	refresh := func() {
+		rctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		defer cancel()
+		if err := c.RefreshUsers(rctx); err != nil {
+			log.Printf("[SLACK] user directory refresh failed (add users:read scope to fix): %v", err)
+		}
+	}
+	refresh()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refresh()
+		}
+	}
+}
+
+// GetUser returns a user by ID from the directory, falling back to
+// users.info (whose result is then cached).
+func (c *Client) GetUser(ctx context.Context, userID string) (User, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return User{}, errors.New("empty user id")
+	}
+	if u, ok := c.Users.ByID(userID); ok {
+		return u, nil
+	}
+	u, err := c.fetchUser(ctx, "users.info?user="+url.QueryEscape(userID))
+	if err != nil {
+		return User{}, err
+	}
+	if c.DefaultTeam(ctx) {
+		c.Users.Put(u)
+	}
+	return u, nil
+}
+
+// GetUserByEmail returns the user with the given email from the directory,
+// falling back to users.lookupByEmail (users:read.email scope).
+func (c *Client) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return User{}, errors.New("empty email")
+	}
+	// Emails repeat across workspaces; the directory is the env tokens' one.
+	if u, ok := c.Users.ByEmail(email); ok && c.DefaultTeam(ctx) {
+		return u, nil
+	}
+	u, err := c.fetchUser(ctx, "users.lookupByEmail?email="+url.QueryEscape(email))
+	if err != nil {
+		return User{}, err
+	}
+	if c.DefaultTeam(ctx) {
+		c.Users.Put(u)
+	}
+	return u, nil
+}
+
+// FindUsersByName returns the users matching name (see
+// UserDirectory.FindByName).  It is empty until the directory is loaded.
+func (c *Client) FindUsersByName(name string) []User {
+	return c.Users.FindByName(name)
+}
+
+// fetchUser calls a users.* method that returns a single user, trying the
+// user token and then the bot token.
+func (c *Client) fetchUser(ctx context.Context, method string) (User, error) {
+	tokens := c.userTokens(ctx)
+	if len(tokens) == 0 {
+		return User{}, errors.New("missing slack token")
+	}
+	name, _, _ := strings.Cut(method, "?")
+	var lastErr error
+	for _, token := range tokens {
+		req, _ := http.NewRequestWithContext(ctx, "GET", c.APIBaseURL+"/"+method, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := c.Do(req, 10*time.Second)
+		if err != nil {
+			return User{}, err
+		}
+		rb, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return User{}, fmt.Errorf("slack status=%d body=%s", resp.StatusCode, preview(string(rb), 300))
+		}
+		var out struct {
+			OK    bool    `json:"ok"`
+			Error string  `json:"error"`
+			User  apiUser `json:"user"`
+		}
+		if err := json.Unmarshal(rb, &out); err != nil {
+			return User{}, err
+		}
+		if !out.OK {
+			lastErr = fmt.Errorf("%s error: %s", name, out.Error)
+			// A missing scope on one token may be granted on the other.
+			if out.Error == "missing_scope" || out.Error == "not_allowed_token_type" {
+				continue
+			}
+			return User{}, lastErr
+		}
+		if strings.TrimSpace(out.User.Name) == "" {
+			return User{}, fmt.Errorf("%s returned empty name", name)
+		}
+		return out.User.user(), nil
+	}
+	return User{}, lastErr
+}
@@ -1,6 +1,7 @@
 package slack
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -18,20 +19,21 @@ type File struct {
 	Mimetype           string `json:"mimetype"`
 	Filetype           string `json:"filetype"`
 	Size               int64  `json:"size"`
+	DurationMs         int64  `json:"duration_ms"` // audio and video only
 	URLPrivateDownload string `json:"url_private_download"`
 	ExternalURL        string `json:"external_url"`
 }
 
 // GetThreadFiles fetches all file attachments from the messages of a Slack
 // thread and returns them deduplicated by file ID.
-func (c *Client) GetThreadFiles(channel, threadTs string) ([]File, error) {
-	if c.BotToken == "" {
+func (c *Client) GetThreadFiles(ctx context.Context, channel, threadTs string) ([]File, error) {
+	if c.botToken(ctx) == "" {
 		return nil, errors.New("missing Slack bot token")
 	}
 
 	u := fmt.Sprintf("%s/conversations.replies?channel=%s&ts=%s&limit=200", c.APIBaseURL, url.QueryEscape(channel), url.QueryEscape(threadTs))
-	req, _ := http.NewRequest("GET", u, nil)
-	req.Header.Set("Authorization", "Bearer "+c.BotToken)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+	req.Header.Set("Authorization", "Bearer "+c.botToken(ctx))
 	resp, err := c.Do(req, 15*time.Second)
 	if err != nil {
 		return nil, err
@@ -72,18 +74,18 @@ func (c *Client) GetThreadFiles(channel, threadTs string) ([]File, error) {
 // the bot token. If the response is HTML (Slack login redirect), the token
 // lacks files:read scope, and an error is returned.
 // The caller is responsible for enforcing size limits.
-func (c *Client) DownloadFile(urlPrivate string) ([]byte, error) {
+func (c *Client) DownloadFile(ctx context.Context, urlPrivate string) ([]byte, error) {
 	urlPrivate = strings.TrimSpace(urlPrivate)
 	if urlPrivate == "" {
 		return nil, errors.New("empty file URL")
 	}
 
 	var tokens []string
-	if c.UserToken != "" {
-		tokens = append(tokens, c.UserToken)
+	if t := c.userToken(ctx); t != "" {
+		tokens = append(tokens, t)
 	}
-	if c.BotToken != "" {
-		tokens = append(tokens, c.BotToken)
+	if t := c.botToken(ctx); t != "" {
+		tokens = append(tokens, t)
 	}
 	if len(tokens) == 0 {
 		return nil, errors.New("missing Slack token")
@@ -91,7 +93,7 @@ func (c *Client) DownloadFile(urlPrivate string) ([]byte, error) {
 
 	var lastErr error
 	for _, token := range tokens {
-		req, err := http.NewRequest("GET", urlPrivate, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", urlPrivate, nil)
 		if err != nil {
 			return nil, fmt.Errorf("build download request: %w", err)
 		}
@@ -0,0 +1,319 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HistoryMessage is one message of a channel's history as returned by
+// GetChannelMessages.  Thread replies carry the ThreadTs of their parent;
+// top-level messages leave it empty.
+type HistoryMessage struct {
+	Ts         string
+	ThreadTs   string
+	User       string
+	Text       string
+	ReplyCount int
+}
+
+// errHistoryTokenScope marks a history call the current token cannot make
+// (missing scope, not a member); the next token is tried.
+var errHistoryTokenScope = errors.New("token cannot read channel history")
+
+type historyRaw struct {
+	Subtype    string `json:"subtype,omitempty"`
+	User       string `json:"user,omitempty"`
+	Text       string `json:"text"`
+	Ts         string `json:"ts"`
+	ThreadTs   string `json:"thread_ts,omitempty"`
+	BotID      string `json:"bot_id,omitempty"`
+	ReplyCount int    `json:"reply_count,omitempty"`
+}
+
+type historyPage struct {
+	OK               bool         `json:"ok"`
+	Error            string       `json:"error"`
+	Messages         []historyRaw `json:"messages"`
+	HasMore          bool         `json:"has_more"`
+	ResponseMetadata struct {
+		NextCursor string `json:"next_cursor"`
+	} `json:"response_metadata"`
+}
+
+// GetChannelMessages reads a channel's history between oldest and latest,
+// following conversations.history cursors and fetching the replies of the
+// threads started in the period.  Messages from bots and system subtypes
+// (joins, topic changes...) are dropped and Text is flattened to one line
+// with mentions and links in plain text.  At most maxMessages are returned,
+// ordered by ts; truncated reports whether the cap was hit.  History pages
+// come newest first, so a truncated read keeps the most recent messages;
+// a quarter of the cap is reserved for thread replies so a busy channel
+// still shows its discussions.
+//
+// The user token can read channels the bot was never added to, so it is
+// only used when requesterID is a member of the channel; otherwise the
+// channel is read with the bot token alone.
+func (c *Client) GetChannelMessages(ctx context.Context, channelID, requesterID string, oldest, latest time.Time, maxMessages int) (msgs []HistoryMessage, truncated bool, err error) {
+	if maxMessages <= 0 {
+		maxMessages = 1000
+	}
+	var tokens []string
+	if t := c.userToken(ctx); t != "" {
+		if c.isChannelMember(ctx, channelID, requesterID) {
+			tokens = append(tokens, t)
+		} else {
+			log.Printf("[SEC] GetChannelMessages %s: requester %q is not a member — reading with the bot token only", channelID, requesterID)
+		}
+	}
+	if t := c.botToken(ctx); t != "" {
+		tokens = append(tokens, t)
+	}
+	if len(tokens) == 0 {
+		return nil, false, errors.New("missing Slack token")
+	}
+	for _, token := range tokens {
+		msgs, truncated, err = c.channelMessages(ctx, token, channelID, oldest, latest, maxMessages)
+		if errors.Is(err, errHistoryTokenScope) {
+			log.Printf("[SLACK] GetChannelMessages %s: %v — trying next token", channelID, err)
+			continue
+		}
+		return msgs, truncated, err
+	}
+	return nil, false, fmt.Errorf("GetChannelMessages %s: no token succeeded (bot not in the channel, or missing channels:history scope?)", channelID)
+}
+
+func (c *Client) channelMessages(ctx context.Context, token, channelID string, oldest, latest time.Time, maxMessages int) ([]HistoryMessage, bool, error) {
+	oldestTs := fmt.Sprintf("%.6f", float64(oldest.UnixNano())/1e9)
+	latestTs := fmt.Sprintf("%.6f", float64(latest.UnixNano())/1e9)
+	base := fmt.Sprintf("%s/conversations.history?channel=%s&oldest=%s&latest=%s&limit=200",
+		c.APIBaseURL, url.QueryEscape(channelID), oldestTs, latestTs)
+
+	seen := map[string]bool{}
+	var out []HistoryMessage
+	// add appends m and reports whether there is still room under limit.
+	add := func(m historyRaw, parent string, limit int) bool {
+		if seen[m.Ts] || !includeHistoryMessage(m) {
+			return len(out) < limit
+		}
+		seen[m.Ts] = true
+		out = append(out, HistoryMessage{
+			Ts:         m.Ts,
+			ThreadTs:   parent,
+			User:       m.User,
+			Text:       cleanSlackTextMax(m.Text, 1500),
+			ReplyCount: m.ReplyCount,
+		})
+		return len(out) < limit
+	}
+
+	// Top-level messages stop at historyCap; replies use what is left.
+	historyCap := maxMessages - maxMessages/4
+	truncated := false
+	var parents []string
+	cursor := ""
+history:
+	for {
+		u := base
+		if cursor != "" {
+			u += "&cursor=" + url.QueryEscape(cursor)
+		}
+		page, err := c.historyGet(ctx, token, u)
+		if err != nil {
+			return nil, false, fmt.Errorf("conversations.history: %w", err)
+		}
+		for _, m := range page.Messages {
+			if m.ReplyCount > 0 {
+				parents = append(parents, m.Ts)
+			}
+			if !add(m, "", historyCap) {
+				truncated = true
+				break history
+			}
+		}
+		cursor = strings.TrimSpace(page.ResponseMetadata.NextCursor)
+		if !page.HasMore || cursor == "" {
+			break
+		}
+	}
+
+	latestSec := latest.Unix()
+	for _, parent := range parents {
+		cursor := ""
+		for {
+			u := fmt.Sprintf("%s/conversations.replies?channel=%s&ts=%s&limit=200",
+				c.APIBaseURL, url.QueryEscape(channelID), url.QueryEscape(parent))
+			if cursor != "" {
+				u += "&cursor=" + url.QueryEscape(cursor)
+			}
+			page, err := c.historyGet(ctx, token, u)
+			if err != nil {
+				// A single unreadable thread should not sink the digest.
+				log.Printf("[WARN] conversations.replies %s ts=%s: %v", channelID, parent, err)
+				break
+			}
+			for _, m := range page.Messages {
+				if m.Ts == parent {
+					continue // the root is already in the channel history
+				}
+				if sec, _ := parseSlackTs(m.Ts); sec >= latestSec {
+					continue
+				}
+				if !add(m, parent, maxMessages) {
+					return sortHistory(out), true, nil
+				}
+			}
+			cursor = strings.TrimSpace(page.ResponseMetadata.NextCursor)
+			if !page.HasMore || cursor == "" {
+				break
+			}
+		}
+	}
+	return sortHistory(out), truncated, nil
+}
+
+// isChannelMember reports whether userID is a member of channelID.  Any
+// failure counts as not a member.
+func (c *Client) isChannelMember(ctx context.Context, channelID, userID string) bool {
+	if strings.TrimSpace(userID) == "" {
+		return false
+	}
+	for _, token := range c.userTokens(ctx) {
+		member, err := c.channelHasMember(ctx, token, channelID, userID)
+		if err == nil {
+			return member
+		}
+		log.Printf("[SLACK] conversations.members %s: %v", channelID, err)
+	}
+	return false
+}
+
+// channelHasMember pages conversations.members looking for userID.
+func (c *Client) channelHasMember(ctx context.Context, token, channelID, userID string) (bool, error) {
+	cursor := ""
+	for {
+		u := fmt.Sprintf("%s/conversations.members?channel=%s&limit=1000", c.APIBaseURL, url.QueryEscape(channelID))
+		if cursor != "" {
+			u += "&cursor=" + url.QueryEscape(cursor)
+		}
+		req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := c.Do(req, 20*time.Second)
+		if err != nil {
+			return false, err
+		}
+		rb, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		var page struct {
+			OK               bool     `json:"ok"`
+			Error            string   `json:"error"`
+			Members          []string `json:"members"`
+			ResponseMetadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		if err := json.Unmarshal(rb, &page); err != nil {
+			return false, err
+		}
+		if !page.OK {
+			return false, fmt.Errorf("slack error: %s", page.Error)
+		}
+		for _, m := range page.Members {
+			if m == userID {
+				return true, nil
+			}
+		}
+		cursor = strings.TrimSpace(page.ResponseMetadata.NextCursor)
+		if cursor == "" {
+			return false, nil
+		}
+	}
+}
+
+// historyGet fetches one page of conversations.history or
+// conversations.replies.  Scope errors wrap errHistoryTokenScope.
+func (c *Client) historyGet(ctx context.Context, token, u string) (historyPage, error) {
+	var page historyPage
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.Do(req, 20*time.Second)
+	if err != nil {
+		return page, err
+	}
+	rb, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode == 429 {
+		return page, fmt.Errorf("rate_limited retry_after=%s", resp.Header.Get("Retry-After"))
+	}
+	if resp.StatusCode >= 300 {
+		return page, fmt.Errorf("slack status=%d body=%s", resp.StatusCode, preview(string(rb), 400))
+	}
+	if err := json.Unmarshal(rb, &page); err != nil {
+		return page, err
+	}
+	if !page.OK {
+		if page.Error == "missing_scope" || page.Error == "not_in_channel" {
+			return page, fmt.Errorf("%w: %s", errHistoryTokenScope, page.Error)
+		}
+		return page, fmt.Errorf("slack error: %s", page.Error)
+	}
+	return page, nil
+}
+
+// includeHistoryMessage keeps human messages, file shares and replies
+// broadcast to the channel.
+func includeHistoryMessage(m historyRaw) bool {
+	if m.BotID != "" || strings.TrimSpace(m.Text) == "" {
+		return false
+	}
+	switch m.Subtype {
+	case "", "thread_broadcast", "file_share":
+		return true
+	}
+	return false
+}
+
+func sortHistory(msgs []HistoryMessage) []HistoryMessage {
+	sort.SliceStable(msgs, func(i, j int) bool {
+		aSec, aMicro := parseSlackTs(msgs[i].Ts)
+		bSec, bMicro := parseSlackTs(msgs[j].Ts)
+		if aSec != bSec {
+			return aSec < bSec
+		}
+		return aMicro < bMicro
+	})
+	return msgs
+}
+
+// WorkspaceURL returns the workspace's base URL (https://acme.slack.com),
+// taken from the permalink of a message in channel.  Combined with
+// MessageURL it links many messages with a single API call.
+func (c *Client) WorkspaceURL(ctx context.Context, channel, messageTs string) (string, error) {
+	link, err := c.GetPermalink(ctx, channel, messageTs)
+	if err != nil {
+		return "", err
+	}
+	base, _, ok := strings.Cut(link, "/archives/")
+	if !ok {
+		return "", fmt.Errorf("unexpected permalink format: %s", link)
+	}
+	return base, nil
+}
+
+// MessageURL builds the permalink of a message from the workspace URL.
+// Replies link into their thread.
+func MessageURL(workspaceURL, channel, ts, threadTs string) string {
+	u := fmt.Sprintf("%s/archives/%s/p%s", workspaceURL, channel, strings.ReplaceAll(ts, ".", ""))
+	if threadTs != "" && threadTs != ts {
+		u += "?thread_ts=" + threadTs + "&cid=" + channel
+	}
+	return u
+}
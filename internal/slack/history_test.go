@@ -0,0 +1,107 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// historyServer fakes conversations.members, .history and .replies for
+// channel C1: history holds top-level messages 100..91 (newest first), the
+// newest of which has three replies, and U1 is the only member.
+type historyServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	historyAuth []string
+}
+
+func newHistoryServer(t *testing.T) *historyServer {
+	t.Helper()
+	s := &historyServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body any
+		switch r.URL.Path {
+		case "/conversations.members":
+			body = map[string]any{"ok": true, "members": []string{"U1", "UBOT"}}
+		case "/conversations.history":
+			s.mu.Lock()
+			s.historyAuth = append(s.historyAuth, r.Header.Get("Authorization"))
+			s.mu.Unlock()
+			var msgs []historyRaw
+			for i := 100; i > 90; i-- {
+				m := historyRaw{User: "U1", Text: fmt.Sprintf("msg %d", i), Ts: fmt.Sprintf("%d.000000", i)}
+				if i == 100 {
+					m.ReplyCount = 3
+				}
+				msgs = append(msgs, m)
+			}
+			body = map[string]any{"ok": true, "messages": msgs}
+		case "/conversations.replies":
+			msgs := []historyRaw{{User: "U1", Text: "msg 100", Ts: "100.000000"}}
+			for i := 1; i <= 3; i++ {
+				msgs = append(msgs, historyRaw{User: "U2", Text: fmt.Sprintf("reply %d", i), Ts: fmt.Sprintf("10%d.000000", i), ThreadTs: "100.000000"})
+			}
+			body = map[string]any{"ok": true, "messages": msgs}
+		default:
+			body = map[string]any{"ok": false, "error": "unknown_method"}
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestGetChannelMessagesUsesUserTokenOnlyForMembers(t *testing.T) {
+	srv := newHistoryServer(t)
+	c := &Client{APIBaseURL: srv.URL, BotToken: "xoxb", UserToken: "xoxp"}
+	oldest, latest := time.Unix(0, 0), time.Unix(1000, 0)
+
+	for _, tt := range []struct {
+		requester, want string
+	}{
+		{"U1", "Bearer xoxp"},
+		{"U9", "Bearer xoxb"},
+		{"", "Bearer xoxb"},
+	} {
+		srv.historyAuth = nil
+		if _, _, err := c.GetChannelMessages(context.Background(), "C1", tt.requester, oldest, latest, 100); err != nil {
+			t.Fatalf("requester %q: %v", tt.requester, err)
+		}
+		if len(srv.historyAuth) == 0 || srv.historyAuth[0] != tt.want {
+			t.Errorf("requester %q read history with %v, want %s", tt.requester, srv.historyAuth, tt.want)
+		}
+	}
+}
+
+func TestGetChannelMessagesReservesRoomForReplies(t *testing.T) {
+	srv := newHistoryServer(t)
+	c := &Client{APIBaseURL: srv.URL, BotToken: "xoxb"}
+
+	msgs, truncated, err := c.GetChannelMessages(context.Background(), "C1", "U1", time.Unix(0, 0), time.Unix(1000, 0), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !truncated {
+		t.Error("truncated = false, want true")
+	}
+	var top, replies []string
+	for _, m := range msgs {
+		if m.ThreadTs == "" {
+			top = append(top, m.Text)
+		} else {
+			replies = append(replies, m.Text)
+		}
+	}
+	// 8 - 8/4 = 6 top-level messages, the most recent ones, then replies.
+	if len(top) != 6 || top[0] != "msg 95" || top[5] != "msg 100" {
+		t.Errorf("top-level messages = %v, want msg 95..msg 100", top)
+	}
+	if len(replies) != 2 {
+		t.Errorf("replies = %v, want 2 of the thread's replies", replies)
+	}
+}
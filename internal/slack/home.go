@@ -0,0 +1,33 @@
+package slack
+
+import "context"
+
+// AppHomeOpenedEvent is the "app_home_opened" event, sent each time a user
+// opens one of the app's tabs.  See https://api.slack.com/events/app_home_opened.
+type AppHomeOpenedEvent struct {
+	Type    string `json:"type"`
+	User    string `json:"user"`
+	Channel string `json:"channel"`
+	Tab     string `json:"tab"` // "home" or "messages"
+}
+
+// PublishHome publishes blocks as userID's App Home tab (views.publish).
+// It requires the Home Tab to be enabled in the app settings.
+func (c *Client) PublishHome(ctx context.Context, userID string, blocks []Block) error {
+	return c.postJSON(ctx, "views.publish", map[string]any{
+		"user_id": userID,
+		"view":    Block{"type": "home", "blocks": blocks},
+	}, nil)
+}
+
+// OpenDM opens (or reuses) the direct message channel between the bot and
+// userID and returns its ID (conversations.open, scope im:write).
+func (c *Client) OpenDM(ctx context.Context, userID string) (string, error) {
+	var out struct {
+		Channel struct {
+			ID string `json:"id"`
+		} `json:"channel"`
+	}
+	err := c.postJSON(ctx, "conversations.open", map[string]any{"users": userID}, &out)
+	return out.Channel.ID, err
+}
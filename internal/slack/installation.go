@@ -0,0 +1,187 @@
+package slack
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+const installationsSQL = `
+CREATE TABLE IF NOT EXISTS slack_installations (
+    team_id       TEXT        PRIMARY KEY,
+    team_name     TEXT        NOT NULL DEFAULT '',
+    enterprise_id TEXT        NOT NULL DEFAULT '',
+    bot_token     TEXT        NOT NULL,
+    bot_user_id   TEXT        NOT NULL DEFAULT '',
+    user_token    TEXT        NOT NULL DEFAULT '',
+    user_id       TEXT        NOT NULL DEFAULT '',
+    installed_by  TEXT        NOT NULL DEFAULT '',
+    installed_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+`
+
+// Installation is a workspace the app was installed in through the OAuth
+// flow, with the tokens Slack granted for it.
+type Installation struct {
+	TeamID       string
+	TeamName     string
+	EnterpriseID string
+	BotToken     string
+	BotUserID    string
+	// UserToken is the installer's user token (user scopes such as
+	// search:read); empty when no user scopes were requested.
+	UserToken   string
+	UserID      string // owner of UserToken
+	InstalledBy string
+	InstalledAt time.Time
+}
+
+// InstallationStore keeps installations in Postgres (TELEMETRY_DB_URL) or,
+// without a database, in memory.  Every installation is cached in memory:
+// the store is read on each Web API call to pick the workspace's tokens.
+type InstallationStore struct {
+	db *sql.DB
+
+	mu  sync.RWMutex
+	mem map[string]Installation
+}
+
+// NewInstallationStore opens the installations table and loads it.  A
+// Postgres failure falls back to memory, so installations are lost on
+// restart and workspaces must be reinstalled.
+func NewInstallationStore(cfg config.Config) *InstallationStore {
+	s := &InstallationStore{mem: make(map[string]Installation)}
+	if cfg.TelemetryDBURL != "" {
+		s.db = openInstallationsDB(cfg.TelemetryDBURL)
+	}
+	if s.db != nil {
+		if err := s.load(); err != nil {
+			log.Printf("[SLACK] load installations failed: %v", err)
+		}
+	}
+	log.Printf("[BOOT] slack installations persistent=%t workspaces=%d", s.db != nil, s.Len())
+	return s
+}
+
+func openInstallationsDB(dsn string) *sql.DB {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		log.Printf("[SLACK] installations open failed: %v — using memory only", err)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		log.Printf("[SLACK] installations ping failed: %v — using memory only", err)
+		_ = db.Close()
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, installationsSQL); err != nil {
+		log.Printf("[SLACK] installations migrate failed: %v — using memory only", err)
+		_ = db.Close()
+		return nil
+	}
+	return db
+}
+
+func (s *InstallationStore) load() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, `SELECT team_id, team_name, enterprise_id, bot_token, bot_user_id, user_token, user_id, installed_by, installed_at FROM slack_installations`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for rows.Next() {
+		var in Installation
+		if err := rows.Scan(&in.TeamID, &in.TeamName, &in.EnterpriseID, &in.BotToken, &in.BotUserID, &in.UserToken, &in.UserID, &in.InstalledBy, &in.InstalledAt); err != nil {
+			return err
+		}
+		s.mem[in.TeamID] = in
+	}
+	return rows.Err()
+}
+
+// Persistent reports whether installations survive a restart.
+func (s *InstallationStore) Persistent() bool { return s != nil && s.db != nil }
+
+// Len returns the number of installed workspaces.
+func (s *InstallationStore) Len() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.mem)
+}
+
+// Get returns the installation of teamID.
+func (s *InstallationStore) Get(teamID string) (Installation, bool) {
+	if s == nil || teamID == "" {
+		return Installation{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	in, ok := s.mem[teamID]
+	return in, ok
+}
+
+// List returns every installation.
+func (s *InstallationStore) List() []Installation {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Installation, 0, len(s.mem))
+	for _, in := range s.mem {
+		out = append(out, in)
+	}
+	return out
+}
+
+// Save stores (or replaces, on reinstall) an installation.
+func (s *InstallationStore) Save(ctx context.Context, in Installation) error {
+	if in.InstalledAt.IsZero() {
+		in.InstalledAt = time.Now()
+	}
+	if s.db != nil {
+		_, err := s.db.ExecContext(ctx, `
+INSERT INTO slack_installations (team_id, team_name, enterprise_id, bot_token, bot_user_id, user_token, user_id, installed_by, installed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (team_id) DO UPDATE SET
+    team_name = EXCLUDED.team_name, enterprise_id = EXCLUDED.enterprise_id,
+    bot_token = EXCLUDED.bot_token, bot_user_id = EXCLUDED.bot_user_id,
+    user_token = EXCLUDED.user_token, user_id = EXCLUDED.user_id,
+    installed_by = EXCLUDED.installed_by, installed_at = EXCLUDED.installed_at`,
+			in.TeamID, in.TeamName, in.EnterpriseID, in.BotToken, in.BotUserID, in.UserToken, in.UserID, in.InstalledBy, in.InstalledAt)
+		if err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	s.mem[in.TeamID] = in
+	s.mu.Unlock()
+	return nil
+}
+
+// Delete removes the installation of teamID (app_uninstalled).
+func (s *InstallationStore) Delete(ctx context.Context, teamID string) error {
+	if s.db != nil {
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM slack_installations WHERE team_id = $1`, teamID); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	delete(s.mem, teamID)
+	s.mu.Unlock()
+	return nil
+}
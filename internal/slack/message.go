@@ -2,6 +2,7 @@ package slack
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -19,6 +20,8 @@ import (
 type EventEnvelope struct {
 	Type      string          `json:"type"`
 	Challenge string          `json:"challenge,omitempty"`
+	EventID   string          `json:"event_id,omitempty"`
+	TeamID    string          `json:"team_id,omitempty"`
 	Event     json.RawMessage `json:"event,omitempty"`
 }
 
@@ -37,14 +40,21 @@ type MessageEvent struct {
 	Files     []File `json:"files,omitempty"`
 	// Message is populated for message_changed events (e.g., edits and tombstone deletions in DMs).
 	Message *InnerMessage `json:"message,omitempty"`
+	// PreviousMessage is the message before a message_changed event; link
+	// unfurls also send one, with the text unchanged.
+	PreviousMessage *InnerMessage `json:"previous_message,omitempty"`
 }
 
 // InnerMessage is the nested "message" object inside message_changed events.
 type InnerMessage struct {
-	Ts      string `json:"ts"`
-	Subtype string `json:"subtype,omitempty"`
-	Text    string `json:"text"`
-	Hidden  bool   `json:"hidden,omitempty"`
+	Ts       string `json:"ts"`
+	ThreadTs string `json:"thread_ts,omitempty"`
+	Subtype  string `json:"subtype,omitempty"`
+	Text     string `json:"text"`
+	User     string `json:"user,omitempty"`
+	BotID    string `json:"bot_id,omitempty"`
+	Hidden   bool   `json:"hidden,omitempty"`
+	Files    []File `json:"files,omitempty"`
 }
 
 // PostMessageRequest encapsulates the body of a chat.postMessage call.
@@ -57,14 +67,24 @@ type PostMessageRequest struct {
 // MessageTracker keeps a mapping from originTs (the user's triggering message)
 // to one or more bot reply timestamps so that when a user deletes their message,
 // the bot can delete ALL of its replies automatically (including multi-chunk long replies).
+//
+// It also remembers the cancel function of the request still being processed
+// for each origin message, so deleting the message stops in-flight LLM and SQL
+// work instead of letting it finish and post into a deleted thread.
 type MessageTracker struct {
-	mu   sync.RWMutex
-	data map[string][]string // key: channel+":"+originTs → []botTs
+	mu       sync.RWMutex
+	data     map[string][]string         // key: channel+":"+originTs → []botTs
+	inflight map[string]*inflightRequest // key: channel+":"+originTs
 }
 
+// inflightRequest is the cancel func of a request being processed.  A
+// pointer so done only forgets its own entry, not a newer request's for the
+// same message (an edit re-answering it).
+type inflightRequest struct{ cancel context.CancelFunc }
+
 // NewMessageTracker constructs an empty MessageTracker.
 func NewMessageTracker() *MessageTracker {
-	return &MessageTracker{data: make(map[string][]string)}
+	return &MessageTracker{data: make(map[string][]string), inflight: make(map[string]*inflightRequest)}
 }
 func key(channel, originTs string) string { return channel + ":" + originTs }
 
@@ -82,6 +102,38 @@ func (t *MessageTracker) Delete(channel, originTs string) {
 	t.mu.Unlock()
 }
 
+// Begin derives a cancellable context for processing the user message at
+// originTs.  The returned done func must be called once processing ends; it
+// releases the context and forgets the entry.
+func (t *MessageTracker) Begin(parent context.Context, channel, originTs string) (ctx context.Context, done func()) {
+	ctx, cancel := context.WithCancel(parent)
+	k := key(channel, originTs)
+	req := &inflightRequest{cancel: cancel}
+	t.mu.Lock()
+	t.inflight[k] = req
+	t.mu.Unlock()
+	return ctx, func() {
+		t.mu.Lock()
+		if t.inflight[k] == req {
+			delete(t.inflight, k)
+		}
+		t.mu.Unlock()
+		cancel()
+	}
+}
+
+// Cancel stops the in-flight processing of the user message at originTs, if
+// any, and reports whether there was one.
+func (t *MessageTracker) Cancel(channel, originTs string) bool {
+	t.mu.Lock()
+	req, ok := t.inflight[key(channel, originTs)]
+	t.mu.Unlock()
+	if ok {
+		req.cancel()
+	}
+	return ok
+}
+
 // Track appends botTs to the list of bot replies for the user message at originTs.
 // Multiple calls accumulate all reply timestamps so every chunk can be deleted.
 func (t *MessageTracker) Track(channel, originTs, botTs string) {
@@ -94,8 +146,8 @@ func (t *MessageTracker) Track(channel, originTs, botTs string) {
 // PostMessage posts a message to Slack.  A non-empty threadTs will cause
 // the message to be sent as a reply in the specified thread.  An error
 // is returned if the message could not be sent.
-func (c *Client) PostMessage(channel, threadTs, text string) error {
-	if c.BotToken == "" {
+func (c *Client) PostMessage(ctx context.Context, channel, threadTs, text string) error {
+	if c.botToken(ctx) == "" {
 		return errors.New("missing Slack bot token")
 	}
 
@@ -111,8 +163,8 @@ func (c *Client) PostMessage(channel, threadTs, text string) error {
 	}
 	b, _ := json.Marshal(payload)
 
-	req, _ := http.NewRequest("POST", c.APIBaseURL+"/chat.postMessage", bytes.NewReader(b))
-	req.Header.Set("Authorization", "Bearer "+c.BotToken)
+	req, _ := http.NewRequestWithContext(ctx, "POST", c.APIBaseURL+"/chat.postMessage", bytes.NewReader(b))
+	req.Header.Set("Authorization", "Bearer "+c.botToken(ctx))
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 	resp, err := c.Do(req, 15*time.Second)
 	if err != nil {
@@ -137,14 +189,14 @@ func (c *Client) PostMessage(channel, threadTs, text string) error {
 }
 
 // DeleteMessage deletes a message the bot posted via chat.delete.
-func (c *Client) DeleteMessage(channel, ts string) error {
-	if c.BotToken == "" {
+func (c *Client) DeleteMessage(ctx context.Context, channel, ts string) error {
+	if c.botToken(ctx) == "" {
 		return errors.New("missing Slack bot token")
 	}
 	payload := map[string]string{"channel": channel, "ts": ts}
 	b, _ := json.Marshal(payload)
-	req, _ := http.NewRequest("POST", c.APIBaseURL+"/chat.delete", bytes.NewReader(b))
-	req.Header.Set("Authorization", "Bearer "+c.BotToken)
+	req, _ := http.NewRequestWithContext(ctx, "POST", c.APIBaseURL+"/chat.delete", bytes.NewReader(b))
+	req.Header.Set("Authorization", "Bearer "+c.botToken(ctx))
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 	resp, err := c.Do(req, 10*time.Second)
 	if err != nil {
@@ -165,8 +217,8 @@ func (c *Client) DeleteMessage(channel, ts string) error {
 
 // PostMessageAndGetTS posts a message to Slack and returns the timestamp
 // of the posted message.  It is used to get a handle for later updates.
-func (c *Client) PostMessageAndGetTS(channel, threadTs, text string) (string, error) {
-	if c.BotToken == "" {
+func (c *Client) PostMessageAndGetTS(ctx context.Context, channel, threadTs, text string) (string, error) {
+	if c.botToken(ctx) == "" {
 		return "", errors.New("missing Slack bot token")
 	}
 
@@ -182,8 +234,8 @@ func (c *Client) PostMessageAndGetTS(channel, threadTs, text string) (string, er
 	}
 	b, _ := json.Marshal(payload)
 
-	req, _ := http.NewRequest("POST", c.APIBaseURL+"/chat.postMessage", bytes.NewReader(b))
-	req.Header.Set("Authorization", "Bearer "+c.BotToken)
+	req, _ := http.NewRequestWithContext(ctx, "POST", c.APIBaseURL+"/chat.postMessage", bytes.NewReader(b))
+	req.Header.Set("Authorization", "Bearer "+c.botToken(ctx))
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 
 	resp, err := c.Do(req, 15*time.Second)
@@ -208,8 +260,8 @@ func (c *Client) PostMessageAndGetTS(channel, threadTs, text string) (string, er
 
 // UpdateMessage updates an existing Slack message in-place.  It is used
 // to replace the placeholder with the actual answer.
-func (c *Client) UpdateMessage(channel, ts, text string) error {
-	if c.BotToken == "" {
+func (c *Client) UpdateMessage(ctx context.Context, channel, ts, text string) error {
+	if c.botToken(ctx) == "" {
 		return errors.New("missing Slack bot token")
 	}
 
@@ -224,8 +276,8 @@ func (c *Client) UpdateMessage(channel, ts, text string) error {
 		"text":    text,
 	}
 	b, _ := json.Marshal(payload)
-	req, _ := http.NewRequest("POST", c.APIBaseURL+"/chat.update", bytes.NewReader(b))
-	req.Header.Set("Authorization", "Bearer "+c.BotToken)
+	req, _ := http.NewRequestWithContext(ctx, "POST", c.APIBaseURL+"/chat.update", bytes.NewReader(b))
+	req.Header.Set("Authorization", "Bearer "+c.botToken(ctx))
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 
 	resp, err := c.Do(req, 15*time.Second)
@@ -0,0 +1,252 @@
+package slack
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Web API rate-limit tiers, in requests per minute.
+// See https://api.slack.com/apis/rate-limits.
+const (
+	tier2 = 20
+	tier3 = 50
+	tier4 = 100
+	// tierPost approximates the "one message per second" special limit of
+	// chat.postMessage.
+	tierPost = 60
+)
+
+// methodTiers maps the Web API methods Jarvis calls to their tier.  Methods
+// not listed use tier 3.
+var methodTiers = map[string]int{
+	"search.messages":       tier2,
+	"conversations.list":    tier2,
+	"users.list":            tier2,
+	"conversations.history": tier3,
+	"conversations.replies": tier3,
+	"conversations.info":    tier3,
+	"conversations.open":    tier3,
+	"chat.update":           tier3,
+	"chat.delete":           tier3,
+	"reactions.get":         tier3,
+	"users.lookupByEmail":   tier3,
+	"users.info":            tier4,
+	"files.info":            tier4,
+	"chat.getPermalink":     tier4,
+	"views.open":            tier4,
+	"views.update":          tier4,
+	"views.publish":         tier4,
+	"chat.postMessage":      tierPost,
+}
+
+// MethodStats are the rate-limit counters of one Web API method (or
+// "external" for response_url posts and file downloads).
+type MethodStats struct {
+	Requests    int64 `json:"requests"`
+	Throttled   int64 `json:"throttled"`    // requests delayed by the local limiter
+	ThrottledMs int64 `json:"throttled_ms"` // total delay added by the local limiter
+	RateLimited int64 `json:"rate_limited"` // 429 answers from Slack
+	Retries     int64 `json:"retries"`
+	Failures    int64 `json:"failures"` // retryable failures returned after the last attempt
+}
+
+// rateLimiter paces Web API calls with one token bucket per method and
+// keeps the per-method counters.
+type rateLimiter struct {
+	limits     map[string]int // per-minute overrides (SLACK_RATE_LIMITS)
+	maxRetries int
+	maxWait    time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	stats   map[string]*MethodStats
+}
+
+func newRateLimiter(limits map[string]int, maxRetries int, maxWait time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limits:     limits,
+		maxRetries: maxRetries,
+		maxWait:    maxWait,
+		buckets:    make(map[string]*bucket),
+		stats:      make(map[string]*MethodStats),
+	}
+}
+
+func (l *rateLimiter) bucket(method string) *bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[method]
+	if !ok {
+		perMinute, ok := l.limits[method]
+		if !ok {
+			perMinute = methodTiers[method]
+		}
+		if perMinute <= 0 {
+			perMinute = tier3
+		}
+		b = newBucket(perMinute)
+		l.buckets[method] = b
+	}
+	return b
+}
+
+// update changes the counters of method under the lock.
+func (l *rateLimiter) update(method string, fn func(*MethodStats)) {
+	if method == "" {
+		method = "external"
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	st, ok := l.stats[method]
+	if !ok {
+		st = &MethodStats{}
+		l.stats[method] = st
+	}
+	fn(st)
+}
+
+// wait blocks until method's bucket grants a request or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, method string) error {
+	d := l.bucket(method).reserve(time.Now())
+	if d <= 0 {
+		return nil
+	}
+	l.update(method, func(st *MethodStats) {
+		st.Throttled++
+		st.ThrottledMs += d.Milliseconds()
+	})
+	return sleepCtx(ctx, d)
+}
+
+// snapshot copies the counters.
+func (l *rateLimiter) snapshot() map[string]MethodStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[string]MethodStats, len(l.stats))
+	for m, st := range l.stats {
+		out[m] = *st
+	}
+	return out
+}
+
+// bucket is a token bucket refilled at the method's per-minute rate.  Its
+// capacity lets half a minute's worth of calls burst, as Slack tolerates.
+type bucket struct {
+	mu       sync.Mutex
+	rate     float64 // tokens per second
+	capacity float64
+	tokens   float64
+	last     time.Time
+	// blockedUntil holds every caller back after a 429 until Retry-After
+	// has elapsed.
+	blockedUntil time.Time
+}
+
+func newBucket(perMinute int) *bucket {
+	capacity := math.Max(1, float64(perMinute)/2)
+	return &bucket{rate: float64(perMinute) / 60, capacity: capacity, tokens: capacity, last: time.Now()}
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it.  Tokens may go negative: each reservation queues behind the
+// previous ones.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	if blocked := b.blockedUntil.Sub(now); blocked > wait {
+		wait = blocked
+	}
+	return wait
+}
+
+// pause holds the bucket until t and empties it, so calls resume slowly.
+func (b *bucket) pause(t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t.After(b.blockedUntil) {
+		b.blockedUntil = t
+	}
+	b.tokens = math.Min(b.tokens, 0)
+}
+
+// RateLimitStats returns the rate-limit counters per Web API method.
+func (c *Client) RateLimitStats() map[string]MethodStats {
+	if c.limiter == nil {
+		return map[string]MethodStats{}
+	}
+	return c.limiter.snapshot()
+}
+
+// apiMethod returns the Web API method of req ("chat.postMessage"), or ""
+// when req does not target the Web API (response_url, file downloads).
+func (c *Client) apiMethod(req *http.Request) string {
+	base := strings.TrimRight(c.APIBaseURL, "/") + "/"
+	u := req.URL.String()
+	if c.APIBaseURL == "" || !strings.HasPrefix(u, base) {
+		return ""
+	}
+	method, _, _ := strings.Cut(strings.TrimPrefix(u, base), "?")
+	return method
+}
+
+// retryAfter reads the Retry-After header of a 429 answer (1s when absent).
+func retryAfter(resp *http.Response) time.Duration {
+	if n, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After"))); err == nil && n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return time.Second
+}
+
+// backoff returns the jittered delay before retry number attempt+1.
+func backoff(attempt int) time.Duration {
+	d := 500 * time.Millisecond << attempt
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// retryRequest returns a copy of req whose body can be sent again, or nil
+// when the body cannot be replayed.
+func retryRequest(req *http.Request) *http.Request {
+	r := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return r
+	}
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	r.Body = body
+	return r
+}
+
+// fitsDeadline reports whether waiting d still leaves ctx time to retry.
+func fitsDeadline(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > d
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,261 @@
+package slack
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedServer answers each request with the next status in script
+// (repeating the last one) and records the request bodies.
+type scriptedServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	script []int
+	bodies []string
+}
+
+func newScriptedServer(t *testing.T, retryAfter string, script ...int) *scriptedServer {
+	t.Helper()
+	s := &scriptedServer{script: script}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		status := s.script[min(len(s.bodies), len(s.script)-1)]
+		s.bodies = append(s.bodies, string(b))
+		s.mu.Unlock()
+		if status == 0 {
+			// Drop the connection: a network error for the client.
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *scriptedServer) hits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func limitedClient(srv *scriptedServer, maxRetries int, maxWait time.Duration) *Client {
+	return &Client{APIBaseURL: srv.URL, limiter: newRateLimiter(nil, maxRetries, maxWait)}
+}
+
+func doMethod(t *testing.T, c *Client, httpMethod, apiMethod, body string) (*http.Response, error) {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, _ := http.NewRequest(httpMethod, c.APIBaseURL+"/"+apiMethod, r)
+	resp, err := c.Do(req, 10*time.Second)
+	if resp != nil {
+		t.Cleanup(func() { resp.Body.Close() })
+	}
+	return resp, err
+}
+
+func TestDoRetriesAfterRetryAfter(t *testing.T) {
+	srv := newScriptedServer(t, "1", http.StatusTooManyRequests, http.StatusOK)
+	c := limitedClient(srv, 3, 5*time.Second)
+
+	start := time.Now()
+	resp, err := doMethod(t, c, "GET", "conversations.replies?channel=C1", "")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Do: status=%v err=%v", resp, err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least the 1s Retry-After", elapsed)
+	}
+	if n := srv.hits(); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+	st := c.RateLimitStats()["conversations.replies"]
+	if st.Requests != 2 || st.RateLimited != 1 || st.Retries != 1 || st.Failures != 0 {
+		t.Errorf("stats = %+v", st)
+	}
+
+	// The 429 paused the method's bucket for every caller, not just this one.
+	b := c.limiter.bucket("conversations.replies")
+	b.mu.Lock()
+	blocked := b.blockedUntil
+	b.mu.Unlock()
+	if blocked.Before(start.Add(time.Second)) {
+		t.Errorf("bucket blocked until %s, want at least start+1s", blocked)
+	}
+}
+
+func TestDoRetriesWriteOn429WithSameBody(t *testing.T) {
+	// Slack rejects a rate-limited call before acting on it, so even a
+	// write is safe to send again after Retry-After.
+	srv := newScriptedServer(t, "1", http.StatusTooManyRequests, http.StatusOK)
+	c := limitedClient(srv, 3, 5*time.Second)
+
+	resp, err := doMethod(t, c, "POST", "chat.postMessage", `{"channel":"C1","text":"oi"}`)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Do: status=%v err=%v", resp, err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.bodies) != 2 || srv.bodies[0] != srv.bodies[1] || srv.bodies[1] == "" {
+		t.Errorf("bodies = %q, want the same body twice", srv.bodies)
+	}
+}
+
+func TestDoDoesNotRetryFailedWrites(t *testing.T) {
+	for _, status := range []int{http.StatusInternalServerError, 0} {
+		srv := newScriptedServer(t, "1", status, http.StatusOK)
+		c := limitedClient(srv, 3, 5*time.Second)
+
+		resp, err := doMethod(t, c, "POST", "chat.postMessage", `{"text":"oi"}`)
+		if status == 0 {
+			if err == nil {
+				t.Errorf("dropped connection: want error")
+			}
+		} else if err != nil || resp.StatusCode != status {
+			t.Errorf("status %d: got %v err=%v", status, resp, err)
+		}
+		if n := srv.hits(); n != 1 {
+			t.Errorf("status %d: a write was sent %d times", status, n)
+		}
+		if st := c.RateLimitStats()["chat.postMessage"]; st.Retries != 0 {
+			t.Errorf("status %d: stats = %+v", status, st)
+		}
+	}
+}
+
+func TestDoRetriesFailedReads(t *testing.T) {
+	for _, status := range []int{http.StatusBadGateway, 0} {
+		srv := newScriptedServer(t, "1", status, http.StatusOK)
+		c := limitedClient(srv, 3, 5*time.Second)
+
+		resp, err := doMethod(t, c, "GET", "users.info?user=U1", "")
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Errorf("status %d: got %v err=%v", status, resp, err)
+		}
+		if n := srv.hits(); n != 2 {
+			t.Errorf("status %d: requests = %d, want 2", status, n)
+		}
+	}
+}
+
+func TestDoGivesUpAfterMaxRetries(t *testing.T) {
+	srv := newScriptedServer(t, "1", http.StatusTooManyRequests)
+	c := limitedClient(srv, 1, 5*time.Second)
+
+	resp, err := doMethod(t, c, "GET", "search.messages?query=x", "")
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Do: got %v err=%v, want the last 429", resp, err)
+	}
+	if n := srv.hits(); n != 2 {
+		t.Errorf("requests = %d, want 2 (one retry)", n)
+	}
+	st := c.RateLimitStats()["search.messages"]
+	if st.RateLimited != 2 || st.Retries != 1 || st.Failures != 1 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestDoDoesNotWaitPastLimits(t *testing.T) {
+	// Retry-After above SLACK_RETRY_MAX_WAIT is returned right away.
+	srv := newScriptedServer(t, "60", http.StatusTooManyRequests, http.StatusOK)
+	c := limitedClient(srv, 3, 5*time.Second)
+	start := time.Now()
+	resp, err := doMethod(t, c, "GET", "users.list", "")
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Do: got %v err=%v, want the 429", resp, err)
+	}
+	if n := srv.hits(); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("returned after %s", elapsed)
+	}
+
+	// A Retry-After past the caller's deadline is not waited for either.
+	srv2 := newScriptedServer(t, "2", http.StatusTooManyRequests, http.StatusOK)
+	c2 := limitedClient(srv2, 3, 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv2.URL+"/users.list", nil)
+	resp2, err := c2.Do(req, 10*time.Second)
+	if err != nil || resp2.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Do with a short deadline: got %v err=%v", resp2, err)
+	}
+	resp2.Body.Close()
+	if n := srv2.hits(); n != 1 {
+		t.Errorf("requests with a short deadline = %d, want 1", n)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	now := time.Now()
+	b := newBucket(60) // one per second, bursts of 30
+	b.last = now
+	for i := 0; i < 30; i++ {
+		if d := b.reserve(now); d != 0 {
+			t.Fatalf("burst call %d waits %s", i, d)
+		}
+	}
+	if d := b.reserve(now); d != time.Second {
+		t.Errorf("call past the burst waits %s, want 1s", d)
+	}
+	if d := b.reserve(now); d != 2*time.Second {
+		t.Errorf("next call waits %s, want 2s (queued behind the previous one)", d)
+	}
+
+	// Refilled after a minute, but a 429 pause holds everyone back.
+	later := now.Add(time.Minute)
+	b.pause(later.Add(5 * time.Second))
+	if d := b.reserve(later); d != 5*time.Second {
+		t.Errorf("call during a pause waits %s, want 5s", d)
+	}
+}
+
+func TestRateLimiterOverrides(t *testing.T) {
+	l := newRateLimiter(map[string]int{"chat.postMessage": 120}, 3, time.Second)
+	for method, want := range map[string]float64{
+		"chat.postMessage": 2,                   // override
+		"search.messages":  float64(tier2) / 60, // tier table
+		"unknown.method":   float64(tier3) / 60, // default tier
+	} {
+		if got := l.bucket(method).rate; got != want {
+			t.Errorf("%s rate = %v, want %v", method, got, want)
+		}
+	}
+}
+
+func TestAPIMethodAndRetryAfter(t *testing.T) {
+	c := &Client{APIBaseURL: "https://slack.com/api/"}
+	for u, want := range map[string]string{
+		"https://slack.com/api/chat.postMessage":                    "chat.postMessage",
+		"https://slack.com/api/conversations.replies?channel=C1":    "conversations.replies",
+		"https://hooks.slack.com/commands/T1/123":                   "",
+		"https://files.slack.com/files-pri/T1-F1/download/clip.mp4": "",
+	} {
+		req, _ := http.NewRequest("GET", u, nil)
+		if got := c.apiMethod(req); got != want {
+			t.Errorf("apiMethod(%s) = %q, want %q", u, got, want)
+		}
+	}
+	for header, want := range map[string]time.Duration{"": time.Second, "7": 7 * time.Second, " 3 ": 3 * time.Second, "x": time.Second, "-2": time.Second} {
+		resp := &http.Response{Header: http.Header{"Retry-After": []string{header}}}
+		if got := retryAfter(resp); got != want {
+			t.Errorf("retryAfter(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ReactionEvent is the "reaction_added" event.
+// See https://api.slack.com/events/reaction_added.
+type ReactionEvent struct {
+	Type     string `json:"type"`
+	User     string `json:"user"`
+	Reaction string `json:"reaction"` // emoji name without colons, e.g. "tldr" or "+1::skin-tone-2"
+	ItemUser string `json:"item_user"`
+	Item     struct {
+		Type    string `json:"type"` // "message", "file"...
+		Channel string `json:"channel"`
+		Ts      string `json:"ts"`
+	} `json:"item"`
+}
+
+// Emoji returns the reaction name without its skin-tone modifier.
+func (e ReactionEvent) Emoji() string {
+	name, _, _ := strings.Cut(e.Reaction, "::")
+	return name
+}
+
+// Message is a single message read back from a conversation.
+type Message struct {
+	Ts       string `json:"ts"`
+	ThreadTs string `json:"thread_ts,omitempty"`
+	User     string `json:"user,omitempty"`
+	BotID    string `json:"bot_id,omitempty"`
+	Text     string `json:"text"`
+	Files    []File `json:"files,omitempty"`
+}
+
+// GetMessage fetches the message at ts, whether it is a thread root, a reply
+// or a standalone message.  conversations.replies always returns the thread
+// root first, so the range is pinned to ts and the matching message picked.
+func (c *Client) GetMessage(ctx context.Context, channel, ts string) (Message, error) {
+	if c.botToken(ctx) == "" {
+		return Message{}, errors.New("missing Slack bot token")
+	}
+	u := fmt.Sprintf("%s/conversations.replies?channel=%s&ts=%s&oldest=%s&latest=%s&inclusive=true&limit=2",
+		c.APIBaseURL, url.QueryEscape(channel), url.QueryEscape(ts), url.QueryEscape(ts), url.QueryEscape(ts))
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+	req.Header.Set("Authorization", "Bearer "+c.botToken(ctx))
+	resp, err := c.Do(req, 15*time.Second)
+	if err != nil {
+		return Message{}, err
+	}
+	defer resp.Body.Close()
+	rb, _ := io.ReadAll(resp.Body)
+	var data struct {
+		OK       bool      `json:"ok"`
+		Error    string    `json:"error"`
+		Messages []Message `json:"messages"`
+	}
+	if err := json.Unmarshal(rb, &data); err != nil {
+		return Message{}, err
+	}
+	if !data.OK {
+		return Message{}, fmt.Errorf("slack error: %s", data.Error)
+	}
+	for _, m := range data.Messages {
+		if m.Ts == ts {
+			return m, nil
+		}
+	}
+	return Message{}, fmt.Errorf("message %s not found in %s", ts, channel)
+}
@@ -1,6 +1,7 @@
 package slack
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -66,20 +67,20 @@ type SearchMessagesResp struct {
 // complex boolean expressions that combine quoted phrases with OR —
 // a combination that works in the Slack UI but returns 0 results via
 // the API.
-func (c *Client) SearchMessagesAll(query string) ([]SearchMessage, error) {
+func (c *Client) SearchMessagesAll(ctx context.Context, query string) ([]SearchMessage, error) {
 	query = strings.TrimSpace(query)
 	if query == "" {
 		return nil, errors.New("empty query")
 	}
-	if c.UserToken == "" {
+	if c.userToken(ctx) == "" {
 		return nil, errors.New("missing Slack user token (xoxp)")
 	}
 
-	query = c.rewriteFromToUserIDs(query)
+	query = c.rewriteFromToUserIDs(ctx, c.rewriteFromToNames(ctx, query))
 
 	clauses := splitTopLevelOR(query)
 	if len(clauses) == 1 {
-		return c.searchMessagesPages(clauses[0])
+		return c.searchMessagesPages(ctx, clauses[0])
 	}
 
 	// Execute each OR clause independently and merge results.
@@ -88,7 +89,7 @@ func (c *Client) SearchMessagesAll(query string) ([]SearchMessage, error) {
 	var merged []SearchMessage
 	for _, clause := range clauses {
 		log.Printf("[SLACK] OR clause search: %q", clause)
-		results, err := c.searchMessagesPages(clause)
+		results, err := c.searchMessagesPages(ctx, clause)
 		if err != nil {
 			log.Printf("[SLACK] OR clause %q failed: %v (skipping)", clause, err)
 			continue
@@ -111,7 +112,41 @@ func (c *Client) SearchMessagesAll(query string) ([]SearchMessage, error) {
 	return merged, nil
 }
 
-func (c *Client) rewriteFromToUserIDs(q string) string {
+// reFromToName matches from:/to: filters written with a handle or a
+// person's name ("from:@Fernanda", "to:joao.silva").
+var reFromToName = regexp.MustCompile(`\b(from|to):@?([\p{L}][\p{L}\p{N}._-]*)`)
+
+// reRawUserID matches a bare Slack user ID.
+var reRawUserID = regexp.MustCompile(`^[UW][A-Z0-9]{6,}$`)
+
+// rewriteFromToNames replaces from:/to: filters that name a person instead
+// of their handle ("o que a Fernanda disse" → from:@Fernanda) with the
+// handle found in the user directory.  Unknown or ambiguous names are kept,
+// as are searches in workspaces the directory does not cover.
+func (c *Client) rewriteFromToNames(ctx context.Context, q string) string {
+	if !c.Users.Loaded() || !c.DefaultTeam(ctx) {
+		return q
+	}
+	return reFromToName.ReplaceAllStringFunc(q, func(m string) string {
+		sub := reFromToName.FindStringSubmatch(m)
+		name := sub[2]
+		if reRawUserID.MatchString(name) {
+			return m
+		}
+		if _, ok := c.Users.ByHandle(name); ok {
+			return m
+		}
+		users := c.Users.FindByName(name)
+		if len(users) != 1 {
+			log.Printf("[SLACK] from/to name %q matched %d users — keeping", name, len(users))
+			return m
+		}
+		log.Printf("[SLACK] resolved %s:%s → %s:@%s", sub[1], name, sub[1], users[0].Handle)
+		return sub[1] + ":@" + users[0].Handle
+	})
+}
+
+func (c *Client) rewriteFromToUserIDs(ctx context.Context, q string) string {
 	q = strings.TrimSpace(q)
 	if q == "" {
 		return q
@@ -151,7 +186,7 @@ func (c *Client) rewriteFromToUserIDs(q string) string {
 
 	idToName := map[string]string{}
 	for _, id := range ids {
-		name, err := c.GetUsernameByID(id)
+		name, err := c.GetUsernameByID(ctx, id)
 		if err != nil {
 			// fallback: leave empty; the filter will be removed below
 			continue
@@ -179,7 +214,7 @@ func (c *Client) rewriteFromToUserIDs(q string) string {
 		userID := m[1]
 		full := m[0]
 		if _, already := idToName[userID]; !already {
-			name, err := c.GetUsernameByID(userID)
+			name, err := c.GetUsernameByID(ctx, userID)
 			if err == nil && name != "" {
 				idToName[userID] = name
 			}
@@ -196,7 +231,7 @@ func (c *Client) rewriteFromToUserIDs(q string) string {
 
 // searchMessagesPages executes a single Slack search query with
 // cursor-based page iteration and returns up to 200 raw matches.
-func (c *Client) searchMessagesPages(query string) ([]SearchMessage, error) {
+func (c *Client) searchMessagesPages(ctx context.Context, query string) ([]SearchMessage, error) {
 	maxPages := c.SearchMaxPages
 	if maxPages < 1 {
 		maxPages = 10
@@ -207,8 +242,8 @@ func (c *Client) searchMessagesPages(query string) ([]SearchMessage, error) {
 	var out []SearchMessage
 	for page := 1; page <= maxPages; page++ {
 		u := fmt.Sprintf("%s/search.messages?query=%s&count=20&page=%d", c.APIBaseURL, url.QueryEscape(query), page)
-		req, _ := http.NewRequest("GET", u, nil)
-		req.Header.Set("Authorization", "Bearer "+c.UserToken)
+		req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+		req.Header.Set("Authorization", "Bearer "+c.userToken(ctx))
 		resp, err := c.Do(req, 20*time.Second)
 		if err != nil {
 			return nil, err
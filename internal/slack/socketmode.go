@@ -0,0 +1,195 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// socketReadTimeout is how long a connection may stay silent before it
+	// is considered dead.  We ping every socketPingInterval, so a healthy
+	// peer always answers well within it.
+	socketReadTimeout  = 60 * time.Second
+	socketPingInterval = 20 * time.Second
+
+	socketMinBackoff = time.Second
+	socketMaxBackoff = 30 * time.Second
+)
+
+// errSocketRefresh is returned by a session that ended because Slack asked
+// for a new connection (disconnect envelope); the next one opens right away.
+var errSocketRefresh = errors.New("disconnect requested by Slack")
+
+// SocketEnvelope is a message received over a Socket Mode connection.  For
+// "events_api" envelopes Payload holds the same JSON the Events API POSTs
+// to /slack/events.  See https://api.slack.com/apis/socket-mode.
+type SocketEnvelope struct {
+	EnvelopeID   string          `json:"envelope_id,omitempty"`
+	Type         string          `json:"type"`
+	Payload      json.RawMessage `json:"payload,omitempty"`
+	RetryAttempt int             `json:"retry_attempt,omitempty"`
+	RetryReason  string          `json:"retry_reason,omitempty"`
+	// Reason is set on "disconnect" envelopes (refresh_requested, warning,
+	// link_disabled).
+	Reason string `json:"reason,omitempty"`
+}
+
+// SocketMode receives events over Slack's Socket Mode WebSocket instead of
+// the public Events API endpoint, so dev and staging instances need no
+// public URL.  It requires an app-level token (xapp-) with the
+// connections:write scope.
+type SocketMode struct {
+	Slack    *Client
+	AppToken string
+}
+
+// NewSocketMode returns a Socket Mode receiver that opens connections with
+// appToken through c's API base URL and HTTP client.
+func NewSocketMode(c *Client, appToken string) *SocketMode {
+	return &SocketMode{Slack: c, AppToken: appToken}
+}
+
+// Run keeps a Socket Mode connection open until ctx is cancelled.  handle is
+// called on the read loop and its result, when non-nil, is sent as the ack
+// payload (a slash command reply, modal validation errors), so it must
+// return quickly and move slow work to a goroutine — Slack expects the ack
+// within 3 seconds.  Lost connections are reopened with exponential backoff
+// and jitter.
+func (s *SocketMode) Run(ctx context.Context, handle func(SocketEnvelope) any) {
+	backoff := socketMinBackoff
+	for ctx.Err() == nil {
+		connected, err := s.session(ctx, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = socketMinBackoff
+		}
+		if errors.Is(err, errSocketRefresh) {
+			continue
+		}
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		log.Printf("[SLACK][socket] connection lost: %v — reconnecting in %s", err, wait.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		backoff = min(backoff*2, socketMaxBackoff)
+	}
+}
+
+// session opens one connection and reads from it until it fails, Slack asks
+// for a refresh or ctx is cancelled.  connected reports whether the hello
+// envelope arrived, i.e. whether the backoff should reset.
+func (s *SocketMode) session(ctx context.Context, handle func(SocketEnvelope) any) (connected bool, err error) {
+	openCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	wsURL, err := s.openConnection(openCtx)
+	if err == nil {
+		var ws *wsConn
+		ws, err = dialWebSocket(openCtx, wsURL)
+		cancel()
+		if err != nil {
+			return false, fmt.Errorf("dial: %w", err)
+		}
+		return s.read(ctx, ws, handle)
+	}
+	cancel()
+	return false, fmt.Errorf("apps.connections.open: %w", err)
+}
+
+func (s *SocketMode) read(ctx context.Context, ws *wsConn, handle func(SocketEnvelope) any) (connected bool, err error) {
+	ws.readTimeout = socketReadTimeout
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(socketPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				_ = ws.Close()
+				return
+			case <-ctx.Done():
+				_ = ws.Close()
+				return
+			case <-ticker.C:
+				if err := ws.Ping(); err != nil {
+					log.Printf("[SLACK][socket] ping failed: %v", err)
+				}
+			}
+		}
+	}()
+
+	for {
+		raw, err := ws.ReadMessage()
+		if err != nil {
+			return connected, err
+		}
+		var env SocketEnvelope
+		if err := json.Unmarshal(raw, &env); err != nil {
+			log.Printf("[ERR] socket envelope unmarshal: %v body=%.300s", err, raw)
+			continue
+		}
+		switch env.Type {
+		case "hello":
+			connected = true
+			log.Printf("[SLACK][socket] connected")
+			continue
+		case "disconnect":
+			log.Printf("[SLACK][socket] disconnect requested reason=%q", env.Reason)
+			return connected, errSocketRefresh
+		}
+		log.Printf("[SLACK][socket] envelope type=%q retry=%d", env.Type, env.RetryAttempt)
+		payload := handle(env)
+		if env.EnvelopeID == "" {
+			continue
+		}
+		ack, _ := json.Marshal(struct {
+			EnvelopeID string `json:"envelope_id"`
+			Payload    any    `json:"payload,omitempty"`
+		}{env.EnvelopeID, payload})
+		if err := ws.WriteText(ack); err != nil {
+			return connected, fmt.Errorf("ack: %w", err)
+		}
+	}
+}
+
+// openConnection calls apps.connections.open and returns the WebSocket URL.
+func (s *SocketMode) openConnection(ctx context.Context) (string, error) {
+	if strings.TrimSpace(s.AppToken) == "" {
+		return "", errors.New("missing Slack app token")
+	}
+	req, _ := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(s.Slack.APIBaseURL, "/")+"/apps.connections.open", nil)
+	req.Header.Set("Authorization", "Bearer "+s.AppToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.Slack.Do(req, 15*time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		OK    bool   `json:"ok"`
+		URL   string `json:"url"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return "", fmt.Errorf("status=%d: %w", resp.StatusCode, err)
+	}
+	if !out.OK {
+		return "", fmt.Errorf("slack error: %s", out.Error)
+	}
+	return out.URL, nil
+}
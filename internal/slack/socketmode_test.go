@@ -0,0 +1,218 @@
+package slack
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// socketServer is a fake Slack: apps.connections.open hands out the URL of
+// its own /link endpoint, and every WebSocket connection is served by the
+// next function in sessions.
+type socketServer struct {
+	*httptest.Server
+	opens    atomic.Int32
+	sessions chan func(rw *bufio.ReadWriter)
+}
+
+func newSocketServer(t *testing.T) *socketServer {
+	t.Helper()
+	s := &socketServer{sessions: make(chan func(rw *bufio.ReadWriter), 8)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apps.connections.open", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer xapp-test" {
+			t.Errorf("Authorization = %q", got)
+		}
+		s.opens.Add(1)
+		fmt.Fprintf(w, `{"ok":true,"url":%q}`, wsURL(s.Server)+"/link")
+	})
+	mux.HandleFunc("/link", func(w http.ResponseWriter, r *http.Request) {
+		conn, rw := wsUpgrade(t, w, r, nil)
+		defer conn.Close()
+		select {
+		case serve := <-s.sessions:
+			serve(rw)
+		case <-time.After(5 * time.Second):
+		}
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func sendEnvelope(t *testing.T, rw *bufio.ReadWriter, env string) {
+	t.Helper()
+	if err := writeServerFrame(rw, true, wsText, []byte(env)); err != nil {
+		t.Errorf("send envelope: %v", err)
+	}
+}
+
+// readAck reads the client's next frame as an ack.
+func readAck(t *testing.T, rw *bufio.ReadWriter) map[string]any {
+	t.Helper()
+	for {
+		op, payload, err := readClientFrame(t, rw)
+		if err != nil {
+			t.Errorf("read ack: %v", err)
+			return nil
+		}
+		if op != wsText {
+			continue
+		}
+		var ack map[string]any
+		if err := json.Unmarshal(payload, &ack); err != nil {
+			t.Errorf("ack %q: %v", payload, err)
+		}
+		return ack
+	}
+}
+
+// drain reads client frames until the client hangs up.
+func drain(t *testing.T, rw *bufio.ReadWriter) {
+	for {
+		if _, _, err := readClientFrame(t, rw); err != nil {
+			return
+		}
+	}
+}
+
+func runSocketMode(t *testing.T, srv *socketServer, handle func(SocketEnvelope) any) (cancel func()) {
+	t.Helper()
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	sm := NewSocketMode(&Client{APIBaseURL: srv.URL}, "xapp-test")
+	stopped := make(chan struct{})
+	go func() {
+		sm.Run(ctx, handle)
+		close(stopped)
+	}()
+	return func() {
+		cancelCtx()
+		select {
+		case <-stopped:
+		case <-time.After(5 * time.Second):
+			t.Error("Run did not return after cancel")
+		}
+	}
+}
+
+func TestSocketModeAcksAndReconnectsOnDisconnect(t *testing.T) {
+	srv := newSocketServer(t)
+	acks := make(chan map[string]any, 4)
+	reconnected := make(chan struct{})
+
+	srv.sessions <- func(rw *bufio.ReadWriter) {
+		sendEnvelope(t, rw, `{"type":"hello"}`)
+		sendEnvelope(t, rw, `{"envelope_id":"e1","type":"events_api","payload":{"event":{"type":"app_mention"}}}`)
+		acks <- readAck(t, rw)
+		sendEnvelope(t, rw, `{"envelope_id":"e2","type":"slash_commands","payload":{"command":"/jarvis"}}`)
+		acks <- readAck(t, rw)
+		// Envelopes without an id are dispatched but never acked.
+		sendEnvelope(t, rw, `{"type":"events_api","payload":{}}`)
+		sendEnvelope(t, rw, `{"type":"disconnect","reason":"refresh_requested"}`)
+	}
+	srv.sessions <- func(rw *bufio.ReadWriter) {
+		close(reconnected)
+		sendEnvelope(t, rw, `{"type":"hello"}`)
+		drain(t, rw)
+	}
+
+	var got []SocketEnvelope
+	envs := make(chan SocketEnvelope, 4)
+	started := time.Now()
+	stop := runSocketMode(t, srv, func(env SocketEnvelope) any {
+		envs <- env
+		if env.Type == "slash_commands" {
+			return map[string]string{"text": "ok"}
+		}
+		return nil
+	})
+	defer stop()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case ack := <-acks:
+			want := fmt.Sprintf("e%d", i+1)
+			if ack["envelope_id"] != want {
+				t.Errorf("ack %d envelope_id = %v, want %s", i, ack["envelope_id"], want)
+			}
+			_, hasPayload := ack["payload"]
+			if hasPayload != (i == 1) {
+				t.Errorf("ack %d = %v: payload present %t", i, ack, hasPayload)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("ack not received")
+		}
+	}
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reconnect after disconnect")
+	}
+	// A refresh reconnects right away, without the backoff.
+	if d := time.Since(started); d >= socketMinBackoff {
+		t.Errorf("reconnect after disconnect took %s", d)
+	}
+	if n := srv.opens.Load(); n != 2 {
+		t.Errorf("apps.connections.open calls = %d, want 2", n)
+	}
+	for len(got) < 3 {
+		select {
+		case env := <-envs:
+			got = append(got, env)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("dispatched %d envelopes, want 3", len(got))
+		}
+	}
+	if got[0].Type != "events_api" || !strings.Contains(string(got[0].Payload), "app_mention") {
+		t.Errorf("first envelope = %+v", got[0])
+	}
+	for _, env := range got {
+		if env.Type == "hello" || env.Type == "disconnect" {
+			t.Errorf("control envelope %q dispatched to the handler", env.Type)
+		}
+	}
+}
+
+func TestSocketModeReconnectsAfterDrop(t *testing.T) {
+	srv := newSocketServer(t)
+	reconnected := make(chan struct{})
+	srv.sessions <- func(rw *bufio.ReadWriter) {
+		sendEnvelope(t, rw, `{"type":"hello"}`)
+		sendEnvelope(t, rw, `not json`) // logged and skipped
+		// Returning drops the connection without a close frame.
+	}
+	srv.sessions <- func(rw *bufio.ReadWriter) {
+		close(reconnected)
+		drain(t, rw)
+	}
+	stop := runSocketMode(t, srv, func(SocketEnvelope) any { return nil })
+	defer stop()
+
+	select {
+	case <-reconnected:
+	case <-time.After(socketMinBackoff*2 + 5*time.Second):
+		t.Fatal("no reconnect after the connection dropped")
+	}
+}
+
+func TestSocketModeOpenError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok":false,"error":"invalid_auth"}`)
+	}))
+	defer srv.Close()
+	sm := NewSocketMode(&Client{APIBaseURL: srv.URL}, "xapp-test")
+	if _, err := sm.openConnection(context.Background()); err == nil || !strings.Contains(err.Error(), "invalid_auth") {
+		t.Fatalf("openConnection: err = %v, want invalid_auth", err)
+	}
+	sm.AppToken = " "
+	if _, err := sm.openConnection(context.Background()); err == nil {
+		t.Fatal("openConnection without a token: want error")
+	}
+}
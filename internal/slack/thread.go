@@ -1,6 +1,7 @@
 package slack
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,8 +16,8 @@ import (
 // GetThreadHistory fetches up to limit messages from a Slack thread and
 // returns a concatenated text representation.  Messages authored by
 // bots include the bot ID instead of a user ID.
-func (c *Client) GetThreadHistory(channel, threadTs string, limit int) (string, error) {
-	if c.BotToken == "" {
+func (c *Client) GetThreadHistory(ctx context.Context, channel, threadTs string, limit int) (string, error) {
+	if c.botToken(ctx) == "" {
 		return "", errors.New("missing Slack bot token")
 	}
 	if limit <= 0 {
@@ -24,8 +25,8 @@ func (c *Client) GetThreadHistory(channel, threadTs string, limit int) (string,
 	}
 	u := fmt.Sprintf("%s/conversations.replies?channel=%s&ts=%s&limit=%d", c.APIBaseURL, url.QueryEscape(channel), url.QueryEscape(threadTs), limit)
 
-	req, _ := http.NewRequest("GET", u, nil)
-	req.Header.Set("Authorization", "Bearer "+c.BotToken)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+	req.Header.Set("Authorization", "Bearer "+c.botToken(ctx))
 	resp, err := c.Do(req, 15*time.Second)
 	if err != nil {
 		return "", err
@@ -71,8 +72,8 @@ func (c *Client) GetThreadHistory(channel, threadTs string, limit int) (string,
 //
 // It is intended for "permalink mode" where the user provides an explicit Slack
 // thread link, and we want high-fidelity context.
-func (c *Client) GetThreadHistoryFull(channel, threadTs string, maxMessages int, maxChars int) (string, error) {
-	if c.BotToken == "" {
+func (c *Client) GetThreadHistoryFull(ctx context.Context, channel, threadTs string, maxMessages int, maxChars int) (string, error) {
+	if c.botToken(ctx) == "" {
 		return "", errors.New("missing Slack bot token")
 	}
 
@@ -120,10 +121,10 @@ func (c *Client) GetThreadHistoryFull(channel, threadTs string, maxMessages int,
 		if strings.TrimSpace(cursor) != "" {
 			u += "&cursor=" + url.QueryEscape(cursor)
 		}
-		req, _ := http.NewRequest("GET", u, nil)
-		token := c.BotToken
-		if useUserToken && c.UserToken != "" {
-			token = c.UserToken
+		req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+		token := c.botToken(ctx)
+		if useUserToken && c.userToken(ctx) != "" {
+			token = c.userToken(ctx)
 		}
 		req.Header.Set("Authorization", "Bearer "+token)
 		resp, err := c.Do(req, 20*time.Second)
@@ -145,7 +146,7 @@ func (c *Client) GetThreadHistoryFull(channel, threadTs string, maxMessages int,
 		}
 		if !data.OK {
 			// If bot token failed with not_in_channel, and we have user token, retry with user token
-			if !useUserToken && data.Error == "not_in_channel" && c.UserToken != "" {
+			if !useUserToken && data.Error == "not_in_channel" && c.userToken(ctx) != "" {
 				useUserToken = true
 				cursor = ""
 				all = nil
@@ -221,14 +222,14 @@ func (c *Client) GetThreadHistoryFull(channel, threadTs string, maxMessages int,
 
 // GetPermalink returns a permalink for a given message timestamp in a
 // channel.  An empty string and error are returned if the call fails.
-func (c *Client) GetPermalink(channel, messageTs string) (string, error) {
-	if c.BotToken == "" {
+func (c *Client) GetPermalink(ctx context.Context, channel, messageTs string) (string, error) {
+	if c.botToken(ctx) == "" {
 		return "", errors.New("missing Slack bot token")
 	}
 
 	u := fmt.Sprintf("%s/chat.getPermalink?channel=%s&message_ts=%s", c.APIBaseURL, url.QueryEscape(channel), url.QueryEscape(messageTs))
-	req, _ := http.NewRequest("GET", u, nil)
-	req.Header.Set("Authorization", "Bearer "+c.BotToken)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+	req.Header.Set("Authorization", "Bearer "+c.botToken(ctx))
 	resp, err := c.Do(req, 10*time.Second)
 	if err != nil {
 		return "", err
@@ -1,16 +1,11 @@
 package slack
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
 	"regexp"
 	"strings"
-	"time"
 )
 
 // reFromUserID matches "from:USERID" patterns in a Slack search query where
@@ -21,7 +16,7 @@ var reFromUserID = regexp.MustCompile(`\bfrom:([UW][A-Z0-9]+)\b`)
 // with @username. When the display name is already embedded after |, it is used
 // directly. Otherwise, GetUsernameByID is called (which fast-paths via the cached
 // user token owner, avoiding the need for users:read scope in that case).
-func (c *Client) ResolveUserMentions(text string) string {
+func (c *Client) ResolveUserMentions(ctx context.Context, text string) string {
 	return reSlackUserMention.ReplaceAllStringFunc(text, func(m string) string {
 		sub := reSlackUserMention.FindStringSubmatch(m)
 		if len(sub) < 3 {
@@ -32,7 +27,7 @@ func (c *Client) ResolveUserMentions(text string) string {
 		if name != "" {
 			return "@" + name
 		}
-		username, err := c.GetUsernameByID(id)
+		username, err := c.GetUsernameByID(ctx, id)
 		if err != nil {
 			return m // keep original if you can't resolve
 		}
@@ -40,9 +35,9 @@ func (c *Client) ResolveUserMentions(text string) string {
 	})
 }
 
-// GetUsernameByID calls users.info and returns the Slack "name" (handle)
-// E.g. "user.name". It prefers the user token when available.
-func (c *Client) GetUsernameByID(userID string) (string, error) {
+// GetUsernameByID returns the Slack "name" (handle) of a user, e.g.
+// "user.name", from the user directory or, on a miss, users.info.
+func (c *Client) GetUsernameByID(ctx context.Context, userID string) (string, error) {
 	userID = strings.TrimSpace(userID)
 	if userID == "" {
 		return "", errors.New("empty user id")
@@ -54,46 +49,11 @@ func (c *Client) GetUsernameByID(userID string) (string, error) {
 		return c.UserTokenUsername, nil
 	}
 
-	token := c.UserToken
-	if token == "" {
-		token = c.BotToken
-	}
-	if token == "" {
-		return "", errors.New("missing slack token")
-	}
-
-	u := fmt.Sprintf("%s/users.info?user=%s", c.APIBaseURL, url.QueryEscape(userID))
-	req, _ := http.NewRequest("GET", u, nil)
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err := c.Do(req, 10*time.Second)
+	u, err := c.GetUser(ctx, userID)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	rb, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode >= 300 {
-		return "", fmt.Errorf("slack status=%d body=%s", resp.StatusCode, preview(string(rb), 300))
-	}
-
-	var out struct {
-		OK    bool   `json:"ok"`
-		Error string `json:"error"`
-		User  struct {
-			Name string `json:"name"`
-		} `json:"user"`
-	}
-	if err := json.Unmarshal(rb, &out); err != nil {
-		return "", err
-	}
-	if !out.OK {
-		return "", fmt.Errorf("users.info error: %s", out.Error)
-	}
-	if strings.TrimSpace(out.User.Name) == "" {
-		return "", errors.New("users.info returned empty name")
-	}
-	return out.User.Name, nil
+	return u.Handle, nil
 }
 
 // ResolveUserIDsInQuery replaces from:USERID tokens with from:@username so
@@ -102,14 +62,14 @@ func (c *Client) GetUsernameByID(userID string) (string, error) {
 // from:USERID token is removed from the query — the Slack search API does not
 // understand raw user IDs as a from: filter, so leaving it would silently
 // break user filtering. The search runs more broadly in that case.
-func (c *Client) ResolveUserIDsInQuery(q string) string {
+func (c *Client) ResolveUserIDsInQuery(ctx context.Context, q string) string {
 	result := reFromUserID.ReplaceAllStringFunc(q, func(match string) string {
 		sub := reFromUserID.FindStringSubmatch(match)
 		if len(sub) < 2 {
 			return match
 		}
 		userID := sub[1]
-		name, err := c.GetUsernameByID(userID)
+		name, err := c.GetUsernameByID(ctx, userID)
 		if err != nil {
 			log.Printf("[SLACK] ResolveUserIDsInQuery: could not resolve %s (add users:read scope to fix): %v", userID, err)
 			// Return an empty string to remove the broken filter; the Slack search
@@ -0,0 +1,312 @@
+package slack
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsUpgrade answers the opening handshake on r and returns the hijacked
+// connection, as a Socket Mode server would.
+func wsUpgrade(t *testing.T, w http.ResponseWriter, r *http.Request, accept func(key string) string) (net.Conn, *bufio.ReadWriter) {
+	t.Helper()
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || r.Header.Get("Sec-WebSocket-Version") != "13" {
+		t.Errorf("handshake headers = %v", r.Header)
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if accept == nil {
+		accept = wsAccept
+	}
+	conn, rw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		t.Fatalf("hijack: %v", err)
+	}
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + accept(key) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		t.Fatalf("handshake write: %v", err)
+	}
+	return conn, rw
+}
+
+func wsAccept(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// newWSServer starts a server that upgrades every request and hands the
+// connection to serve; the connection is closed when serve returns.
+func newWSServer(t *testing.T, serve func(rw *bufio.ReadWriter)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw := wsUpgrade(t, w, r, nil)
+		defer conn.Close()
+		serve(rw)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func wsURL(srv *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// writeServerFrame writes one unmasked frame, as servers do.
+func writeServerFrame(rw *bufio.ReadWriter, fin bool, op byte, payload []byte) error {
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	hdr := []byte{b0}
+	switch n := len(payload); {
+	case n < 126:
+		hdr = append(hdr, byte(n))
+	case n <= 0xffff:
+		hdr = append(hdr, 126)
+		hdr = binary.BigEndian.AppendUint16(hdr, uint16(n))
+	default:
+		hdr = append(hdr, 127)
+		hdr = binary.BigEndian.AppendUint64(hdr, uint64(n))
+	}
+	rw.Write(hdr)
+	rw.Write(payload)
+	return rw.Flush()
+}
+
+// readClientFrame reads one frame and fails unless the client masked it.
+func readClientFrame(t *testing.T, rw *bufio.ReadWriter) (op byte, payload []byte, err error) {
+	var hdr [2]byte
+	if _, err = io.ReadFull(rw, hdr[:]); err != nil {
+		return
+	}
+	if hdr[0]&0x80 == 0 {
+		t.Errorf("client frame without FIN")
+	}
+	op = hdr[0] & 0x0f
+	if hdr[1]&0x80 == 0 {
+		t.Errorf("client frame op=%d is not masked", op)
+	}
+	n := uint64(hdr[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(rw, ext[:]); err != nil {
+			return
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(rw, ext[:]); err != nil {
+			return
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(rw, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(rw, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return
+}
+
+func dialTest(t *testing.T, srv *httptest.Server) *wsConn {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ws, err := dialWebSocket(ctx, wsURL(srv))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { ws.conn.Close() })
+	return ws
+}
+
+func TestWebSocketEchoLengths(t *testing.T) {
+	// 7-bit, 16-bit and 64-bit payload lengths, both directions.
+	sizes := []int{0, 125, 126, 300, 0xffff, 0x10000, 70000}
+	srv := newWSServer(t, func(rw *bufio.ReadWriter) {
+		for range sizes {
+			op, payload, err := readClientFrame(t, rw)
+			if err != nil {
+				t.Errorf("server read: %v", err)
+				return
+			}
+			if op != wsText {
+				t.Errorf("op = %d, want text", op)
+			}
+			if err := writeServerFrame(rw, true, wsText, payload); err != nil {
+				t.Errorf("server write: %v", err)
+				return
+			}
+		}
+	})
+	ws := dialTest(t, srv)
+	for _, n := range sizes {
+		msg := bytes.Repeat([]byte("abcdefg"), n/7+1)[:n]
+		if err := ws.WriteText(msg); err != nil {
+			t.Fatalf("write %d bytes: %v", n, err)
+		}
+		got, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatalf("read %d bytes: %v", n, err)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Fatalf("echo of %d bytes: got %d bytes back", n, len(got))
+		}
+	}
+}
+
+func TestWebSocketBadAccept(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _ := wsUpgrade(t, w, r, func(string) string { return "bogus" })
+		conn.Close()
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := dialWebSocket(ctx, wsURL(srv)); err == nil || !strings.Contains(err.Error(), "Sec-WebSocket-Accept") {
+		t.Fatalf("dial with a bad accept: err = %v", err)
+	}
+}
+
+func TestWebSocketHandshakeStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := dialWebSocket(ctx, wsURL(srv)); err == nil || !strings.Contains(err.Error(), "status=403") {
+		t.Fatalf("dial to a non-websocket endpoint: err = %v", err)
+	}
+}
+
+func TestWebSocketFragmentedWithPing(t *testing.T) {
+	pong := make(chan []byte, 1)
+	srv := newWSServer(t, func(rw *bufio.ReadWriter) {
+		writeServerFrame(rw, false, wsText, []byte("hel"))
+		writeServerFrame(rw, true, wsPing, []byte("are you there"))
+		writeServerFrame(rw, false, wsContinuation, []byte("lo "))
+		writeServerFrame(rw, true, wsContinuation, []byte("world"))
+		op, payload, err := readClientFrame(t, rw)
+		if err != nil || op != wsPong {
+			t.Errorf("after ping: op=%d err=%v, want pong", op, err)
+		}
+		pong <- payload
+	})
+	ws := dialTest(t, srv)
+	got, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "hello world" {
+		t.Errorf("message = %q, want %q", got, "hello world")
+	}
+	select {
+	case p := <-pong:
+		if string(p) != "are you there" {
+			t.Errorf("pong payload = %q", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no pong")
+	}
+}
+
+func TestWebSocketClose(t *testing.T) {
+	echoed := make(chan []byte, 1)
+	srv := newWSServer(t, func(rw *bufio.ReadWriter) {
+		writeServerFrame(rw, true, wsPong, nil) // unsolicited pongs are skipped
+		writeServerFrame(rw, true, wsClose, []byte{0x03, 0xe9})
+		op, payload, err := readClientFrame(t, rw)
+		if err != nil || op != wsClose {
+			t.Errorf("after close: op=%d err=%v, want close", op, err)
+		}
+		echoed <- payload
+	})
+	ws := dialTest(t, srv)
+	if _, err := ws.ReadMessage(); !errors.Is(err, io.EOF) {
+		t.Fatalf("read after close: err = %v, want io.EOF", err)
+	}
+	select {
+	case p := <-echoed:
+		if !bytes.Equal(p, []byte{0x03, 0xe9}) {
+			t.Errorf("echoed close payload = %x", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("close not echoed")
+	}
+}
+
+func TestWebSocketProtocolErrors(t *testing.T) {
+	half := bytes.Repeat([]byte{'x'}, wsMaxMessage/2+1)
+	tests := []struct {
+		name   string
+		frames func(rw *bufio.ReadWriter)
+		want   string
+	}{
+		{"frame too large", func(rw *bufio.ReadWriter) {
+			hdr := []byte{0x80 | wsText, 127}
+			hdr = binary.BigEndian.AppendUint64(hdr, wsMaxMessage+1)
+			rw.Write(hdr)
+			rw.Flush()
+		}, "frame too large"},
+		{"message too large", func(rw *bufio.ReadWriter) {
+			writeServerFrame(rw, false, wsText, half)
+			writeServerFrame(rw, true, wsContinuation, half)
+		}, "message too large"},
+		{"continuation first", func(rw *bufio.ReadWriter) {
+			writeServerFrame(rw, true, wsContinuation, []byte("x"))
+		}, "unexpected continuation"},
+		{"message inside a fragment", func(rw *bufio.ReadWriter) {
+			writeServerFrame(rw, false, wsText, []byte("a"))
+			writeServerFrame(rw, true, wsText, []byte("b"))
+		}, "new message inside"},
+		{"unknown opcode", func(rw *bufio.ReadWriter) {
+			writeServerFrame(rw, true, 0x3, nil)
+		}, "unknown opcode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan struct{})
+			srv := newWSServer(t, func(rw *bufio.ReadWriter) {
+				tt.frames(rw)
+				<-done
+			})
+			defer close(done)
+			ws := dialTest(t, srv)
+			if _, err := ws.ReadMessage(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("read: err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketReadTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := newWSServer(t, func(rw *bufio.ReadWriter) { <-done })
+	defer close(done)
+	ws := dialTest(t, srv)
+	ws.readTimeout = 50 * time.Millisecond
+	_, err := ws.ReadMessage()
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatalf("read from a silent peer: err = %v, want a timeout", err)
+	}
+}
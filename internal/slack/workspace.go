@@ -0,0 +1,187 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// teamKey carries the workspace (team_id) a request belongs to.
+type teamKey struct{}
+
+// WithTeam returns ctx scoped to teamID: Web API calls made with it use the
+// tokens of that workspace's installation.
+func WithTeam(ctx context.Context, teamID string) context.Context {
+	if teamID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, teamKey{}, teamID)
+}
+
+// TeamFromContext returns the workspace ctx is scoped to, or "".
+func TeamFromContext(ctx context.Context) string {
+	teamID, _ := ctx.Value(teamKey{}).(string)
+	return teamID
+}
+
+// installation returns the OAuth installation ctx is scoped to.  It returns
+// false for the workspace of the env tokens (SLACK_BOT_TOKEN), for unscoped
+// contexts and for unknown teams, which all use the env tokens.
+func (c *Client) installation(ctx context.Context) (Installation, bool) {
+	teamID := TeamFromContext(ctx)
+	if teamID == "" || teamID == c.TeamID {
+		return Installation{}, false
+	}
+	return c.Installations.Get(teamID)
+}
+
+// botToken returns the bot token of the workspace ctx is scoped to.
+func (c *Client) botToken(ctx context.Context) string {
+	if in, ok := c.installation(ctx); ok {
+		return in.BotToken
+	}
+	return c.BotToken
+}
+
+// userToken returns the user token of the workspace ctx is scoped to (may
+// be empty for OAuth installs without user scopes).
+func (c *Client) userToken(ctx context.Context) string {
+	if in, ok := c.installation(ctx); ok {
+		return in.UserToken
+	}
+	return c.UserToken
+}
+
+// BotUserIDFor returns the bot's user ID in the workspace ctx is scoped to.
+func (c *Client) BotUserIDFor(ctx context.Context) string {
+	if in, ok := c.installation(ctx); ok {
+		return in.BotUserID
+	}
+	return c.BotUserID
+}
+
+// DefaultTeam reports whether ctx is scoped to the workspace of the env
+// tokens, where the cached user directory applies.
+func (c *Client) DefaultTeam(ctx context.Context) bool {
+	_, ok := c.installation(ctx)
+	return !ok
+}
+
+// InstalledTeam returns the workspace ctx is scoped to when it is not the env
+// tokens' one, or "".  Per-workspace settings are keyed by it and deny by
+// default, so an unknown team never falls back to the env workspace's.
+func (c *Client) InstalledTeam(ctx context.Context) string {
+	teamID := TeamFromContext(ctx)
+	if c == nil || teamID == c.TeamID {
+		return ""
+	}
+	return teamID
+}
+
+// KnowsTeam reports whether events of teamID can be answered: it is the env
+// tokens' workspace, or an allowed workspace with an installation.  An empty
+// teamID is accepted.
+func (c *Client) KnowsTeam(teamID string) bool {
+	if teamID == "" || teamID == c.TeamID {
+		return true
+	}
+	if !c.AllowedTeams[teamID] {
+		return false
+	}
+	_, ok := c.Installations.Get(teamID)
+	return ok
+}
+
+// RevokeToken invalidates token with auth.revoke (used to undo installs from
+// workspaces that are not allowed).
+func (c *Client) RevokeToken(ctx context.Context, token string) error {
+	req, _ := http.NewRequestWithContext(ctx, "POST", c.APIBaseURL+"/auth.revoke", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.Do(req, 15*time.Second)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	var out struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return err
+	}
+	if !out.OK {
+		return fmt.Errorf("auth.revoke error: %s", out.Error)
+	}
+	return nil
+}
+
+// ExchangeOAuthCode completes the OAuth v2 install flow: it trades code for
+// the workspace's tokens with oauth.v2.access and returns the installation.
+func (c *Client) ExchangeOAuthCode(ctx context.Context, clientID, clientSecret, code, redirectURI string) (Installation, error) {
+	form := url.Values{}
+	form.Set("code", code)
+	if redirectURI != "" {
+		form.Set("redirect_uri", redirectURI)
+	}
+	req, _ := http.NewRequestWithContext(ctx, "POST", c.APIBaseURL+"/oauth.v2.access", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, clientSecret)
+	resp, err := c.Do(req, 15*time.Second)
+	if err != nil {
+		return Installation{}, err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return Installation{}, fmt.Errorf("oauth.v2.access status=%d body=%s", resp.StatusCode, preview(string(b), 300))
+	}
+	var out struct {
+		OK          bool   `json:"ok"`
+		Error       string `json:"error"`
+		AccessToken string `json:"access_token"`
+		BotUserID   string `json:"bot_user_id"`
+		Team        struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"team"`
+		Enterprise *struct {
+			ID string `json:"id"`
+		} `json:"enterprise"`
+		AuthedUser struct {
+			ID          string `json:"id"`
+			AccessToken string `json:"access_token"`
+		} `json:"authed_user"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return Installation{}, err
+	}
+	if !out.OK {
+		return Installation{}, fmt.Errorf("oauth.v2.access error: %s", out.Error)
+	}
+	if out.Team.ID == "" || out.AccessToken == "" {
+		return Installation{}, errors.New("oauth.v2.access: no team or bot token (is the bot scope requested?)")
+	}
+	in := Installation{
+		TeamID:      out.Team.ID,
+		TeamName:    out.Team.Name,
+		BotToken:    out.AccessToken,
+		BotUserID:   out.BotUserID,
+		UserToken:   out.AuthedUser.AccessToken,
+		InstalledBy: out.AuthedUser.ID,
+		InstalledAt: time.Now(),
+	}
+	if out.AuthedUser.AccessToken != "" {
+		in.UserID = out.AuthedUser.ID
+	}
+	if out.Enterprise != nil {
+		in.EnterpriseID = out.Enterprise.ID
+	}
+	return in, nil
+}
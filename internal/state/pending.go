@@ -1,6 +1,7 @@
 package state
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -18,11 +19,15 @@ type PendingIssue struct {
 	ThreadTs     string
 	OriginTs     string
 	OriginalText string
+	UserID       string // who asked for the card; drafts are listed on their App Home
 	Source       string // "thread_based" or "explicit"
 	Draft        jira.IssueDraft
 	Drafts       []jira.IssueDraft // multi-card flow: queue of drafts awaiting confirmation
 	NeedProject  bool
 	NeedType     bool
+	// PromptTs is the Block Kit message asking to complete or confirm the
+	// draft, refreshed when the draft is edited through the modal.
+	PromptTs string
 }
 
 // Store maintains a mapping of pending issues per thread.  Entries
@@ -70,9 +75,68 @@ func (s *Store) Load(channel, threadTs string) *PendingIssue {
 	return &cp
 }
 
+// Take removes a pending issue and returns it, or nil when it is missing or
+// expired.  Only one of several concurrent callers gets the entry, so a
+// double-clicked or retried confirmation acts once.
+func (s *Store) Take(channel, threadTs string) *PendingIssue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := s.key(channel, threadTs)
+	p := s.byThread[key]
+	if p == nil {
+		return nil
+	}
+	delete(s.byThread, key)
+	if s.ttl > 0 && time.Since(p.CreatedAt) > s.ttl {
+		return nil
+	}
+	return p
+}
+
+// Update applies fn to the pending issue of channel/thread under the store's
+// lock and returns a copy of the result, or nil when there is no unexpired
+// entry (e.g. it was taken meanwhile); fn is then not called.
+func (s *Store) Update(channel, threadTs string, fn func(*PendingIssue)) *PendingIssue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := s.key(channel, threadTs)
+	p := s.byThread[key]
+	if p == nil {
+		return nil
+	}
+	if s.ttl > 0 && time.Since(p.CreatedAt) > s.ttl {
+		delete(s.byThread, key)
+		return nil
+	}
+	fn(p)
+	cp := *p
+	return &cp
+}
+
 // Delete removes a pending issue from the store.
 func (s *Store) Delete(channel, threadTs string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.byThread, s.key(channel, threadTs))
 }
+
+// ListByUser returns copies of the unexpired pending issues requested by
+// userID, newest first.
+func (s *Store) ListByUser(userID string) []*PendingIssue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*PendingIssue
+	for key, p := range s.byThread {
+		if s.ttl > 0 && time.Since(p.CreatedAt) > s.ttl {
+			delete(s.byThread, key)
+			continue
+		}
+		if p.UserID != userID {
+			continue
+		}
+		cp := *p
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
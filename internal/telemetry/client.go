@@ -20,7 +20,7 @@ type Client struct {
 // Event holds all metrics captured for a single HandleMessage invocation.
 type Event struct {
 	Channel         string
-	ChannelType     string // "dm" | "channel"
+	ChannelType     string // "dm" | "channel" | "api"
 	ThreadTs        string
 	OriginTs        string
 	SenderUserID    string
@@ -43,6 +43,23 @@ type Event struct {
 	Success         bool
 	ErrorStage      string
 	CSVGenerated    bool
+	// PromptTokens, CompletionTokens and CostUSD total every LLM call made
+	// for the message; LLMCalls breaks them down by call site.
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+	LLMCalls         []LLMCall
+}
+
+// LLMCall is the token usage of one LLM call, labelled by call site
+// ("router", "sql", "answer"...).
+type LLMCall struct {
+	Site             string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+	Estimated        bool
 }
 
 const migrateSQL = `
@@ -83,6 +100,23 @@ CREATE TABLE IF NOT EXISTS conversations (
     answer     TEXT        NOT NULL
 );
 CREATE INDEX IF NOT EXISTS conversations_event_id ON conversations (event_id);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS prompt_tokens     INT     DEFAULT 0;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS completion_tokens INT     DEFAULT 0;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS cost_usd          NUMERIC DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS llm_calls (
+    id                BIGSERIAL PRIMARY KEY,
+    event_id          BIGINT  NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    site              TEXT    NOT NULL,
+    model             TEXT    NOT NULL,
+    prompt_tokens     INT     DEFAULT 0,
+    completion_tokens INT     DEFAULT 0,
+    cost_usd          NUMERIC DEFAULT 0,
+    estimated         BOOLEAN DEFAULT FALSE
+);
+CREATE INDEX IF NOT EXISTS llm_calls_event_id ON llm_calls (event_id);
+CREATE INDEX IF NOT EXISTS llm_calls_site     ON llm_calls (site);
 `
 
 const insertEventSQL = `
@@ -93,7 +127,8 @@ INSERT INTO events (
     metabase_queried, metabase_rows,
     slack_searched, slack_matches,
     outline_searched,
-    llm_model, answer_len, duration_ms, success, error_stage, csv_generated
+    llm_model, answer_len, duration_ms, success, error_stage, csv_generated,
+    prompt_tokens, completion_tokens, cost_usd
 ) VALUES (
     $1, $2, $3, $4, $5,
     $6, $7, $8,
@@ -101,12 +136,28 @@ INSERT INTO events (
     $12, $13,
     $14, $15,
     $16,
-    $17, $18, $19, $20, $21, $22
+    $17, $18, $19, $20, $21, $22,
+    $23, $24, $25
 ) RETURNING id`
 
 const insertConversationSQL = `
 INSERT INTO conversations (event_id, question, answer) VALUES ($1, $2, $3)`
 
+const insertLLMCallSQL = `
+INSERT INTO llm_calls (event_id, site, model, prompt_tokens, completion_tokens, cost_usd, estimated)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+const tokensTodaySQL = `
+SELECT COALESCE(SUM(prompt_tokens + completion_tokens), 0) FROM events
+WHERE received_at >= date_trunc('day', now() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'`
+
+const recentConversationsSQL = `
+SELECT e.received_at, e.channel_id, c.question, c.answer
+FROM conversations c JOIN events e ON e.id = c.event_id
+WHERE e.sender_user_id = $1
+ORDER BY e.received_at DESC
+LIMIT $2`
+
 // NewClient connects to PostgreSQL and runs migrations.
 // Returns nil (silently) when TELEMETRY_DB_URL is empty, so telemetry is fully optional.
 func NewClient(cfg config.Config) *Client {
@@ -153,6 +204,7 @@ func (c *Client) Record(e Event) {
 			e.SlackSearched, e.SlackMatches,
 			e.OutlineSearched,
 			nullableStr(e.LLMModel), e.AnswerLen, e.DurationMs, e.Success, nullableStr(e.ErrorStage), e.CSVGenerated,
+			e.PromptTokens, e.CompletionTokens, e.CostUSD,
 		).Scan(&eventID)
 		if err != nil {
 			log.Printf("[TELEMETRY] insert event failed: %v", err)
@@ -164,9 +216,63 @@ func (c *Client) Record(e Event) {
 				log.Printf("[TELEMETRY] insert conversation failed: %v", err)
 			}
 		}
+
+		for _, call := range e.LLMCalls {
+			if _, err := c.db.ExecContext(ctx, insertLLMCallSQL, eventID, call.Site, call.Model,
+				call.PromptTokens, call.CompletionTokens, call.CostUSD, call.Estimated); err != nil {
+				log.Printf("[TELEMETRY] insert llm call failed: %v", err)
+				break
+			}
+		}
 	}()
 }
 
+// TokensToday returns the tokens recorded today (UTC) for the sender and for
+// the channel.  Used to seed the in-memory daily budgets after a restart.
+// Safe to call on a nil *Client, which reports zero.
+func (c *Client) TokensToday(ctx context.Context, senderUserID, channel string) (user, channelTokens int, err error) {
+	if c == nil {
+		return 0, 0, nil
+	}
+	if err := c.db.QueryRowContext(ctx, tokensTodaySQL+" AND sender_user_id = $1", senderUserID).Scan(&user); err != nil {
+		return 0, 0, err
+	}
+	if err := c.db.QueryRowContext(ctx, tokensTodaySQL+" AND channel_id = $1", channel).Scan(&channelTokens); err != nil {
+		return 0, 0, err
+	}
+	return user, channelTokens, nil
+}
+
+// Conversation is a recorded question and its answer.
+type Conversation struct {
+	ReceivedAt time.Time
+	Channel    string
+	Question   string
+	Answer     string
+}
+
+// RecentConversations returns the last limit questions asked by userID,
+// newest first.  Safe to call on a nil *Client, which has none.
+func (c *Client) RecentConversations(ctx context.Context, userID string, limit int) ([]Conversation, error) {
+	if c == nil {
+		return nil, nil
+	}
+	rows, err := c.db.QueryContext(ctx, recentConversationsSQL, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Conversation
+	for rows.Next() {
+		var cv Conversation
+		if err := rows.Scan(&cv.ReceivedAt, &cv.Channel, &cv.Question, &cv.Answer); err != nil {
+			return nil, err
+		}
+		out = append(out, cv)
+	}
+	return out, rows.Err()
+}
+
 // Close releases the underlying database connection pool.
 func (c *Client) Close() {
 	if c == nil {
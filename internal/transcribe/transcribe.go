@@ -0,0 +1,284 @@
+// Package transcribe turns audio and video attachments into timestamped text
+// through a Whisper-compatible /audio/transcriptions endpoint (OpenAI, or a
+// local faster-whisper / whisper.cpp server).
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+// MaxBytes is the largest file the OpenAI endpoint accepts (25 MB).
+const MaxBytes = 25 * 1024 * 1024
+
+// Errors for recordings outside the limits, so callers can tell the user
+// why an attachment was not transcribed.
+var (
+	ErrTooLarge = errors.New("file too large")
+	ErrTooLong  = errors.New("recording too long")
+)
+
+// cacheSize bounds the transcripts kept in memory; a cached transcript
+// saves the download and the call when a thread is asked about again.
+const (
+	cacheSize = 200
+	cacheTTL  = 24 * time.Hour
+)
+
+// Segment is a stretch of speech with its offset in the recording.
+type Segment struct {
+	Start float64 `json:"start"` // seconds
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Transcript is the transcription of one recording.  Segments is empty when
+// the server only returns plain text.
+type Transcript struct {
+	Text     string    `json:"text"`
+	Language string    `json:"language"`
+	Duration float64   `json:"duration"` // seconds; 0 when unknown
+	Segments []Segment `json:"segments"`
+	// CutAt is the offset, in seconds, where segments past the client's
+	// MaxDuration were dropped; 0 when the transcript is complete.
+	CutAt float64 `json:"-"`
+}
+
+// Format renders the transcript one segment per line, prefixed with its
+// timestamp ("[01:23] ...").
+func (t Transcript) Format() string {
+	if len(t.Segments) == 0 {
+		return strings.TrimSpace(t.Text)
+	}
+	var b strings.Builder
+	for _, s := range t.Segments {
+		text := strings.TrimSpace(s.Text)
+		if text == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "[%s] %s\n", Timestamp(s.Start), text)
+	}
+	if t.CutAt > 0 {
+		fmt.Fprintf(&b, "[AVISO: transcrição interrompida em %s por exceder a duração máxima]\n", Timestamp(t.CutAt))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// cut drops the segments that start at or after limit, so an overlong
+// recording still yields its beginning.
+func (t Transcript) cut(limit time.Duration) Transcript {
+	secs := limit.Seconds()
+	if limit <= 0 || t.Duration <= secs || len(t.Segments) == 0 {
+		return t
+	}
+	n := 0
+	for n < len(t.Segments) && t.Segments[n].Start < secs {
+		n++
+	}
+	t.Segments = t.Segments[:n:n]
+	t.CutAt = secs
+	return t
+}
+
+// Timestamp formats seconds as mm:ss, or h:mm:ss past an hour.
+func Timestamp(seconds float64) string {
+	total := int(seconds)
+	h, m, s := total/3600, total/60%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
+
+// Client calls the transcription endpoint and caches transcripts by key.
+type Client struct {
+	BaseURL  string
+	APIKey   string
+	Model    string
+	Language string
+	// MaxDuration is the longest recording transcribed; 0 means no limit.
+	MaxDuration time.Duration
+	HTTPClient  *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedTranscript
+}
+
+type cachedTranscript struct {
+	t       Transcript
+	expires time.Time
+}
+
+// NewClient constructs a transcription client from config.  Returns nil when
+// transcription is not configured.
+func NewClient(cfg config.Config) *Client {
+	if !cfg.TranscriptionEnabled() {
+		return nil
+	}
+	log.Printf("[BOOT] transcription enabled base_url=%q model=%q language=%q", cfg.TranscriptionBaseURL, cfg.TranscriptionModel, cfg.TranscriptionLanguage)
+	return &Client{
+		BaseURL:     strings.TrimRight(cfg.TranscriptionBaseURL, "/"),
+		APIKey:      cfg.TranscriptionAPIKey,
+		Model:       cfg.TranscriptionModel,
+		Language:    cfg.TranscriptionLanguage,
+		MaxDuration: cfg.TranscriptionMaxDuration,
+		HTTPClient:  &http.Client{Timeout: 5 * time.Minute},
+		cache:       make(map[string]cachedTranscript),
+	}
+}
+
+// CheckDuration returns ErrTooLong when a recording of length d exceeds
+// MaxDuration, so it can be skipped before it is downloaded.
+func (c *Client) CheckDuration(d time.Duration) error {
+	if c.MaxDuration > 0 && d > c.MaxDuration {
+		return fmt.Errorf("%w (%s, max %s)", ErrTooLong, d.Round(time.Second), c.MaxDuration)
+	}
+	return nil
+}
+
+// Cached returns the transcript stored under key, if any.
+func (c *Client) Cached(key string) (Transcript, bool) {
+	if key == "" {
+		return Transcript{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.cache[key]
+	if !ok || time.Now().After(e.expires) {
+		return Transcript{}, false
+	}
+	return e.t, true
+}
+
+func (c *Client) store(key string, t Transcript) {
+	if key == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.cache) >= cacheSize {
+		for k, e := range c.cache {
+			if now.After(e.expires) {
+				delete(c.cache, k)
+			}
+		}
+		// Still full: drop an arbitrary entry.
+		for k := range c.cache {
+			if len(c.cache) < cacheSize {
+				break
+			}
+			delete(c.cache, k)
+		}
+	}
+	c.cache[key] = cachedTranscript{t: t, expires: now.Add(cacheTTL)}
+}
+
+// Transcribe sends the recording to the endpoint and caches the result under
+// key (e.g. the Slack file ID; "" skips the cache).
+func (c *Client) Transcribe(ctx context.Context, key, name, mimetype string, data []byte) (Transcript, error) {
+	if t, ok := c.Cached(key); ok {
+		return t, nil
+	}
+	if len(data) == 0 {
+		return Transcript{}, errors.New("empty file")
+	}
+	if len(data) > MaxBytes {
+		return Transcript{}, fmt.Errorf("%w (%d bytes, max %d)", ErrTooLarge, len(data), MaxBytes)
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", uploadName(name, mimetype))
+	if err != nil {
+		return Transcript{}, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return Transcript{}, err
+	}
+	_ = w.WriteField("model", c.Model)
+	_ = w.WriteField("response_format", "verbose_json")
+	_ = w.WriteField("timestamp_granularities[]", "segment")
+	if c.Language != "" {
+		_ = w.WriteField("language", c.Language)
+	}
+	if err := w.Close(); err != nil {
+		return Transcript{}, err
+	}
+
+	start := time.Now()
+	req, _ := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/audio/transcriptions", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return Transcript{}, err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Transcript{}, fmt.Errorf("transcription status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+
+	var out Transcript
+	if err := json.Unmarshal(b, &out); err != nil {
+		// Servers that ignore response_format answer with plain text.
+		out = Transcript{Text: string(b)}
+	}
+	if out.Duration == 0 && len(out.Segments) > 0 {
+		out.Duration = out.Segments[len(out.Segments)-1].End
+	}
+	out = out.cut(c.MaxDuration)
+	log.Printf("[JARVIS] transcribed %q bytes=%d duration=%.0fs segments=%d dur=%s", name, len(data), out.Duration, len(out.Segments), time.Since(start))
+	c.store(key, out)
+	return out, nil
+}
+
+// uploadName returns name with a file extension, which the endpoint uses to
+// detect the container format.
+func uploadName(name, mimetype string) string {
+	if name == "" {
+		name = "recording"
+	}
+	if filepath.Ext(name) != "" {
+		return name
+	}
+	if ext, ok := extensions[strings.ToLower(mimetype)]; ok {
+		return name + ext
+	}
+	if exts, _ := mime.ExtensionsByType(mimetype); len(exts) > 0 {
+		return name + exts[0]
+	}
+	return name
+}
+
+// extensions are the extensions Whisper recognises for common recording
+// types, where the mime package would pick another (".weba" for webm).
+var extensions = map[string]string{
+	"audio/webm":  ".webm",
+	"video/webm":  ".webm",
+	"audio/mpeg":  ".mp3",
+	"audio/mp4":   ".m4a",
+	"audio/x-m4a": ".m4a",
+	"video/mp4":   ".mp4",
+	"audio/ogg":   ".ogg",
+	"audio/wav":   ".wav",
+	"audio/x-wav": ".wav",
+	"audio/flac":  ".flac",
+}
@@ -0,0 +1,196 @@
+package transcribe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DanielFillol/Jarvis/internal/config"
+)
+
+// whisperServer fakes /audio/transcriptions: it checks the multipart request
+// and answers with body.
+func whisperServer(t *testing.T, status int, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Method != http.MethodPost || r.URL.Path != "/audio/transcriptions" {
+			t.Errorf("request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("multipart: %v", err)
+			return
+		}
+		for field, want := range map[string]string{
+			"model":                     "whisper-1",
+			"response_format":           "verbose_json",
+			"timestamp_granularities[]": "segment",
+			"language":                  "pt",
+		} {
+			if got := r.FormValue(field); got != want {
+				t.Errorf("field %s = %q, want %q", field, got, want)
+			}
+		}
+		f, hdr, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("file part: %v", err)
+			return
+		}
+		data, _ := io.ReadAll(f)
+		if hdr.Filename != "nota.webm" || string(data) != "audio bytes" {
+			t.Errorf("file = %q %q", hdr.Filename, data)
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newTestClient(url string, maxDuration time.Duration) *Client {
+	return NewClient(config.Config{
+		TranscriptionBaseURL:     url,
+		TranscriptionAPIKey:      "sk-test",
+		TranscriptionModel:       "whisper-1",
+		TranscriptionLanguage:    "pt",
+		TranscriptionMaxDuration: maxDuration,
+	})
+}
+
+const verboseJSON = `{"text":"Oi. O botão some. Depois volta.","language":"portuguese","segments":[
+	{"start":0,"end":4.2,"text":" Oi."},
+	{"start":65.4,"end":70,"text":" O botão some."},
+	{"start":70,"end":71,"text":"  "},
+	{"start":3725,"end":3730.5,"text":" Depois volta."}]}`
+
+func TestTranscribeVerboseJSON(t *testing.T) {
+	srv, calls := whisperServer(t, http.StatusOK, verboseJSON)
+	c := newTestClient(srv.URL, 0)
+
+	got, err := c.Transcribe(context.Background(), "F1", "nota", "audio/webm", []byte("audio bytes"))
+	if err != nil {
+		t.Fatalf("Transcribe: %v", err)
+	}
+	want := "[00:00] Oi.\n[01:05] O botão some.\n[1:02:05] Depois volta."
+	if got.Format() != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", got.Format(), want)
+	}
+	if got.Duration != 3730.5 {
+		t.Errorf("Duration = %v, want the end of the last segment", got.Duration)
+	}
+
+	// The second ask about the same file is served from the cache.
+	if _, err := c.Transcribe(context.Background(), "F1", "nota", "audio/webm", []byte("audio bytes")); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("endpoint calls = %d, want 1", n)
+	}
+}
+
+func TestTranscribePlainText(t *testing.T) {
+	srv, _ := whisperServer(t, http.StatusOK, "  só texto  ")
+	c := newTestClient(srv.URL, 0)
+	got, err := c.Transcribe(context.Background(), "", "nota.webm", "audio/webm", []byte("audio bytes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Format() != "só texto" {
+		t.Errorf("Format() = %q", got.Format())
+	}
+}
+
+func TestTranscribeErrors(t *testing.T) {
+	srv, calls := whisperServer(t, http.StatusBadRequest, `{"error":"bad audio"}`)
+	c := newTestClient(srv.URL, 0)
+	ctx := context.Background()
+
+	if _, err := c.Transcribe(ctx, "F2", "nota", "audio/webm", []byte("audio bytes")); err == nil || !strings.Contains(err.Error(), "status=400") {
+		t.Errorf("endpoint error: err = %v", err)
+	}
+	if _, ok := c.Cached("F2"); ok {
+		t.Error("a failed transcription was cached")
+	}
+	if _, err := c.Transcribe(ctx, "", "nota", "audio/webm", nil); err == nil {
+		t.Error("empty file: want error")
+	}
+	big := make([]byte, MaxBytes+1)
+	if _, err := c.Transcribe(ctx, "", "nota", "audio/webm", big); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized file: err = %v, want ErrTooLarge", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("endpoint calls = %d, want 1 (limits are checked before the upload)", n)
+	}
+}
+
+func TestTranscribeMaxDuration(t *testing.T) {
+	srv, _ := whisperServer(t, http.StatusOK, `{"duration":120,"segments":[
+		{"start":0,"end":30,"text":"um"},
+		{"start":30,"end":60,"text":"dois"},
+		{"start":60,"end":90,"text":"três"},
+		{"start":90,"end":120,"text":"quatro"}]}`)
+	c := newTestClient(srv.URL, time.Minute)
+
+	got, err := c.Transcribe(context.Background(), "", "nota", "audio/webm", []byte("audio bytes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[00:00] um\n[00:30] dois\n[AVISO: transcrição interrompida em 01:00 por exceder a duração máxima]"
+	if got.Format() != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", got.Format(), want)
+	}
+
+	if err := c.CheckDuration(61 * time.Second); !errors.Is(err, ErrTooLong) {
+		t.Errorf("CheckDuration(61s) = %v, want ErrTooLong", err)
+	}
+	for _, d := range []time.Duration{0, time.Minute} {
+		if err := c.CheckDuration(d); err != nil {
+			t.Errorf("CheckDuration(%s) = %v", d, err)
+		}
+	}
+	c.MaxDuration = 0
+	if err := c.CheckDuration(10 * time.Hour); err != nil {
+		t.Errorf("CheckDuration without a limit = %v", err)
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	for secs, want := range map[float64]string{
+		0:      "00:00",
+		9.9:    "00:09",
+		65.4:   "01:05",
+		3599:   "59:59",
+		3600:   "1:00:00",
+		7384.2: "2:03:04",
+	} {
+		if got := Timestamp(secs); got != want {
+			t.Errorf("Timestamp(%v) = %q, want %q", secs, got, want)
+		}
+	}
+}
+
+func TestUploadName(t *testing.T) {
+	tests := []struct{ name, mimetype, want string }{
+		{"nota.m4a", "audio/mp4", "nota.m4a"},
+		{"nota", "audio/webm", "nota.webm"},
+		{"clip", "video/mp4", "clip.mp4"},
+		{"", "audio/mpeg", "recording.mp3"},
+		{"nota", "application/x-unknown", "nota"},
+	}
+	for _, tt := range tests {
+		if got := uploadName(tt.name, tt.mimetype); got != tt.want {
+			t.Errorf("uploadName(%q, %q) = %q, want %q", tt.name, tt.mimetype, got, tt.want)
+		}
+	}
+}